@@ -0,0 +1,89 @@
+package cleanarg
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var assignmentRE = regexp.MustCompile(`^[A-Za-z_][0-9A-Za-z_]*=`)
+
+// FindAssignField takes a reflect.Value, which must represent a struct,
+// and returns the field tagged arg-assign, if any. The boolean return value
+// indicates whether such a field was found.
+// Returns an error if there is more than one such field, or if the field
+// is not of type map[string]string.
+func findAssignField(v reflect.Value) (reflect.StructField, bool, error) {
+	found, out := false, reflect.StructField{}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+
+		if _, ok := field.Tag.Lookup(tagAssign); !ok {
+			continue
+		}
+		if _, ok := field.Tag.Lookup(tagIgnore); ok {
+			continue
+		}
+
+		if found {
+			return reflect.StructField{}, false,
+				fmt.Errorf("at most one field may be tagged %s", tagAssign)
+		}
+		if field.Type != reflect.TypeOf(map[string]string{}) {
+			return reflect.StructField{}, false,
+				fmt.Errorf("field tagged %s must be map[string]string", tagAssign)
+		}
+
+		found, out = true, field
+	}
+
+	return out, found, nil
+}
+
+// PopulateAssignments takes a reflect.Value, which must represent a
+// pointer to the struct to populate, a slice of positional tokens, and
+// the number of these tokens that preceded the "--" token (if any).
+// If the struct has a field tagged arg-assign, all tokens of the form
+// NAME=value among the leading tokens are stored in this field, with NAME
+// as key, and removed from the slice of tokens. (NAME must consist of
+// letters, digits, and underscores, and may not begin with a digit.)
+// Returns the remaining tokens, or an error if the struct is malformed.
+func populateAssignments(v reflect.Value, tokens []string,
+	leading int) ([]string, error) {
+
+	field, ok, err := findAssignField(v)
+	if err != nil || !ok {
+		return tokens, err
+	}
+
+	target := v.FieldByIndex(field.Index)
+	if target.IsNil() {
+		target.Set(reflect.MakeMap(field.Type))
+	}
+
+	out := []string{}
+	for i, token := range tokens {
+		if i >= leading || !assignmentRE.MatchString(token) {
+			out = append(out, token)
+			continue
+		}
+
+		name, value, _ := strings.Cut(token, "=")
+		target.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(value))
+	}
+
+	return out, nil
+}
+
+// CountAfterEndFlags returns the number of tokens that follow the "--"
+// token, or zero if there is no such token.
+func countAfterEndFlags(tokens []string) int {
+	for i, token := range tokens {
+		if token == endFlagsIndicator {
+			return len(tokens) - i - 1
+		}
+	}
+	return 0
+}
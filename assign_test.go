@@ -0,0 +1,107 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+type assignArgs struct {
+	Name    string            `arg-flag:"-s"`
+	Vars    map[string]string `arg-assign:"" arg-help:"Variables"`
+	Targets []string
+}
+
+func Test_FromSliceAssignments(t *testing.T) {
+	tests := []struct {
+		slice   []string
+		name    string
+		vars    map[string]string
+		targets []string
+	}{
+		{[]string{}, "", map[string]string{}, nil},
+		{[]string{"build"}, "", map[string]string{}, []string{"build"}},
+		{[]string{"build", "DEBUG=1", "PREFIX=/opt"}, "",
+			map[string]string{"DEBUG": "1", "PREFIX": "/opt"},
+			[]string{"build"}},
+		{[]string{"A=1", "build", "A=2", "B="}, "",
+			map[string]string{"A": "2", "B": ""}, []string{"build"}},
+		{[]string{"X=a=b"}, "", map[string]string{"X": "a=b"}, nil},
+		{[]string{"-s", "N=v", "M=w"}, "N=v",
+			map[string]string{"M": "w"}, nil},
+		{[]string{"A=1", "--", "B=2"}, "",
+			map[string]string{"A": "1"}, []string{"B=2"}},
+		{[]string{"=1", "1A=2", "a-b=3"}, "", map[string]string{},
+			[]string{"=1", "1A=2", "a-b=3"}},
+	}
+
+	for _, test := range tests {
+		a := assignArgs{}
+
+		if err := FromSlice(test.slice, &a); err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+
+		if a.Name != test.name || !maps.Equal(a.Vars, test.vars) ||
+			!slices.Equal(a.Targets, test.targets) {
+			t.Errorf("%v: got=%v want=%v %v %v",
+				test.slice, a, test.name, test.vars, test.targets)
+		}
+	}
+
+	// Without arg-assign tag, assignments are positionals
+	b := struct{ Rest []string }{}
+	if err := FromSlice([]string{"A=1"}, &b); err != nil ||
+		!slices.Equal(b.Rest, []string{"A=1"}) {
+		t.Errorf("Without tag: got=%v err=%v", b.Rest, err)
+	}
+}
+
+func Test_findAssignField(t *testing.T) {
+	tests := []struct {
+		data    any
+		found   bool
+		wantErr bool
+	}{
+		{struct{}{}, false, false},
+		{struct {
+			M map[string]string `arg-assign:""`
+		}{}, true, false},
+		{struct {
+			M map[string]string `arg-assign:"" arg-ignore:""`
+		}{}, false, false},
+		{struct {
+			M map[string]int `arg-assign:""`
+		}{}, false, true},
+		{struct {
+			M, N map[string]string `arg-assign:""`
+		}{}, false, true},
+	}
+
+	for i, test := range tests {
+		_, found, err := findAssignField(reflect.ValueOf(test.data))
+		if found != test.found || (err != nil) != test.wantErr {
+			t.Errorf("%d: got=%v,%v want=%v,%v",
+				i, found, err, test.found, test.wantErr)
+		}
+	}
+}
+
+func Test_WriteUsageAssignments(t *testing.T) {
+	sb := strings.Builder{}
+	WriteShortUsage(&sb, &assignArgs{})
+	want := shortUsagePrefix + "[-s string] [NAME=value]+ [string]+ \n"
+	if sb.String() != want {
+		t.Errorf("want=%s\ngot=%s", want, sb.String())
+	}
+
+	sb = strings.Builder{}
+	WriteUsage(&sb, &assignArgs{})
+	if !strings.Contains(sb.String(), "[NAME=value] (repeatable) Variables") {
+		t.Errorf("Missing assignments:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,56 @@
+package cleanarg
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words of the arg-flag tag that stand for flags derived from the name of
+// the field: the long flag only, or the long and the short flag
+const (
+	autoFlag      = "auto"
+	autoFlagShort = "auto-short"
+)
+
+// ExpandAutoFlags takes an arg-flag tag and the name of the field, and
+// replaces the words "auto" and "auto-short" in the tag by the flags
+// derived from the field name: "auto" becomes the long flag (the field name
+// in kebab-case, as in --max-retries for MaxRetries), and "auto-short"
+// becomes the long flag together with the short flag (the first letter of
+// the field name, in lower case, as in -m). Other words are kept as they
+// are (so that "auto -r" gives --max-retries and -r).
+func expandAutoFlags(tag, name string) string {
+	words := strings.Fields(tag)
+	for i, word := range words {
+		switch word {
+		case autoFlag:
+			words[i] = "--" + kebabCase(name)
+		case autoFlagShort:
+			words[i] = "--" + kebabCase(name) + " -" +
+				strings.ToLower(name[:1])
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// KebabCase converts a field name in CamelCase to kebab-case (all lower
+// case, words separated by hyphens). Runs of upper-case letters are kept
+// together as one word, as in HTTPPort (http-port) or UserID (user-id).
+func kebabCase(name string) string {
+	r := []rune(name)
+	b := strings.Builder{}
+
+	for i, c := range r {
+		if i > 0 && unicode.IsUpper(c) {
+			prev := r[i-1]
+			nextLower := i+1 < len(r) && unicode.IsLower(r[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && nextLower) {
+				b.WriteRune('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+
+	return b.String()
+}
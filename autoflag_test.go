@@ -0,0 +1,78 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"strings"
+)
+
+func Test_kebabCase(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Verbose", "verbose"},
+		{"MaxRetries", "max-retries"},
+		{"HTTPPort", "http-port"},
+		{"UserID", "user-id"},
+		{"Level2Cache", "level2-cache"},
+		{"dryRun", "dry-run"},
+	}
+
+	for _, test := range tests {
+		if got := kebabCase(test.name); got != test.want {
+			t.Errorf("%s: got=%s want=%s", test.name, got, test.want)
+		}
+	}
+}
+
+func Test_FromSliceAutoFlags(t *testing.T) {
+	type args struct {
+		MaxRetries int    `arg-flag:"auto"`
+		Verbose    bool   `arg-flag:"auto-short"`
+		OutputDir  string `arg-flag:"auto -o"`
+		Name       string
+	}
+
+	tests := []struct {
+		slice []string
+		want  args
+	}{
+		{[]string{"--max-retries", "3", "x"}, args{MaxRetries: 3, Name: "x"}},
+		{[]string{"-v", "x"}, args{Verbose: true, Name: "x"}},
+		{[]string{"x", "--verbose", "--output-dir=out"},
+			args{Verbose: true, OutputDir: "out", Name: "x"}},
+		{[]string{"-o", "out", "x"}, args{OutputDir: "out", Name: "x"}},
+	}
+
+	for _, test := range tests {
+		a := args{}
+		if err := FromSlice(test.slice, &a); err != nil {
+			t.Fatalf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if a != test.want {
+			t.Errorf("%v: got=%+v want=%+v", test.slice, a, test.want)
+		}
+	}
+
+	sb := strings.Builder{}
+	if err := WriteShortUsage(&sb, &args{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{"[-o|--output-dir string]", "[-v]",
+		"[--max-retries int]"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("missing %q in %q", want, sb.String())
+		}
+	}
+
+	// Derived flags may collide like explicit ones
+	bad := struct {
+		Verbose bool `arg-flag:"auto-short"`
+		Version bool `arg-flag:"auto-short"`
+	}{}
+	err := FromSlice([]string{}, &bad)
+	if err == nil || err.Error() != "duplicate flag: -v" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package cleanarg
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ByteSize is a number of bytes. Fields of this type accept values with
+// human-readable units, such as "512", "10MB", or "1.5GiB": decimal units
+// (kB, MB, GB, TB, PB, EB) are powers of 1000, binary units (KiB, MiB,
+// GiB, TiB, PiB, EiB) are powers of 1024. Units are not case-sensitive,
+// and the trailing "B" is optional (so "10M" is "10MB"). A fractional
+// number ("1.5GiB") is permitted, as long as the result is a whole number
+// of bytes.
+type ByteSize int64
+
+// The type of ByteSize fields.
+var typeByteSize = reflect.TypeOf(ByteSize(0))
+
+// Units of byte sizes, largest first within each kind, for formatting.
+var (
+	binaryUnits  = []string{"EiB", "PiB", "TiB", "GiB", "MiB", "KiB"}
+	decimalUnits = []string{"EB", "PB", "TB", "GB", "MB", "kB"}
+)
+
+// Multipliers of byte size units, keyed on the lowercase unit, without
+// the trailing "b".
+var byteMultipliers = map[string]int64{
+	"":   1,
+	"k":  1e3,
+	"m":  1e6,
+	"g":  1e9,
+	"t":  1e12,
+	"p":  1e15,
+	"e":  1e18,
+	"ki": 1 << 10,
+	"mi": 1 << 20,
+	"gi": 1 << 30,
+	"ti": 1 << 40,
+	"pi": 1 << 50,
+	"ei": 1 << 60,
+}
+
+// String returns the size in the largest binary unit that represents it
+// exactly (eg. "10MiB"), or else in the largest such decimal unit (eg.
+// "1500kB"), or else in bytes (eg. "512B"). ParseByteSize() accepts the
+// result.
+func (b ByteSize) String() string {
+	for _, units := range [][]string{binaryUnits, decimalUnits} {
+		for _, u := range units {
+			m := byteMultipliers[strings.ToLower(u[:len(u)-1])]
+			if b != 0 && int64(b)%m == 0 {
+				return fmt.Sprintf("%d%s", int64(b)/m, u)
+			}
+		}
+	}
+	return fmt.Sprintf("%dB", int64(b))
+}
+
+// ParseByteSize converts its argument, a number with an optional unit (see
+// ByteSize), to a ByteSize.
+// Returns an error if the string is malformed, the size is negative, or
+// the result is not a whole number of bytes or does not fit into an int64.
+func ParseByteSize(s string) (ByteSize, error) {
+	i := strings.IndexFunc(s, unicode.IsLetter)
+	if i < 0 {
+		i = len(s)
+	}
+	num := strings.TrimSpace(s[:i])
+	unit := strings.TrimSuffix(strings.ToLower(s[i:]), "b")
+
+	mult, ok := byteMultipliers[unit]
+	if !ok || num == "" || strings.HasPrefix(num, "-") {
+		return 0, errorf("invalid byte size: %s", s)
+	}
+
+	// Exact arithmetic if possible, to avoid rounding for large values
+	if n, err := strconv.ParseInt(num, 10, 64); err == nil {
+		if n > math.MaxInt64/mult {
+			return 0, errorf("byte size out of range: %s", s)
+		}
+		return ByteSize(n * mult), nil
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, errorf("invalid byte size: %s", s)
+	}
+	// Allow for rounding errors in the float representation (eg. "0.3kB")
+	f *= float64(mult)
+	r := math.Round(f)
+	if math.Abs(f-r) > 1e-9*math.Max(1, f) {
+		return 0, errorf("not a whole number of bytes: %s", s)
+	}
+	f = r
+	if f >= math.MaxInt64 {
+		return 0, errorf("byte size out of range: %s", s)
+	}
+	return ByteSize(f), nil
+}
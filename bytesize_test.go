@@ -0,0 +1,105 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"strings"
+)
+
+func Test_ParseByteSize(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    ByteSize
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"512", 512, false},
+		{"512B", 512, false},
+		{"10MB", 10000000, false},
+		{"10M", 10000000, false},
+		{"10mb", 10000000, false},
+		{"1.5GiB", 1610612736, false},
+		{"2KiB", 2048, false},
+		{"2ki", 2048, false},
+		{"0.3kB", 300, false},
+		{"10 MB", 10000000, false},
+		{"8EiB", 0, true},
+		{"1.5B", 0, true},
+		{"-1kB", 0, true},
+		{"MB", 0, true},
+		{"10XB", 0, true},
+		{"ten", 0, true},
+		{"", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseByteSize(test.s)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: Unexpected error: %v", test.s, err)
+		}
+		if err == nil && got != test.want {
+			t.Errorf("%q: got=%d want=%d", test.s, got, test.want)
+		}
+	}
+}
+
+func Test_ByteSizeString(t *testing.T) {
+	tests := []struct {
+		b    ByteSize
+		want string
+	}{
+		{0, "0B"},
+		{512, "512B"},
+		{2048, "2KiB"},
+		{10485760, "10MiB"},
+		{1500, "1500B"},
+		{1500000, "1500kB"},
+		{10000000, "10MB"},
+		{1 << 60, "1EiB"},
+	}
+
+	for _, test := range tests {
+		got := test.b.String()
+		if got != test.want {
+			t.Errorf("%d: got=%s want=%s", test.b, got, test.want)
+		}
+		if b, err := ParseByteSize(got); err != nil || b != test.b {
+			t.Errorf("%s: round trip got=%d, %v", got, b, err)
+		}
+	}
+}
+
+func Test_FromSliceByteSize(t *testing.T) {
+	type sizeArgs struct {
+		Limit ByteSize   `arg-flag:"-l" arg-default:"1GiB" arg-max:"10GiB"`
+		Parts []ByteSize `arg-flag:"-p"`
+	}
+
+	a := sizeArgs{}
+	err := FromSlice([]string{"-l", "512MB", "-p", "1k", "-p", "2KiB"}, &a)
+	if err != nil || a.Limit != 512000000 || len(a.Parts) != 2 ||
+		a.Parts[0] != 1000 || a.Parts[1] != 2048 {
+		t.Errorf("got=%v, %v", a, err)
+	}
+
+	a = sizeArgs{}
+	if err := FromSlice([]string{}, &a); err != nil || a.Limit != 1<<30 {
+		t.Errorf("got=%v, %v want default", a, err)
+	}
+
+	err = FromSlice([]string{"-l", "1.5x"}, &sizeArgs{})
+	if !errors.Is(err, ErrConversion) {
+		t.Errorf("got=%v want ErrConversion", err)
+	}
+	err = FromSlice([]string{"-l", "11GiB"}, &sizeArgs{})
+	if !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("got=%v want ErrOutOfRange", err)
+	}
+
+	sb := strings.Builder{}
+	WriteUsage(&sb, &sizeArgs{})
+	if !strings.Contains(sb.String(), "e.g. 512, 10MB, 1.5GiB") {
+		t.Errorf("Missing hint:\n%s", sb.String())
+	}
+}
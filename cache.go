@@ -0,0 +1,48 @@
+package cleanarg
+
+import (
+	"reflect"
+	"sync"
+)
+
+// The results of analyzeStruct depend only on the type of the struct, and
+// are cached (keyed on reflect.Type), so that repeated parses of the same
+// type do not repeat the reflection and validation of struct tags.
+var analysisCache sync.Map
+
+// structAnalysis holds the results of analyzeStruct for a struct type.
+type structAnalysis struct {
+	options     map[string]fieldInfo
+	positionals []fieldInfo
+}
+
+// LoadAnalysis returns a copy of the cached results of analyzeStruct for
+// the given type, if any.
+func loadAnalysis(t reflect.Type) (map[string]fieldInfo, []fieldInfo, bool) {
+	c, ok := analysisCache.Load(t)
+	if !ok {
+		return nil, nil, false
+	}
+
+	a := c.(structAnalysis)
+	return copyOptions(a.options), append([]fieldInfo{}, a.positionals...), true
+}
+
+// StoreAnalysis caches the results of analyzeStruct for the given type,
+// and returns copies of them, which the caller may modify freely.
+func storeAnalysis(t reflect.Type, options map[string]fieldInfo,
+	positionals []fieldInfo) (map[string]fieldInfo, []fieldInfo) {
+
+	analysisCache.Store(t, structAnalysis{options, positionals})
+	return copyOptions(options), append([]fieldInfo{}, positionals...)
+}
+
+// CopyOptions returns a copy of a map of options. The fieldInfo values are
+// copied; slices in them are shared, and must not be modified in place.
+func copyOptions(options map[string]fieldInfo) map[string]fieldInfo {
+	out := make(map[string]fieldInfo, len(options))
+	for k, info := range options {
+		out[k] = info
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package cleanarg
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func Test_analyzeStructCache(t *testing.T) {
+	type cacheArgs struct {
+		Count int    `arg-flag:"-c --count" arg-default:"3"`
+		Name  string `arg-flag:"-n"`
+		Files []string
+	}
+
+	v := reflect.ValueOf(&cacheArgs{}).Elem()
+	opts1, pos1, err := analyzeStruct(v)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Modifying the results must not affect later calls
+	info := opts1["-c"]
+	info.defaultval = "7"
+	opts1["-c"] = info
+	delete(opts1, "-n")
+	pos1[0].defaultval = "x"
+
+	opts2, pos2, err := analyzeStruct(v)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(opts2) != 3 || opts2["-c"].defaultval != "3" ||
+		pos2[0].defaultval != "" {
+		t.Errorf("Cache modified: got=%v %v", opts2, pos2)
+	}
+
+	// Runtime defaults (which modify the analysis) apply to one parse only
+	p := NewParser(WithDefault("Count", 5))
+	a := cacheArgs{}
+	if err := p.Parse([]string{}, &a); err != nil || a.Count != 5 {
+		t.Errorf("Runtime default: got=%v err=%v", a, err)
+	}
+	a = cacheArgs{}
+	if err := FromSlice([]string{}, &a); err != nil || a.Count != 3 {
+		t.Errorf("After runtime default: got=%v err=%v", a, err)
+	}
+
+	// Errors are not cached, but reported every time
+	type badArgs struct {
+		Count **int `arg-flag:"-c"`
+	}
+	for i := 0; i < 2; i++ {
+		if err := FromSlice([]string{}, &badArgs{}); err == nil {
+			t.Errorf("Expected error")
+		}
+	}
+}
+
+func Test_analyzeStructConcurrent(t *testing.T) {
+	type concArgs struct {
+		Count int `arg-flag:"-c"`
+		Files []string
+	}
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a := concArgs{}
+			errs <- FromSlice([]string{"-c", "2", "x"}, &a)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+}
@@ -1,11 +1,16 @@
 package cleanarg
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net"
+	"net/netip"
 	"os"
 	"reflect"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -13,11 +18,51 @@ import (
 )
 
 const (
-	tagFlag    = "arg-flag"
-	tagHelp    = "arg-help"
-	tagDefault = "arg-default"
-	tagFormat  = "arg-format"
-	tagIgnore  = "arg-ignore"
+	tagFlag     = "arg-flag"
+	tagHelp     = "arg-help"
+	tagDefault  = "arg-default"
+	tagFormat   = "arg-format"
+	tagIgnore   = "arg-ignore"
+	tagSecret   = "arg-secret"
+	tagURL      = "arg-url"
+	tagChoices  = "arg-choices"
+	tagConst    = "arg-const"
+	tagXor      = "arg-xor"
+	tagRequire  = "arg-require-one"
+	tagAssign   = "arg-assign"
+	tagCommand  = "arg-command"
+	tagEnv      = "arg-env"
+	tagPrefix   = "arg-prefix"
+	tagConfig   = "arg-config"
+	tagRest     = "arg-rest"
+	tagNegate   = "arg-negate"
+	tagCount    = "arg-count"
+	tagOnce     = "arg-once"
+	tagMaxCnt   = "arg-max-count"
+	tagTogether = "arg-together"
+	tagRequires = "arg-requires"
+	tagMin      = "arg-min"
+	tagMax      = "arg-max"
+	tagPattern  = "arg-pattern"
+	tagLocation = "arg-location"
+	tagRelative = "arg-relative"
+	tagEncoding = "arg-encoding"
+	tagSep      = "arg-sep"
+	tagReset    = "arg-reset"
+	tagUnique   = "arg-unique"
+	tagSorted   = "arg-sorted"
+	tagOptional = "arg-optional"
+	tagName     = "arg-name"
+	tagNumber   = "arg-number"
+	tagSection  = "arg-section"
+	tagConfirm  = "arg-confirm"
+	tagHidden   = "arg-hidden"
+	tagDeprec   = "arg-deprecated"
+	tagAlias    = "arg-alias"
+	tagGroup    = "arg-group"
+	tagRequired = "arg-required"
+	tagPersist  = "arg-persistent"
+	tagDefCmd   = "arg-default-command"
 )
 
 const (
@@ -29,6 +74,19 @@ const (
 	defaultTimeFormat = "2006-01-02 15:04:05" // no TimeZone!
 )
 
+// Keywords for the arg-format tag of numeric fields
+const (
+	formatDecimalComma = "decimal-comma" // float64: accept "3,14" for "3.14"
+	formatSI           = "si"            // int: accept "2k", "1.5M", "3G"
+	formatPercent      = "percent"       // float64: "75%" and "75" are 0.75
+	formatFraction     = "fraction"      // float64: "75%" and "0.75" are 0.75
+)
+
+// Decimal multipliers for SI suffixes (not binary: see byte sizes)
+var siMultipliers = map[byte]float64{
+	'k': 1e3, 'K': 1e3, 'M': 1e6, 'G': 1e9, 'T': 1e12, 'P': 1e15, 'E': 1e18,
+}
+
 const (
 	helpArgument  = `\*.+?\*`
 	helpDelimiter = "*"
@@ -51,6 +109,13 @@ func init() {
 	allowedTypes[reflect.TypeOf(float64(0.0))] = struct{}{}
 	allowedTypes[reflect.TypeOf(time.Now())] = struct{}{}
 	allowedTypes[reflect.TypeOf(time.Duration(0))] = struct{}{}
+	allowedTypes[typeByteSize] = struct{}{}
+	allowedTypes[typeFileMode] = struct{}{}
+	allowedTypes[typeBytes] = struct{}{}
+	allowedTypes[typeRune] = struct{}{}
+	for _, t := range networkTypes {
+		allowedTypes[t] = struct{}{}
+	}
 
 	shortFlagRE = regexp.MustCompile(shortFlag)
 	longFlagRE = regexp.MustCompile(longFlag)
@@ -79,15 +144,58 @@ type fieldInfo struct {
 	// Command line values
 	flag  string
 	value string
+	index int // index of the flag's token, -1 if not known
 
 	// Tags
 	help       string
 	defaultval string
 	format     string
+	secret     bool
+	url        string
+	argname    string // placeholder in usage messages (arg-name)
+	choices    []string
+	pattern    *regexp.Regexp // permitted string values (arg-pattern)
+	location   *time.Location // location of time values (arg-location)
+	xorGroup   string
+	reqGroup   string
+	togGroup   string   // options that must be supplied together
+	group      string   // heading in usage messages (arg-group)
+	requires   []string // flags of options required by this one
+	required   bool     // the option must be supplied (arg-required)
+	persistent bool     // also accepted after a subcommand name
+	env        string
+	noEnv      bool   // no environment variable, even with a prefix
+	encoding   string // encoding of []byte values (arg-encoding)
+	sep        string // separator of slice values (arg-sep)
+	resetval   string // value that empties a slice (arg-reset)
+	canReset   bool   // true if resetval is given
+	unique     bool   // duplicate elements are removed (arg-unique)
+	sorted     bool   // elements are sorted (arg-sorted)
+	mayOmit    bool   // positional that may be omitted (arg-optional)
+	isNumber   bool   // set by bare numbers, as in -20 (arg-number)
+	section    string // token that starts the positional's section
+	confirm    string // prompt asking for confirmation (arg-confirm)
+	hasConfirm bool   // true if the field is tagged arg-confirm
+	hidden     bool   // omitted from usage and completion (arg-hidden)
+	deprecated string // what to use instead (arg-deprecated)
+	isDeprec   bool   // true if the field is tagged arg-deprecated
+	isAlias    bool   // entry of a flag not listed in usage (arg-alias)
+	isConfig   bool   // names a configuration file
+	isCount    bool   // incremented by each occurrence of a flag
+	relative   bool   // accepts relative times (arg-relative)
+	maxCount   int    // maximum number of occurrences (0: unlimited)
+	minval     string // lower bound of numeric values (arg-min)
+	maxval     string // upper bound of numeric values (arg-max)
 
 	// Inferred
-	isSlice  bool
-	baseType reflect.Type
+	isSlice    bool
+	isPointer  bool // nil unless a value is supplied
+	isOptional bool // Optional[T], unset unless a value is supplied
+	baseType   reflect.Type
+
+	// Flags from arg-const set the field to a fixed value, take no argument
+	isConst  bool
+	constval string
 
 	allFlags []string // all flags for this option, used by printUsage
 }
@@ -123,9 +231,17 @@ func unwrap(s any) (reflect.Value, error) {
 //
 // Returns an error if one of the fields is improper, or more than one
 // positional arg is a slice.
+//
+// Results are cached per struct type; the returned map and slice are
+// copies, which the caller may modify (but not the slices they contain).
 func analyzeStruct(v reflect.Value) (map[string]fieldInfo, []fieldInfo, error) {
 	typeInfo := v.Type()
 
+	// The results depend only on the type (see cache.go)
+	if options, positionals, ok := loadAnalysis(typeInfo); ok {
+		return options, positionals, nil
+	}
+
 	options := map[string]fieldInfo{}
 	positionals := []fieldInfo{}
 
@@ -138,16 +254,94 @@ func analyzeStruct(v reflect.Value) (map[string]fieldInfo, []fieldInfo, error) {
 			continue
 		}
 
+		// Fields with special roles are handled elsewhere
+		if _, ok := field.Tag.Lookup(tagAssign); ok {
+			continue
+		}
+		if _, ok := field.Tag.Lookup(tagCommand); ok {
+			continue
+		}
+		if _, ok := field.Tag.Lookup(tagRest); ok {
+			continue
+		}
+
+		// Nested structs contribute their options, with prefixed flags
+		if prefix, ok := field.Tag.Lookup(tagPrefix); ok {
+			if err := analyzeNested(field, prefix, options); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		// Embedded structs contribute their options and positionals
+		allowed := isAllowedType(field.Type)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && !allowed {
+			pos, err := analyzeEmbedded(field, options)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			for _, info := range pos {
+				positionals = append(positionals, info)
+
+				// Each section may have a slice of its own
+				if info.section != "" {
+					slices = 0
+				}
+				if info.isSlice {
+					slices += 1
+					if slices > 1 {
+						return nil, nil,
+							fmt.Errorf("At most one positional field may be slice")
+					}
+				}
+			}
+			continue
+		}
+
 		info, err := makeFieldInfo(field)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		if flag, ok := field.Tag.Lookup(tagFlag); ok {
-			// Field has tag "arg-flag": treat as options field
+		flag, hasFlag := field.Tag.Lookup(tagFlag)
+		consts, hasConst := field.Tag.Lookup(tagConst)
+
+		if hasFlag || hasConst {
+			// Field has tag "arg-flag" or "arg-const": treat as options field
+
+			// Options may be omitted anyway
+			if info.mayOmit {
+				return nil, nil, fmt.Errorf("%s requires positional field: %s",
+					tagOptional, info.Name)
+			}
+			if info.section != "" {
+				return nil, nil, fmt.Errorf("%s requires positional field: %s",
+					tagSection, info.Name)
+			}
+
+			// Environment variables can not be repeated
+			if info.env != "" && info.isSlice {
+				return nil, nil,
+					fmt.Errorf("%s not permitted on slice: %s", tagEnv, info.Name)
+			}
+
+			// Configuration files are named by strings
+			if info.isConfig && info.baseType != reflect.TypeOf("") {
+				return nil, nil,
+					fmt.Errorf("%s requires string field: %s", tagConfig, info.Name)
+			}
+
+			// Occurrences are counted in an int
+			if info.isCount &&
+				(info.baseType != reflect.TypeOf(0) || info.isSlice) {
+				return nil, nil,
+					fmt.Errorf("%s requires int field: %s", tagCount, info.Name)
+			}
 
-			// Extract flags from tag entry
-			flags, err := extractFlagsSorted(flag)
+			// Extract flags from tag entry (derived from the field name, if
+			// "auto")
+			flags, err := extractFlagsSorted(expandAutoFlags(flag, field.Name))
 			if err != nil {
 				return nil, nil, err
 			}
@@ -157,15 +351,122 @@ func analyzeStruct(v reflect.Value) (map[string]fieldInfo, []fieldInfo, error) {
 
 			// For each flag, create a separate entry in map
 			for _, f := range flags {
+				if _, ok := options[f]; ok {
+					return nil, nil, fmt.Errorf("duplicate flag: %s", f)
+				}
 				options[f] = info
 			}
 
+			// Aliases (arg-alias) work like the flags, but are not listed
+			if alias, ok := field.Tag.Lookup(tagAlias); ok {
+				if !hasFlag {
+					return nil, nil, fmt.Errorf("%s requires %s: %s",
+						tagAlias, tagFlag, info.Name)
+				}
+				aliases, err := extractFlagsSorted(alias)
+				if err != nil {
+					return nil, nil, err
+				}
+				for _, a := range aliases {
+					if _, ok := options[a]; ok {
+						return nil, nil, fmt.Errorf("duplicate flag: %s", a)
+					}
+					ainfo := info
+					ainfo.isAlias = true
+					options[a] = ainfo
+				}
+			}
+
+			// Each fixed-value flag is an option of its own
+			constFlags, err := extractConstFlags(consts)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			// Negated flags (arg-negate) set a boolean field to false
+			if negate, ok := field.Tag.Lookup(tagNegate); ok {
+				negated, err := extractNegatedFlags(info, negate)
+				if err != nil {
+					return nil, nil, err
+				}
+				constFlags = append(constFlags, negated...)
+			}
+			for _, c := range constFlags {
+				if _, ok := options[c[0]]; ok {
+					return nil, nil, fmt.Errorf("duplicate flag: %s", c[0])
+				}
+
+				cinfo := info
+				cinfo.allFlags = []string{c[0]}
+				cinfo.isConst = true
+				cinfo.isCount = false
+				cinfo.constval = c[1]
+				options[c[0]] = cinfo
+			}
+
 		} else {
 			// If not flag/option, treat field as positional
 
+			if info.env != "" {
+				return nil, nil,
+					fmt.Errorf("%s requires %s: %s", tagEnv, tagFlag, info.Name)
+			}
+			if info.isConfig {
+				return nil, nil,
+					fmt.Errorf("%s requires %s: %s", tagConfig, tagFlag, info.Name)
+			}
+			if _, ok := field.Tag.Lookup(tagNegate); ok {
+				return nil, nil,
+					fmt.Errorf("%s requires %s: %s", tagNegate, tagFlag, info.Name)
+			}
+			if info.isCount {
+				return nil, nil,
+					fmt.Errorf("%s requires %s: %s", tagCount, tagFlag, info.Name)
+			}
+			if info.maxCount != 0 {
+				return nil, nil, fmt.Errorf("%s and %s require %s: %s",
+					tagOnce, tagMaxCnt, tagFlag, info.Name)
+			}
+			if info.isNumber {
+				return nil, nil,
+					fmt.Errorf("%s requires %s: %s", tagNumber, tagFlag, info.Name)
+			}
+			if info.hasConfirm {
+				return nil, nil,
+					fmt.Errorf("%s requires %s: %s", tagConfirm, tagFlag, info.Name)
+			}
+			if info.hidden {
+				return nil, nil,
+					fmt.Errorf("%s requires %s: %s", tagHidden, tagFlag, info.Name)
+			}
+			if info.isDeprec {
+				return nil, nil,
+					fmt.Errorf("%s requires %s: %s", tagDeprec, tagFlag, info.Name)
+			}
+			if _, ok := field.Tag.Lookup(tagAlias); ok {
+				return nil, nil,
+					fmt.Errorf("%s requires %s: %s", tagAlias, tagFlag, info.Name)
+			}
+			if info.group != "" {
+				return nil, nil,
+					fmt.Errorf("%s requires %s: %s", tagGroup, tagFlag, info.Name)
+			}
+			if info.required {
+				return nil, nil,
+					fmt.Errorf("%s requires %s: %s", tagRequired, tagFlag, info.Name)
+			}
+			if info.persistent {
+				return nil, nil,
+					fmt.Errorf("%s requires %s: %s", tagPersist, tagFlag, info.Name)
+			}
+
 			positionals = append(positionals, info)
 
-			// Count positional slices; more than one is an error
+			// Count positional slices; more than one (per section) is an
+			// error
+			if info.section != "" {
+				slices = 0
+			}
 			if info.isSlice {
 				slices += 1
 				if slices > 1 {
@@ -176,9 +477,107 @@ func analyzeStruct(v reflect.Value) (map[string]fieldInfo, []fieldInfo, error) {
 		}
 	}
 
+	if err := checkSections(positionals); err != nil {
+		return nil, nil, err
+	}
+	for _, sec := range splitSections(positionals, nil) {
+		if err := checkOptionalPositionals(sec.fields); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := checkNumberOptions(options); err != nil {
+		return nil, nil, err
+	}
+	if err := checkConfirmOptions(options); err != nil {
+		return nil, nil, err
+	}
+	if err := checkRequires(options); err != nil {
+		return nil, nil, err
+	}
+
+	options, positionals = storeAnalysis(typeInfo, options, positionals)
 	return options, positionals, nil
 }
 
+// CheckOptionalPositionals takes the positional fields of a struct, and
+// checks those that may be omitted (arg-optional): they must follow all
+// other positionals, and are not permitted together with a positional
+// slice. Returns an error if one of these conditions is violated.
+func checkOptionalPositionals(positionals []fieldInfo) error {
+	optional, slice := "", false
+	for _, info := range positionals {
+		if info.mayOmit && info.isSlice {
+			return fmt.Errorf("%s not permitted on slice: %s", tagOptional,
+				info.Name)
+		}
+		if info.mayOmit && optional == "" {
+			optional = info.Name
+		}
+		slice = slice || info.isSlice
+	}
+	if optional != "" && slice {
+		return fmt.Errorf("%s not permitted with positional slice: %s",
+			tagOptional, optional)
+	}
+
+	omitted := ""
+	for _, info := range positionals {
+		switch {
+		case info.mayOmit:
+			omitted = info.Name
+		case omitted != "":
+			return fmt.Errorf("positional %s follows %s positional %s",
+				info.Name, tagOptional, omitted)
+		}
+	}
+	return nil
+}
+
+// UniqueOptions takes a map of options, as returned by analyzeStruct, and
+// returns a slice that contains each option only once (no matter how many
+// flags or aliases it has), ordered by the option's first flag (in sorted
+// order).
+func uniqueOptions(options map[string]fieldInfo) []fieldInfo {
+	keys := sortableFlags{}
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Sort(keys)
+
+	out := []fieldInfo{}
+	seen := map[string]struct{}{}
+	for _, k := range keys {
+		if _, ok := seen[k]; ok {
+			continue
+		}
+
+		info := options[k]
+		if info.isAlias {
+			continue
+		}
+		for _, f := range info.allFlags {
+			seen[f] = struct{}{}
+		}
+		out = append(out, info)
+	}
+
+	return out
+}
+
+// OptionAliases takes a map of options, as returned by analyzeStruct, and
+// one of the options, and returns the aliases (arg-alias) of the option's
+// field, in sorted order. Fixed-value flags have no aliases.
+func optionAliases(options map[string]fieldInfo, info fieldInfo) []string {
+	aliases := sortableFlags{}
+	for k, o := range options {
+		if o.isAlias && o.Name == info.Name && !info.isConst {
+			aliases = append(aliases, k)
+		}
+	}
+	sort.Sort(aliases)
+	return aliases
+}
+
 // MakeFieldInfo analyses the struct field supplied as argument,
 // reading both the field's type and build tags. Returns a populated
 // fieldInfo on success, or an error if it encounters a forbidden
@@ -191,30 +590,155 @@ func makeFieldInfo(field reflect.StructField) (fieldInfo, error) {
 		help:       field.Tag.Get(tagHelp),
 		defaultval: field.Tag.Get(tagDefault),
 		format:     field.Tag.Get(tagFormat),
+		url:        field.Tag.Get(tagURL),
+		argname:    strings.TrimSpace(field.Tag.Get(tagName)),
+		choices:    strings.Fields(field.Tag.Get(tagChoices)),
+		xorGroup:   field.Tag.Get(tagXor),
+		reqGroup:   field.Tag.Get(tagRequire),
+		togGroup:   field.Tag.Get(tagTogether),
+		group:      strings.TrimSpace(field.Tag.Get(tagGroup)),
+		requires:   strings.Fields(field.Tag.Get(tagRequires)),
+		env:        strings.TrimSpace(field.Tag.Get(tagEnv)),
+		encoding:   strings.TrimSpace(field.Tag.Get(tagEncoding)),
+		sep:        field.Tag.Get(tagSep),
+		minval:     strings.TrimSpace(field.Tag.Get(tagMin)),
+		maxval:     strings.TrimSpace(field.Tag.Get(tagMax)),
+		index:      -1,
 	}
 
-	// Disallows pointers
-	if field.Type.Kind() == reflect.Pointer {
-		return fieldInfo{},
-			fmt.Errorf("pointers not permitted in struct, maybe use %s tag",
-				tagIgnore)
+	if info.env == noEnvName {
+		info.env, info.noEnv = "", true
+	}
+
+	_, info.secret = field.Tag.Lookup(tagSecret)
+	_, info.isConfig = field.Tag.Lookup(tagConfig)
+	_, info.isCount = field.Tag.Lookup(tagCount)
+	_, info.relative = field.Tag.Lookup(tagRelative)
+	info.resetval, info.canReset = field.Tag.Lookup(tagReset)
+	_, info.unique = field.Tag.Lookup(tagUnique)
+	_, info.sorted = field.Tag.Lookup(tagSorted)
+	_, info.mayOmit = field.Tag.Lookup(tagOptional)
+	_, info.isNumber = field.Tag.Lookup(tagNumber)
+	info.confirm, info.hasConfirm = field.Tag.Lookup(tagConfirm)
+	_, info.hidden = field.Tag.Lookup(tagHidden)
+	_, info.required = field.Tag.Lookup(tagRequired)
+	_, info.persistent = field.Tag.Lookup(tagPersist)
+	info.deprecated, info.isDeprec = field.Tag.Lookup(tagDeprec)
+	info.deprecated = strings.TrimSpace(info.deprecated)
+	info.confirm = strings.TrimSpace(info.confirm)
+
+	// Positionals may be split into sections by a literal token
+	if s, ok := field.Tag.Lookup(tagSection); ok {
+		info.section = strings.TrimSpace(s)
+		if !sectionRE.MatchString(info.section) {
+			return fieldInfo{}, fmt.Errorf("malformed %s: %s", tagSection,
+				field.Name)
+		}
+	}
+
+	// Restrictions on repeated flags (arg-once, arg-max-count)
+	if _, ok := field.Tag.Lookup(tagOnce); ok {
+		info.maxCount = 1
+	}
+	if s, ok := field.Tag.Lookup(tagMaxCnt); ok {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n < 1 || info.maxCount != 0 {
+			return fieldInfo{}, fmt.Errorf("malformed %s: %s", tagMaxCnt,
+				field.Name)
+		}
+		info.maxCount = n
 	}
 
 	info.baseType = field.Type
 
-	// Unwrap the base type of slice elements
-	if field.Type.Kind() == reflect.Slice {
+	// Pointers to permitted types remain nil unless a value is supplied
+	// (and Optionals remain unset); pointers to pointers or slices (and
+	// Optionals of them) are not permitted
+	if field.Type.Kind() == reflect.Pointer {
+		info.isPointer = true
+		info.baseType = field.Type.Elem()
+	} else if t, ok := optionalElem(field.Type); ok {
+		info.isOptional = true
+		info.baseType = t
+	}
+	if info.isPointer || info.isOptional {
+		kind := info.baseType.Kind()
+		if kind == reflect.Pointer ||
+			(kind == reflect.Slice && !isAllowedType(info.baseType)) {
+			return fieldInfo{},
+				fmt.Errorf("%s not permitted in struct, maybe use %s tag",
+					field.Type.String(), tagIgnore)
+		}
+	}
+
+	// Unwrap the base type of slice elements (unless the slice type has a
+	// converter of its own)
+	if field.Type.Kind() == reflect.Slice && !isAllowedType(field.Type) {
 		info.isSlice = true
 		info.baseType = field.Type.Elem()
 	}
 
-	// Check for permissible base types
-	if _, ok := allowedTypes[info.baseType]; !ok {
+	// Check for permissible base types (including registered converters)
+	if !isAllowedType(info.baseType) {
 		return fieldInfo{},
 			fmt.Errorf("%s not permitted in struct, maybe use %s tag",
 				info.baseType.String(), tagIgnore)
 	}
 
+	// Bounds must be valid values of a numeric field (arg-min, arg-max)
+	if err := checkRangeTags(info); err != nil {
+		return fieldInfo{}, err
+	}
+
+	// Patterns are compiled once (the analysis is cached)
+	if s, ok := field.Tag.Lookup(tagPattern); ok {
+		if info.baseType != reflect.TypeOf("") {
+			return fieldInfo{}, fmt.Errorf("%s requires string field: %s",
+				tagPattern, field.Name)
+		}
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return fieldInfo{}, fmt.Errorf("malformed %s: %s: %w", tagPattern,
+				field.Name, err)
+		}
+		info.pattern = re
+	}
+
+	// Locations are loaded once, like patterns
+	if s, ok := field.Tag.Lookup(tagLocation); ok {
+		if info.baseType != reflect.TypeOf(time.Time{}) {
+			return fieldInfo{}, fmt.Errorf("%s requires time.Time field: %s",
+				tagLocation, field.Name)
+		}
+		loc, err := time.LoadLocation(strings.TrimSpace(s))
+		if err != nil || strings.TrimSpace(s) == "" {
+			return fieldInfo{}, fmt.Errorf("malformed %s: %s", tagLocation,
+				field.Name)
+		}
+		info.location = loc
+	}
+
+	if info.relative && info.baseType != reflect.TypeOf(time.Time{}) {
+		return fieldInfo{}, fmt.Errorf("%s requires time.Time field: %s",
+			tagRelative, field.Name)
+	}
+
+	if err := checkEncodingTag(info); err != nil {
+		return fieldInfo{}, err
+	}
+
+	if err := checkSeparatorTag(info); err != nil {
+		return fieldInfo{}, err
+	}
+
+	if err := checkResetTag(info); err != nil {
+		return fieldInfo{}, err
+	}
+
+	if err := checkUniqueTags(info); err != nil {
+		return fieldInfo{}, err
+	}
+
 	return info, nil
 }
 
@@ -239,9 +763,79 @@ func extractFlagsSorted(s string) (sortableFlags, error) {
 	return out, nil
 }
 
+// ExtractConstFlags parses its argument, which should be an arg-const tag,
+// consisting of whitespace-separated "flag=value" pairs. Returns the pairs,
+// in order, or an error if one of them is misformed.
+func extractConstFlags(s string) ([][2]string, error) {
+	out := [][2]string{}
+
+	for _, token := range strings.Fields(s) {
+		flag, value, ok := strings.Cut(token, "=")
+		if !ok {
+			return nil, fmt.Errorf("missing value for fixed-value flag: %s", token)
+		}
+		if !shortFlagRE.MatchString(flag) && !longFlagRE.MatchString(flag) {
+			return nil, fmt.Errorf("malformed flag: %s", flag)
+		}
+
+		out = append(out, [2]string{flag, value})
+	}
+
+	return out, nil
+}
+
+// ExtractNegatedFlags takes the fieldInfo of an option, which must be a
+// (non-slice) boolean, and its arg-negate tag, which is either empty or a
+// whitespace-separated list of flags, and returns the flags that set the
+// option to false, as "flag=value" pairs like those of extractConstFlags().
+// If the tag is empty, each long flag --xx of the option is negated by a
+// flag --no-xx, and each flag +x by the flag -x.
+// Returns an error if the option is not a boolean, if one of the flags is
+// misformed, or if no flags result.
+func extractNegatedFlags(info fieldInfo, s string) ([][2]string, error) {
+	if info.baseType != reflect.TypeOf(true) || info.isSlice {
+		return nil, fmt.Errorf("%s requires bool field: %s", tagNegate,
+			info.Name)
+	}
+
+	flags := strings.Fields(s)
+	if len(flags) == 0 {
+		for _, f := range info.allFlags {
+			switch {
+			case strings.HasPrefix(f, "--"):
+				flags = append(flags, "--no-"+f[2:])
+			case strings.HasPrefix(f, "+"):
+				flags = append(flags, "-"+f[1:])
+			}
+		}
+	}
+	if len(flags) == 0 {
+		return nil, fmt.Errorf("%s requires long or + flag: %s", tagNegate,
+			info.Name)
+	}
+
+	out := [][2]string{}
+	for _, f := range flags {
+		if !shortFlagRE.MatchString(f) && !longFlagRE.MatchString(f) {
+			return nil, fmt.Errorf("malformed flag: %s", f)
+		}
+		out = append(out, [2]string{f, "false"})
+	}
+
+	return out, nil
+}
+
+// TakesArgument reports whether the flags of an option require an argument:
+// all flags do, except those of boolean fields, fixed-value flags, and
+// flags that are counted (arg-count).
+func takesArgument(info fieldInfo) bool {
+	return info.baseType != reflect.TypeOf(true) && !info.isConst &&
+		!info.isCount
+}
+
 // PopulateFromSlice takes a slice of tokens, a pointer to a struct, and
-// a flag that indicates whether values MUST be fused to their flags, and
-// populates the struct from the tokens.
+// a Parser that holds the configuration to use (such as whether values MUST
+// be fused to their flags), and populates the struct from the tokens.
 //
 // Returns an error if the struct or its tags are malformed, if the number
 // of tokens does not match the struct, or if one of the tokens (or one of
@@ -251,20 +845,197 @@ func extractFlagsSorted(s string) (sortableFlags, error) {
 // positionals.
 // Unrecognized flags (tokens like -X, --XX, +X, but without matching tag
 // entries) are treated as positionals.
-func populateFromSlice(tokens []string, data any, isFused bool) error {
+func populateFromSlice(tokens []string, data any, p *Parser) error {
 	v, err := unwrap(data)
 	if err != nil {
 		return err
 	}
 
+	tokens, err = p.preprocess(tokens)
+	if err != nil {
+		return err
+	}
+
+	return populateStruct(tokens, v, p, &parseState{defaults: p.defaults})
+}
+
+// parseState holds the state of a single parse, which is passed down from
+// a struct to its subcommands.
+type parseState struct {
+	defaults map[string]any   // runtime defaults (top-level struct only)
+	report   *ParseReport     // nil if no report was requested
+	path     string           // field path of the subcommand, with final "."
+	command  string           // names of the selected subcommands, with final " "
+	indices  []int            // command-line indices of the struct's tokens
+	partial  bool             // leading tokens only (see ParsePartial)
+	selected *[]reflect.Value // structs of the selected subcommands, if wanted
+}
+
+// Positions takes the indices of some of the struct's tokens, and returns
+// their indices on the command line.
+func (st *parseState) positions(index []int) []int {
+	out := make([]int, len(index))
+	for i, k := range index {
+		out[i] = st.position(k)
+	}
+	return out
+}
+
+// Position takes the index of one of the struct's tokens, and returns its
+// index on the command line.
+func (st *parseState) position(k int) int {
+	if st.indices == nil {
+		return k
+	}
+	return mappedIndex(k, st.indices)
+}
+
+// PopulateStruct takes a slice of tokens, a reflect.Value, which must
+// represent the struct to populate, a Parser, and the state of the parse
+// (including runtime defaults, keyed on field name), and populates the
+// struct from the tokens. If the struct has subcommands, and one of them is
+// selected by the tokens, the subcommand's struct is populated recursively
+// (without runtime defaults, which only apply to the top-level struct).
+// Returns an error if the struct or its tags are malformed, or if the
+// tokens cannot be assigned to the struct.
+func populateStruct(tokens []string, v reflect.Value, p *Parser,
+	st *parseState) (err error) {
+
+	isFused := p.fused
+
 	options, positionals, err := analyzeStruct(v)
 	if err != nil {
 		return err
 	}
+	options = withEnvPrefix(options, p.envPrefix)
+
+	// Where each field's value came from, if not from the command line
+	origins := fieldOrigins{}
+
+	commands, err := findCommands(v)
+	if err != nil {
+		return err
+	}
+
+	// Single-dash long flags (as in -name) are rewritten to their usual form
+	if p.singleDash {
+		tokens = rewriteSingleDash(p, options, tokens, isFused,
+			len(commands) > 0)
+	}
 
-	// If not fused mode, populate non-slice options w/ default values
+	// Windows-style flags (as in /name:value) are rewritten likewise
+	if p.windows {
+		tokens = rewriteWindowsFlags(p, options, tokens, isFused,
+			len(commands) > 0)
+	}
+
+	// Automatic help and version, before any other errors can occur
+	if err := checkSpecialFlags(p, st, v, options, tokens,
+		isFused); err != nil {
+		return err
+	}
+
+	// Runtime defaults take the place of arg-default tags
+	if err := applyDefaults(options, positionals, st.defaults); err != nil {
+		return err
+	}
+
+	// If not fused mode, populate options w/ default values (with sticky
+	// defaults, only those that have not been set already)
+	defaultOpts := map[string]fieldInfo{}
 	if !isFused {
-		if err := populateDefaults(options, v); err != nil {
+		defaultOpts = options
+		if p.sticky {
+			defaultOpts = withoutPreset(options, v)
+		}
+		if err := populateDefaults(defaultOpts, v); err != nil {
+			return err
+		}
+		origins.addDefaults(options, defaultOpts, st.defaults)
+	}
+	origins.addPositionalDefaults(positionals, st.defaults)
+
+	// Configuration files take precedence over default values (top level);
+	// files named on the command line (arg-config) are read last
+	configured := map[string]string{}
+	if st.path == "" {
+		sources := append(append([]configSource{}, p.configs...),
+			configFlagSources(v, options, positionals, tokens, isFused)...)
+
+		configured, err = populateConfig(sources, options, v)
+		if err != nil {
+			return err
+		}
+		origins.add(configured, SourceConfig)
+	}
+
+	// Environment variables take precedence over default values
+	environ, err := populateEnv(options, v)
+	if err != nil {
+		return err
+	}
+	origins.add(environ, SourceEnv)
+
+	// Bare numbers (as in -20) set the option tagged arg-number (if any)
+	tokens = rewriteNumbers(options, tokens, isFused,
+		len(commands) > 0 || p.posix)
+
+	// Split off the subcommand (if any), and the tokens that belong to it;
+	// these follow the subcommand's name, unless the default subcommand
+	// was selected, which has none
+	allTokens := len(tokens)
+	tokens, cmd, cmdTokens, err := splitCommand(v, options, positionals,
+		tokens, isFused)
+	if err != nil {
+		return err
+	}
+	cmdOffset := allTokens - len(cmdTokens)
+
+	// Persistent flags (arg-persistent) may follow the subcommand name; the
+	// indices of the struct's tokens, and the subcommand's, among the tokens
+	// as given are kept, for errors and reports (nil: unchanged)
+	var index, cmdIndex []int
+	if cmd != nil {
+		moved, rest, err := splitPersistent(options, cmd.Type, cmdTokens,
+			isFused)
+		if err != nil {
+			return err
+		}
+		cmdTokens, cmdIndex = []string{}, []int{}
+		for _, t := range rest {
+			cmdTokens = append(cmdTokens, t.token)
+			cmdIndex = append(cmdIndex, cmdOffset+t.index)
+		}
+		if len(moved) > 0 {
+			tokens = append([]string{}, tokens...)
+			for i := range tokens {
+				index = append(index, i)
+			}
+			for _, t := range moved {
+				tokens = append(tokens, t.token)
+				index = append(index, cmdOffset+t.index)
+			}
+		}
+	}
+	defer func() { err = mapIndex(err, index) }()
+
+	// Tokens following "--" go to the arg-rest field (if any), verbatim
+	if !st.partial {
+		tokens, err = populateRest(v, tokens)
+		if err != nil {
+			return err
+		}
+	}
+
+	// With POSIX ordering, the first positional token ends the flags
+	posixAt := -1
+	if p.posix {
+		tokens, posixAt = posixOrder(options, tokens, isFused, p.strict)
+	}
+
+	// In strict mode, unknown flags are errors rather than positionals
+	if p.strict {
+		if err := checkUnknownFlags(options, tokens, isFused); err != nil {
 			return err
 		}
 	}
@@ -275,35 +1046,171 @@ func populateFromSlice(tokens []string, data any, isFused bool) error {
 		return err
 	}
 
-	// ... use results to populate struct
+	// Extract NAME=value assignments (before "--") from positional tokens
+	posTokens, err = populateAssignments(v, posTokens,
+		len(posTokens)-countAfterEndFlags(tokens))
+	if err != nil {
+		return err
+	}
+
+	// With sticky defaults, values from the command line replace slices
+	// (as they do for slices from configuration files, or default values)
+	if p.sticky {
+		resetSlices(retainedOpts, positionals, len(posTokens), v)
+	} else {
+		resetConfigured(retainedOpts, configured, v)
+		resetDefaulted(retainedOpts, defaultOpts, v)
+	}
+
+	// ... use results to populate struct (unless flags are repeated too often)
+	suppliedOpts := retainedOpts
+	retainedOpts = applyDuplicatePolicy(p.duplicates, retainedOpts)
+	if err := validateRepeats(retainedOpts); err != nil {
+		return err
+	}
 	if err := populateOptions(retainedOpts, v); err != nil {
 		return err
 	}
-	if err := populatePositionals(positionals, posTokens, v); err != nil {
+	if !st.partial {
+		warnDeprecated(p, retainedOpts)
+	}
+	if err := populateSections(positionals, posTokens, v); err != nil {
+		return hintUnknownFlags(err, options, tokens, isFused)
+	}
+
+	// Remove duplicates from, and sort, slices (arg-unique, arg-sorted)
+	normalizeSlices(options, positionals, v)
+
+	// Check constraints that involve several fields
+	if err := validateGroups(options, retainedOpts); err != nil {
 		return err
 	}
 
-	return nil
+	// Check that required options are set (arg-required)
+	if !st.partial {
+		err := validateRequired(options, retainedOpts, configured, environ)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Ask for confirmation of options that were not supplied (arg-confirm)
+	if p.confirm && !st.partial {
+		if err := confirmOptions(p, options, retainedOpts, v); err != nil {
+			return err
+		}
+	}
+
+	// Record which fields were set, and where
+	if st.report != nil {
+		idx := positionalTokenIndices(v, options, tokens, isFused)
+		if len(idx) != len(posTokens) {
+			idx = nil // should not happen; don't report positions
+		}
+		for j, k := range idx {
+			if posixAt >= 0 && k > posixAt {
+				idx[j] = k - 1 // the "--" inserted for POSIX ordering
+			}
+		}
+		rst := *st
+		if index != nil {
+			rst.indices = st.positions(index)
+		}
+		st.report.record(&rst, options, splitSections(positionals, posTokens),
+			suppliedOpts, retainedOpts, idx, origins)
+	}
+
+	// Populate the subcommand (token indices relative to its tokens)
+	if cmd != nil {
+		err := populateCommand(*cmd, cmdTokens, v, p, &parseState{
+			report:   st.report,
+			path:     st.path + cmd.Name + ".",
+			command:  st.command + cmd.name + " ",
+			indices:  st.positions(cmdIndex),
+			selected: st.selected,
+		})
+		if err != nil {
+			index = nil // the subcommand's own indices
+			return mapIndex(err, cmdIndex)
+		}
+	}
+
+	// Finally, let the struct check itself (after its subcommand)
+	return validateStruct(v)
 }
 
 // Given a map of options, and a reflect.Value representing a pointer to the
-// struct to populate, populate all non-slice options with their default
-// values (if any). Returns an error if default value conversion fails.
+// struct to populate, populate all options with their default values (if
+// any). The default value of a slice may hold several elements, separated
+// by the field's separator (see defaultSeparator).
+// Returns an error if default value conversion fails.
 func populateDefaults(options map[string]fieldInfo, v reflect.Value) error {
 	defaultOptions := []fieldInfo{}
 
+	seen := map[string]struct{}{}
 	for _, info := range options {
+		_, done := seen[info.Name]
+		if info.isSlice && info.defaultval != "" && !done {
+			// Each slice is populated once (not once for each flag), and
+			// its elements do not fall back on the default themselves
+			seen[info.Name] = struct{}{}
+			info.isConst, info.isCount = false, false
+			info.value, info.defaultval = info.defaultval, ""
+			info.sep = defaultSeparator(info)
+			defaultOptions = append(defaultOptions, info)
+		}
+
 		if !info.isSlice && info.defaultval != "" {
+			// The default applies to the field, not the fixed-value flag
+			// (or the counted flag); boolean defaults are parsed (like
+			// fixed values)
+			info.isConst = info.baseType == reflect.TypeOf(true)
+			info.isCount = false
+			info.constval = info.defaultval
 			defaultOptions = append(defaultOptions, info)
 		}
 	}
 	if err := populateOptions(defaultOptions, v); err != nil {
-		return err
+		// Not a ParseError: the default is part of the struct definition
+		return fmt.Errorf("invalid default value: %v", err)
 	}
 
 	return nil
 }
 
+// Given a map of options, and a reflect.Value representing a pointer to the
+// struct to populate, populate all options tagged arg-env with the value of
+// their environment variable, if it is set and not empty. Since this
+// happens after default values have been applied, and before values from
+// the command line are, the command line takes precedence over the
+// environment, which takes precedence over default values.
+// Returns the names of the fields that were populated (mapped to their
+// variables), or an error if value conversion fails.
+func populateEnv(options map[string]fieldInfo,
+	v reflect.Value) (map[string]string, error) {
+
+	out := map[string]string{}
+	for _, info := range options {
+		s := os.Getenv(info.env)
+		if info.env == "" || s == "" {
+			continue
+		}
+
+		// The value applies to the field, not the fixed-value flag; boolean
+		// values are parsed (like fixed values), rather than implied
+		info.isConst = info.baseType == reflect.TypeOf(true)
+		info.isCount = false
+		info.constval = s
+		info.value = s
+		if err := populateField(info, v); err != nil {
+			return nil, errorf("environment variable %s: %w", info.env, err)
+		}
+		out[info.Name] = info.env
+	}
+
+	return out, nil
+}
+
 func processTokens(options map[string]fieldInfo, tokens []string,
 	isFused bool) ([]fieldInfo, []string, error) {
 	// return processTokens1(options, tokens, isFused)
@@ -373,7 +1280,7 @@ func processTokens1(options map[string]fieldInfo, tokens []string,
 			if len(tokens) > i {
 				info.value = tokens[i]
 			} else {
-				return nil, nil, fmt.Errorf("not enough values")
+				return nil, nil, errorf("not enough values")
 			}
 		}
 		retainedOptions = append(retainedOptions, info)
@@ -432,7 +1339,7 @@ func processTokens2(options map[string]fieldInfo, tokens []string,
 			if len(tokens) > i {
 				info.value = tokens[i]
 			} else {
-				return nil, nil, fmt.Errorf("not enough values")
+				return nil, nil, errorf("not enough values")
 			}
 		}
 		retainedOptions = append(retainedOptions, info)
@@ -560,6 +1467,39 @@ func chopToken(s string) (string, string) {
 	}
 }
 
+// ExplicitBool takes the fieldInfo of a known flag, the token in which
+// the flag was found, and the rest of the token following the flag (as
+// returned by chopToken), and returns the explicit value given for a
+// boolean flag, as in --color=no (or --color=) or -b=false. The boolean
+// return value is false if the flag is not boolean, or the token does not
+// contain an explicit value. Fixed-value flags never take a value.
+func explicitBool(info fieldInfo, token, rest string) (string, bool) {
+	if info.baseType != reflect.TypeOf(true) || info.isConst {
+		return "", false
+	}
+
+	if strings.HasPrefix(token, "--") {
+		return rest, strings.Contains(token, "=")
+	}
+	if strings.HasPrefix(rest, "=") {
+		return rest[1:], true
+	}
+	return "", false
+}
+
+// FusedShortValue takes a token and the rest of the token following the
+// flag (as returned by chopToken), and returns the value fused to the
+// flag: for short flags, a leading equality sign is dropped (so that -c=9
+// means the same as -c9). The boolean return value is true if the sign was
+// dropped.
+func fusedShortValue(token, rest string) (string, bool) {
+	if strings.HasPrefix(token, "--") {
+		return rest, false
+	}
+	value, ok := strings.CutPrefix(rest, "=")
+	return value, ok
+}
+
 // ProcessMaybeFlags takes a slice of tokens, which may be a mix of flags,
 // their associated values, and positional arguments, and a map of fieldInfo,
 // keyed on the flag. Returns a slice of fieldInfo containing the recognized,
@@ -580,12 +1520,16 @@ func processMaybeFlags(tokens []string, options map[string]fieldInfo,
 		return flags, positionals, nil
 	}
 
+	// Index of the current token, for error reporting
+	n, pos := len(tokens), -1
+
 	isCompound := false
 	for token := ""; len(tokens) > 0 || token != ""; {
 
 		if token == "" {
 			token, tokens = tokens[0], tokens[1:]
 			isCompound = false
+			pos = n - len(tokens) - 1
 		}
 
 		flag, rest := chopToken(token)
@@ -593,7 +1537,8 @@ func processMaybeFlags(tokens []string, options map[string]fieldInfo,
 
 		// When parsing compound flag, all flags should be recognized
 		if !ok && isCompound {
-			return nil, nil, fmt.Errorf("Unexpected %s in compound flag", token)
+			return nil, nil, newParseError(ErrUnknownFlag, token, pos,
+				"Unexpected %s in compound flag", token)
 		}
 
 		// Not recognized as flag (known or not); treat as positional
@@ -603,6 +1548,23 @@ func processMaybeFlags(tokens []string, options map[string]fieldInfo,
 			continue
 		}
 
+		// Booleans may take an explicit value, as in --color=no or -b=false
+		if value, ok := explicitBool(info, token, rest); ok {
+			if value == "" {
+				err := newParseError(ErrMissingValue, token, pos,
+					"missing value: %s", token)
+				err.Field, err.Flag = info.Name, flag
+				return nil, nil, err
+			}
+
+			info.flag = flag
+			info.value = value
+			info.index = pos
+			flags = append(flags, info)
+			token = ""
+			continue
+		}
+
 		// Now: flag is a known flag. Is it complete? Is it compound?
 		// Complete: boolean and rest empty OR not boolean and rest not empty
 		//           this means isBoolean and isRestEmpty must be equal!
@@ -610,20 +1572,30 @@ func processMaybeFlags(tokens []string, options map[string]fieldInfo,
 		// Incomplete: not boolean and rest empty
 
 		// Two variables to make the switch below more readable
-		isFlagBoolean := info.baseType == reflect.TypeOf(true)
+		// (Fixed-value flags behave just like boolean flags here)
+		isFlagBoolean := !takesArgument(info)
 		isRestEmpty := rest == ""
 
 		switch {
 		case isFlagBoolean == isRestEmpty: // Complete
+			// Short flags may be fused with an equality sign, as in -c=9
+			value, ok := fusedShortValue(token, rest)
+			if ok && value == "" && !isFlagBoolean {
+				err := newParseError(ErrMissingValue, token, pos,
+					"missing value: %s", token)
+				err.Field, err.Flag = info.Name, flag
+				return nil, nil, err
+			}
+
 			info.flag = flag
-			info.value = rest
+			info.value = value
 			token = ""
 
 		case isFlagBoolean && !isRestEmpty: // Compound
 			// Do NOT discard token; instead use rest to form new token!
 			info.flag = flag
 			info.value = ""
-			token = "-" + rest
+			token = flag[:1] + rest
 
 			// If compound, then all following flags must be recognized!
 			isCompound = true
@@ -640,7 +1612,10 @@ func processMaybeFlags(tokens []string, options map[string]fieldInfo,
 					info.value = tokens[0]
 					token, tokens = "", tokens[1:]
 				} else {
-					return nil, nil, fmt.Errorf("not enough tokens: %s", flag)
+					err := newParseError(ErrMissingValue, flag, pos,
+						"not enough tokens: %s", flag)
+					err.Field, err.Flag = info.Name, flag
+					return nil, nil, err
 				}
 			}
 
@@ -648,6 +1623,11 @@ func processMaybeFlags(tokens []string, options map[string]fieldInfo,
 			// Cannot happen, because all options are covered!
 		}
 
+		if info.isConst {
+			info.value = info.constval
+		}
+
+		info.index = pos
 		flags = append(flags, info)
 	}
 
@@ -666,87 +1646,453 @@ func processMaybeFlags(tokens []string, options map[string]fieldInfo,
 // Behavior undefined (may panic) if fieldInfo does not refer to an
 // existing, publicly accessible field.
 func populateField(info fieldInfo, v reflect.Value) error {
+	// Counters are incremented by each occurrence of their flags
+	if info.isCount {
+		field := fieldValue(info, v)
+		field.SetInt(field.Int() + 1)
+		return nil
+	}
+
+	// The reset value (arg-reset) empties a slice, rather than appending
+	if isReset(info) {
+		resetSlice(info, v)
+		return nil
+	}
+
+	// Values of slices with a separator (arg-sep) hold several elements
+	if info.isSlice && info.sep != "" && info.value != "" {
+		for _, elem := range splitValue(info.value, info.sep) {
+			elemInfo := info
+			elemInfo.sep, elemInfo.value = "", elem
+			elemInfo.canReset = false
+			if err := populateField(elemInfo, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Types implementing flag.Value set themselves (see setFlagValue)
+	if !info.isSlice && isFlagValue(info.baseType) {
+		value := info.value
+		if value == "" {
+			value = info.defaultval
+		}
+		return setFlagValue(info, v, value)
+	}
+
 	// Convert the input value to the appropriate baseType,
 	// then wrap the result into a reflect.Value again (also pointer)
 	vv, err := convertToType(info)
 	if err != nil {
-		return err
+		if errors.As(err, new(*ParseError)) {
+			return err
+		}
+		return valueError(ErrConversion, info, err)
+	}
+	if err := validateValue(vv); err != nil {
+		err = errorf("invalid value for %s: %w", displayFlag(info), err)
+		return valueError(ErrValidation, info, err)
+	}
+
+	field := fieldValue(info, v) // field is reflect.Value
+
+	// If field is slice and not assigned yet, create a slice of proper type
+	if info.isSlice && field.IsNil() {
+		field.Set(reflect.MakeSlice(reflect.SliceOf(info.baseType), 0, 0))
+	}
+
+	if info.isSlice {
+		field.Set(reflect.Append(field, vv))
+	} else {
+		field.Set(vv)
+	}
+
+	return nil
+}
+
+// FieldValue takes a fieldInfo and a reflect.Value, which must represent
+// the struct to populate, and returns the field that receives values. For
+// pointer fields, this is the value pointed to, which is allocated first if
+// the pointer is nil; for Optional fields, it is the value held by the
+// Optional, which is marked as set.
+func fieldValue(info fieldInfo, v reflect.Value) reflect.Value {
+	field := v.FieldByIndex(info.Index)
+	if info.isOptional {
+		return field.Addr().Interface().(optionalValue).setValue()
+	}
+	if !info.isPointer {
+		return field
+	}
+	if field.IsNil() {
+		field.Set(reflect.New(info.baseType))
+	}
+	return field.Elem()
+}
+
+// CheckChoices takes a fieldInfo and a value for it, and returns an error
+// if the field has permitted values (arg-choices), and the value is not
+// one of them. Booleans, which take no value, are never restricted.
+func checkChoices(info fieldInfo, value string) error {
+	if len(info.choices) > 0 && info.baseType != reflect.TypeOf(true) &&
+		!slices.Contains(info.choices, value) {
+		err := errorf("invalid value %q for %s, must be one of: %s",
+			value, info.Name, strings.Join(info.choices, ", "))
+		return valueError(ErrInvalidChoice, info, err)
+	}
+	return nil
+}
+
+// ConvertToType takes a fieldInfo, and converts its (string) value field
+// into the appropriate type. If the value field is the empty string, it
+// uses the default value instead.
+// Conversion to time.Time type uses the given format, unless it is empty.
+// Returns a reflect.Value of the converted value.
+// Returns an error if the conversion fails, or if the value is not one of
+// the permitted values (arg-choices); the error does not contain the value
+// if the field is tagged arg-secret.
+func convertToType(info fieldInfo) (reflect.Value, error) {
+
+	// Pull in default value
+	value := info.value
+	if value == "" {
+		value = info.defaultval
+	}
+
+	// Restrict to permitted values (booleans take no value)
+	if err := checkChoices(info, value); err != nil {
+		return reflect.Value{}, err
+	}
+
+	switch info.baseType {
+	case reflect.TypeOf(true):
+		// Without fixed or explicit value, a boolean flag means true
+		s := info.value
+		if info.isConst {
+			s = info.constval
+		}
+
+		t := true
+		if s != "" {
+			b, err := parseBool(s)
+			if err != nil {
+				return reflect.Value{}, redactError(info, err)
+			}
+			t = b
+		}
+		return reflect.ValueOf(t), nil
+
+	case reflect.TypeOf(string("")):
+		if info.pattern != nil && !info.pattern.MatchString(value) {
+			err := errorf("%s must match %s", displayFlag(info),
+				info.pattern)
+			return reflect.Value{}, valueError(ErrPatternMismatch, info, err)
+		}
+		return reflect.ValueOf(value), nil
+
+	case reflect.TypeOf(int(0)):
+		var i int
+		var err error
+		if hasFormat(info, formatSI) {
+			i, err = parseSI(value)
+		} else if i, err = strconv.Atoi(value); err != nil {
+			return reflect.Value{}, conversionError(info, value, err)
+		}
+		if err != nil {
+			return reflect.Value{}, redactError(info, err)
+		}
+		return checkRange(info, reflect.ValueOf(i))
+
+	case reflect.TypeOf(float64(0.0)):
+		if hasFormat(info, formatDecimalComma) {
+			value = normalizeDecimalComma(value)
+		}
+		var f float64
+		var err error
+		if hasFormat(info, formatPercent) || hasFormat(info, formatFraction) {
+			f, err = parsePercent(value, hasFormat(info, formatPercent))
+		} else if f, err = strconv.ParseFloat(value, 64); err != nil {
+			return reflect.Value{}, conversionError(info, value, err)
+		}
+		if err != nil {
+			return reflect.Value{}, redactError(info, err)
+		}
+		return checkRange(info, reflect.ValueOf(f))
+
+	case reflect.TypeOf(time.Now()):
+		// Relative expressions (arg-relative) take precedence; values without
+		// zone are in UTC, unless arg-location says otherwise
+		var t time.Time
+		var err error
+		isRelative := false
+		if info.relative {
+			loc := info.location
+			if loc == nil {
+				loc = time.UTC
+			}
+			t, isRelative, err = parseRelativeTime(value, loc)
+		}
+		switch {
+		case err != nil:
+			return reflect.Value{}, redactError(info, err)
+		case isRelative:
+		case info.location != nil:
+			t, err = time.ParseInLocation(timeLayout(info), value, info.location)
+		default:
+			t, err = time.Parse(timeLayout(info), value)
+		}
+		if err != nil {
+			return reflect.Value{}, conversionError(info, value, err)
+		}
+		return reflect.ValueOf(t), nil
+
+	case reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return reflect.Value{}, conversionError(info, value, err)
+		}
+		return checkRange(info, reflect.ValueOf(d))
+
+	case typeByteSize:
+		b, err := ParseByteSize(value)
+		if err != nil {
+			return reflect.Value{}, redactError(info, err)
+		}
+		return checkRange(info, reflect.ValueOf(b))
+
+	case typeRune:
+		r, err := parseRune(value)
+		if err != nil {
+			return reflect.Value{}, redactError(info, err)
+		}
+		return reflect.ValueOf(r), nil
+
+	case typeBytes:
+		b, err := decodeBytes(info, value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(b), nil
+
+	case typeFileMode:
+		m, err := parseFileMode(value)
+		if err != nil {
+			return reflect.Value{}, redactError(info, err)
+		}
+		return reflect.ValueOf(m), nil
+
+	case typeIP, typeIPNet, typeAddr, typePrefix, typeAddrPort:
+		return convertNetwork(info, value)
+
+	default:
+		// Types with registered converters
+		return convertCustom(info, value)
+	}
+}
+
+// FormatValue is the inverse of convertToType: it takes a fieldInfo and a
+// value of the field's base type, and returns a string representation of
+// the value that convertToType accepts for this field (taking arg-format
+// into account). Strings are returned unchanged, whatever the field's type.
+// Boolean false is represented by the empty string (ie. "not set").
+// Returns an error if the value is neither a string nor of the base type.
+func formatValue(info fieldInfo, x any) (string, error) {
+	if s, ok := x.(string); ok {
+		return s, nil
 	}
 
-	field := v.FieldByName(info.Name) // field is reflect.Value
+	if reflect.TypeOf(x) != info.baseType {
+		return "", fmt.Errorf("value of type %T not permitted for %s (%s)",
+			x, info.Name, info.baseType.String())
+	}
+
+	switch val := x.(type) {
+	case bool:
+		if val {
+			return "true", nil
+		}
+		return "", nil
+
+	case int:
+		return strconv.Itoa(val), nil
+
+	case float64:
+		if hasFormat(info, formatPercent) || hasFormat(info, formatFraction) {
+			return strconv.FormatFloat(100*val, 'g', -1, 64) + "%", nil
+		}
+		return strconv.FormatFloat(val, 'g', -1, 64), nil
+
+	case time.Time:
+		if info.location != nil {
+			val = val.In(info.location)
+		}
+		return val.Format(timeLayout(info)), nil
 
-	// If field is slice and not assigned yet, create a slice of proper type
-	if info.isSlice && field.IsNil() {
-		field.Set(reflect.MakeSlice(reflect.SliceOf(info.baseType), 0, 0))
+	case time.Duration:
+		return val.String(), nil
+
+	case ByteSize:
+		return val.String(), nil
+
+	case os.FileMode:
+		return formatFileMode(val), nil
+
+	case []byte:
+		return encodeBytes(info, val), nil
+
+	case rune:
+		return string(val), nil
+
+	case net.IP, net.IPNet, netip.Addr, netip.Prefix, netip.AddrPort:
+		return formatNetwork(val), nil
+
+	default:
+		// Types with registered converters
+		return formatCustom(x), nil
 	}
+}
 
-	if info.isSlice {
-		field.Set(reflect.Append(field, vv))
-	} else {
-		field.Set(vv)
+// TimeLayout returns the layout for parsing and formatting the time.Time
+// field described by the supplied fieldInfo: the arg-format tag, if set, or
+// the default layout otherwise.
+func timeLayout(info fieldInfo) string {
+	if info.format != "" {
+		return info.format
 	}
+	return defaultTimeFormat
+}
 
-	return nil
+// HasFormat reports whether the arg-format tag of the supplied field info
+// contains the given keyword. For numeric fields, the arg-format tag is a
+// whitespace-separated list of keywords (for time fields, it is a layout
+// string, and this function should not be used).
+func hasFormat(info fieldInfo, keyword string) bool {
+	for _, f := range strings.Fields(info.format) {
+		if f == keyword {
+			return true
+		}
+	}
+	return false
 }
 
-// ConvertToType takes a fieldInfo, and converts its (string) value field
-// into the appropriate type. If the value field is the empty string, it
-// uses the default value instead.
-// Conversion to time.Time type uses the given format, unless it is empty.
-// Returns a reflect.Value of the converted value.
-// Returns an error if the conversion fails.
-func convertToType(info fieldInfo) (reflect.Value, error) {
+// ParseBool parses a boolean value, case-insensitively: "true", "yes",
+// "on", "1" (and "t") are true; "false", "no", "off", "0" (and "f") are
+// false. Returns an error for any other value.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "on", "1", "t":
+		return true, nil
+	case "false", "no", "off", "0", "f":
+		return false, nil
+	}
+	return false, errorf("invalid boolean value: %s", s)
+}
 
-	// Pull in default value
-	value := info.value
-	if value == "" {
-		value = info.defaultval
+// NormalizeDecimalComma replaces a single decimal comma in its argument by
+// a decimal point, so that "3,14" becomes "3.14". Strings that contain more
+// than one comma, or a decimal point as well, are returned unchanged (they
+// are ambiguous, and will fail to convert).
+func normalizeDecimalComma(s string) string {
+	if strings.Count(s, ",") == 1 && !strings.Contains(s, ".") {
+		return strings.Replace(s, ",", ".", 1)
 	}
+	return s
+}
 
-	switch info.baseType {
-	case reflect.TypeOf(true):
-		t := true
-		return reflect.ValueOf(t), nil
+// ParseSI converts its argument to an int. The argument may carry a single
+// trailing SI suffix (k, M, G, T, P, E; "K" is accepted for "k"), which
+// multiplies the value by the corresponding power of 1000. The part before
+// the suffix may be fractional ("1.5M"), as long as the result is integral.
+// Returns an error if the string is malformed, or the result is not an
+// integer or does not fit into an int.
+func parseSI(s string) (int, error) {
+	if s == "" {
+		return 0, errorf("invalid value: empty string")
+	}
 
-	case reflect.TypeOf(string("")):
-		return reflect.ValueOf(value), nil
+	mult, ok := siMultipliers[s[len(s)-1]]
+	if !ok {
+		return strconv.Atoi(s)
+	}
+	mantissa := s[:len(s)-1]
 
-	case reflect.TypeOf(int(0)):
-		i, err := strconv.Atoi(value)
-		if err != nil {
-			return reflect.Value{}, err
+	// Exact arithmetic if possible, to avoid rounding for large values
+	if i, err := strconv.Atoi(mantissa); err == nil {
+		m := int(mult)
+		if i > math.MaxInt/m || i < math.MinInt/m {
+			return 0, errorf("value out of range: %s", s)
 		}
-		return reflect.ValueOf(i), nil
+		return i * m, nil
+	}
 
-	case reflect.TypeOf(float64(0.0)):
-		f, err := strconv.ParseFloat(value, 64)
-		if err != nil {
-			return reflect.Value{}, err
-		}
-		return reflect.ValueOf(f), nil
+	f, err := strconv.ParseFloat(mantissa, 64)
+	if err != nil {
+		return 0, errorf("invalid value: %s", s)
+	}
+	// Allow for rounding errors in the float representation (eg. "0.3k")
+	f *= mult
+	r := math.Round(f)
+	if math.Abs(f-r) > 1e-9*math.Max(1, math.Abs(f)) {
+		return 0, errorf("not an integer: %s", s)
+	}
+	f = r
+	if f >= math.MaxInt || f < math.MinInt {
+		return 0, errorf("value out of range: %s", s)
+	}
+	return int(f), nil
+}
 
-	case reflect.TypeOf(time.Now()):
-		format := defaultTimeFormat
-		if info.format != "" {
-			format = info.format
-		}
-		t, err := time.Parse(format, value)
-		if err != nil {
-			return reflect.Value{}, err
-		}
-		return reflect.ValueOf(t), nil
+// ParsePercent converts its argument, which represents a percentage, to a
+// fraction between 0 and 1. A value with a trailing "%" is always divided
+// by 100; a bare number is divided by 100 if bareIsPercent is true, and
+// is taken as a fraction otherwise. Hence "75%" is 0.75 in either case,
+// whereas "75" is 0.75 only if bareIsPercent is true.
+// Returns an error if the string is malformed, or if the result is not
+// within the range 0 to 1 (ie. 0% to 100%).
+func parsePercent(s string, bareIsPercent bool) (float64, error) {
+	num, hasSign := strings.CutSuffix(s, "%")
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil {
+		return 0, errorf("invalid percentage: %s", s)
+	}
+	if hasSign || bareIsPercent {
+		f /= 100
+	}
 
-	case reflect.TypeOf(time.Duration(0)):
-		d, err := time.ParseDuration(value)
-		if err != nil {
-			return reflect.Value{}, err
-		}
-		return reflect.ValueOf(d), nil
+	if f < 0 || f > 1 || math.IsNaN(f) {
+		return 0, errorf("percentage out of range 0-100%%: %s", s)
+	}
+	return f, nil
+}
 
-	default:
-		// Never get here
-		return reflect.Value{}, fmt.Errorf("invalid type")
+// Placeholder for the values of secret fields (arg-secret) in output
+// written by the package (such as WriteValues() or ToShellString())
+const redactedValue = "********"
+
+// RedactError takes a fieldInfo and an error that occurred while converting
+// the field's value. For fields tagged arg-secret, the error is replaced by
+// a generic message that does not contain the offending value, to keep
+// passwords and tokens out of logs; otherwise, the error is returned as is.
+func redactError(info fieldInfo, err error) error {
+	if !info.secret {
+		return err
+	}
+	return errorf("invalid value for %s (value redacted)", info.Name)
+}
+
+// ConversionError takes a fieldInfo, a value that cannot be converted to
+// the field's type, and the error of the conversion (as of strconv or
+// time), and returns a ParseError of kind ErrConversion, whose message
+// names the value and the flag, and can be translated (see SetMessages()).
+// The error of the conversion is kept as the ParseError's Err. Values of
+// secret fields are redacted (see redactError()).
+func conversionError(info fieldInfo, value string, err error) *ParseError {
+	pe := valueError(ErrConversion, info, err)
+	if !info.secret {
+		pe.msg = messagef("invalid value %q for %s", value, displayFlag(info))
 	}
+	return pe
 }
 
 // PopulateOptions takes a slice of fieldInfo and a reflect.Value,
@@ -781,7 +2127,9 @@ func populateOptions(options []fieldInfo, v reflect.Value) error {
 //   (in case no slice is present)
 // - if there are fewer tokens than fields, even if the slice is left empty
 //   (in case there is a slice)
-// Positional arguments should not be pointers (semantics are not clear!)
+// Positional fields tagged arg-optional may be omitted (if there is no
+// slice); they then receive their default value, if any. Other positional
+// pointer (and Optional) fields are always set.
 func populatePositionals(positionals []fieldInfo, tokens []string,
 	v reflect.Value) error {
 
@@ -797,17 +2145,39 @@ func populatePositionals(positionals []fieldInfo, tokens []string,
 		return fmt.Errorf("at most one positional may be slice")
 	}
 
-	// No slice
+	// No slice (trailing positionals may be omitted, see arg-optional)
 	if cnt == 0 {
-		if len(positionals) != len(tokens) {
+		required := 0
+		for _, p := range positionals {
+			if !p.mayOmit {
+				required += 1
+			}
+		}
+		if len(tokens) < required || len(tokens) > len(positionals) {
+			kind := ErrTooFewPositionals
+			if len(tokens) > len(positionals) {
+				kind = ErrTooManyPositionals
+			}
 			s := "number of positional fields does not match number of tokens"
-			return fmt.Errorf(s)
+			return newParseError(kind, "", -1, s)
 		}
 
 		for i, t := range tokens {
 			positionals[i].value = t
 			if err := populateField(positionals[i], v); err != nil {
-				return fmt.Errorf("error populating positional field %d", i)
+				return errorf("error populating positional field %d: %w",
+					i, err)
+			}
+		}
+
+		// Omitted positionals receive their default value (if any)
+		for i := len(tokens); i < len(positionals); i++ {
+			if positionals[i].defaultval == "" {
+				continue
+			}
+			positionals[i].value = ""
+			if err := populateField(positionals[i], v); err != nil {
+				return fmt.Errorf("invalid default value: %v", err)
 			}
 		}
 
@@ -820,20 +2190,21 @@ func populatePositionals(positionals []fieldInfo, tokens []string,
 	between := len(tokens) - before - after // tokens (!) to put into slice
 
 	if between < 0 {
-		return fmt.Errorf("not enough tokens to fill all positional fields")
+		return newParseError(ErrTooFewPositionals, "", -1,
+			"not enough tokens to fill all positional fields")
 	}
 
 	for i := 0; i < before; i++ {
 		positionals[i].value = tokens[i]
 		if err := populateField(positionals[i], v); err != nil {
-			return fmt.Errorf("error populating positional field %d", i)
+			return errorf("error populating positional field %d: %w", i, err)
 		}
 	}
 
 	for i := 0; i < between; i++ {
 		positionals[pos].value = tokens[pos+i]
 		if err := populateField(positionals[pos], v); err != nil {
-			return fmt.Errorf("error populating slice of positionals")
+			return errorf("error populating slice of positionals: %w", err)
 		}
 	}
 
@@ -841,7 +2212,8 @@ func populatePositionals(positionals []fieldInfo, tokens []string,
 	for i := 0; i < after; i++ {
 		positionals[dst+i].value = tokens[src+i]
 		if err := populateField(positionals[dst+i], v); err != nil {
-			return fmt.Errorf("error populating positional field %d", dst+i)
+			return errorf("error populating positional field %d: %w",
+				dst+i, err)
 		}
 	}
 
@@ -856,7 +2228,7 @@ func populatePositionals(positionals []fieldInfo, tokens []string,
 // the number of tokens does not match the number of fields in the struct,
 // or if any of the type conversions fails.
 func FromSlice(tokens []string, data any) error {
-	return populateFromSlice(tokens, data, false)
+	return populateFromSlice(tokens, data, &Parser{})
 }
 
 // FromCommandLine takes a pointer to a struct and populates the struct
@@ -867,7 +2239,7 @@ func FromSlice(tokens []string, data any) error {
 // the number of tokens does not match the number of fields in the struct,
 // or if any of the type conversions fails.
 func FromCommandLine(data any) error {
-	return populateFromSlice(os.Args[1:], data, false)
+	return populateFromSlice(os.Args[1:], data, &Parser{})
 }
 
 // FromSliceFused takes a pointer to a struct and populates the struct
@@ -879,7 +2251,7 @@ func FromCommandLine(data any) error {
 // the number of tokens does not match the number of fields in the struct,
 // or if any of the type conversions fails.
 func FromSliceFused(tokens []string, data any) error {
-	return populateFromSlice(tokens, data, true)
+	return populateFromSlice(tokens, data, &Parser{fused: true})
 }
 
 // FromSliceFused takes a pointer to a struct and populates the struct
@@ -891,7 +2263,7 @@ func FromSliceFused(tokens []string, data any) error {
 // the number of tokens does not match the number of fields in the struct,
 // or if any of the type conversions fails.
 func FromCommandLineFused(data any) error {
-	return populateFromSlice(os.Args[1:], data, true)
+	return populateFromSlice(os.Args[1:], data, &Parser{fused: true})
 }
 
 // PrintShortUsage takes a pointer to a struct and writes a one-line
@@ -903,9 +2275,27 @@ func PrintShortUsage(data any) error {
 }
 
 // WriteShortUsage takes a pointer to a struct and writes a one-line
-// description of the identified options and positional fields to w.
+// description of the identified options and positional fields to w,
+// preceded by the program name (the base name of os.Args[0]), as in
+// "usage: mytool [-b] [-s string] [string]+". Boolean options with a short
+// flag are collapsed into a single group, as in [-abc].
 // Returns an error if the struct contains unsupported types.
 func WriteShortUsage(w io.Writer, data any) error {
+	return writeShortUsage(w, data, UsageOptions{})
+}
+
+// WriteShortUsage writes the one-line usage message for the struct that
+// data points to (see WriteShortUsage()), as tuned by the UsageOptions,
+// beginning with "usage:" and the program name.
+func writeShortUsage(w io.Writer, data any, opts UsageOptions) error {
+	fmt.Fprintf(w, "%s ", messagef("usage: %s", opts.programName()))
+	return writeShortUsageList(w, data, opts)
+}
+
+// WriteShortUsageList writes the description of the options and the
+// positional fields of the one-line usage message (see WriteShortUsage())
+// to w, without the program name, as tuned by the UsageOptions.
+func writeShortUsageList(w io.Writer, data any, opts UsageOptions) error {
 	v, err := unwrap(data)
 	if err != nil {
 		return err
@@ -915,12 +2305,24 @@ func WriteShortUsage(w io.Writer, data any) error {
 	if err != nil {
 		return err
 	}
+	options = usageView(options, opts)
 
-	keys := sortableFlags{}
-	for k, _ := range options {
-		keys = append(keys, k)
+	keys := usageFlags(options, opts)
+
+	// Collect boolean options with a short flag, to show them as one group
+	// [-abc], at the position of the first of them
+	group, grouped := "", map[string]struct{}{}
+	for _, k := range keys {
+		if _, ok := grouped[k]; ok {
+			continue
+		}
+		if f := groupableFlag(options[k]); f != "" {
+			group += f[1:]
+			for _, f := range options[k].allFlags {
+				grouped[f] = struct{}{}
+			}
+		}
 	}
-	sort.Sort(keys)
 
 	// Options
 	seen := map[string]struct{}{}
@@ -935,37 +2337,114 @@ func WriteShortUsage(w io.Writer, data any) error {
 		for _, f := range info.allFlags {
 			seen[f] = struct{}{}
 		}
-		fmt.Fprintf(w, "[%s", strings.Join(info.allFlags, "|"))
+
+		// Print the group of boolean flags instead (but only once)
+		if _, ok := grouped[k]; ok {
+			if group != "" {
+				fmt.Fprintf(w, "[-%s] ", group)
+				group = ""
+			}
+			continue
+		}
+
+		// Required options are not enclosed in brackets
+		open, close := "[", "]"
+		if info.required && !info.isConst {
+			open, close = "", ""
+		}
+		fmt.Fprintf(w, "%s%s", open, strings.Join(info.allFlags, "|"))
 
 		_, argname := formatHelp(info, false)
+		argname = choicesArg(info, argname)
 
 		// Don't print argument for booleans; otherwise, print arg
-		if info.baseType != reflect.TypeOf(true) {
+		if takesArgument(info) {
 			fmt.Fprintf(w, " %s", argname)
 		}
-		fmt.Fprintf(w, "]")
-		if info.isSlice {
+		fmt.Fprintf(w, "%s", close)
+		if info.isSlice || info.isCount {
 			fmt.Fprintf(w, "+")
 		}
 		fmt.Fprintf(w, " ")
 	}
 
+	// Assignments
+	if _, ok, err := findAssignField(v); err != nil {
+		return err
+	} else if ok {
+		fmt.Fprintf(w, "[NAME=value]+ ")
+	}
+
 	// Positionals
 	for _, p := range positionals {
 		_, argname := formatHelp(p, true)
+		argname = choicesArg(p, argname)
 
+		if p.section != "" {
+			fmt.Fprintf(w, "%s ", p.section)
+		}
 		fmt.Fprintf(w, "[%s]", argname)
 		if p.isSlice {
 			fmt.Fprintf(w, "+")
 		}
+		if p.mayOmit {
+			fmt.Fprintf(w, "?")
+		}
 		fmt.Fprintf(w, " ")
 	}
 
+	// Tokens following "--"
+	if _, ok, err := findRestField(v); err != nil {
+		return err
+	} else if ok {
+		fmt.Fprintf(w, "[-- string]+ ")
+	}
+
+	// Subcommands
+	commands, err := findCommands(v)
+	if err != nil {
+		return err
+	}
+	if len(commands) > 0 {
+		fmt.Fprintf(w, "{%s} ... ", strings.Join(commandNames(commands), "|"))
+	}
+
 	fmt.Fprintf(w, "\n")
 
 	return nil
 }
 
+// VisibleFlags returns the flags of all options that are not hidden
+// (arg-hidden), in sorted order, as shown in usage messages. Aliases
+// (arg-alias) are not included.
+func visibleFlags(options map[string]fieldInfo) sortableFlags {
+	keys := sortableFlags{}
+	for k, info := range options {
+		if !info.hidden && !info.isAlias {
+			keys = append(keys, k)
+		}
+	}
+	sort.Sort(keys)
+	return keys
+}
+
+// GroupableFlag returns the first short flag with a "-" prefix of the
+// supplied option, if the option is a (non-repeatable) boolean or takes
+// a fixed value, so that it can be shown as part of a group like [-abc]
+// in the short usage line. Returns the empty string otherwise.
+func groupableFlag(info fieldInfo) string {
+	if takesArgument(info) || info.isSlice || info.isCount || info.required {
+		return ""
+	}
+
+	for _, f := range info.allFlags {
+		if shortFlagRE.MatchString(f) && strings.HasPrefix(f, "-") {
+			return f
+		}
+	}
+	return ""
+}
+
 // PrintUsage takes a pointer to a struct and writes a detailed description
 // of the identified options and positional fields, including the help text
 // provided by the arg-help tag, to standard error.
@@ -976,9 +2455,20 @@ func PrintUsage(data any) error {
 
 // WriteUsage takes a pointer to a struct and writes a detailed description
 // of the identified options and positional fields, including the help text
-// provided by the arg-help tag, to w.
+// provided by the arg-help tag and the link provided by the arg-url tag,
+// to w. If options are grouped (arg-group), they are shown under group
+// headings, and positional fields and subcommands under headings of their
+// own.
 // Returns an error if the struct contains unsupported types.
 func WriteUsage(w io.Writer, data any) error {
+	return writeUsage(w, data, UsageOptions{}, "")
+}
+
+// WriteUsage writes the detailed usage message for the struct that data
+// points to (see WriteUsage()), as tuned by the UsageOptions, followed by
+// the lines of automatic flags (as formatted by writeHelp); if options are
+// grouped, these are shown with the options that have no group.
+func writeUsage(w io.Writer, data any, opts UsageOptions, auto string) error {
 	v, err := unwrap(data)
 	if err != nil {
 		return err
@@ -988,83 +2478,210 @@ func WriteUsage(w io.Writer, data any) error {
 	if err != nil {
 		return err
 	}
+	assign, hasAssign, err := findAssignField(v)
+	if err != nil {
+		return err
+	}
+	rest, hasRest, err := findRestField(v)
+	if err != nil {
+		return err
+	}
+	commands, err := findCommands(v)
+	if err != nil {
+		return err
+	}
+	options = usageView(options, opts)
 
-	keys := sortableFlags{}
-	for k, _ := range options {
-		keys = append(keys, k)
+	keys := usageFlags(options, opts)
+
+	// Synopsis and prologue (if any)
+	writeUsageHeader(w, opts)
+
+	// Options, under their group headings (if any)
+	groups := usageGroups(options)
+	grouped := len(groups) > 0 && groups[len(groups)-1] != ""
+	if grouped && auto != "" && groups[0] != "" {
+		groups = append([]string{""}, groups...)
 	}
-	sort.Sort(keys)
 
-	// Options
 	seen := map[string]struct{}{}
-	for _, k := range keys {
-		if _, ok := seen[k]; ok {
-			continue
+	for _, group := range groups {
+		if grouped {
+			fmt.Fprintf(w, "%s:\n", groupHeading(group))
 		}
 
-		info := options[k]
-
-		// Indent
-		fmt.Fprintf(w, "    ")
+		for _, k := range keys {
+			if _, ok := seen[k]; ok || options[k].group != group {
+				continue
+			}
 
-		// Print all flags as one line, space-separated (also: remember!)
-		for _, f := range info.allFlags {
-			seen[f] = struct{}{}
-			fmt.Fprintf(w, "%s ", f)
-		}
+			info := options[k]
 
-		help, argname := formatHelp(info, false)
-		defval := ""
-		if info.defaultval != "" {
-			defval = "=" + info.defaultval
+			// Each option is shown once, not once for each flag
+			for _, f := range info.allFlags {
+				seen[f] = struct{}{}
+			}
+			writeOptionUsage(w, info, opts)
 		}
 
-		// Don't print argument for booleans; otherwise, print arg
-		if info.baseType != reflect.TypeOf(true) {
-			fmt.Fprintf(w, "[%s%s]", argname, defval)
-		}
-		if info.isSlice {
-			fmt.Fprintf(w, " (repeatable)")
+		if grouped && group == "" {
+			fmt.Fprint(w, auto)
 		}
+	}
 
-		// Print actual help text (if any!), on new line, indented
-		if help != "" {
-			fmt.Fprintf(w, "\n       %s", help)
-		}
+	if grouped && (hasAssign || len(positionals) > 0 || hasRest) {
+		fmt.Fprintf(w, "%s:\n", message(argumentsHeading))
+	}
 
-		// Newline
-		fmt.Fprintf(w, "\n")
+	// Assignments
+	if hasAssign {
+		fmt.Fprintf(w, "    [NAME=value] (%s) %s\n", message("repeatable"),
+			assign.Tag.Get(tagHelp))
 	}
 
 	// Positionals
 	for _, p := range positionals {
 		help, argname := formatHelp(p, true)
+		argname = choicesArg(p, argname)
+		help = appendHint(help, formatHint(p))
 
 		fmt.Fprintf(w, "    [%s] ", argname)
 		if p.isSlice {
-			fmt.Fprintf(w, "(repeatable) ")
+			fmt.Fprintf(w, "(%s) ", message("repeatable"))
+		}
+		if p.mayOmit {
+			fmt.Fprintf(w, "(%s) ", message("optional"))
 		}
 		fmt.Fprintf(w, "%s\n", help)
+		if p.url != "" {
+			fmt.Fprintf(w, "       %s\n", messagef("See: %s", p.url))
+		}
+	}
+
+	// Tokens following "--"
+	if hasRest {
+		fmt.Fprintf(w, "    [-- string] (%s) %s\n", message("repeatable"),
+			rest.Tag.Get(tagHelp))
+	}
+
+	// Subcommands
+	if grouped && len(commands) > 0 {
+		fmt.Fprintf(w, "%s:\n", message(commandsHeading))
+	}
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "    %s ", cmd.name)
+		if names := commandNames(childCommands(cmd)); len(names) > 0 {
+			fmt.Fprintf(w, "{%s} ", strings.Join(names, "|"))
+		}
+		fmt.Fprintf(w, "...")
+		if help := commandHelp(cmd); help != "" {
+			fmt.Fprintf(w, "\n       %s", help)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+
+	if !grouped {
+		fmt.Fprint(w, auto)
+	}
+
+	// Environment variables (if requested)
+	if opts.ShowEnv {
+		writeUsageEnv(w, options)
 	}
 
+	// Examples and epilogue (if any)
+	writeUsageFooter(w, opts)
+
 	return nil
 }
 
+// WriteOptionUsage writes the description of a single option, as part of
+// the detailed usage message, to w: its flags and argument on one line,
+// followed by its help text and link (if any) on lines of their own, as
+// tuned by the UsageOptions.
+func writeOptionUsage(w io.Writer, info fieldInfo, opts UsageOptions) {
+	// Indent
+	fmt.Fprintf(w, "    ")
+
+	// Print all flags as one line, space-separated
+	for _, f := range info.allFlags {
+		fmt.Fprintf(w, "%s ", f)
+	}
+
+	help, argname := formatHelp(info, false)
+	argname = choicesArg(info, argname)
+	defval := ""
+	if info.defaultval != "" && !opts.ShowDefaults {
+		defval = "=" + info.defaultval
+	}
+
+	// Don't print argument for booleans; otherwise, print arg
+	if takesArgument(info) {
+		fmt.Fprintf(w, "[%s%s]", argname, defval)
+	}
+	if (info.isSlice || info.isCount) && info.maxCount != 1 {
+		fmt.Fprintf(w, " (%s", message("repeatable"))
+		if info.maxCount > 1 {
+			fmt.Fprintf(w, ", %s", messagef("at most %d times", info.maxCount))
+		}
+		fmt.Fprintf(w, ")")
+	}
+
+	// Print actual help text (if any!), on new line, indented
+	if info.isConst {
+		help = appendHint(help, messagef("sets value %s", info.constval))
+	} else {
+		help = appendHint(help, formatHint(info))
+		if info.env != "" {
+			help = appendHint(help, messagef("env: %s", info.env))
+		}
+	}
+	if info.isDeprec {
+		help = appendHint(help, deprecationHint(info))
+	}
+	if info.required && !info.isConst {
+		help = appendHint(help, message("required"))
+	}
+	if opts.ShowDefaults && info.defaultval != "" && !info.isConst {
+		help = appendHint(help, messagef("default: %s", displayDefault(info)))
+	}
+	if help != "" {
+		for _, line := range wrapText(help, opts.Columns-7) {
+			fmt.Fprintf(w, "\n       %s", line)
+		}
+	}
+
+	// Print link to documentation (if any!), on new line, indented
+	if info.url != "" {
+		fmt.Fprintf(w, "\n       %s", messagef("See: %s", info.url))
+	}
+
+	// Newline
+	fmt.Fprintf(w, "\n")
+}
+
 // FormatHelp extracts the help text (if any) from the tag values of the
 // supplied field info. If the help text contains a term inclosed by special
 // delimiters, that term is extracted and the the delimiters removed from the
 // help text. The modified help text, and the extracted term, are returned.
+// A placeholder given explicitly (arg-name) takes precedence over the term.
 // If the help text is empty or no term was identified in the text, the base
 // type for the field is returned instead. If the help text is empty and the
 // useName flag is true the field name of the field is substituted for the
 // help text.
 func formatHelp(info fieldInfo, useName bool) (string, string) {
 	help, argname := info.help, info.baseType.String()
+	if info.baseType == typeRune {
+		argname = "rune" // rather than int32
+	}
 
 	if limits := helpArgumentRE.FindStringIndex(info.help); limits != nil {
 		argname = help[limits[0]+1 : limits[1]-1]
 		help = strings.ReplaceAll(help, helpDelimiter, "")
 	}
+	if info.argname != "" {
+		argname = info.argname
+	}
 
 	if help == "" && useName {
 		help = info.Name
@@ -1073,19 +2690,116 @@ func formatHelp(info fieldInfo, useName bool) (string, string) {
 	return help, argname
 }
 
+// ChoicesArg returns the permitted values (arg-choices) of the supplied
+// field, formatted for usage messages as in {json|yaml|table}, or the
+// supplied argument name if there are no permitted values (or if the
+// field has an explicit placeholder, see arg-name).
+func choicesArg(info fieldInfo, argname string) string {
+	if len(info.choices) == 0 || info.argname != "" {
+		return argname
+	}
+	return "{" + strings.Join(info.choices, "|") + "}"
+}
+
+// FormatHint returns a brief hint on the accepted input of the supplied
+// field: its syntax (see syntaxHint), its separator (arg-sep), its reset
+// value (arg-reset), its bare-number flags (arg-number), and its bounds
+// (arg-min, arg-max).
+// Returns the empty string if no hint is necessary.
+func formatHint(info fieldInfo) string {
+	hints := []string{}
+	for _, h := range []string{
+		syntaxHint(info), separatorHint(info), resetHint(info),
+		numberHint(info), rangeHint(info),
+	} {
+		if h != "" {
+			hints = append(hints, h)
+		}
+	}
+	return strings.Join(hints, "; ")
+}
+
+// SyntaxHint returns a brief hint on the accepted input syntax for the
+// supplied field, for types (and arg-format keywords) whose syntax is not
+// obvious. Returns the empty string if no hint is necessary.
+func syntaxHint(info fieldInfo) string {
+	switch info.baseType {
+	case reflect.TypeOf(""):
+		if info.pattern != nil {
+			return messagef("pattern: %s", info.pattern.String())
+		}
+
+	case reflect.TypeOf(time.Now()):
+		if info.relative {
+			return messagef("format: %s, or e.g. now-2h, yesterday",
+				timeLayout(info))
+		}
+		return messagef("format: %s", timeLayout(info))
+
+	case reflect.TypeOf(time.Duration(0)):
+		return message("e.g. 300ms, 1.5h")
+
+	case typeByteSize:
+		return message("e.g. 512, 10MB, 1.5GiB")
+
+	case typeFileMode:
+		return message("octal, e.g. 0644")
+
+	case typeBytes:
+		if info.encoding != "" {
+			return messagef("%s encoded", info.encoding)
+		}
+
+	case typeRune:
+		return message("a single character")
+
+	case typeIP, typeIPNet, typeAddr, typePrefix, typeAddrPort:
+		return networkHint(info.baseType)
+
+	case reflect.TypeOf(int(0)):
+		if hasFormat(info, formatSI) {
+			return message("e.g. 500, 2k, 1.5M")
+		}
+
+	case reflect.TypeOf(float64(0.0)):
+		switch {
+		case hasFormat(info, formatPercent):
+			return message("e.g. 75%, 75")
+		case hasFormat(info, formatFraction):
+			return message("e.g. 75%, 0.75")
+		case hasFormat(info, formatDecimalComma):
+			return message("e.g. 3.14, 3,14")
+		}
+	}
+
+	return ""
+}
+
+// AppendHint appends a syntax hint (if any) to a help text, in parentheses.
+func appendHint(help, hint string) string {
+	switch {
+	case hint == "":
+		return help
+	case help == "":
+		return "(" + hint + ")"
+	default:
+		return help + " (" + hint + ")"
+	}
+}
+
 // PrintValues takes a pointer to a populated struct and writes the names
 // and types of its fields, together with their current values, to standard
 // error.
 // Returns an error if the struct contains non-ignored unsupported types.
 func PrintValues(data any) error {
-	return writeValues(os.Stderr, data, false)
+	return writeValues(os.Stderr, data, false, nil)
 }
 
 // WriteValues takes a pointer to a populated struct and writes the names
 // and types of its fields, together with their current values, to w.
 // Returns an error if the struct contains non-ignored unsupported types.
 func WriteValues(w io.Writer, data any) error {
-	return writeValues(w, data, false)
+	return writeValues(w, data, false, nil)
 }
 
 // PrintValuesWithTags takes a pointer to a populated struct and writes the
@@ -1093,7 +2807,7 @@ func WriteValues(w io.Writer, data any) error {
 // values, to standard error.
 // Returns an error if the struct contains non-ignored unsupported types.
 func PrintValuesWithTags(data any) error {
-	return writeValues(os.Stderr, data, true)
+	return writeValues(os.Stderr, data, true, nil)
 }
 
 // WriteValuesWithTags takes a pointer to a populated struct and writes the
@@ -1101,10 +2815,29 @@ func PrintValuesWithTags(data any) error {
 // values, to w.
 // Returns an error if the struct contains non-ignored unsupported types.
 func WriteValuesWithTags(w io.Writer, data any) error {
-	return writeValues(w, data, true)
+	return writeValues(w, data, true, nil)
 }
 
-func writeValues(w io.Writer, data any, withTags bool) error {
+// PrintValuesWithReport takes a pointer to a populated struct and a report,
+// as returned by Parser.ParseWithReport(), and writes the names and types
+// of its fields, together with their current values and where the values
+// came from, to standard error.
+// Returns an error if the struct contains non-ignored unsupported types.
+func PrintValuesWithReport(data any, report *ParseReport) error {
+	return writeValues(os.Stderr, data, false, report)
+}
+
+// WriteValuesWithReport takes a pointer to a populated struct and a report,
+// as returned by Parser.ParseWithReport(), and writes the names and types
+// of its fields, together with their current values and where the values
+// came from, to w.
+// Returns an error if the struct contains non-ignored unsupported types.
+func WriteValuesWithReport(w io.Writer, data any, report *ParseReport) error {
+	return writeValues(w, data, false, report)
+}
+
+func writeValues(w io.Writer, data any, withTags bool,
+	report *ParseReport) error {
 	v, err := unwrap(data)
 	if err != nil {
 		return err
@@ -1125,7 +2858,7 @@ func writeValues(w io.Writer, data any, withTags bool) error {
 			mxType = len(field.Type.String())
 		}
 
-		tmp := len(fmt.Sprintf("%v", v.Field(i)))
+		tmp := len(displayValue(field, v.Field(i)))
 		if tmp > mxVal {
 			mxVal = tmp
 		}
@@ -1138,11 +2871,44 @@ func writeValues(w io.Writer, data any, withTags bool) error {
 		if withTags {
 			tag = string(field.Tag)
 		}
+		if report != nil {
+			tag = report.Provenance(field.Name)
+		}
 
 		fmt.Fprintf(w, "%-*s   %-*s   %-*s   %s\n",
 			mxName, field.Name, mxType, field.Type.String(),
-			mxVal, fmt.Sprintf("%v", v.Field(i)), tag)
+			mxVal, displayValue(field, v.Field(i)), tag)
 	}
 
 	return nil
 }
+
+// DisplayValue takes a struct field and its value, and returns the value
+// as displayed by WriteValues(): the value pointed to, for pointers, and
+// "********" for fields tagged arg-secret (unless the value is zero).
+func displayValue(field reflect.StructField, v reflect.Value) string {
+	if _, ok := field.Tag.Lookup(tagSecret); ok && !v.IsZero() {
+		return redactedValue
+	}
+	return fmt.Sprintf("%v", pointee(v))
+}
+
+// DisplayDefault returns the default value (arg-default) of an option, as
+// displayed by usage messages and man pages: "********" for fields tagged
+// arg-secret.
+func displayDefault(info fieldInfo) string {
+	if info.secret {
+		return redactedValue
+	}
+	return info.defaultval
+}
+
+// Pointee returns the value pointed to by its argument, if it is a non-nil
+// pointer, so that values (rather than addresses) are displayed; otherwise,
+// it returns its argument.
+func pointee(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		return v.Elem()
+	}
+	return v
+}
@@ -3,12 +3,18 @@ package cleanarg
 import (
 	"testing"
 
+	"errors"
+	"os"
+	"path/filepath"
 	"reflect"
 	"slices"
 	"strings"
 	"time"
 )
 
+// The beginning of the short usage line, with the name of the test binary
+var shortUsagePrefix = "usage: " + filepath.Base(os.Args[0]) + " "
+
 func Test_unwrap(t *testing.T) {
 	i := 1
 	s := struct{}{}
@@ -40,7 +46,8 @@ func Test_makeFieldInfoErr(t *testing.T) {
 		b float32
 		c struct{}
 		d *struct{}
-		e *int
+		e **int
+		f *[]int
 	}{}
 
 	v, _ := unwrap(&s)
@@ -213,13 +220,85 @@ func Test_extractFlagsSortedErr(t *testing.T) {
 	}
 }
 
+func Test_extractConstFlags(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    [][2]string
+		wantErr bool
+	}{
+		{"", [][2]string{}, false},
+		{"-q=0", [][2]string{{"-q", "0"}}, false},
+		{"-q=0 --debug=3", [][2]string{{"-q", "0"}, {"--debug", "3"}}, false},
+		{"--name=", [][2]string{{"--name", ""}}, false},
+		{"--name=a=b", [][2]string{{"--name", "a=b"}}, false},
+		{"-q", nil, true},
+		{"-qq=1", nil, true},
+		{"q=1", nil, true},
+	}
+
+	for _, test := range tests {
+		got, err := extractConstFlags(test.data)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: Unexpected error: %v", test.data, err)
+			continue
+		}
+
+		if err == nil && !slices.Equal(got, test.want) {
+			t.Errorf("%s: got=%v want=%v", test.data, got, test.want)
+		}
+	}
+}
+
+func Test_extractNegatedFlags(t *testing.T) {
+	boolean := fieldInfo{baseType: reflect.TypeOf(true)}
+
+	tests := []struct {
+		info    fieldInfo
+		data    string
+		want    [][2]string
+		wantErr bool
+	}{
+		{withFlags(boolean, "-v", "--verbose"), "",
+			[][2]string{{"--no-verbose", "false"}}, false},
+		{withFlags(boolean, "--color", "--colour"), "",
+			[][2]string{{"--no-color", "false"}, {"--no-colour", "false"}}, false},
+		{withFlags(boolean, "-v"), "-q --quiet",
+			[][2]string{{"-q", "false"}, {"--quiet", "false"}}, false},
+		{withFlags(boolean, "-v"), "", nil, true},
+		{withFlags(boolean, "-v"), "quiet", nil, true},
+		{withFlags(fieldInfo{baseType: reflect.TypeOf(0)}, "--level"), "",
+			nil, true},
+		{withFlags(fieldInfo{baseType: reflect.TypeOf(true), isSlice: true},
+			"--verbose"), "", nil, true},
+	}
+
+	for _, test := range tests {
+		got, err := extractNegatedFlags(test.info, test.data)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v %s: Unexpected error: %v", test.info.allFlags,
+				test.data, err)
+			continue
+		}
+
+		if err == nil && !slices.Equal(got, test.want) {
+			t.Errorf("%v %s: got=%v want=%v", test.info.allFlags, test.data,
+				got, test.want)
+		}
+	}
+}
+
+func withFlags(info fieldInfo, flags ...string) fieldInfo {
+	info.allFlags = flags
+	return info
+}
+
 func Test_analyzeStructErr(t *testing.T) {
 
 	tests := []struct {
 		data any
 		text string
 	}{
-		{struct{ p *int }{}, "Disallowed member type"},
+		{struct{ p **int }{}, "Disallowed member type"},
 		{struct{ a struct{} }{}, "Disallowed member type"},
 		{struct{ a, b []int }{}, "Two slices"},
 		{struct {
@@ -230,6 +309,22 @@ func Test_analyzeStructErr(t *testing.T) {
 			e []int
 		}{},
 			"Flags and two slices"},
+		{struct {
+			a int `arg-const:"-q"`
+		}{}, "Fixed-value flag without value"},
+		{struct {
+			a int `arg-flag:"-q" arg-const:"-q=1"`
+		}{}, "Fixed-value flag duplicates flag"},
+		{struct {
+			a int `arg-flag:"-a"`
+			b int `arg-flag:"-b -a"`
+		}{}, "Duplicate flag"},
+		{struct {
+			a int `arg-env:"A"`
+		}{}, "Environment variable for positional"},
+		{struct {
+			a []int `arg-flag:"-a" arg-env:"A"`
+		}{}, "Environment variable for slice"},
 	}
 
 	for _, test := range tests {
@@ -409,8 +504,9 @@ func Test_populateDefaultsOk(t *testing.T) {
 	if s.S2 != "" || s.S3 != "" || s.S4 != "" {
 		t.Errorf("Missing string zero: %v", s)
 	}
-	if s.I5 != nil || s.F5 != nil || s.S5 != nil || s.T6 != nil {
-		t.Errorf("Missing slice nil: %v", s)
+	if len(s.I5) != 1 || s.I5[0] != 3 || len(s.F5) != 1 || s.F5[0] != 4.0 ||
+		len(s.S5) != 1 || s.S5[0] != "uvw" || len(s.T6) != 1 {
+		t.Errorf("Missing slice default: %v", s)
 	}
 
 	if s.T1 != time.Date(2025, 1, 1, 11, 11, 11, 0, time.UTC) {
@@ -841,6 +937,21 @@ func Test_convertToType(tt *testing.T) {
 		{t(int(0)), "1.0", "", "", true, v(1)},
 		{t(int(0)), "-1", "", "", false, v(-1)},
 		{t(int(0)), "- 1", "", "", true, v(1)},
+		{t(int(0)), "2k", "", "", true, v(2000)},
+		{t(int(0)), "2k", "", "si", false, v(2000)},
+		{t(int(0)), "2K", "", "si", false, v(2000)},
+		{t(int(0)), "-3M", "", "si", false, v(-3000000)},
+		{t(int(0)), "1.5M", "", "si", false, v(1500000)},
+		{t(int(0)), "0.3k", "", "si", false, v(300)},
+		{t(int(0)), "3G", "", "si", false, v(3000000000)},
+		{t(int(0)), "42", "", "si", false, v(42)},
+		{t(int(0)), "", "1k", "si", false, v(1000)},
+		{t(int(0)), "1.5", "", "si", true, v(0)},
+		{t(int(0)), "1.0001k", "", "si", true, v(0)},
+		{t(int(0)), "2Ki", "", "si", true, v(0)},
+		{t(int(0)), "k", "", "si", true, v(0)},
+		{t(int(0)), "", "", "si", true, v(0)},
+		{t(int(0)), "10E", "", "si", true, v(0)},
 
 		{t(float64(0.)), "", "", "", true, v(0.)},
 		{t(float64(0.)), "0", "", "", false, v(0.)},
@@ -850,6 +961,25 @@ func Test_convertToType(tt *testing.T) {
 		{t(float64(0.)), "2e1", "0", "", false, v(20.)},
 		{t(float64(0.)), "2e-1", "0", "", false, v(.2)},
 		{t(float64(0.)), "", "-1e2", "", false, v(-100.)},
+		{t(float64(0.)), "3,14", "", "", true, v(3.14)},
+		{t(float64(0.)), "3,14", "", "decimal-comma", false, v(3.14)},
+		{t(float64(0.)), "3.14", "", "decimal-comma", false, v(3.14)},
+		{t(float64(0.)), "-0,5", "", "decimal-comma", false, v(-.5)},
+		{t(float64(0.)), "", "2,5", "decimal-comma", false, v(2.5)},
+		{t(float64(0.)), "1,000,5", "", "decimal-comma", true, v(0.)},
+		{t(float64(0.)), "1.000,5", "", "decimal-comma", true, v(0.)},
+		{t(float64(0.)), "75%", "", "", true, v(0.)},
+		{t(float64(0.)), "75%", "", "percent", false, v(.75)},
+		{t(float64(0.)), "75", "", "percent", false, v(.75)},
+		{t(float64(0.)), "75%", "", "fraction", false, v(.75)},
+		{t(float64(0.)), "0.75", "", "fraction", false, v(.75)},
+		{t(float64(0.)), "0%", "", "percent", false, v(0.)},
+		{t(float64(0.)), "100%", "", "fraction", false, v(1.)},
+		{t(float64(0.)), "12,5%", "", "percent decimal-comma", false, v(.125)},
+		{t(float64(0.)), "75", "", "fraction", true, v(0.)},
+		{t(float64(0.)), "101%", "", "percent", true, v(0.)},
+		{t(float64(0.)), "-5", "", "percent", true, v(0.)},
+		{t(float64(0.)), "%", "", "percent", true, v(0.)},
 
 		{t(time.Now()), "", "", "", true, v(time.Now())},
 		{t(time.Now()), "2004-12-01 23:45:00", "", "", false,
@@ -898,13 +1028,110 @@ func Test_convertToType(tt *testing.T) {
 
 }
 
+func Test_convertToTypeSecret(t *testing.T) {
+	s := struct {
+		Plain  int `arg-flag:"-p"`
+		Secret int `arg-flag:"-s" arg-secret:""`
+	}{}
+
+	v, _ := unwrap(&s)
+
+	tests := []struct {
+		fieldName string
+		leaks     bool
+	}{
+		{"Plain", true},
+		{"Secret", false},
+	}
+
+	for _, test := range tests {
+		field, _ := v.Type().FieldByName(test.fieldName)
+		info, _ := makeFieldInfo(field)
+		info.value = "hunter2"
+
+		_, err := convertToType(info)
+		if err == nil {
+			t.Errorf("%s: Expected error", test.fieldName)
+			continue
+		}
+
+		if strings.Contains(err.Error(), "hunter2") != test.leaks {
+			t.Errorf("%s: Unexpected error message: %v", test.fieldName, err)
+		}
+	}
+
+	if err := FromSlice([]string{"-s", "hunter2"}, &s); err == nil ||
+		strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("Secret value not redacted: %v", err)
+	}
+}
+
+func Test_formatValue(t *testing.T) {
+	s := struct {
+		B bool
+		I int `arg-format:"si"`
+		F float64
+		P float64 `arg-format:"percent"`
+		D time.Duration
+		T time.Time `arg-format:"2006-01-02"`
+		S []string
+	}{}
+
+	v, _ := unwrap(&s)
+
+	tests := []struct {
+		fieldName string
+		value     any
+		want      string
+	}{
+		{"B", true, "true"},
+		{"B", false, ""},
+		{"I", 2000, "2000"},
+		{"I", "2k", "2k"},
+		{"F", 0.5, "0.5"},
+		{"P", 0.75, "75%"},
+		{"D", 1500 * time.Millisecond, "1.5s"},
+		{"T", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), "2025-01-02"},
+		{"S", "x", "x"},
+	}
+
+	for _, test := range tests {
+		field, _ := v.Type().FieldByName(test.fieldName)
+		info, _ := makeFieldInfo(field)
+
+		got, err := formatValue(info, test.value)
+		if err != nil || got != test.want {
+			t.Errorf("%s: got=%s want=%s err=%v",
+				test.fieldName, got, test.want, err)
+			continue
+		}
+
+		// Round trip (except for strings)
+		if _, ok := test.value.(string); ok || got == "" {
+			continue
+		}
+		info.value = got
+		vv, err := convertToType(info)
+		if err != nil || !vv.Equal(reflect.ValueOf(test.value)) {
+			t.Errorf("%s: Round trip: got=%v want=%v err=%v",
+				test.fieldName, vv, test.value, err)
+		}
+	}
+
+	field, _ := v.Type().FieldByName("I")
+	info, _ := makeFieldInfo(field)
+	if _, err := formatValue(info, 1.5); err == nil {
+		t.Errorf("Expected error for value of wrong type")
+	}
+}
+
 func Test_populateOptionsOk(t *testing.T) {
 	// Compare: Test_populateField()
 
 	// Remember that only public (ie: capitalized) fields can be set!
 	s := struct {
 		I int
-		//		P *int
+		P *int
 		S []int
 	}{}
 
@@ -916,7 +1143,7 @@ func Test_populateOptionsOk(t *testing.T) {
 		err       bool
 	}{
 		{"I", "2", false},
-		//		{"P", "3", false},
+		{"P", "3", false},
 		{"S", "4", false},
 		{"S", "5", false},
 	}
@@ -939,9 +1166,9 @@ func Test_populateOptionsOk(t *testing.T) {
 	if s.I != 2 {
 		t.Errorf("Bad scalar assignment: got=%v", s.I)
 	}
-	// if *s.P != 3 {
-	// 	t.Errorf("Bad pointer assignment: got=%v", *s.P)
-	// }
+	if s.P == nil || *s.P != 3 {
+		t.Errorf("Bad pointer assignment: got=%v", s.P)
+	}
 	if len(s.S) != 2 || s.S[0] != 4 || s.S[1] != 5 {
 		t.Errorf("Bad slice assignment: got=%v", s.S)
 	}
@@ -1084,6 +1311,8 @@ func Test_formatHelp(t *testing.T) {
 		B int `arg-help:"text *with* term"`
 		C int `arg-help:""`
 		D int
+		E int `arg-name:"COUNT"`
+		F int `arg-help:"text *with* term" arg-name:"N"`
 	}{}
 
 	v, _ := unwrap(&s)
@@ -1096,6 +1325,8 @@ func Test_formatHelp(t *testing.T) {
 		{"B", "text with term", "text with term", "with"},
 		{"C", "", "C", "int"},
 		{"D", "", "D", "int"},
+		{"E", "", "E", "COUNT"},
+		{"F", "text with term", "text with term", "N"},
 	}
 
 	for _, test := range tests {
@@ -1116,6 +1347,433 @@ func Test_formatHelp(t *testing.T) {
 	}
 }
 
+func Test_formatHint(t *testing.T) {
+	s := struct {
+		A int
+		B int `arg-format:"si"`
+		C float64
+		D float64 `arg-format:"percent"`
+		E float64 `arg-format:"decimal-comma"`
+		F time.Duration
+		G time.Time
+		H time.Time `arg-format:"2006-01-02"`
+		I []time.Duration
+		J string
+	}{}
+
+	v, _ := unwrap(&s)
+
+	tests := []struct {
+		fieldName, hint string
+	}{
+		{"A", ""},
+		{"B", "e.g. 500, 2k, 1.5M"},
+		{"C", ""},
+		{"D", "e.g. 75%, 75"},
+		{"E", "e.g. 3.14, 3,14"},
+		{"F", "e.g. 300ms, 1.5h"},
+		{"G", "format: 2006-01-02 15:04:05"},
+		{"H", "format: 2006-01-02"},
+		{"I", "e.g. 300ms, 1.5h"},
+		{"J", ""},
+	}
+
+	for _, test := range tests {
+		field, _ := v.Type().FieldByName(test.fieldName)
+		info, _ := makeFieldInfo(field)
+
+		if got := formatHint(info); got != test.hint {
+			t.Errorf("%s: got=%s want=%s", test.fieldName, got, test.hint)
+		}
+	}
+
+	if got := appendHint("help", "hint"); got != "help (hint)" {
+		t.Errorf("appendHint: got=%s", got)
+	}
+	if got := appendHint("", "hint"); got != "(hint)" {
+		t.Errorf("appendHint: got=%s", got)
+	}
+	if got := appendHint("help", ""); got != "help" {
+		t.Errorf("appendHint: got=%s", got)
+	}
+}
+
+func Test_FromSliceConst(t *testing.T) {
+	type constArgs struct {
+		Level int      `arg-flag:"--level" arg-const:"-q=0 -v=2 --debug=3" arg-default:"1"`
+		Color bool     `arg-flag:"--color" arg-const:"--no-color=false" arg-default:"true"`
+		Modes []string `arg-const:"-r=read -w=write"`
+	}
+
+	tests := []struct {
+		slice   []string
+		want    constArgs
+		wantErr bool
+	}{
+		{[]string{}, constArgs{1, true, nil}, false},
+		{[]string{"-q"}, constArgs{0, true, nil}, false},
+		{[]string{"-v"}, constArgs{2, true, nil}, false},
+		{[]string{"--debug"}, constArgs{3, true, nil}, false},
+		{[]string{"--level", "7"}, constArgs{7, true, nil}, false},
+		{[]string{"-v", "--level=5"}, constArgs{5, true, nil}, false},
+		{[]string{"--level=5", "-q"}, constArgs{0, true, nil}, false},
+		{[]string{"--no-color"}, constArgs{1, false, nil}, false},
+		{[]string{"--no-color", "--color"}, constArgs{1, true, nil}, false},
+		{[]string{"-vr"}, constArgs{2, true, []string{"read"}}, false},
+		{[]string{"-rwq"}, constArgs{0, true, []string{"read", "write"}}, false},
+		{[]string{"-v", "1"}, constArgs{}, true}, // no positional field
+	}
+
+	for _, test := range tests {
+		c := constArgs{}
+
+		err := FromSlice(test.slice, &c)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+
+		if err == nil && (c.Level != test.want.Level ||
+			c.Color != test.want.Color || !slices.Equal(c.Modes, test.want.Modes)) {
+			t.Errorf("%v: got=%v want=%v", test.slice, c, test.want)
+		}
+	}
+
+	sb := strings.Builder{}
+	WriteShortUsage(&sb, &constArgs{})
+	want := shortUsagePrefix +
+		"[-qv] [-r]+ [-w]+ [--color] [--debug] [--level int] [--no-color] \n"
+	if sb.String() != want {
+		t.Errorf("want=%s\ngot=%s", want, sb.String())
+	}
+}
+
+func Test_FromSliceNegate(t *testing.T) {
+	type negateArgs struct {
+		Color   bool `arg-flag:"--color" arg-negate:"" arg-default:"true"`
+		Verbose bool `arg-flag:"-v --verbose" arg-negate:"-q"`
+	}
+
+	tests := []struct {
+		slice   []string
+		want    negateArgs
+		wantErr bool
+	}{
+		{[]string{}, negateArgs{true, false}, false},
+		{[]string{"--no-color"}, negateArgs{false, false}, false},
+		{[]string{"--no-color", "--color"}, negateArgs{true, false}, false},
+		{[]string{"-v"}, negateArgs{true, true}, false},
+		{[]string{"-vq"}, negateArgs{true, false}, false},
+		{[]string{"-qv"}, negateArgs{true, true}, false},
+		{[]string{"--no-verbose"}, negateArgs{}, true}, // not negatable
+		{[]string{"--no-color=x"}, negateArgs{}, true},
+	}
+
+	for _, test := range tests {
+		c := negateArgs{}
+
+		err := FromSlice(test.slice, &c)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if err == nil && c != test.want {
+			t.Errorf("%v: got=%v want=%v", test.slice, c, test.want)
+		}
+	}
+
+	// Negation requires a boolean option
+	bad := []any{
+		&struct {
+			N int `arg-flag:"--n" arg-negate:""`
+		}{},
+		&struct {
+			B bool `arg-negate:""`
+		}{},
+		&struct {
+			B bool `arg-flag:"--b" arg-negate:"" arg-const:"--no-b=false"`
+		}{},
+	}
+	for i, data := range bad {
+		if err := FromSlice([]string{}, data); err == nil {
+			t.Errorf("%d: Expected error", i)
+		}
+	}
+}
+
+func Test_FromSliceToggle(t *testing.T) {
+	type toggleArgs struct {
+		Trace   bool `arg-flag:"+x" arg-negate:""`
+		Verbose bool `arg-flag:"+v" arg-negate:"" arg-default:"true"`
+		Level   int  `arg-flag:"-l"`
+		Args    []string
+	}
+
+	tests := []struct {
+		slice   []string
+		want    toggleArgs
+		wantErr bool
+	}{
+		{[]string{}, toggleArgs{false, true, 0, nil}, false},
+		{[]string{"+x", "-v"}, toggleArgs{true, false, 0, nil}, false},
+		{[]string{"+x", "-x"}, toggleArgs{false, true, 0, nil}, false},
+		{[]string{"+xv"}, toggleArgs{true, true, 0, nil}, false},
+		{[]string{"-vx"}, toggleArgs{false, false, 0, nil}, false},
+		{[]string{"-xl", "3"}, toggleArgs{false, true, 3, nil}, false},
+		{[]string{"+xl", "3"}, toggleArgs{}, true}, // no flag +l
+	}
+
+	for _, test := range tests {
+		c := toggleArgs{}
+
+		err := FromSlice(test.slice, &c)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if err == nil && !reflect.DeepEqual(c, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.slice, c, test.want)
+		}
+	}
+}
+
+func Test_FromSliceAlias(t *testing.T) {
+	type aliasArgs struct {
+		Output string   `arg-flag:"-o --output" arg-alias:"-O --out"`
+		Quiet  bool     `arg-flag:"-q" arg-alias:"-s --silent" arg-negate:"--loud"`
+		Tags   []string `arg-flag:"--tag" arg-alias:"-t"`
+		Args   []string
+	}
+
+	tests := []struct {
+		slice   []string
+		want    aliasArgs
+		wantErr bool
+	}{
+		{[]string{"--out", "x"}, aliasArgs{Output: "x"}, false},
+		{[]string{"-Ox", "-s"}, aliasArgs{Output: "x", Quiet: true}, false},
+		{[]string{"--out=x", "--silent", "--loud"}, aliasArgs{Output: "x"},
+			false},
+		{[]string{"-t", "a", "--tag", "b", "-tc"},
+			aliasArgs{Tags: []string{"a", "b", "c"}}, false},
+		{[]string{"--no-out", "x"}, aliasArgs{Args: []string{"--no-out", "x"}},
+			false},
+	}
+
+	for _, test := range tests {
+		c := aliasArgs{}
+
+		err := FromSlice(test.slice, &c)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if err == nil && !reflect.DeepEqual(c, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.slice, c, test.want)
+		}
+	}
+
+	// Aliases are not listed in usage messages
+	sb := strings.Builder{}
+	WriteUsage(&sb, &aliasArgs{})
+	WriteShortUsage(&sb, &aliasArgs{})
+	for _, alias := range []string{"-O", "--out ", "-s ", "--silent", " -t "} {
+		if strings.Contains(sb.String(), alias) {
+			t.Errorf("Alias %s in usage:\n%s", alias, sb.String())
+		}
+	}
+
+	spec, err := Analyze(&aliasArgs{})
+	if err != nil || !reflect.DeepEqual(spec.Options[0].Aliases,
+		[]string{"-O", "--out"}) {
+		t.Errorf("Unexpected spec: %+v, %v", spec.Options[0], err)
+	}
+
+	// Aliases must be well-formed and unique, and name a flag
+	for _, data := range []any{
+		&struct {
+			A string `arg-flag:"-a" arg-alias:"-b"`
+			B string `arg-flag:"-b"`
+		}{},
+		&struct {
+			A string `arg-flag:"-a" arg-alias:"b"`
+		}{},
+		&struct {
+			A string `arg-alias:"-a"`
+		}{},
+	} {
+		if err := FromSlice([]string{}, data); err == nil {
+			t.Errorf("%T: Expected error", data)
+		}
+	}
+}
+
+func Test_FromSliceCount(t *testing.T) {
+	type countArgs struct {
+		Verbose int    `arg-flag:"-v --verbose" arg-count:""`
+		Level   int    `arg-flag:"-l" arg-count:"" arg-const:"-q=0" arg-default:"1"`
+		Name    string `arg-flag:"-n"`
+	}
+
+	tests := []struct {
+		slice   []string
+		want    countArgs
+		wantErr bool
+	}{
+		{[]string{}, countArgs{0, 1, ""}, false},
+		{[]string{"-v"}, countArgs{1, 1, ""}, false},
+		{[]string{"-vvv"}, countArgs{3, 1, ""}, false},
+		{[]string{"-v", "--verbose", "-vv"}, countArgs{4, 1, ""}, false},
+		{[]string{"-ll"}, countArgs{0, 3, ""}, false},
+		{[]string{"-ll", "-q", "-l"}, countArgs{0, 1, ""}, false},
+		{[]string{"-vln", "x", "-v"}, countArgs{2, 2, "x"}, false},
+		{[]string{"-v", "2"}, countArgs{}, true}, // no positional field
+		{[]string{"--verbose=2"}, countArgs{}, true},
+	}
+
+	for _, test := range tests {
+		c := countArgs{}
+
+		err := FromSlice(test.slice, &c)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if err == nil && c != test.want {
+			t.Errorf("%v: got=%v want=%v", test.slice, c, test.want)
+		}
+	}
+
+	sb := strings.Builder{}
+	WriteShortUsage(&sb, &countArgs{})
+	want := shortUsagePrefix + "[-l]+ [-n string] [-q] [-v|--verbose]+ \n"
+	if sb.String() != want {
+		t.Errorf("want=%s\ngot=%s", want, sb.String())
+	}
+
+	// Counters must be int options
+	bad := []any{
+		&struct {
+			N bool `arg-flag:"-n" arg-count:""`
+		}{},
+		&struct {
+			N []int `arg-flag:"-n" arg-count:""`
+		}{},
+		&struct {
+			N int `arg-count:""`
+		}{},
+	}
+	for i, data := range bad {
+		if err := FromSlice([]string{}, data); err == nil {
+			t.Errorf("%d: Expected error", i)
+		}
+	}
+}
+
+func Test_FromSliceExplicitBool(t *testing.T) {
+	type boolArgs struct {
+		Color   bool   `arg-flag:"-c --color" arg-default:"true"`
+		Verbose bool   `arg-flag:"-v" arg-const:"--quiet=off"`
+		Quiet   bool   `arg-flag:"-q" arg-default:"no"`
+		Flags   []bool `arg-flag:"-f"`
+	}
+
+	tests := []struct {
+		slice   []string
+		want    boolArgs
+		wantErr bool
+	}{
+		{[]string{}, boolArgs{true, false, false, nil}, false},
+		{[]string{"--color=false"}, boolArgs{false, false, false, nil}, false},
+		{[]string{"--color=No", "-c"}, boolArgs{true, false, false, nil}, false},
+		{[]string{"-c=off"}, boolArgs{false, false, false, nil}, false},
+		{[]string{"-vc=0"}, boolArgs{false, true, false, nil}, false},
+		{[]string{"-v=yes", "--quiet"}, boolArgs{true, false, false, nil}, false},
+		{[]string{"-q"}, boolArgs{true, false, true, nil}, false},
+		{[]string{"-q=ON"}, boolArgs{true, false, true, nil}, false},
+		{[]string{"-f", "-f=0", "-f=1"},
+			boolArgs{true, false, false, []bool{true, false, true}}, false},
+		{[]string{"--color="}, boolArgs{}, true},
+		{[]string{"-c="}, boolArgs{}, true},
+		{[]string{"--color=maybe"}, boolArgs{}, true},
+		{[]string{"--quiet=no"}, boolArgs{}, true}, // fixed value
+	}
+
+	for _, test := range tests {
+		c := boolArgs{}
+
+		err := FromSlice(test.slice, &c)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if err == nil && !reflect.DeepEqual(c, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.slice, c, test.want)
+		}
+	}
+
+	// The explicit value must be given; it is not taken from the next token
+	err := FromSlice([]string{"-c="}, &boolArgs{})
+	if !errors.Is(err, ErrMissingValue) {
+		t.Errorf("Expected missing value, got: %v", err)
+	}
+}
+
+func Test_FromSliceShortEquals(t *testing.T) {
+	type eqArgs struct {
+		Counter int    `arg-flag:"-c --counter"`
+		Verbose bool   `arg-flag:"-v"`
+		Name    string `arg-flag:"-n"`
+	}
+
+	tests := []struct {
+		slice   []string
+		want    eqArgs
+		wantErr bool
+	}{
+		{[]string{"-c=9"}, eqArgs{9, false, ""}, false},
+		{[]string{"-c9", "-n=a=b"}, eqArgs{9, false, "a=b"}, false},
+		{[]string{"-vc=9"}, eqArgs{9, true, ""}, false},
+		{[]string{"-n==x"}, eqArgs{0, false, "=x"}, false},
+		{[]string{"-n", "=x"}, eqArgs{0, false, "=x"}, false},
+		{[]string{"-c="}, eqArgs{}, true},
+	}
+
+	for _, test := range tests {
+		c := eqArgs{}
+
+		err := FromSlice(test.slice, &c)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if err == nil && !reflect.DeepEqual(c, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.slice, c, test.want)
+		}
+	}
+}
+
+func Test_parseBool(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    bool
+		wantErr bool
+	}{
+		{"true", true, false},
+		{"YES", true, false},
+		{"On", true, false},
+		{"1", true, false},
+		{"T", true, false},
+		{"false", false, false},
+		{"no", false, false},
+		{"OFF", false, false},
+		{"0", false, false},
+		{"", false, true},
+		{"y", false, true},
+		{"2", false, true},
+	}
+
+	for _, test := range tests {
+		got, err := parseBool(test.data)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("%q: got=%v,%v want=%v", test.data, got, err, test.want)
+		}
+	}
+}
+
 type simpleArgs struct {
 	Flag    bool      `arg-flag:"-b" arg-help:"This is a flag"`
 	Counter int       `arg-flag:"+c" arg-help:"This is the *counter* here"`
@@ -1153,6 +1811,197 @@ func equal_simple(want, got *simpleArgs) bool {
 	return true
 }
 
+func Test_FromSliceChoices(t *testing.T) {
+	type choiceArgs struct {
+		Format string `arg-flag:"-f --format" arg-choices:"json yaml table" arg-default:"json"`
+		Level  int    `arg-flag:"-l" arg-choices:"1 2 3" arg-default:"1"`
+		Key    string `arg-flag:"-k" arg-choices:"a b" arg-secret:""`
+		Mode   string `arg-choices:"fast slow"`
+	}
+
+	tests := []struct {
+		slice   []string
+		want    choiceArgs
+		wantErr bool
+	}{
+		{[]string{"fast"}, choiceArgs{"json", 1, "", "fast"}, false},
+		{[]string{"-f", "yaml", "-l3", "slow"},
+			choiceArgs{"yaml", 3, "", "slow"}, false},
+		{[]string{"--format=table", "-k", "b", "fast"},
+			choiceArgs{"table", 1, "b", "fast"}, false},
+		{[]string{"-f", "xml", "fast"}, choiceArgs{}, true},
+		{[]string{"-l", "4", "fast"}, choiceArgs{}, true},
+		{[]string{"medium"}, choiceArgs{}, true},
+	}
+
+	for _, test := range tests {
+		a := choiceArgs{}
+
+		err := FromSlice(test.slice, &a)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if err == nil && a != test.want {
+			t.Errorf("%v: got=%v want=%v", test.slice, a, test.want)
+		}
+	}
+
+	// The error lists the permitted values, but redacts secrets
+	err := FromSlice([]string{"-f", "xml", "fast"}, &choiceArgs{})
+	if err == nil || !strings.Contains(err.Error(), "json, yaml, table") {
+		t.Errorf("Expected list of choices, got: %v", err)
+	}
+	err = FromSlice([]string{"-k", "xyzzy", "fast"}, &choiceArgs{})
+	if err == nil || strings.Contains(err.Error(), "xyzzy") {
+		t.Errorf("Expected redacted error, got: %v", err)
+	}
+
+	sb := strings.Builder{}
+	WriteShortUsage(&sb, &choiceArgs{})
+	want := shortUsagePrefix +
+		"[-f|--format {json|yaml|table}] [-k {a|b}] [-l {1|2|3}] [{fast|slow}] \n"
+	if sb.String() != want {
+		t.Errorf("want=%s\ngot=%s", want, sb.String())
+	}
+
+	sb = strings.Builder{}
+	WriteUsage(&sb, &choiceArgs{})
+	if !strings.Contains(sb.String(), "-f --format [{json|yaml|table}=json]") {
+		t.Errorf("Missing choices:\n%s", sb.String())
+	}
+}
+
+func Test_FromSlicePattern(t *testing.T) {
+	type patternArgs struct {
+		Name  string   `arg-flag:"-n --name" arg-pattern:"^[a-z0-9-]+$" arg-default:"web"`
+		Tags  []string `arg-flag:"-t" arg-pattern:"^[a-z]+$"`
+		Token string   `arg-flag:"-k" arg-pattern:"^[0-9a-f]{8}$" arg-secret:""`
+		Host  string   `arg-pattern:"\\."`
+	}
+
+	tests := []struct {
+		slice   []string
+		want    patternArgs
+		wantErr string
+	}{
+		{[]string{"a.b"}, patternArgs{"web", nil, "", "a.b"}, ""},
+		{[]string{"-n", "db-1", "-t", "x", "-t", "y", "-k", "0123abcd", "a.b"},
+			patternArgs{"db-1", []string{"x", "y"}, "0123abcd", "a.b"}, ""},
+		{[]string{"-n", "DB", "a.b"}, patternArgs{},
+			`--name must match ^[a-z0-9-]+$`},
+		{[]string{"-t", "x", "-t", "y2", "a.b"}, patternArgs{},
+			`-t must match ^[a-z]+$`},
+		{[]string{"-k", "xyzzy", "a.b"}, patternArgs{},
+			"invalid value for Token (value redacted)"},
+		{[]string{"ab"}, patternArgs{},
+			`error populating positional field 0: Host must match \.`},
+	}
+
+	for _, test := range tests {
+		a := patternArgs{}
+
+		err := FromSlice(test.slice, &a)
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+		if test.wantErr != "" && !errors.Is(err, ErrPatternMismatch) {
+			t.Errorf("%v: got=%v want ErrPatternMismatch", test.slice, err)
+		}
+		if err == nil && !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.slice, a, test.want)
+		}
+	}
+
+	// Patterns are restricted to strings, and must compile
+	type intPattern struct {
+		N int `arg-flag:"-n" arg-pattern:"^[0-9]$"`
+	}
+	type badPattern struct {
+		S string `arg-flag:"-s" arg-pattern:"[a-"`
+	}
+	if err := FromSlice([]string{}, &intPattern{}); err == nil {
+		t.Errorf("Expected error for arg-pattern on int field")
+	}
+	if err := FromSlice([]string{}, &badPattern{}); err == nil ||
+		!strings.HasPrefix(err.Error(), "malformed arg-pattern: S") {
+		t.Errorf("Expected malformed arg-pattern, got: %v", err)
+	}
+
+	sb := strings.Builder{}
+	WriteUsage(&sb, &patternArgs{})
+	if !strings.Contains(sb.String(), "(pattern: ^[a-z0-9-]+$)") {
+		t.Errorf("Missing pattern:\n%s", sb.String())
+	}
+}
+
+func Test_FromSliceLocation(t *testing.T) {
+	type locationArgs struct {
+		Start time.Time   `arg-flag:"--start" arg-location:"Europe/Berlin"`
+		Local time.Time   `arg-flag:"--local" arg-location:"Local"`
+		Zoned time.Time   `arg-flag:"--zoned" arg-location:"America/New_York" arg-format:"2006-01-02 15:04 -0700"`
+		UTC   time.Time   `arg-flag:"--utc"`
+		All   []time.Time `arg-flag:"--at" arg-location:"Asia/Tokyo"`
+	}
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone database not available: %v", err)
+	}
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+
+	a := locationArgs{}
+	err = FromSlice([]string{"--start", "2024-07-01 09:00:00",
+		"--local", "2024-07-01 09:00:00", "--zoned", "2024-07-01 09:00 +0000",
+		"--utc", "2024-07-01 09:00:00", "--at", "2024-01-01 00:00:00"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		got, want time.Time
+	}{
+		{"Start", a.Start, time.Date(2024, 7, 1, 9, 0, 0, 0, berlin)},
+		{"Local", a.Local, time.Date(2024, 7, 1, 9, 0, 0, 0, time.Local)},
+		{"Zoned", a.Zoned, time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)},
+		{"UTC", a.UTC, time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)},
+		{"All", a.All[0], time.Date(2024, 1, 1, 0, 0, 0, 0, tokyo)},
+	}
+	for _, test := range tests {
+		if !test.got.Equal(test.want) {
+			t.Errorf("%s: got=%v want=%v", test.name, test.got, test.want)
+		}
+	}
+	if a.Start.Location().String() != "Europe/Berlin" {
+		t.Errorf("got location=%v want Europe/Berlin", a.Start.Location())
+	}
+
+	// Formatted values are in the field's location
+	options, _, _ := analyzeStruct(reflect.ValueOf(&locationArgs{}).Elem())
+	s, err := formatValue(options["--start"], a.Start.UTC())
+	if err != nil || s != "2024-07-01 09:00:00" {
+		t.Errorf("got=%q, %v want=2024-07-01 09:00:00", s, err)
+	}
+
+	// Locations are restricted to time fields, and must exist
+	type intLocation struct {
+		N int `arg-flag:"-n" arg-location:"UTC"`
+	}
+	type badLocation struct {
+		T time.Time `arg-flag:"-t" arg-location:"Mars/Olympus_Mons"`
+	}
+	if err := FromSlice([]string{}, &intLocation{}); err == nil {
+		t.Errorf("Expected error for arg-location on int field")
+	}
+	if err := FromSlice([]string{}, &badLocation{}); err == nil ||
+		err.Error() != "malformed arg-location: T" {
+		t.Errorf("Expected malformed arg-location, got: %v", err)
+	}
+}
+
 func Test_FromSliceSimple(t *testing.T) {
 
 	tests := []struct {
@@ -1579,6 +2428,72 @@ func Test_FromSliceDefaultUnfused(t *testing.T) {
 	}
 }
 
+func Test_FromSliceEnv(t *testing.T) {
+	type envArgs struct {
+		Port    int    `arg-flag:"-p" arg-env:"CLEANARG_PORT" arg-default:"80"`
+		Host    string `arg-flag:"-H" arg-env:"CLEANARG_HOST"`
+		Verbose bool   `arg-flag:"-v" arg-env:"CLEANARG_VERBOSE"`
+	}
+
+	tests := []struct {
+		env     map[string]string
+		slice   []string
+		want    envArgs
+		wantErr bool
+	}{
+		{map[string]string{}, []string{}, envArgs{80, "", false}, false},
+		{map[string]string{"CLEANARG_PORT": "8080"}, []string{},
+			envArgs{8080, "", false}, false},
+		{map[string]string{"CLEANARG_PORT": "8080"}, []string{"-p", "9"},
+			envArgs{9, "", false}, false},
+		{map[string]string{"CLEANARG_PORT": ""}, []string{},
+			envArgs{80, "", false}, false},
+		{map[string]string{"CLEANARG_HOST": "h", "CLEANARG_VERBOSE": "true"},
+			[]string{}, envArgs{80, "h", true}, false},
+		{map[string]string{"CLEANARG_VERBOSE": "1"}, []string{"-v"},
+			envArgs{80, "", true}, false},
+		{map[string]string{"CLEANARG_PORT": "x"}, []string{},
+			envArgs{}, true},
+		{map[string]string{"CLEANARG_VERBOSE": "off"}, []string{},
+			envArgs{80, "", false}, false},
+		{map[string]string{"CLEANARG_VERBOSE": "maybe"}, []string{"-v"},
+			envArgs{}, true},
+	}
+
+	for _, test := range tests {
+		for k, v := range test.env {
+			t.Setenv(k, v)
+		}
+
+		a := envArgs{}
+		err := FromSlice(test.slice, &a)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v %v: Unexpected error: %v", test.env, test.slice, err)
+		}
+		if err == nil && a != test.want {
+			t.Errorf("%v %v: got=%v want=%v", test.env, test.slice, a, test.want)
+		}
+
+		for k := range test.env {
+			os.Unsetenv(k)
+		}
+	}
+
+	// Fused mode: environment, but no default
+	t.Setenv("CLEANARG_HOST", "h")
+	a := envArgs{}
+	if err := FromSliceFused([]string{}, &a); err != nil ||
+		a != (envArgs{0, "h", false}) {
+		t.Errorf("Fused: got=%v err=%v", a, err)
+	}
+
+	sb := strings.Builder{}
+	WriteUsage(&sb, &envArgs{})
+	if !strings.Contains(sb.String(), "(env: CLEANARG_PORT)") {
+		t.Errorf("Missing environment variable:\n%s", sb.String())
+	}
+}
+
 func Test_FromSliceDefaultFused(t *testing.T) {
 
 	tests := []struct {
@@ -1679,7 +2594,7 @@ func Test_FromSliceBad(t *testing.T) {
 
 func Test_WriteShortUsage(t *testing.T) {
 	arg1 := simpleArgs{}
-	want1 := "[+c counter] [-b] [-s|--name string] [--time time.Time] [int] [source] [string]+ \n"
+	want1 := shortUsagePrefix + "[+c counter] [-b] [-s|--name string] [--time time.Time] [int] [source] [string]+ \n"
 
 	sb := strings.Builder{}
 	WriteShortUsage(&sb, &arg1)
@@ -1691,7 +2606,7 @@ func Test_WriteShortUsage(t *testing.T) {
 	// -----
 
 	arg2 := sliceArgs{}
-	want2 := "[-b]+ [-s string]+ [string] [string]+ \n"
+	want2 := shortUsagePrefix + "[-b]+ [-s string]+ [string] [string]+ \n"
 
 	sb = strings.Builder{}
 	WriteShortUsage(&sb, &arg2)
@@ -1699,6 +2614,27 @@ func Test_WriteShortUsage(t *testing.T) {
 	if sb.String() != want2 {
 		t.Errorf("want=%s\ngot=%s", want2, sb.String())
 	}
+
+	// -----
+
+	arg3 := struct {
+		All     bool   `arg-flag:"-a --all"`
+		Long    bool   `arg-flag:"-l"`
+		Verbose []bool `arg-flag:"-v"`
+		Plus    bool   `arg-flag:"+p"`
+		Color   bool   `arg-flag:"--color"`
+		Count   int    `arg-flag:"-c"`
+		Zero    bool   `arg-flag:"-0"`
+		Files   []string
+	}{}
+	want3 := shortUsagePrefix + "[+p] [-0al] [-c int] [-v]+ [--color] [string]+ \n"
+
+	sb = strings.Builder{}
+	WriteShortUsage(&sb, &arg3)
+
+	if sb.String() != want3 {
+		t.Errorf("want=%s\ngot=%s", want3, sb.String())
+	}
 }
 
 func Test_WriteUsage(t *testing.T) {
@@ -1709,6 +2645,27 @@ func Test_WriteUsage(t *testing.T) {
 		PrintUsage(&arg1)
 		PrintUsage(&arg2)
 	}
+
+	sb := strings.Builder{}
+	WriteUsage(&sb, &arg1)
+
+	if !strings.Contains(sb.String(), "(format: 2006-01-02 15:04:05)") {
+		t.Errorf("Missing format hint:\n%s", sb.String())
+	}
+	arg3 := struct {
+		Timeout int    `arg-flag:"--timeout" arg-url:"https://example.com/t"`
+		File    string `arg-url:"https://example.com/f"`
+	}{}
+
+	sb = strings.Builder{}
+	WriteUsage(&sb, &arg3)
+
+	for _, want := range []string{"See: https://example.com/t",
+		"See: https://example.com/f"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("Missing link %s:\n%s", want, sb.String())
+		}
+	}
 }
 
 func Test_WriteValues(t *testing.T) {
@@ -1721,3 +2678,209 @@ func Test_WriteValues(t *testing.T) {
 		PrintValuesWithTags(&arg)
 	}
 }
+
+func Test_WriteValuesSecret(t *testing.T) {
+	type secretArgs struct {
+		User     string  `arg-flag:"-u"`
+		Password string  `arg-flag:"-p" arg-secret:""`
+		Token    *string `arg-flag:"-t" arg-secret:""`
+		Key      string  `arg-flag:"-k" arg-secret:""`
+	}
+
+	a := secretArgs{}
+	if err := FromSlice([]string{"-u", "me", "-p", "s3cr3t", "-t", "abc"},
+		&a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	sb := strings.Builder{}
+	if err := WriteValues(&sb, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out := sb.String()
+	if strings.Contains(out, "s3cr3t") || strings.Contains(out, "abc") ||
+		strings.Count(out, "********") != 2 || !strings.Contains(out, "me") {
+		t.Errorf("Unexpected values:\n%s", out)
+	}
+}
+
+func Test_FromSlicePointer(t *testing.T) {
+	type ptrArgs struct {
+		Num     *int     `arg-flag:"-n"`
+		Verbose *bool    `arg-flag:"-v"`
+		Name    *string  `arg-flag:"--name"`
+		Level   *int     `arg-flag:"-l" arg-default:"3"`
+		Debug   *int     `arg-flag:"-d" arg-count:""`
+		Ratio   *float64 `arg-flag:"-r" arg-min:"0" arg-max:"1"`
+	}
+
+	a := ptrArgs{}
+	if err := FromSlice([]string{}, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a.Num != nil || a.Verbose != nil || a.Name != nil || a.Debug != nil ||
+		a.Ratio != nil {
+		t.Errorf("Expected nil pointers: got=%+v", a)
+	}
+	if a.Level == nil || *a.Level != 3 {
+		t.Errorf("Expected default: got=%v", a.Level)
+	}
+
+	a = ptrArgs{}
+	err := FromSlice([]string{"-n", "0", "-v", "--name", "", "-dd"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a.Num == nil || *a.Num != 0 || a.Verbose == nil || !*a.Verbose ||
+		a.Name == nil || *a.Name != "" || a.Debug == nil || *a.Debug != 2 {
+		t.Errorf("Expected values: got=%+v", a)
+	}
+
+	err = FromSlice([]string{"-r", "2"}, &ptrArgs{})
+	if !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("Expected ErrOutOfRange: got=%v", err)
+	}
+}
+
+func Test_FromSliceOptionalPositional(t *testing.T) {
+	type optPosArgs struct {
+		Verbose bool `arg-flag:"-v"`
+		Src     string
+		Dst     string `arg-optional:"" arg-default:"."`
+		Mode    *int   `arg-optional:""`
+	}
+
+	tests := []struct {
+		tokens []string
+		src    string
+		dst    string
+		mode   int   // -1: nil
+		kind   error // expected error, if any
+	}{
+		{[]string{"a"}, "a", ".", -1, nil},
+		{[]string{"a", "b"}, "a", "b", -1, nil},
+		{[]string{"-v", "a", "b", "7"}, "a", "b", 7, nil},
+		{[]string{}, "", "", -1, ErrTooFewPositionals},
+		{[]string{"a", "b", "7", "8"}, "", "", -1, ErrTooManyPositionals},
+	}
+
+	for _, test := range tests {
+		a := optPosArgs{}
+		err := FromSlice(test.tokens, &a)
+		if test.kind != nil {
+			if !errors.Is(err, test.kind) {
+				t.Errorf("%v: expected %v, got=%v", test.tokens, test.kind, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.tokens, err)
+			continue
+		}
+		if a.Src != test.src || a.Dst != test.dst ||
+			(test.mode < 0) != (a.Mode == nil) ||
+			(a.Mode != nil && *a.Mode != test.mode) {
+			t.Errorf("%v: got=%+v", test.tokens, a)
+		}
+	}
+
+	sb := strings.Builder{}
+	WriteShortUsage(&sb, &optPosArgs{})
+	if !strings.Contains(sb.String(), "[string] [string]? [int]?") {
+		t.Errorf("Unexpected usage: %s", sb.String())
+	}
+
+	bad := []struct {
+		data any
+		text string
+	}{
+		{&struct {
+			A string `arg-optional:""`
+			B string
+		}{}, "follows arg-optional positional A"},
+		{&struct {
+			A string `arg-optional:""`
+			B []string
+		}{}, "not permitted with positional slice"},
+		{&struct {
+			A []string `arg-optional:""`
+		}{}, "not permitted on slice"},
+		{&struct {
+			A string `arg-flag:"-a" arg-optional:""`
+		}{}, "requires positional field"},
+	}
+	for _, test := range bad {
+		err := FromSlice([]string{}, test.data)
+		if err == nil || !strings.Contains(err.Error(), test.text) {
+			t.Errorf("Expected error %q: got=%v", test.text, err)
+		}
+	}
+}
+
+func Test_WriteUsageHidden(t *testing.T) {
+	type hiddenArgs struct {
+		Verbose bool   `arg-flag:"-v"`
+		Debug   bool   `arg-flag:"-d --debug" arg-hidden:"" arg-negate:""`
+		Trace   string `arg-flag:"--trace" arg-hidden:"" arg-help:"Trace *spans*"`
+		Files   []string
+	}
+
+	sb := strings.Builder{}
+	WriteShortUsage(&sb, &hiddenArgs{})
+	if sb.String() != shortUsagePrefix+"[-v] [string]+ \n" {
+		t.Errorf("Unexpected short usage: %q", sb.String())
+	}
+
+	sb = strings.Builder{}
+	WriteUsage(&sb, &hiddenArgs{})
+	if strings.Contains(sb.String(), "-d") ||
+		strings.Contains(sb.String(), "trace") ||
+		!strings.Contains(sb.String(), "-v") {
+		t.Errorf("Unexpected usage:\n%s", sb.String())
+	}
+
+	// Hidden flags parse normally, but are not suggested
+	data := hiddenArgs{}
+	err := FromSlice([]string{"-d", "--trace", "x", "--no-debug", "a"}, &data)
+	if err != nil || data.Debug || data.Trace != "x" {
+		t.Errorf("Unexpected result: %+v, %v", data, err)
+	}
+	p := NewParser(WithStrict())
+	err = p.Parse([]string{"--trac"}, &hiddenArgs{})
+	if err == nil || strings.Contains(err.Error(), "--trace") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Only options may be hidden
+	err = FromSlice([]string{}, &struct {
+		Src string `arg-hidden:""`
+	}{})
+	if err == nil || err.Error() != "arg-hidden requires arg-flag: Src" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func Test_WriteUsageArgName(t *testing.T) {
+	type nameArgs struct {
+		Format string `arg-flag:"-f" arg-choices:"json yaml" arg-name:"FMT"`
+		Level  string `arg-flag:"-l" arg-choices:"low high"`
+		Src    string `arg-name:"SOURCE" arg-help:"File to copy"`
+		Dst    string `arg-name:"DEST"`
+	}
+
+	sb := strings.Builder{}
+	WriteShortUsage(&sb, &nameArgs{})
+	if !strings.Contains(sb.String(), "[-f FMT]") ||
+		!strings.Contains(sb.String(), "[-l {low|high}]") ||
+		!strings.Contains(sb.String(), "[SOURCE] [DEST]") {
+		t.Errorf("Unexpected short usage:\n%s", sb.String())
+	}
+
+	sb = strings.Builder{}
+	WriteUsage(&sb, &nameArgs{})
+	if !strings.Contains(sb.String(), "[SOURCE] File to copy") ||
+		!strings.Contains(sb.String(), "[DEST] Dst") ||
+		!strings.Contains(sb.String(), "-f [FMT]") {
+		t.Errorf("Unexpected usage:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,713 @@
+// Command cleanarg-gen generates a function that populates a struct from
+// command-line tokens, just like cleanarg.FromSlice(), but without
+// reflection. This is useful for programs that care about startup latency
+// or binary size, or that run in environments with limited support for
+// reflection (such as TinyGo).
+//
+// Usage (typically in a go:generate directive):
+//
+//	//go:generate cleanarg-gen -type Config
+//
+// This reads the struct type Config from the Go files in the current
+// directory, and writes config_cleanarg.go, which defines
+//
+//	func ParseConfig(tokens []string, c *Config) error
+//
+// The generated code does not import cleanarg. It supports the struct tags
+// arg-flag, arg-default, arg-const, arg-choices, arg-secret, arg-ignore,
+// arg-help, and arg-url, and arg-format for time.Time fields; structs that
+// use other tags (or embedded structs) are rejected. Errors carry the same
+// messages as those of FromSlice(), but are plain errors (not
+// *cleanarg.ParseError), and do not suggest similar flags.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/janert/cleanarg"
+)
+
+// Tags that the generated code does not support
+var unsupportedTags = []string{
+	"arg-xor", "arg-require-one", "arg-assign", "arg-command", "arg-env",
+	"arg-prefix", "arg-config", "arg-rest", "arg-count", "arg-once",
+	"arg-max-count", "arg-together", "arg-requires", "arg-min", "arg-max",
+	"arg-pattern", "arg-location", "arg-relative",
+	"arg-encoding", "arg-sep", "arg-reset", "arg-unique", "arg-sorted",
+	"arg-optional", "arg-name", "arg-number", "arg-section", "arg-confirm",
+	"arg-deprecated", "arg-alias", "arg-required",
+}
+
+// Default layout for time.Time fields without arg-format (as in cleanarg)
+const defaultTimeFormat = "2006-01-02 15:04:05"
+
+// Boolean values, as accepted by cleanarg (case-insensitively)
+var boolValues = map[string]bool{
+	"true": true, "yes": true, "on": true, "1": true, "t": true,
+	"false": false, "no": false, "off": false, "0": false, "f": false,
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "cleanarg-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Run parses the command-line arguments, and generates the code.
+func run(args []string) error {
+	fs := flag.NewFlagSet("cleanarg-gen", flag.ContinueOnError)
+	typeName := fs.String("type", "", "name of the struct type (required)")
+	funcName := fs.String("func", "", "name of the generated function (default Parse<type>)")
+	output := fs.String("output", "", "output file (default <type>_cleanarg.go)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *typeName == "" {
+		return fmt.Errorf("missing -type")
+	}
+	if *funcName == "" {
+		*funcName = "Parse" + *typeName
+	}
+
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	if *output == "" {
+		*output = filepath.Join(dir, strings.ToLower(*typeName)+"_cleanarg.go")
+	}
+
+	pkg, st, err := findStruct(dir, *typeName)
+	if err != nil {
+		return err
+	}
+
+	src, err := generate(pkg, *typeName, *funcName, st)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(*output, src, 0644)
+}
+
+// FindStruct parses the (non-test) Go files in dir, and returns the name
+// of the package and the definition of the struct type with the given name.
+func findStruct(dir, typeName string) (string, *ast.StructType, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", nil, err
+	}
+	sort.Strings(files)
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			return "", nil, err
+		}
+
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Name.Name != typeName {
+					continue
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					return "", nil, fmt.Errorf("%s is not a struct", typeName)
+				}
+				return f.Name.Name, st, nil
+			}
+		}
+	}
+
+	return "", nil, fmt.Errorf("type %s not found in %s", typeName, dir)
+}
+
+// StructType takes the definition of a struct type, and returns an
+// equivalent reflect.Type (without ignored fields), so that the struct can
+// be analyzed by cleanarg itself. Returns an error if the struct uses
+// features that the generated code does not support.
+func structType(st *ast.StructType) (reflect.Type, error) {
+	fields := []reflect.StructField{}
+
+	for _, field := range st.Fields.List {
+		tag := reflect.StructTag("")
+		if field.Tag != nil {
+			s, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return nil, err
+			}
+			tag = reflect.StructTag(s)
+		}
+		if _, ok := tag.Lookup("arg-ignore"); ok {
+			continue
+		}
+
+		if len(field.Names) == 0 {
+			return nil, fmt.Errorf("embedded fields not supported")
+		}
+		for _, t := range unsupportedTags {
+			if _, ok := tag.Lookup(t); ok {
+				return nil, fmt.Errorf("%s not supported: %s", t,
+					field.Names[0].Name)
+			}
+		}
+
+		typ, err := fieldType(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %s", err, field.Names[0].Name)
+		}
+
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				return nil, fmt.Errorf("field must be exported: %s", name.Name)
+			}
+			fields = append(fields, reflect.StructField{
+				Name: name.Name, Type: typ, Tag: tag,
+			})
+		}
+	}
+
+	return reflect.StructOf(fields), nil
+}
+
+// FieldType returns the reflect.Type for a type expression, which must be
+// one of the types supported by cleanarg, or a slice of one of them.
+func fieldType(expr ast.Expr) (reflect.Type, error) {
+	if arr, ok := expr.(*ast.ArrayType); ok && arr.Len == nil {
+		elem, err := fieldType(arr.Elt)
+		if err != nil {
+			return nil, err
+		}
+		if elem.Kind() == reflect.Slice {
+			return nil, fmt.Errorf("unsupported type")
+		}
+		return reflect.SliceOf(elem), nil
+	}
+
+	name := ""
+	switch x := expr.(type) {
+	case *ast.Ident:
+		name = x.Name
+	case *ast.SelectorExpr:
+		if pkg, ok := x.X.(*ast.Ident); ok {
+			name = pkg.Name + "." + x.Sel.Name
+		}
+	}
+
+	switch name {
+	case "bool":
+		return reflect.TypeOf(false), nil
+	case "int":
+		return reflect.TypeOf(0), nil
+	case "float64":
+		return reflect.TypeOf(0.0), nil
+	case "string":
+		return reflect.TypeOf(""), nil
+	case "time.Time":
+		return reflect.TypeOf(time.Time{}), nil
+	case "time.Duration":
+		return reflect.TypeOf(time.Duration(0)), nil
+	}
+	return nil, fmt.Errorf("unsupported type")
+}
+
+// genField describes a field, as needed for the generated code.
+type genField struct {
+	id      int
+	name    string
+	flag    string       // preferred flag, or the name for positionals
+	typ     reflect.Type // element type, for slices
+	isSlice bool
+	defval  string
+	format  string
+	secret  bool
+	choices []string
+}
+
+// GenFlag describes a flag, as needed for the generated code.
+type genFlag struct {
+	flag     string
+	id       int
+	takesArg bool
+	isConst  bool
+	constval string
+}
+
+// Generate returns the (formatted) source code of the function that
+// populates the struct.
+func generate(pkg, typeName, funcName string,
+	st *ast.StructType) ([]byte, error) {
+
+	typ, err := structType(st)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := cleanarg.Analyze(reflect.New(typ).Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	// Fields, in order of declaration; flags, in sorted order
+	fields, ids := []*genField{}, map[string]*genField{}
+	field := func(name string) *genField {
+		if f, ok := ids[name]; ok {
+			return f
+		}
+		sf, _ := typ.FieldByName(name)
+		f := &genField{id: len(fields), name: name, flag: name, typ: sf.Type}
+		if sf.Type.Kind() == reflect.Slice {
+			f.typ, f.isSlice = sf.Type.Elem(), true
+		}
+		fields = append(fields, f)
+		ids[name] = f
+		return f
+	}
+
+	flags := []genFlag{}
+	for _, o := range spec.Options {
+		f := field(o.Name)
+		if !o.Const {
+			f.defval, f.format, f.secret = o.Default, o.Format, o.Secret
+			f.choices = o.Choices
+			f.flag = o.Flags[len(o.Flags)-1]
+		}
+		if o.Const && f.typ == reflect.TypeOf(false) {
+			if _, ok := boolValues[strings.ToLower(o.ConstValue)]; !ok {
+				return nil, fmt.Errorf("invalid fixed value for %s: %s", o.Name,
+					o.ConstValue)
+			}
+		}
+
+		for _, fl := range o.Flags {
+			flags = append(flags, genFlag{
+				flag:     fl,
+				id:       f.id,
+				takesArg: o.Type != reflect.TypeOf(false) && !o.Const,
+				isConst:  o.Const,
+				constval: o.ConstValue,
+			})
+		}
+	}
+
+	positionals := []*genField{}
+	for _, p := range spec.Positionals {
+		f := field(p.Name)
+		f.format, f.secret, f.choices = p.Format, p.Secret, p.Choices
+		positionals = append(positionals, f)
+	}
+
+	for _, f := range fields {
+		if f.format != "" && f.typ != reflect.TypeOf(time.Time{}) {
+			return nil, fmt.Errorf("arg-format not supported: %s", f.name)
+		}
+	}
+
+	g := &generator{funcName: funcName, typeName: typeName}
+	g.header(pkg, fields)
+	g.parseFunc(fields, positionals)
+	g.flagFunc(flags)
+	g.tokensFunc()
+	g.setFunc(fields)
+	g.boolFunc(fields)
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+// generator accumulates the generated code.
+type generator struct {
+	buf      bytes.Buffer
+	funcName string // name of the generated function
+	typeName string // name of the struct type
+}
+
+// Printf appends formatted code.
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// Helper returns the name of a helper of the generated function.
+func (g *generator) helper(name string) string {
+	return strings.ToLower(g.funcName[:1]) + g.funcName[1:] + name
+}
+
+// Header writes the package clause and the imports.
+func (g *generator) header(pkg string, fields []*genField) {
+	imports := map[string]bool{"fmt": true}
+	for _, f := range fields {
+		switch f.typ {
+		case reflect.TypeOf(0), reflect.TypeOf(0.0):
+			imports["strconv"] = true
+		case reflect.TypeOf(time.Time{}), reflect.TypeOf(time.Duration(0)):
+			imports["time"] = true
+		}
+	}
+	imports["strings"] = true
+
+	names := []string{}
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g.printf("// Code generated by cleanarg-gen; DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", pkg)
+	g.printf("import (\n")
+	for _, name := range names {
+		g.printf("\t%q\n", name)
+	}
+	g.printf(")\n\n")
+}
+
+// ParseFunc writes the generated function itself.
+func (g *generator) parseFunc(fields, positionals []*genField) {
+	set := g.helper("Set")
+
+	g.printf("// %s takes a slice of string tokens and a pointer to a %s, and\n",
+		g.funcName, g.typeName)
+	g.printf("// populates the struct from the tokens, just like cleanarg.FromSlice(),\n")
+	g.printf("// but without reflection.\n")
+	g.printf("func %s(tokens []string, c *%s) error {\n", g.funcName, g.typeName)
+
+	// Default values of options (not slices, not positionals)
+	isPositional := map[int]bool{}
+	for _, p := range positionals {
+		isPositional[p.id] = true
+	}
+	for _, f := range fields {
+		if f.isSlice || f.defval == "" || isPositional[f.id] {
+			continue
+		}
+		if f.typ == reflect.TypeOf(false) {
+			// Boolean defaults are parsed, like fixed values
+			g.printf("if err := %s(c, %d, %q, true); err != nil {\n", set, f.id,
+				f.defval)
+		} else {
+			g.printf("if err := %s(c, %d, \"\", false); err != nil {\n", set,
+				f.id)
+		}
+		g.printf("return fmt.Errorf(\"invalid default value: %%v\", err)\n")
+		g.printf("}\n")
+	}
+
+	g.printf("\nopts, pos, err := %s(tokens)\n", g.helper("Tokens"))
+	g.printf("if err != nil {\nreturn err\n}\n")
+	g.printf("for _, o := range opts {\n")
+	g.printf("if err := %s(c, o.id, o.value, o.isConst); err != nil {\n", set)
+	g.printf("return err\n}\n}\n\n")
+
+	// Positionals, as assigned by cleanarg's populatePositionals
+	slice := -1
+	for i, p := range positionals {
+		if p.isSlice {
+			slice = i
+		}
+	}
+
+	if slice < 0 {
+		g.printf("if len(pos) != %d {\n", len(positionals))
+		g.printf("return fmt.Errorf(\"number of positional fields does not match number of tokens\")\n")
+		g.printf("}\n")
+		for i, p := range positionals {
+			g.printf("if err := %s(c, %d, pos[%d], false); err != nil {\n",
+				set, p.id, i)
+			g.printf("return fmt.Errorf(\"error populating positional field %d: %%w\", err)\n", i)
+			g.printf("}\n")
+		}
+	} else {
+		before, after := slice, len(positionals)-slice-1
+		if before+after > 0 {
+			g.printf("between := len(pos) - %d\n", before+after)
+		} else {
+			g.printf("between := len(pos)\n")
+		}
+		g.printf("if between < 0 {\n")
+		g.printf("return fmt.Errorf(\"not enough tokens to fill all positional fields\")\n")
+		g.printf("}\n")
+		for i := 0; i < before; i++ {
+			g.printf("if err := %s(c, %d, pos[%d], false); err != nil {\n",
+				set, positionals[i].id, i)
+			g.printf("return fmt.Errorf(\"error populating positional field %d: %%w\", err)\n", i)
+			g.printf("}\n")
+		}
+		g.printf("for i := 0; i < between; i++ {\n")
+		index := "i"
+		if before > 0 {
+			index = fmt.Sprintf("%d+i", before)
+		}
+		g.printf("if err := %s(c, %d, pos[%s], false); err != nil {\n",
+			set, positionals[slice].id, index)
+		g.printf("return fmt.Errorf(\"error populating slice of positionals: %%w\", err)\n")
+		g.printf("}\n}\n")
+		for i := 0; i < after; i++ {
+			g.printf("if err := %s(c, %d, pos[len(pos)-%d], false); err != nil {\n",
+				set, positionals[slice+1+i].id, after-i)
+			g.printf("return fmt.Errorf(\"error populating positional field %d: %%w\", err)\n",
+				slice+1+i)
+			g.printf("}\n")
+		}
+	}
+
+	// The struct may check itself, as with cleanarg.Validator
+	g.printf("\nif v, ok := any(c).(interface{ Validate() error }); ok {\n")
+	g.printf("return v.Validate()\n}\n")
+	g.printf("return nil\n}\n\n")
+}
+
+// FlagFunc writes the function that looks up a flag.
+func (g *generator) flagFunc(flags []genFlag) {
+	g.printf("// %s describes an option found among the tokens.\n",
+		g.helper("Option"))
+	g.printf("type %s struct {\n", g.helper("Option"))
+	g.printf("id int\nvalue string\nisConst bool\n}\n\n")
+
+	g.printf("// %s returns the field of a flag, whether it takes an argument,\n",
+		g.helper("Flag"))
+	g.printf("// and its fixed value (for arg-const flags).\n")
+	g.printf("func %s(flag string) (id int, takesArg, isConst bool, constval string, ok bool) {\n",
+		g.helper("Flag"))
+	g.printf("switch flag {\n")
+	for _, f := range flags {
+		g.printf("case %q:\n", f.flag)
+		g.printf("return %d, %t, %t, %q, true\n", f.id, f.takesArg, f.isConst,
+			f.constval)
+	}
+	g.printf("}\n")
+	g.printf("return 0, false, false, \"\", false\n}\n\n")
+}
+
+// TokensFunc writes the function that splits the tokens into options and
+// positionals, following the rules of cleanarg's processTokens.
+func (g *generator) tokensFunc() {
+	g.printf(`// %[1]s splits the tokens into options and positionals.
+func %[1]s(tokens []string) ([]%[2]s, []string, error) {
+	end := len(tokens)
+	for i, t := range tokens {
+		if t == "--" {
+			end = i
+			break
+		}
+	}
+
+	opts, pos := []%[2]s{}, []string{}
+	rest, compound := tokens[:end], false
+	for token := ""; len(rest) > 0 || token != ""; {
+		if token == "" {
+			token, rest = rest[0], rest[1:]
+			compound = false
+		}
+
+		flag, tail := %[3]s(token)
+		id, takesArg, isConst, constval, ok := %[4]s(flag)
+		if !ok && compound {
+			return nil, nil, fmt.Errorf("Unexpected %%s in compound flag", token)
+		}
+		if !ok {
+			pos = append(pos, token)
+			token = ""
+			continue
+		}
+
+		// Booleans may take an explicit value, as in --color=no or -b=false
+		long := strings.HasPrefix(token, "--")
+		if !takesArg && !isConst && (long && strings.Contains(token, "=") ||
+			!long && strings.HasPrefix(tail, "=")) {
+			if !long {
+				tail = tail[1:]
+			}
+			if tail == "" {
+				return nil, nil, fmt.Errorf("missing value: %%s", token)
+			}
+			opts = append(opts, %[2]s{id: id, value: tail})
+			token = ""
+			continue
+		}
+
+		o := %[2]s{id: id, isConst: isConst}
+		switch {
+		case !takesArg && tail == "":
+			token = ""
+		case !takesArg:
+			token, compound = flag[:1]+tail, true
+		case tail != "":
+			// Short flags may be fused with an equality sign, as in -c=9
+			if !long && strings.HasPrefix(tail, "=") {
+				tail = tail[1:]
+				if tail == "" {
+					return nil, nil, fmt.Errorf("missing value: %%s", token)
+				}
+			}
+			o.value, token = tail, ""
+		case len(rest) > 0:
+			o.value, token, rest = rest[0], "", rest[1:]
+		default:
+			return nil, nil, fmt.Errorf("not enough tokens: %%s", flag)
+		}
+		if isConst {
+			o.value = constval
+		}
+		opts = append(opts, o)
+	}
+
+	if end < len(tokens) {
+		pos = append(pos, tokens[end+1:]...)
+	}
+	return opts, pos, nil
+}
+
+// %[3]s splits a token that looks like a flag into the flag and the rest.
+func %[3]s(s string) (string, string) {
+	switch {
+	case s == "--", s == "-", s == "+":
+		return s, ""
+	case strings.HasPrefix(s, "--"):
+		flag, rest, _ := strings.Cut(s, "=")
+		return flag, rest
+	case strings.HasPrefix(s, "-"), strings.HasPrefix(s, "+"):
+		return s[0:2], s[2:]
+	default:
+		return s, ""
+	}
+}
+
+`, g.helper("Tokens"), g.helper("Option"), g.helper("Chop"), g.helper("Flag"))
+}
+
+// SetFunc writes the function that converts a value and sets a field,
+// following the rules of cleanarg's convertToType.
+func (g *generator) setFunc(fields []*genField) {
+	g.printf("// %s converts the value and sets the field with the given id.\n",
+		g.helper("Set"))
+	g.printf("func %s(c *%s, id int, value string, isConst bool) error {\n",
+		g.helper("Set"), g.typeName)
+	g.printf("switch id {\n")
+
+	for _, f := range fields {
+		g.printf("case %d: // %s\n", f.id, f.name)
+
+		// Errors of secret fields do not reveal the value; failed
+		// conversions name the value and the flag
+		fail := "return err"
+		invalid := fmt.Sprintf(
+			"return fmt.Errorf(\"invalid value %%q for %s\", value)", f.flag)
+		if f.secret {
+			fail = fmt.Sprintf(
+				"return fmt.Errorf(\"invalid value for %s (value redacted)\")",
+				f.name)
+			invalid = fail
+		}
+
+		isBool := f.typ == reflect.TypeOf(false)
+		if f.defval != "" && !isBool {
+			g.printf("if value == \"\" {\nvalue = %q\n}\n", f.defval)
+		}
+
+		if len(f.choices) > 0 && !isBool {
+			quoted := []string{}
+			for _, c := range f.choices {
+				quoted = append(quoted, strconv.Quote(c))
+			}
+			g.printf("switch value {\ncase %s:\ndefault:\n",
+				strings.Join(quoted, ", "))
+			if f.secret {
+				g.printf("%s\n}\n", fail)
+			} else {
+				g.printf("return fmt.Errorf(\"invalid value %%q for %s, must be one of: %s\", value)\n}\n",
+					f.name, strings.Join(f.choices, ", "))
+			}
+		}
+
+		switch f.typ {
+		case reflect.TypeOf(false):
+			// Without fixed or explicit value, a boolean flag means true
+			g.printf("x := true\n")
+			g.printf("if value != \"\" {\n")
+			g.printf("b, err := %s(value)\n", g.helper("Bool"))
+			g.printf("if err != nil {\n%s\n}\n", fail)
+			g.printf("x = b\n}\n")
+		case reflect.TypeOf(""):
+			g.printf("x := value\n")
+		case reflect.TypeOf(0):
+			g.printf("x, err := strconv.Atoi(value)\n")
+			g.printf("if err != nil {\n%s\n}\n", invalid)
+		case reflect.TypeOf(0.0):
+			g.printf("x, err := strconv.ParseFloat(value, 64)\n")
+			g.printf("if err != nil {\n%s\n}\n", invalid)
+		case reflect.TypeOf(time.Time{}):
+			layout := f.format
+			if layout == "" {
+				layout = defaultTimeFormat
+			}
+			g.printf("x, err := time.Parse(%q, value)\n", layout)
+			g.printf("if err != nil {\n%s\n}\n", invalid)
+		case reflect.TypeOf(time.Duration(0)):
+			g.printf("x, err := time.ParseDuration(value)\n")
+			g.printf("if err != nil {\n%s\n}\n", invalid)
+		}
+
+		if f.isSlice {
+			g.printf("c.%s = append(c.%s, x)\n", f.name, f.name)
+		} else {
+			g.printf("c.%s = x\n", f.name)
+		}
+	}
+
+	g.printf("}\nreturn nil\n}\n")
+}
+
+// BoolFunc writes the function that parses boolean values, if there are
+// boolean fields.
+func (g *generator) boolFunc(fields []*genField) {
+	for _, f := range fields {
+		if f.typ != reflect.TypeOf(false) {
+			continue
+		}
+
+		g.printf("\n// %s parses a boolean value, case-insensitively.\n",
+			g.helper("Bool"))
+		g.printf("func %s(s string) (bool, error) {\n", g.helper("Bool"))
+		g.printf("switch strings.ToLower(s) {\n")
+		for _, b := range []bool{true, false} {
+			values := []string{}
+			for v, ok := range boolValues {
+				if ok == b {
+					values = append(values, strconv.Quote(v))
+				}
+			}
+			sort.Strings(values)
+			g.printf("case %s:\nreturn %t, nil\n", strings.Join(values, ", "), b)
+		}
+		g.printf("}\n")
+		g.printf("return false, fmt.Errorf(\"invalid boolean value: %%s\", s)\n}\n")
+		return
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"testing"
+
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Struct used to compare the generated code with cleanarg.FromSlice()
+const sampleStruct = `package main
+
+import "time"
+
+type Config struct {
+	Verbose bool          ` + "`arg-flag:\"-v --verbose\"`" + `
+	Quiet   bool          ` + "`arg-flag:\"-q\" arg-const:\"--loud=false\"`" + `
+	Level   int           ` + "`arg-flag:\"-l --level\" arg-const:\"--fast=9\" arg-default:\"3\"`" + `
+	Mode    string        ` + "`arg-flag:\"-m\" arg-choices:\"a b c\" arg-default:\"a\"`" + `
+	Ratio   float64       ` + "`arg-flag:\"-r --ratio\"`" + `
+	Tags    []string      ` + "`arg-flag:\"-t --tag\"`" + `
+	Wait    time.Duration ` + "`arg-flag:\"-w\"`" + `
+	Since   time.Time     ` + "`arg-flag:\"--since\" arg-format:\"2006-01-02\"`" + `
+	Token   string        ` + "`arg-flag:\"--token\" arg-secret:\"\" arg-choices:\"x y\"`" + `
+	Color   bool          ` + "`arg-flag:\"--color\" arg-default:\"yes\"`" + `
+	Skip    string        ` + "`arg-ignore:\"\"`" + `
+
+	First string
+	Rest  []int
+	Last  string
+}
+`
+
+// Token slices on which the generated code and FromSlice() must agree
+const sampleCases = `
+var cases = [][]string{
+	{"in", "out"},
+	{"in", "1", "2", "out"},
+	{"-v", "in", "out"},
+	{"-vq", "in", "out"},
+	{"-vl5", "in", "out"},
+	{"-vx", "in", "out"},
+	{"-l", "7", "--level=8", "in", "out"},
+	{"-l=7", "-vm=b", "in", "out"},
+	{"-l=", "in", "out"},
+	{"--fast", "in", "out"},
+	{"--verbose=no", "-q=TRUE", "in", "out"},
+	{"-vq=off", "--color=", "in", "out"},
+	{"-v=", "in", "out"},
+	{"--verbose=maybe", "in", "out"},
+	{"--color=0", "--loud", "in", "out"},
+	{"-q", "--loud", "in", "out"},
+	{"--fast", "-l", "5", "in", "out"},
+	{"-l", "x", "in", "out"},
+	{"-m", "b", "in", "out"},
+	{"-m", "d", "in", "out"},
+	{"-mc", "in", "out"},
+	{"-r", "2.5", "-t", "x", "--tag", "y", "in", "out"},
+	{"-w", "1m30s", "--since", "2024-02-03", "in", "out"},
+	{"--since", "yesterday", "in", "out"},
+	{"--token", "y", "in", "out"},
+	{"--token", "z", "in", "out"},
+	{"--level=", "4", "in", "out"},
+	{"in", "-x", "out"},
+	{"in", "--", "-v", "out"},
+	{"in", "out", "-l"},
+	{"in"},
+	{"in", "x", "out"},
+	{},
+}
+`
+
+const sampleMain = `package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/janert/cleanarg"
+)
+
+func main() {
+	failed := false
+	for _, tokens := range cases {
+		var a, b Config
+		errA := ParseConfig(tokens, &a)
+		errB := cleanarg.FromSlice(tokens, &b)
+
+		if (errA == nil) != (errB == nil) ||
+			errA != nil && errA.Error() != errB.Error() {
+			fmt.Printf("%q: errors differ: %v / %v\n", tokens, errA, errB)
+			failed = true
+		} else if errA == nil && !reflect.DeepEqual(a, b) {
+			fmt.Printf("%q: values differ: %+v / %+v\n", tokens, a, b)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+`
+
+func Test_generateEquivalence(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	root, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module sample\n\ngo 1.21\n\n" +
+			"require github.com/janert/cleanarg v0.0.0\n\n" +
+			"replace github.com/janert/cleanarg => " + root + "\n",
+		"config.go": sampleStruct,
+		"main.go":   sampleMain + sampleCases,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src),
+			0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := run([]string{"-type", "Config", dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(gobin, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("generated code differs from FromSlice: %v\n%s", err, out)
+	}
+}
+
+func Test_generateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string // substring of the error
+	}{
+		{"env", "X int `arg-flag:\"-x\" arg-env:\"X\"`", "arg-env not supported"},
+		{"xor", "X bool `arg-flag:\"-x\" arg-xor:\"a\"`", "arg-xor not supported"},
+		{"command", "X struct{} `arg-command:\"x\"`", "arg-command not supported"},
+		{"type", "X uint `arg-flag:\"-x\"`", "unsupported type"},
+		{"map", "X map[string]int", "unsupported type"},
+		{"embedded", "time.Time", "embedded fields not supported"},
+		{"unexported", "x int `arg-flag:\"-x\"`", "must be exported"},
+		{"format", "X int `arg-flag:\"-x\" arg-format:\"si\"`", "arg-format not supported"},
+		{"analysis", "X int `arg-flag:\"x\"`", "x"},
+		{"const", "X bool `arg-flag:\"-x\" arg-const:\"-y=maybe\"`", "invalid fixed value"},
+	}
+
+	for _, test := range tests {
+		src := "package p\n\nimport \"time\"\n\nvar _ time.Time\n\n" +
+			"type T struct {\n" + test.src + "\n}\n"
+		f, err := parser.ParseFile(token.NewFileSet(), "t.go", src, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		st := f.Decls[2].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).
+			Type.(*ast.StructType)
+
+		_, err = generate("p", "T", "ParseT", st)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: expected error containing %q, got: %v", test.name,
+				test.want, err)
+		}
+	}
+}
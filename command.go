@@ -0,0 +1,346 @@
+package cleanarg
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// commandInfo describes a struct field that defines a subcommand.
+type commandInfo struct {
+	reflect.StructField
+
+	name    string   // Name of the subcommand (arg-command)
+	aliases []string // Further names of the subcommand (arg-command)
+	help    string   // Help text (arg-help)
+	isDef   bool     // Selected if no other one is (arg-default-command)
+}
+
+// Matches returns true if the token is the name or an alias of the
+// subcommand.
+func (cmd commandInfo) matches(token string) bool {
+	return cmd.name == token || slices.Contains(cmd.aliases, token)
+}
+
+// FindCommands takes a reflect.Value, which must represent a struct, and
+// returns a description of all fields that define subcommands (ie. that
+// are tagged arg-command), in order. The tag holds the name of the
+// subcommand, optionally followed by aliases (as in "clone cl").
+// Returns an error if such a field is not a struct (or a pointer to
+// a struct), if a subcommand name or alias is empty, looks like a flag,
+// or is used more than once, or if more than one subcommand is tagged
+// arg-default-command.
+func findCommands(v reflect.Value) ([]commandInfo, error) {
+	out := []commandInfo{}
+	seen := map[string]struct{}{}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+
+		tag, ok := field.Tag.Lookup(tagCommand)
+		if !ok {
+			continue
+		}
+		if _, ok := field.Tag.Lookup(tagIgnore); ok {
+			continue
+		}
+
+		typ := field.Type
+		if typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("subcommand %s must be struct or ptr to struct",
+				field.Name)
+		}
+
+		names := strings.Fields(tag)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("malformed subcommand name: %q", tag)
+		}
+		for _, name := range names {
+			if strings.ContainsAny(name[:1], "-+") {
+				return nil, fmt.Errorf("malformed subcommand name: %q", tag)
+			}
+			if _, ok := seen[name]; ok {
+				return nil, fmt.Errorf("duplicate subcommand name: %s", name)
+			}
+			seen[name] = struct{}{}
+		}
+
+		_, isDef := field.Tag.Lookup(tagDefCmd)
+		if isDef && slices.ContainsFunc(out, func(c commandInfo) bool {
+			return c.isDef
+		}) {
+			return nil, fmt.Errorf("duplicate %s: %s", tagDefCmd, names[0])
+		}
+
+		out = append(out, commandInfo{
+			StructField: field,
+			name:        names[0],
+			aliases:     names[1:],
+			help:        field.Tag.Get(tagHelp),
+			isDef:       isDef,
+		})
+	}
+
+	return out, nil
+}
+
+// ChildCommands takes the description of a subcommand, and returns the
+// subcommands of its own struct (see findCommands()), if any. Malformed
+// subcommands are ignored here (they are reported when selected).
+func childCommands(cmd commandInfo) []commandInfo {
+	t := cmd.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	children, err := findCommands(reflect.New(t).Elem())
+	if err != nil {
+		return nil
+	}
+	return children
+}
+
+// LookupCommand returns the subcommand with the given name or alias, or
+// nil.
+func lookupCommand(commands []commandInfo, name string) *commandInfo {
+	for i := range commands {
+		if commands[i].matches(name) {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// DefaultCommand returns the default subcommand (arg-default-command), or
+// nil.
+func defaultCommand(commands []commandInfo) *commandInfo {
+	for i := range commands {
+		if commands[i].isDef {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// CommandHelp returns the help text of the subcommand (arg-help), followed
+// by hints on its aliases and on whether it is the default subcommand.
+func commandHelp(cmd commandInfo) string {
+	help := cmd.help
+	if len(cmd.aliases) > 0 {
+		help = appendHint(help, messagef("aliases: %s",
+			strings.Join(cmd.aliases, ", ")))
+	}
+	if cmd.isDef {
+		help = appendHint(help, message("default"))
+	}
+	return help
+}
+
+// CommandNames returns the names of the subcommands, in order.
+func commandNames(commands []commandInfo) []string {
+	names := []string{}
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+	return names
+}
+
+// SplitCommand takes a reflect.Value, which must represent a struct, the
+// struct's options and positionals (as returned by analyzeStruct), and a
+// slice of tokens. If the struct defines subcommands, the first positional
+// token (ie. the first token that is neither a flag nor the argument of a
+// flag, and that precedes "--") selects the subcommand.
+// Returns the tokens that precede the subcommand name (to be used for the
+// struct itself), the selected subcommand (or nil), and the tokens that
+// follow the subcommand name (to be used for the subcommand). If no
+// subcommand is selected, all tokens are returned for the struct itself.
+// If the first positional token does not name a subcommand (or if there
+// is none), the default subcommand (arg-default-command) is selected, if
+// there is one, and receives the tokens from the first positional token on.
+// Returns an error if the first positional token does not name a
+// subcommand, unless the struct has positional fields of its own (which
+// are not permitted together with a default subcommand).
+func splitCommand(v reflect.Value, options map[string]fieldInfo,
+	positionals []fieldInfo, tokens []string,
+	isFused bool) ([]string, *commandInfo, []string, error) {
+
+	commands, err := findCommands(v)
+	if err != nil || len(commands) == 0 {
+		return tokens, nil, nil, err
+	}
+
+	def := defaultCommand(commands)
+	if def != nil && len(positionals) > 0 {
+		return nil, nil, nil, fmt.Errorf("%s not permitted with positional "+
+			"fields: %s", tagDefCmd, def.name)
+	}
+
+	i := firstPositional(options, tokens, isFused)
+	if i < 0 {
+		if def != nil {
+			return tokens, def, []string{}, nil
+		}
+		return tokens, nil, nil, nil
+	}
+
+	if cmd := lookupCommand(commands, tokens[i]); cmd != nil {
+		return tokens[:i], cmd, tokens[i+1:], nil
+	}
+	if def != nil {
+		return tokens[:i], def, tokens[i:], nil
+	}
+
+	if len(positionals) == 0 {
+		if looksLikeFlag(tokens[i]) {
+			return nil, nil, nil, unknownFlagError(options, tokens, i)
+		}
+		return nil, nil, nil, unknownCommandError(commands, tokens, i)
+	}
+	return tokens, nil, nil, nil
+}
+
+// UnknownCommandError returns an error for the unknown subcommand in the
+// token with the given index, which lists the available subcommands, and
+// includes the closest matches among their names and aliases, if any.
+func unknownCommandError(commands []commandInfo, tokens []string,
+	index int) *ParseError {
+
+	name := tokens[index]
+	candidates := []string{}
+	for _, cmd := range commands {
+		candidates = append(append(candidates, cmd.name), cmd.aliases...)
+	}
+	available := strings.Join(commandNames(commands), ", ")
+
+	if s := suggest(name, candidates); len(s) > 0 {
+		return newParseError(ErrUnknownCommand, name, index,
+			"unknown command: %s, did you mean %s? (available: %s)", name,
+			strings.Join(s, " or "), available)
+	}
+	return newParseError(ErrUnknownCommand, name, index,
+		"unknown command: %s (available: %s)", name, available)
+}
+
+// FirstPositional takes a map of options and a slice of tokens, and returns
+// the index of the first token that is neither a flag nor the argument of a
+// flag, following the rules of processMaybeFlags(). Returns -1 if there is
+// no such token before the "--" token (or the end of the slice).
+func firstPositional(options map[string]fieldInfo, tokens []string,
+	isFused bool) int {
+
+	if idx := positionalIndices(options, tokens, isFused); len(idx) > 0 {
+		return idx[0]
+	}
+	return -1
+}
+
+// PositionalIndices takes a map of options and a slice of tokens, and
+// returns the indices of all tokens before the "--" token (if any) that are
+// neither flags nor arguments of flags, following the rules of
+// processMaybeFlags(). Unrecognized flags are included.
+func positionalIndices(options map[string]fieldInfo, tokens []string,
+	isFused bool) []int {
+
+	out := []int{}
+
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i] == endFlagsIndicator {
+			break
+		}
+
+		flag, rest := chopToken(tokens[i])
+		info, ok := options[flag]
+		if !ok {
+			out = append(out, i)
+			continue
+		}
+
+		// Compound flags: skip argument-less flags
+		for ok && !takesArgument(info) && rest != "" {
+			flag, rest = chopToken(flag[:1] + rest)
+			info, ok = options[flag]
+		}
+
+		// Flag's argument is the next token
+		if ok && takesArgument(info) && rest == "" && !isFused {
+			i++
+		}
+	}
+
+	return out
+}
+
+// RewriteFlags takes a map of options, a slice of tokens, and a function
+// that maps a token to its rewritten form (the boolean return value is
+// false if the token is not to be rewritten), and returns the tokens with
+// each token that is neither the argument of a flag nor follows "--"
+// rewritten. Rewritten tokens are then processed like any other. If the
+// struct has subcommands, only the tokens preceding the first positional
+// token (the subcommand's name) are rewritten; with POSIX ordering (posix),
+// only those preceding the first positional token (which ends the flags).
+func rewriteFlags(options map[string]fieldInfo, tokens []string,
+	isFused, hasCommands, posix bool,
+	rewrite func(string) (string, bool)) []string {
+
+	out := append([]string{}, tokens...)
+	for i := 0; i < len(out); i++ {
+		if out[i] == endFlagsIndicator {
+			break
+		}
+		if s, ok := rewrite(out[i]); ok {
+			out[i] = s
+		}
+
+		flag, rest := chopToken(out[i])
+		info, ok := options[flag]
+		if !ok {
+			if hasCommands && !strings.HasPrefix(out[i], "-") {
+				break
+			}
+			if posix && !isNumberToken(options, out[i]) {
+				break
+			}
+			continue
+		}
+
+		// Compound flags: skip argument-less flags
+		for ok && !takesArgument(info) && rest != "" {
+			flag, rest = chopToken(flag[:1] + rest)
+			info, ok = options[flag]
+		}
+
+		// Flag's argument is the next token
+		if ok && takesArgument(info) && rest == "" && !isFused {
+			i++
+		}
+	}
+	return out
+}
+
+// PopulateCommand takes the description of a subcommand, a slice of tokens,
+// a reflect.Value, which must represent the struct that contains the
+// subcommand field, a Parser, and the state of the parse for the
+// subcommand, and populates the subcommand's struct from the tokens. If the
+// subcommand field is a nil pointer, a new struct is allocated.
+func populateCommand(cmd commandInfo, tokens []string, v reflect.Value,
+	p *Parser, st *parseState) error {
+
+	field := v.FieldByIndex(cmd.Index)
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+	if st.selected != nil {
+		*st.selected = append(*st.selected, field)
+	}
+
+	if err := populateStruct(tokens, field, p, st); err != nil {
+		return fmt.Errorf("%s: %w", cmd.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,413 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"reflect"
+	"strings"
+)
+
+type cloneCmd struct {
+	Depth int `arg-flag:"--depth"`
+	Repo  string
+}
+
+type pushCmd struct {
+	Force bool `arg-flag:"-f --force"`
+}
+
+type commandArgs struct {
+	Verbose bool      `arg-flag:"-v"`
+	Dir     string    `arg-flag:"-C"`
+	Clone   *cloneCmd `arg-command:"clone" arg-help:"Clone a repository"`
+	Push    pushCmd   `arg-command:"push"`
+}
+
+func Test_FromSliceCommands(t *testing.T) {
+	tests := []struct {
+		slice   []string
+		verbose bool
+		dir     string
+		clone   *cloneCmd
+		force   bool
+	}{
+		{[]string{}, false, "", nil, false},
+		{[]string{"-v"}, true, "", nil, false},
+		{[]string{"clone", "url"}, false, "", &cloneCmd{0, "url"}, false},
+		{[]string{"-v", "-C", "clone", "clone", "--depth", "3", "url"},
+			true, "clone", &cloneCmd{3, "url"}, false},
+		{[]string{"-vCdir", "push", "-f"}, true, "dir", nil, true},
+		{[]string{"push", "--force"}, false, "", nil, true},
+	}
+
+	for _, test := range tests {
+		a := commandArgs{}
+
+		if err := FromSlice(test.slice, &a); err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+
+		if a.Verbose != test.verbose || a.Dir != test.dir ||
+			a.Push.Force != test.force ||
+			!reflect.DeepEqual(a.Clone, test.clone) {
+			t.Errorf("%v: got=%+v want=%+v", test.slice, a, test)
+		}
+	}
+
+	// Fused mode: the flag's argument is part of the flag token
+	a := commandArgs{}
+	err := FromSliceFused([]string{"-Cdir", "clone", "url"}, &a)
+	if err != nil || a.Dir != "dir" || a.Clone == nil || a.Clone.Repo != "url" {
+		t.Errorf("Fused: got=%+v err=%v", a, err)
+	}
+}
+
+func Test_FromSliceCommandsErr(t *testing.T) {
+	tests := [][]string{
+		{"pull"},
+		{"push", "-v"},
+		{"clone", "--depth", "x", "url"},
+	}
+
+	for _, test := range tests {
+		a := commandArgs{}
+		if err := FromSlice(test, &a); err == nil {
+			t.Errorf("%v: Expected error", test)
+		}
+	}
+
+	// With positionals of its own, non-commands are positionals
+	b := struct {
+		Push pushCmd `arg-command:"push"`
+		File string
+	}{}
+	if err := FromSlice([]string{"pull"}, &b); err != nil || b.File != "pull" {
+		t.Errorf("Positional: got=%v err=%v", b.File, err)
+	}
+
+	// After "--", there is no subcommand
+	c := struct {
+		Push pushCmd `arg-command:"push"`
+		File string
+	}{}
+	if err := FromSlice([]string{"--", "push"}, &c); err != nil ||
+		c.File != "push" || c.Push.Force {
+		t.Errorf("End of flags: got=%v err=%v", c.File, err)
+	}
+}
+
+func Test_findCommands(t *testing.T) {
+	tests := []struct {
+		data    any
+		names   []string
+		wantErr bool
+	}{
+		{struct{}{}, []string{}, false},
+		{struct {
+			A pushCmd  `arg-command:" push "`
+			B *pushCmd `arg-command:"pull"`
+		}{}, []string{"push", "pull"}, false},
+		{struct {
+			A pushCmd `arg-command:"push" arg-ignore:""`
+		}{}, []string{}, false},
+		{struct {
+			A string `arg-command:"push"`
+		}{}, nil, true},
+		{struct {
+			A pushCmd `arg-command:""`
+		}{}, nil, true},
+		{struct {
+			A pushCmd `arg-command:"-push"`
+		}{}, nil, true},
+		{struct {
+			A pushCmd `arg-command:"push"`
+			B pushCmd `arg-command:"push"`
+		}{}, nil, true},
+	}
+
+	for i, test := range tests {
+		cmds, err := findCommands(reflect.ValueOf(test.data))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%d: Unexpected error: %v", i, err)
+			continue
+		}
+
+		names := []string{}
+		for _, cmd := range cmds {
+			names = append(names, cmd.name)
+		}
+		if !test.wantErr && !reflect.DeepEqual(names, test.names) {
+			t.Errorf("%d: got=%v want=%v", i, names, test.names)
+		}
+	}
+}
+
+func Test_WriteUsageCommands(t *testing.T) {
+	sb := strings.Builder{}
+	WriteShortUsage(&sb, &commandArgs{})
+	want := shortUsagePrefix + "[-C string] [-v] {clone|push} ... \n"
+	if sb.String() != want {
+		t.Errorf("want=%s\ngot=%s", want, sb.String())
+	}
+
+	sb = strings.Builder{}
+	WriteUsage(&sb, &commandArgs{})
+	if !strings.Contains(sb.String(), "    clone ...\n       Clone a repository\n") ||
+		!strings.Contains(sb.String(), "    push ...\n") {
+		t.Errorf("Missing commands:\n%s", sb.String())
+	}
+}
+
+type remoteAddCmd struct {
+	Force bool `arg-flag:"-f"`
+	Name  string
+}
+
+type remoteCmd struct {
+	Verbose bool          `arg-flag:"-v"`
+	Add     *remoteAddCmd `arg-command:"add" arg-help:"Add a remote"`
+	Remove  *remoteAddCmd `arg-command:"rm"`
+}
+
+type treeArgs struct {
+	Quiet  bool       `arg-flag:"-q"`
+	Remote *remoteCmd `arg-command:"remote" arg-help:"Manage remotes"`
+}
+
+func Test_FromSliceCommandTree(t *testing.T) {
+	a := treeArgs{}
+	err := FromSlice([]string{"-q", "remote", "-v", "add", "-f", "origin"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !a.Quiet || a.Remote == nil || !a.Remote.Verbose ||
+		a.Remote.Add == nil || *a.Remote.Add != (remoteAddCmd{true, "origin"}) ||
+		a.Remote.Remove != nil {
+
+		t.Errorf("got=%+v", a)
+	}
+
+	err = FromSlice([]string{"remote", "push"}, &treeArgs{})
+	if err == nil || err.Error() != "remote: unknown command: push (available: add, rm)" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func Test_WriteHelpCommandTree(t *testing.T) {
+	tests := []struct {
+		tokens  []string
+		program string
+		want    []string
+	}{
+		{[]string{"-h"}, "",
+			[]string{"Usage: [-q] {remote} ... \n",
+				"    remote {add|rm} ...\n       Manage remotes\n"}},
+		{[]string{"remote", "--help"}, "tool",
+			[]string{"Usage: tool remote [-v] {add|rm} ... \n",
+				"    add ...\n       Add a remote\n", "    rm ...\n"}},
+		{[]string{"remote", "add", "-h"}, "",
+			[]string{"Usage: remote add [-f] [string] \n"}},
+	}
+
+	for _, test := range tests {
+		var stderr strings.Builder
+		p := NewParser(WithHelp(), WithErrorWriter(&stderr),
+			WithProgramName(test.program))
+		p.Parse(test.tokens, &treeArgs{})
+
+		for _, want := range test.want {
+			if !strings.Contains(stderr.String(), want) {
+				t.Errorf("%v: missing %q in:\n%s", test.tokens, want,
+					stderr.String())
+			}
+		}
+	}
+}
+
+type aliasLogCmd struct {
+	Count int `arg-flag:"-n"`
+	Rev   []string
+}
+
+type aliasArgs struct {
+	Quiet bool          `arg-flag:"-q"`
+	Clone *cloneCmd     `arg-command:"clone cl" arg-help:"Clone a repository"`
+	Log   *aliasLogCmd  `arg-command:"log" arg-default-command:""`
+	Rem   *remoteAddCmd `arg-command:"remote rem r"`
+}
+
+func Test_FromSliceCommandAliases(t *testing.T) {
+	tests := []struct {
+		slice []string
+		clone bool
+		log   *aliasLogCmd
+		rem   bool
+	}{
+		{[]string{"cl", "url"}, true, nil, false},
+		{[]string{"clone", "url"}, true, nil, false},
+		{[]string{"r", "-f", "origin"}, false, nil, true},
+		{[]string{"log", "-n", "3"}, false, &aliasLogCmd{3, []string{}}, false},
+		{[]string{"-q"}, false, &aliasLogCmd{0, []string{}}, false},
+		{[]string{}, false, &aliasLogCmd{0, []string{}}, false},
+		{[]string{"-q", "-n", "2", "main", "dev"}, false,
+			&aliasLogCmd{2, []string{"main", "dev"}}, false},
+	}
+
+	for _, test := range tests {
+		a := aliasArgs{}
+		if err := FromSlice(test.slice, &a); err != nil {
+			t.Fatalf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if (a.Clone != nil) != test.clone || (a.Rem != nil) != test.rem ||
+			(a.Log == nil) != (test.log == nil) ||
+			(a.Log != nil && (a.Log.Count != test.log.Count ||
+				strings.Join(a.Log.Rev, ",") != strings.Join(test.log.Rev, ","))) {
+
+			t.Errorf("%v: got=%+v %+v", test.slice, a, a.Log)
+		}
+	}
+
+	// Token indices of the default subcommand are not shifted by a name
+	errTests := []struct {
+		slice []string
+		index int
+	}{
+		{[]string{"-n", "x"}, 0},
+		{[]string{"-q", "-n", "x"}, 1},
+		{[]string{"-q", "log", "-n", "x"}, 2},
+	}
+
+	for _, test := range errTests {
+		var pe *ParseError
+		err := FromSlice(test.slice, &aliasArgs{})
+		if !errors.As(err, &pe) || pe.Index != test.index {
+			t.Errorf("%v: got=%v (%+v) want index %d", test.slice, err, pe,
+				test.index)
+		}
+	}
+}
+
+func Test_FromSliceCommandAliasErrors(t *testing.T) {
+	tests := []struct {
+		data    any
+		wantErr string
+	}{
+		{&struct {
+			A *cloneCmd `arg-command:"clone cl"`
+			B *pushCmd  `arg-command:"push cl"`
+		}{}, "duplicate subcommand name: cl"},
+		{&struct {
+			A *cloneCmd `arg-command:"clone -c"`
+		}{}, "malformed subcommand name: \"clone -c\""},
+		{&struct {
+			A *cloneCmd `arg-command:"clone" arg-default-command:""`
+			B *pushCmd  `arg-command:"push" arg-default-command:""`
+		}{}, "duplicate arg-default-command: push"},
+		{&struct {
+			Name string
+			A    *cloneCmd `arg-command:"clone" arg-default-command:""`
+		}{}, "arg-default-command not permitted with positional fields: clone"},
+	}
+
+	for _, test := range tests {
+		err := FromSlice([]string{"x"}, test.data)
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("got=%v want=%s", err, test.wantErr)
+		}
+	}
+}
+
+func Test_WriteUsageCommandAliases(t *testing.T) {
+	var b strings.Builder
+	if err := WriteUsage(&b, &aliasArgs{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"Clone a repository (aliases: cl)", "    log ...\n       (default)",
+		"(aliases: rem, r)"} {
+
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("missing %q in:\n%s", want, b.String())
+		}
+	}
+}
+
+func Test_FromSliceUnknownCommand(t *testing.T) {
+	tests := []struct {
+		slice   []string
+		wantErr string
+	}{
+		{[]string{"clnoe"}, "unknown command: clnoe, did you mean clone? " +
+			"(available: clone, log, remote)"},
+		{[]string{"-q", "rm", "x"}, "unknown command: rm, did you mean " +
+			"rem or r? (available: clone, log, remote)"},
+		{[]string{"fetch"}, "unknown command: fetch " +
+			"(available: clone, log, remote)"},
+	}
+
+	for _, test := range tests {
+		a := struct {
+			Quiet bool          `arg-flag:"-q"`
+			Clone *cloneCmd     `arg-command:"clone cl"`
+			Log   *aliasLogCmd  `arg-command:"log"`
+			Rem   *remoteAddCmd `arg-command:"remote rem r"`
+		}{}
+		err := FromSlice(test.slice, &a)
+		if err == nil || err.Error() != test.wantErr ||
+			!errors.Is(err, ErrUnknownCommand) {
+
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+	}
+}
+
+func Test_WriteHelpCommand(t *testing.T) {
+	tests := []struct {
+		tokens  []string
+		want    string
+		wantErr string
+	}{
+		{[]string{"help"}, "Usage: tool [-q] {remote} ... \n", ""},
+		{[]string{"-q", "help", "remote"},
+			"Usage: tool remote [-v] {add|rm} ... \n", ""},
+		{[]string{"help", "remote", "add"},
+			"Usage: tool remote add [-f] [string] \n", ""},
+		{[]string{"remote", "help", "add"},
+			"Usage: tool remote add [-f] [string] \n", ""},
+		{[]string{"remote", "add", "--help"},
+			"Usage: tool remote add [-f] [string] \n", ""},
+		{[]string{"help", "remte"}, "", "unknown command: remte, " +
+			"did you mean remote? (available: remote)"},
+	}
+
+	for _, test := range tests {
+		var stderr strings.Builder
+		p := NewParser(WithHelp(), WithErrorWriter(&stderr),
+			WithProgramName("tool"))
+		err := p.Parse(test.tokens, &treeArgs{})
+
+		if test.wantErr != "" {
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("%v: got=%v want=%s", test.tokens, err, test.wantErr)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrHelp) ||
+			!strings.HasPrefix(stderr.String(), test.want) {
+
+			t.Errorf("%v: got=%v, %q want=%q", test.tokens, err,
+				stderr.String(), test.want)
+		}
+	}
+
+	// A subcommand named "help" takes precedence
+	a := struct {
+		Help *pushCmd `arg-command:"help"`
+	}{}
+	err := NewParser(WithHelp()).Parse([]string{"help", "-f"}, &a)
+	if err != nil || a.Help == nil || !a.Help.Force {
+		t.Errorf("Unexpected result: %v, %+v", err, a.Help)
+	}
+}
@@ -0,0 +1,485 @@
+package cleanarg
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var nonIdentifierRE = regexp.MustCompile(`[^0-9A-Za-z_]`)
+
+// Complete takes a pointer to a struct and the command-line tokens typed
+// so far (not including the program name), the last of which is the token
+// that is to be completed (possibly empty), and returns the candidates for
+// this token, in sorted order:
+//   - if the preceding token is a flag that takes an argument, the choices
+//     given by the flag's arg-choices tag (if any);
+//   - if the token has the form "--flag=...", the choices for this flag,
+//     including the "--flag=" prefix;
+//   - if the token begins like a flag, all matching flags (except for
+//     those of hidden options, arg-hidden).
+//
+// Tokens following "--" are not completed. Candidates that do not begin
+// with the token being completed are omitted.
+// Returns an error if the struct contains unsupported types.
+func Complete(data any, args []string) ([]string, error) {
+	v, err := unwrap(data)
+	if err != nil {
+		return nil, err
+	}
+
+	options, _, err := analyzeStruct(v)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, prev := "", ""
+	if len(args) > 0 {
+		cur = args[len(args)-1]
+	}
+	if len(args) > 1 {
+		prev = args[len(args)-2]
+	}
+
+	// Only positionals after "--"
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == endFlagsIndicator {
+			return []string{}, nil
+		}
+	}
+
+	// Argument of the preceding flag
+	if info, ok := options[prev]; ok && takesArgument(info) {
+		return matchPrefix(info.choices, cur, ""), nil
+	}
+
+	// Argument fused to a long flag
+	if flag, val, ok := strings.Cut(cur, "="); ok && longFlagRE.MatchString(flag) {
+		if info, ok := options[flag]; ok {
+			return matchPrefix(info.choices, val, flag+"="), nil
+		}
+		return []string{}, nil
+	}
+
+	// Flags
+	if strings.HasPrefix(cur, "-") || strings.HasPrefix(cur, "+") {
+		return matchPrefix(visibleFlags(options), cur, ""), nil
+	}
+
+	return []string{}, nil
+}
+
+// MatchPrefix returns those candidates that begin with prefix, each
+// preceded by lead, in sorted order.
+func matchPrefix(candidates []string, prefix, lead string) []string {
+	out := []string{}
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			out = append(out, lead+c)
+		}
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+// WriteBashCompletion takes a pointer to a struct and writes a bash
+// completion script for the program with the given name to w. The script
+// completes flags, and the arguments of flags with an arg-choices tag;
+// other arguments are completed as file names.
+// To enable completion, the script must be sourced by bash, eg. by placing
+// it in the bash-completion directory.
+// Returns an error if the struct contains unsupported types.
+func WriteBashCompletion(w io.Writer, data any, program string) error {
+	spec, err := Analyze(data)
+	if err != nil {
+		return err
+	}
+	opts := visibleOptions(spec.Options)
+
+	fname := "_" + nonIdentifierRE.ReplaceAllString(program, "_") + "_complete"
+
+	flags := sortableFlags{}
+	for _, o := range opts {
+		flags = append(flags, o.Flags...)
+	}
+	sort.Sort(flags)
+
+	fmt.Fprintf(w, "# bash completion for %s\n", program)
+	fmt.Fprintf(w, "%s() {\n", fname)
+	fmt.Fprintf(w, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(w, "    local prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "    # bash splits \"--flag=value\" into three words\n")
+	fmt.Fprintf(w, "    if [[ \"$cur\" == \"=\" ]]; then\n")
+	fmt.Fprintf(w, "        cur=\"\"\n")
+	fmt.Fprintf(w, "    elif [[ \"$prev\" == \"=\" && $COMP_CWORD -ge 2 ]]; then\n")
+	fmt.Fprintf(w, "        prev=\"${COMP_WORDS[COMP_CWORD-2]}\"\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "    case \"$prev\" in\n")
+
+	for _, o := range opts {
+		if !specTakesArgument(o) {
+			continue
+		}
+
+		fmt.Fprintf(w, "        %s)\n", strings.Join(o.Flags, "|"))
+		if len(o.Choices) > 0 {
+			fmt.Fprintf(w, "            COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") )\n",
+				bashWordList(o.Choices))
+		} else {
+			fmt.Fprintf(w, "            COMPREPLY=( $(compgen -f -- \"$cur\") )\n")
+		}
+		fmt.Fprintf(w, "            return 0\n")
+		fmt.Fprintf(w, "            ;;\n")
+	}
+
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "    case \"$cur\" in\n")
+	fmt.Fprintf(w, "        -*|+*)\n")
+	fmt.Fprintf(w, "            COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") )\n",
+		strings.Join(flags, " "))
+	fmt.Fprintf(w, "            return 0\n")
+	fmt.Fprintf(w, "            ;;\n")
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "    COMPREPLY=( $(compgen -f -- \"$cur\") )\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -F %s %s\n", fname, program)
+
+	return nil
+}
+
+// BashWordList returns the words as a word list for compgen -W, for use
+// within double quotes: words with characters other than letters, digits,
+// and some punctuation are quoted (compgen expands the word list, as the
+// shell would), and the list is escaped for the double quotes.
+func bashWordList(words []string) string {
+	out := []string{}
+	for _, w := range words {
+		if !bashWordRE.MatchString(w) {
+			w = shellQuote(w)
+		}
+		out = append(out, bashDoubleQuoteEscaper.Replace(w))
+	}
+	return strings.Join(out, " ")
+}
+
+// Words that need not be quoted in bash, and characters that are special
+// within double quotes
+var (
+	bashWordRE             = regexp.MustCompile(`^[0-9A-Za-z_.,:/=+@%-]+$`)
+	bashDoubleQuoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`,
+		"$", `\$`, "`", "\\`")
+)
+
+// WriteZshCompletion takes a pointer to a struct and writes a zsh
+// completion script for the program with the given name to w. The script
+// completes flags (with their help texts as descriptions), the arguments
+// of flags and positionals with an arg-choices tag, and string arguments
+// as file names.
+// To enable completion, place the script in a directory in $fpath, under
+// the name "_" followed by the program name, or source it.
+// Returns an error if the struct contains unsupported types.
+func WriteZshCompletion(w io.Writer, data any, program string) error {
+	spec, err := Analyze(data)
+	if err != nil {
+		return err
+	}
+	opts, pos := visibleOptions(spec.Options), spec.Positionals
+
+	fname := "_" + nonIdentifierRE.ReplaceAllString(program, "_")
+
+	specs := []string{}
+	for _, o := range opts {
+		// Flags of the same option exclude each other, unless repeatable
+		lead := ""
+		if o.Repeatable {
+			lead = "*"
+		} else if len(o.Flags) > 1 {
+			lead = "(" + strings.Join(o.Flags, " ") + ")"
+		}
+
+		desc := ""
+		if help := completionHelp(o.Help); help != "" {
+			desc = "[" + zshDescEscaper.Replace(help) + "]"
+		}
+
+		for _, f := range o.Flags {
+			if !specTakesArgument(o) {
+				specs = append(specs, lead+f+desc)
+				continue
+			}
+
+			// Long flags take "--flag=value" or "--flag value", short
+			// flags "-fvalue" or "-f value"
+			suffix := "+"
+			if strings.HasPrefix(f, "--") {
+				suffix = "="
+			}
+			specs = append(specs, lead+f+suffix+desc+":"+
+				zshMessageEscaper.Replace(o.ArgName)+":"+zshAction(o.Type, o.Choices))
+		}
+	}
+
+	// Nothing can be said about positionals following a slice
+	for _, p := range pos {
+		lead := ""
+		if p.Repeatable {
+			lead = "*"
+		}
+		specs = append(specs, lead+":"+zshMessageEscaper.Replace(p.ArgName)+":"+
+			zshAction(p.Type, p.Choices))
+		if p.Repeatable {
+			break
+		}
+	}
+
+	fmt.Fprintf(w, "#compdef %s\n", program)
+	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "# zsh completion for %s\n", program)
+	fmt.Fprintf(w, "%s() {\n", fname)
+	fmt.Fprintf(w, "    _arguments -s -S")
+	for _, spec := range specs {
+		fmt.Fprintf(w, " \\\n        %s", shellQuote(spec))
+	}
+	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "if [ \"$funcstack[1]\" = \"%s\" ]; then\n", fname)
+	fmt.Fprintf(w, "    %s \"$@\"\n", fname)
+	fmt.Fprintf(w, "else\n")
+	fmt.Fprintf(w, "    compdef %s %s\n", fname, program)
+	fmt.Fprintf(w, "fi\n")
+
+	return nil
+}
+
+// WriteFishCompletion takes a pointer to a struct and writes a fish
+// completion script for the program with the given name to w. The script
+// completes flags (with their help texts as descriptions), the arguments
+// of flags and positionals with an arg-choices tag, and string arguments
+// as file names. Flags beginning with "+" can not be completed by fish.
+// To enable completion, place the script in the fish completions directory
+// (eg. ~/.config/fish/completions/), under the program's name with the
+// extension ".fish".
+// Returns an error if the struct contains unsupported types.
+func WriteFishCompletion(w io.Writer, data any, program string) error {
+	spec, err := Analyze(data)
+	if err != nil {
+		return err
+	}
+	opts, pos := visibleOptions(spec.Options), spec.Positionals
+
+	fmt.Fprintf(w, "# fish completion for %s\n", program)
+
+	// Positionals: file names only if there are string positionals
+	files, choices := false, []string{}
+	for _, p := range pos {
+		if completesFiles(p.Type, p.Choices) {
+			files = true
+		}
+		choices = append(choices, p.Choices...)
+	}
+	if !files {
+		fmt.Fprintf(w, "complete -c %s -f\n", program)
+	}
+	if len(choices) > 0 {
+		fmt.Fprintf(w, "complete -c %s -a %s\n", program,
+			shellQuote(strings.Join(choices, " ")))
+	}
+
+	for _, o := range opts {
+		args := []string{}
+		for _, f := range o.Flags {
+			switch {
+			case strings.HasPrefix(f, "--"):
+				args = append(args, "-l", f[2:])
+			case strings.HasPrefix(f, "-"):
+				args = append(args, "-s", f[1:])
+			}
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		if help := completionHelp(o.Help); help != "" {
+			args = append(args, "-d", shellQuote(help))
+		}
+
+		switch {
+		case !specTakesArgument(o):
+		case len(o.Choices) > 0:
+			args = append(args, "-x", "-a", shellQuote(strings.Join(o.Choices, " ")))
+		case completesFiles(o.Type, o.Choices):
+			args = append(args, "-r", "-F")
+		default:
+			args = append(args, "-x")
+		}
+
+		fmt.Fprintf(w, "complete -c %s %s\n", program, strings.Join(args, " "))
+	}
+
+	return nil
+}
+
+// WritePowerShellCompletion takes a pointer to a struct and writes a
+// PowerShell completion script for the program with the given name to w.
+// The script completes flags (with their help texts as tooltips), and the
+// arguments of flags and positionals with an arg-choices tag (also in the
+// form "--flag=value"); PowerShell completes other arguments as file names.
+// To enable completion, dot-source the script (eg. in $PROFILE).
+// Returns an error if the struct contains unsupported types.
+func WritePowerShellCompletion(w io.Writer, data any, program string) error {
+	spec, err := Analyze(data)
+	if err != nil {
+		return err
+	}
+	opts := visibleOptions(spec.Options)
+
+	positionals := []string{}
+	for _, p := range spec.Positionals {
+		positionals = append(positionals, p.Choices...)
+	}
+
+	fmt.Fprintf(w, "# PowerShell completion for %s\n", program)
+	fmt.Fprintf(w, "Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n",
+		psQuote(program))
+	fmt.Fprintf(w, "    param($wordToComplete, $commandAst, $cursorPosition)\n")
+	fmt.Fprintf(w, "\n")
+
+	// Flags and their help texts, and the choices of flags that take an
+	// argument (none: file names); keys are case-sensitive
+	fmt.Fprintf(w, "    $help = [hashtable]::new()\n")
+	fmt.Fprintf(w, "    $choices = [hashtable]::new()\n")
+	for _, o := range opts {
+		help := completionHelp(o.Help)
+		for _, f := range o.Flags {
+			fmt.Fprintf(w, "    $help[%s] = %s\n", psQuote(f), psQuote(help))
+			if specTakesArgument(o) {
+				fmt.Fprintf(w, "    $choices[%s] = %s\n", psQuote(f),
+					psList(o.Choices))
+			}
+		}
+	}
+	fmt.Fprintf(w, "    $positionals = %s\n", psList(positionals))
+	fmt.Fprintf(w, "\n")
+
+	fmt.Fprintf(w, "    # The word preceding the one being completed\n")
+	fmt.Fprintf(w, "    $start = $cursorPosition - $wordToComplete.Length\n")
+	fmt.Fprintf(w, "    $words = @($commandAst.CommandElements |\n")
+	fmt.Fprintf(w, "        Where-Object { $_.Extent.EndOffset -lt $start } |\n")
+	fmt.Fprintf(w, "        ForEach-Object { $_.Extent.Text })\n")
+	fmt.Fprintf(w, "    $prev = ''\n")
+	fmt.Fprintf(w, "    if ($words.Count -gt 1) {\n")
+	fmt.Fprintf(w, "        $prev = $words[-1]\n")
+	fmt.Fprintf(w, "    }\n")
+	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "    $candidates = @()\n")
+	fmt.Fprintf(w, "    if ($choices.ContainsKey($prev)) {\n")
+	fmt.Fprintf(w, "        $candidates = $choices[$prev]\n")
+	fmt.Fprintf(w, "    } elseif ($wordToComplete -cmatch '^(--[^=]+)=' -and\n")
+	fmt.Fprintf(w, "        $choices.ContainsKey($Matches[1])) {\n")
+	fmt.Fprintf(w, "        $flag = $Matches[1]\n")
+	fmt.Fprintf(w, "        $candidates = $choices[$flag] | ForEach-Object { \"$flag=$_\" }\n")
+	fmt.Fprintf(w, "    } elseif ($wordToComplete.StartsWith('-') -or\n")
+	fmt.Fprintf(w, "        $wordToComplete.StartsWith('+')) {\n")
+	fmt.Fprintf(w, "        $candidates = $help.Keys | Sort-Object -CaseSensitive\n")
+	fmt.Fprintf(w, "    } else {\n")
+	fmt.Fprintf(w, "        $candidates = $positionals\n")
+	fmt.Fprintf(w, "    }\n")
+	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "    $candidates | Where-Object { $_.StartsWith($wordToComplete) } |\n")
+	fmt.Fprintf(w, "        ForEach-Object {\n")
+	fmt.Fprintf(w, "            $tip = $help[$_]\n")
+	fmt.Fprintf(w, "            if (-not $tip) { $tip = $_ }\n")
+	fmt.Fprintf(w, "            [System.Management.Automation.CompletionResult]::new(\n")
+	fmt.Fprintf(w, "                $_, $_, 'ParameterValue', $tip)\n")
+	fmt.Fprintf(w, "        }\n")
+	fmt.Fprintf(w, "}\n")
+
+	return nil
+}
+
+// PsQuote encloses s in single quotes, for use in PowerShell scripts.
+// Single quotes in s are doubled.
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// PsList returns the strings as a PowerShell array of quoted strings.
+func psList(list []string) string {
+	quoted := []string{}
+	for _, s := range list {
+		quoted = append(quoted, psQuote(s))
+	}
+	return "@(" + strings.Join(quoted, ", ") + ")"
+}
+
+// VisibleOptions returns those of the supplied options that are not hidden
+// (arg-hidden), in their original order.
+func visibleOptions(opts []OptionSpec) []OptionSpec {
+	out := []OptionSpec{}
+	for _, o := range opts {
+		if !o.Hidden {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// SpecTakesArgument returns true if the flags of the option take an
+// argument (ie. the option is neither boolean, nor a fixed-value flag, nor
+// a counter).
+func specTakesArgument(o OptionSpec) bool {
+	return o.Type != reflect.TypeOf(true) && !o.Const && !o.Count
+}
+
+// CompletesFiles returns true if arguments of the given type (and choices)
+// are completed as file names: strings without arg-choices.
+func completesFiles(t reflect.Type, choices []string) bool {
+	return t == reflect.TypeOf("") && len(choices) == 0
+}
+
+// CompletionHelp returns a help text as a single line, for use as a
+// description in completion scripts.
+func completionHelp(help string) string {
+	return strings.Join(strings.Fields(help), " ")
+}
+
+// ZshAction returns the action for the argument of a flag or positional in
+// a zsh _arguments spec: the choices, file names, or nothing.
+func zshAction(t reflect.Type, choices []string) string {
+	switch {
+	case len(choices) > 0:
+		out := []string{}
+		for _, c := range choices {
+			out = append(out, zshChoiceEscaper.Replace(c))
+		}
+		return "(" + strings.Join(out, " ") + ")"
+	case completesFiles(t, choices):
+		return "_files"
+	default:
+		return " "
+	}
+}
+
+// Characters with a special meaning in parts of zsh _arguments specs
+var (
+	zshDescEscaper    = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`)
+	zshMessageEscaper = strings.NewReplacer(`\`, `\\`, ":", `\:`)
+	zshChoiceEscaper  = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`,
+		" ", `\ `, ":", `\:`)
+)
+
+// ShellQuote encloses s in single quotes, for use in zsh and fish scripts.
+// Single quotes in s are replaced by a closing quote, an escaped quote, and
+// an opening quote (which works in both shells).
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
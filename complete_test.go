@@ -0,0 +1,242 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+type completeArgs struct {
+	Verbose bool   `arg-flag:"-v --verbose"`
+	Format  string `arg-flag:"-f --format" arg-choices:"json yaml table"`
+	Output  string `arg-flag:"-o --output"`
+	Level   []int  `arg-flag:"--level" arg-choices:"1 2 3"`
+	Trace   string `arg-flag:"--trace" arg-choices:"on off" arg-hidden:""`
+	Files   []string
+}
+
+func Test_Complete(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{}, []string{}},
+		{[]string{""}, []string{}},
+		{[]string{"-"}, []string{"--format", "--level", "--output",
+			"--verbose", "-f", "-o", "-v"}},
+		{[]string{"--f"}, []string{"--format"}},
+		{[]string{"--x"}, []string{}},
+		{[]string{"-f", ""}, []string{"json", "table", "yaml"}},
+		{[]string{"--format", "y"}, []string{"yaml"}},
+		{[]string{"--format=t"}, []string{"--format=table"}},
+		{[]string{"--format="}, []string{"--format=json", "--format=table",
+			"--format=yaml"}},
+		{[]string{"--level", ""}, []string{"1", "2", "3"}},
+		{[]string{"-o", ""}, []string{}},
+		{[]string{"-v", ""}, []string{}},
+		{[]string{"-v", "-"}, []string{"--format", "--level", "--output",
+			"--verbose", "-f", "-o", "-v"}},
+		{[]string{"--", "-f", ""}, []string{}},
+		{[]string{"--", "-"}, []string{}},
+		{[]string{"--verbose=x"}, []string{}},
+		{[]string{"--t"}, []string{}},
+		{[]string{"--trace", ""}, []string{"off", "on"}},
+	}
+
+	for _, test := range tests {
+		got, err := Complete(&completeArgs{}, test.args)
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.args, err)
+			continue
+		}
+
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.args, got, test.want)
+		}
+	}
+}
+
+func Test_WriteBashCompletion(t *testing.T) {
+	sb := strings.Builder{}
+	if err := WriteBashCompletion(&sb, &completeArgs{}, "my-tool"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	script := sb.String()
+
+	for _, want := range []string{
+		"_my_tool_complete() {",
+		"-f|--format)",
+		`compgen -W "json yaml table" -- "$cur"`,
+		`compgen -W "1 2 3" -- "$cur"`,
+		`compgen -W "-f -o -v --level --format --output --verbose" -- "$cur"`,
+		"complete -F _my_tool_complete my-tool",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Missing %s:\n%s", want, script)
+		}
+	}
+
+	if strings.Contains(script, "-v|--verbose)") {
+		t.Errorf("Boolean flag takes argument:\n%s", script)
+	}
+	if strings.Contains(script, "--trace") {
+		t.Errorf("Hidden flag completed:\n%s", script)
+	}
+}
+
+func Test_WriteBashCompletionQuoting(t *testing.T) {
+	type quotingArgs struct {
+		Mode string "arg-flag:\"-m\" arg-choices:\"plain say\\\"hi\\\" $HOME `id` it's\""
+	}
+
+	sb := strings.Builder{}
+	if err := WriteBashCompletion(&sb, &quotingArgs{}, "tool"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	script := sb.String()
+
+	want := `compgen -W "plain 'say\"hi\"' '\$HOME' '\` + "`id\\`" +
+		`' 'it'\\''s'" -- "$cur"`
+	if !strings.Contains(script, want) {
+		t.Errorf("Missing %s:\n%s", want, script)
+	}
+
+	// The choices are completed verbatim
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skipf("bash not available: %v", err)
+	}
+	out, err := exec.Command("bash", "-c", script+
+		`COMP_WORDS=(tool -m ""); COMP_CWORD=2; _tool_complete; `+
+		`printf '%s\n' "${COMPREPLY[@]}"`).Output()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := strings.Fields(string(out))
+	wantChoices := []string{"plain", `say"hi"`, "$HOME", "`id`", "it's"}
+	if !slices.Equal(got, wantChoices) {
+		t.Errorf("got=%q want=%q", got, wantChoices)
+	}
+}
+
+func Test_WriteZshCompletion(t *testing.T) {
+	type zshArgs struct {
+		Verbose bool     `arg-flag:"-v --verbose" arg-help:"Be verbose"`
+		Format  string   `arg-flag:"-f --format" arg-choices:"json yaml" arg-help:"Output [format], it's"`
+		Count   int      `arg-flag:"-n" arg-help:"Number of *items*"`
+		Level   []int    `arg-flag:"--level"`
+		Mode    string   `arg-choices:"a b"`
+		Files   []string `arg-help:"Input *files*"`
+	}
+
+	sb := strings.Builder{}
+	if err := WriteZshCompletion(&sb, &zshArgs{}, "my-tool"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	script := sb.String()
+
+	for _, want := range []string{
+		"#compdef my-tool\n",
+		"_my_tool() {",
+		`'(-v --verbose)-v[Be verbose]'`,
+		`'(-v --verbose)--verbose[Be verbose]'`,
+		`'(-f --format)-f+[Output \[format\], it'\''s]:string:(json yaml)'`,
+		`'(-f --format)--format=[Output \[format\], it'\''s]:string:(json yaml)'`,
+		`'-n+[Number of items]:items: '`,
+		`'*--level=:int: '`,
+		`':string:(a b)'`,
+		`'*:files:_files'`,
+		"compdef _my_tool my-tool",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Missing %s:\n%s", want, script)
+		}
+	}
+}
+
+func Test_WriteFishCompletion(t *testing.T) {
+	sb := strings.Builder{}
+	if err := WriteFishCompletion(&sb, &completeArgs{}, "my-tool"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	script := sb.String()
+
+	for _, want := range []string{
+		"complete -c my-tool -s v -l verbose\n",
+		"complete -c my-tool -s f -l format -x -a 'json yaml table'\n",
+		"complete -c my-tool -s o -l output -r -F\n",
+		"complete -c my-tool -l level -x -a '1 2 3'\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Missing %s:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "complete -c my-tool -f\n") {
+		t.Errorf("File completion disabled for positionals:\n%s", script)
+	}
+	if strings.Contains(script, "trace") {
+		t.Errorf("Hidden flag completed:\n%s", script)
+	}
+
+	// Descriptions, and no file names without string positionals
+	type fishArgs struct {
+		Count int `arg-flag:"-n --count" arg-help:"Number of *items*, it's"`
+		Start int
+	}
+	sb.Reset()
+	if err := WriteFishCompletion(&sb, &fishArgs{}, "t"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "# fish completion for t\n" +
+		"complete -c t -f\n" +
+		`complete -c t -s n -l count -d 'Number of items, it'\''s' -x` + "\n"
+	if sb.String() != want {
+		t.Errorf("got=%q want=%q", sb.String(), want)
+	}
+}
+
+func Test_WritePowerShellCompletion(t *testing.T) {
+	sb := strings.Builder{}
+	err := WritePowerShellCompletion(&sb, &completeArgs{}, "my-tool")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	script := sb.String()
+
+	for _, want := range []string{
+		"Register-ArgumentCompleter -Native -CommandName 'my-tool' ",
+		"    $help['--verbose'] = ''\n",
+		"    $choices['-f'] = @('json', 'yaml', 'table')\n",
+		"    $choices['--output'] = @()\n",
+		"    $choices['--level'] = @('1', '2', '3')\n",
+		"    $positionals = @()\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Missing %s:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "trace") ||
+		strings.Contains(script, "$choices['-v']") {
+
+		t.Errorf("Unexpected flag:\n%s", script)
+	}
+
+	// Help texts, and choices of positionals, quoted
+	type psArgs struct {
+		Count int    `arg-flag:"-n" arg-help:"Number of *items*, it's"`
+		Mode  string `arg-choices:"a b'c"`
+	}
+	sb.Reset()
+	if err := WritePowerShellCompletion(&sb, &psArgs{}, "t"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"    $help['-n'] = 'Number of items, it''s'\n",
+		"    $positionals = @('a', 'b''c')\n",
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("Missing %s:\n%s", want, sb.String())
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package cleanarg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// configValues holds the values read from a configuration file, keyed on
+// option name (nested keys joined with "-"). Scalars have a single value.
+type configValues map[string][]string
+
+// configSource is a configuration file registered with a Parser.
+type configSource struct {
+	path string
+}
+
+// AddConfigFile registers a configuration file, which is read whenever a
+// struct is parsed. Values from configuration files take precedence over
+// default values (arg-default, and runtime defaults), but environment
+// variables (arg-env) and the command line take precedence over them. If
+// several files are registered, later ones take precedence over earlier
+// ones. The format of the file is determined by its extension: JSON
+// (.json), YAML (.yaml, .yml), or TOML (.toml). For YAML and TOML, only the
+// subset of the format that is commonly used in configuration files is
+// supported (nested mappings or tables, scalars, and lists of scalars).
+//
+// A configuration file consists of an object (mapping), whose keys are the
+// long flags of the struct's options without the leading "--" (or their
+// field names). Keys of fields tagged arg-ignore are skipped. Nested
+// objects (or tables) are flattened by joining their keys with "-", so
+// that the options of nested structs (arg-prefix) can be given either as
+// "db-host" or as "db": {"host": ...}. Arrays provide the values of slices;
+// they replace (rather than add to) the slice's elements.
+// Unknown keys, as well as missing or malformed files, are errors.
+//
+// A configuration file may also be named on the command line, by an option
+// of type string tagged arg-config (eg. --config). It is read after the
+// files registered with AddConfigFile().
+func (p *Parser) AddConfigFile(path string) {
+	p.configs = append(p.configs, configSource{path: path})
+}
+
+// FromJSONFile takes the path of a JSON configuration file and a pointer
+// to a struct, and populates the struct from the file and then from the
+// command-line arguments, which take precedence over the values from the
+// file. (See Parser.AddConfigFile() for the format of the file.)
+func FromJSONFile(path string, data any) error {
+	p := NewParser()
+	p.AddConfigFile(path)
+	return p.ParseCommandLine(data)
+}
+
+// Load reads the configuration file and returns its values.
+func (c configSource) load() (configValues, error) {
+	buf, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(c.path)) {
+	case ".json":
+		return parseJSONConfig(buf)
+	case ".yaml", ".yml":
+		return parseYAMLConfig(buf)
+	case ".toml":
+		return parseTOMLConfig(buf)
+	default:
+		return nil, fmt.Errorf("unsupported config file format: %s", c.path)
+	}
+}
+
+// ParseJSONConfig takes the contents of a JSON configuration file, which
+// must consist of a single object, and returns its values.
+func parseJSONConfig(buf []byte) (configValues, error) {
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+
+	out := configValues{}
+	if err := flattenConfig(obj, "", out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FlattenConfig takes a (nested) map of values, as decoded from a
+// configuration file, and stores its values in out, with keys composed from
+// the prefix and the nested keys, joined with "-". Values are converted to
+// strings; arrays become multiple values, nulls are skipped.
+func flattenConfig(obj map[string]any, prefix string, out configValues) error {
+	for k, x := range obj {
+		key := prefix + k
+
+		switch x := x.(type) {
+		case nil:
+			continue
+
+		case map[string]any:
+			if err := flattenConfig(x, key+"-", out); err != nil {
+				return err
+			}
+
+		case []any:
+			values := []string{}
+			for _, e := range x {
+				s, err := configString(e)
+				if err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+				values = append(values, s)
+			}
+			out[key] = values
+
+		default:
+			s, err := configString(x)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			out[key] = []string{s}
+		}
+	}
+
+	return nil
+}
+
+// ConfigString converts a scalar value, as decoded from a configuration
+// file, to a string, in the form expected by convertToType().
+func configString(x any) (string, error) {
+	switch x := x.(type) {
+	case string:
+		return x, nil
+	case json.Number:
+		return x.String(), nil
+	case bool:
+		return fmt.Sprintf("%t", x), nil
+	default:
+		return "", fmt.Errorf("unsupported value: %v", x)
+	}
+}
+
+// ConfigFlagSources takes a reflect.Value, which must represent a struct,
+// the struct's options and positionals, and a slice of tokens, and returns
+// the configuration files named by the struct's arg-config options. This is
+// the first pass of a two-pass parse: the tokens are processed (without
+// populating the struct) only to find the values of the arg-config options.
+// If an option does not appear among the tokens, its environment variable
+// (arg-env) or its default value is used instead.
+// Errors are ignored: they are reported by the second pass.
+func configFlagSources(v reflect.Value, options map[string]fieldInfo,
+	positionals []fieldInfo, tokens []string, isFused bool) []configSource {
+
+	out := []configSource{}
+
+	configs := []fieldInfo{}
+	for _, info := range uniqueOptions(options) {
+		if info.isConfig {
+			configs = append(configs, info)
+		}
+	}
+	if len(configs) == 0 {
+		return out
+	}
+
+	// Tokens following a subcommand do not belong to the struct
+	tokens, _, _, err := splitCommand(v, options, positionals, tokens, isFused)
+	if err != nil {
+		return out
+	}
+	retained, _, err := processTokens(options, tokens, isFused)
+	if err != nil {
+		return out
+	}
+
+	for _, info := range configs {
+		paths := []string{}
+		for _, r := range retained {
+			if r.Name == info.Name {
+				paths = append(paths, r.value)
+			}
+		}
+
+		if len(paths) == 0 {
+			if s := os.Getenv(info.env); info.env != "" && s != "" {
+				paths = append(paths, s)
+			} else if !info.isSlice {
+				paths = append(paths, info.defaultval)
+			}
+		}
+
+		for _, path := range paths {
+			if path != "" {
+				out = append(out, configSource{path: path})
+			}
+		}
+	}
+
+	return out
+}
+
+// ConfigKeys takes a map of options, and returns a map of the keys by which
+// the options can be referred to in configuration files: their long flags,
+// without the leading "--", and their field names. Options tagged
+// arg-config are excluded.
+func configKeys(options map[string]fieldInfo) map[string]fieldInfo {
+	out := map[string]fieldInfo{}
+
+	for _, info := range uniqueOptions(options) {
+		if info.isConfig {
+			continue
+		}
+		if info.isConst {
+			if _, ok := out[info.Name]; !ok {
+				info.isConst = false
+				out[info.Name] = info
+			}
+			continue
+		}
+
+		for _, f := range info.allFlags {
+			if strings.HasPrefix(f, "--") {
+				out[f[2:]] = info
+			}
+		}
+		out[info.Name] = info
+	}
+
+	return out
+}
+
+// IgnoredKeys takes a reflect.Value, which must represent a struct, and
+// returns the keys of the fields tagged arg-ignore or arg-config, which are
+// skipped when found in configuration files: their field names, and their
+// long flags (if any), without the leading "--". Fields of nested structs
+// (arg-prefix) and embedded structs are included, with their keys composed
+// like those of their options (see analyzeNested()).
+func ignoredKeys(v reflect.Value) map[string]bool {
+	out := map[string]bool{}
+	addIgnoredKeys(v.Type(), "", "", out)
+	return out
+}
+
+// AddIgnoredKeys takes a struct type, the path of its fields (eg. "DB."),
+// and the prefix of their flags (eg. "db"; empty for the top-level struct),
+// and adds the keys of the fields to be skipped in configuration files to
+// the map, recursing into nested and embedded structs.
+func addIgnoredKeys(t reflect.Type, path, prefix string, out map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		_, ignore := field.Tag.Lookup(tagIgnore)
+		_, config := field.Tag.Lookup(tagConfig)
+		inner, nested := field.Tag.Lookup(tagPrefix)
+
+		switch {
+		case ignore || config:
+			out[path+field.Name] = true
+			tag := expandAutoFlags(field.Tag.Get(tagFlag), field.Name)
+			for _, f := range prefixFlags(strings.Fields(tag), prefix) {
+				if strings.HasPrefix(f, "--") {
+					out[f[2:]] = true
+				}
+			}
+
+		case field.Type.Kind() != reflect.Struct:
+
+		case nested:
+			inner = strings.TrimSpace(inner)
+			if prefix != "" && inner != "" {
+				inner = prefix + "-" + inner
+			} else if inner == "" {
+				inner = prefix
+			}
+			addIgnoredKeys(field.Type, path+field.Name+".", inner, out)
+
+		case field.Anonymous && !isAllowedType(field.Type):
+			addIgnoredKeys(field.Type, path, prefix, out)
+		}
+	}
+}
+
+// PopulateConfig takes a slice of configuration files, a map of options,
+// and a reflect.Value, which must represent the struct to populate, reads
+// the configuration files (in order), and populates the struct's options
+// from them. Returns the names of the fields that were populated (mapped to
+// the path of the last file that provided a value), or an error if a file
+// cannot be read, contains unknown keys, or values that cannot be
+// converted.
+func populateConfig(sources []configSource, options map[string]fieldInfo,
+	v reflect.Value) (map[string]string, error) {
+
+	configured := map[string]string{}
+	keys := configKeys(options)
+	ignored := ignoredKeys(v)
+
+	for _, c := range sources {
+		values, err := c.load()
+		if err != nil {
+			return nil, errorf("config file %s: %w", c.path, err)
+		}
+
+		// Process keys in sorted order, for predictable errors
+		names := []string{}
+		for k := range values {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		for _, k := range names {
+			info, ok := keys[k]
+			if !ok && ignored[k] {
+				continue
+			}
+			if !ok {
+				return nil, errorf("config file %s: unknown key: %s",
+					c.path, k)
+			}
+
+			if err := populateConfigValue(info, values[k], v); err != nil {
+				return nil, errorf("config file %s: %s: %w", c.path, k, err)
+			}
+			configured[info.Name] = c.path
+		}
+	}
+
+	return configured, nil
+}
+
+// PopulateConfigValue takes a fieldInfo, the values for it from a
+// configuration file, and a reflect.Value, which must represent the struct
+// to populate, and sets the field to the values (replacing all elements of
+// a slice). Returns an error if there is more than one value for a
+// non-slice field, or if a value cannot be converted.
+func populateConfigValue(info fieldInfo, values []string,
+	v reflect.Value) error {
+
+	if !info.isSlice && len(values) != 1 {
+		return errorf("expected a single value for %s", info.Name)
+	}
+	if info.isSlice {
+		v.FieldByIndex(info.Index).SetZero()
+	}
+
+	for _, s := range values {
+		// Boolean values are parsed (like fixed values), rather than implied
+		info.isConst = info.baseType == reflect.TypeOf(true)
+		info.isCount = false
+		info.constval = s
+		info.value = s
+		if err := populateField(info, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
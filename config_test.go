@@ -0,0 +1,257 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+type configArgs struct {
+	Port    int           `arg-flag:"-p --port" arg-default:"80"`
+	Verbose bool          `arg-flag:"-v --verbose" arg-default:"true"`
+	Timeout time.Duration `arg-flag:"--timeout"`
+	Tags    []string      `arg-flag:"-t --tag"`
+	Name    string        `arg-flag:"-n" arg-env:"CLEANARG_NAME"`
+	DB      poolConfig    `arg-prefix:"db"`
+}
+
+// writeConfig writes a configuration file to a temporary directory, and
+// returns its path.
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_ParserConfigJSON(t *testing.T) {
+	tests := []struct {
+		config string
+		slice  []string
+		want   configArgs
+	}{
+		{`{}`, []string{},
+			configArgs{80, true, 0, nil, "", poolConfig{4}}},
+		{`{"port": 8080, "verbose": false, "timeout": "1m30s"}`, []string{},
+			configArgs{8080, false, 90 * time.Second, nil, "", poolConfig{4}}},
+		{`{"port": 8080, "tag": ["a", "b"]}`, []string{"-p", "9"},
+			configArgs{9, true, 0, []string{"a", "b"}, "", poolConfig{4}}},
+		{`{"tag": ["a", "b"]}`, []string{"-t", "c"},
+			configArgs{80, true, 0, []string{"c"}, "", poolConfig{4}}},
+		{`{"Name": "x", "db": {"size": 8}}`, []string{},
+			configArgs{80, true, 0, nil, "x", poolConfig{8}}},
+		{`{"db-size": 16, "n": null}`, []string{},
+			configArgs{80, true, 0, nil, "", poolConfig{16}}},
+	}
+
+	for _, test := range tests {
+		p := NewParser()
+		p.AddConfigFile(writeConfig(t, "config.json", test.config))
+
+		a := configArgs{}
+		if err := p.Parse(test.slice, &a); err != nil {
+			t.Errorf("%s: Unexpected error: %v", test.config, err)
+			continue
+		}
+		if !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%s: got=%v want=%v", test.config, a, test.want)
+		}
+	}
+
+	// Precedence: command line > environment > config file > default
+	t.Setenv("CLEANARG_NAME", "env")
+	p := NewParser()
+	p.AddConfigFile(writeConfig(t, "a.json", `{"Name": "a", "port": 1}`))
+	p.AddConfigFile(writeConfig(t, "b.json", `{"Name": "b"}`))
+	a := configArgs{}
+	if err := p.Parse([]string{}, &a); err != nil || a.Name != "env" ||
+		a.Port != 1 {
+		t.Errorf("Precedence: got=%v err=%v", a, err)
+	}
+	os.Unsetenv("CLEANARG_NAME")
+	a = configArgs{}
+	if err := p.Parse([]string{}, &a); err != nil || a.Name != "b" {
+		t.Errorf("Later file: got=%v err=%v", a, err)
+	}
+}
+
+func Test_ParserConfigFormats(t *testing.T) {
+	type args struct {
+		Port  int      `arg-flag:"--port" arg-default:"80"`
+		Tags  []string `arg-flag:"--tag"`
+		Host  string   `arg-flag:"--db-host"`
+		Cache string   `arg-flag:"--cache" arg-ignore:""`
+		Note  string   `arg-ignore:""`
+	}
+
+	tests := []struct {
+		name, config string
+	}{
+		{"c.json", `{"port": 8080, "tag": ["a", "b"], "db": {"host": "x"},
+			"cache": "y", "Note": "z"}`},
+		{"c.yaml", "port: 8080\ntag: [a, b]\ndb:\n  host: x\n" +
+			"cache: y\nNote: z\n"},
+		{"c.yml", "port: 8080\ntag:\n  - a\n  - b\ndb-host: x\n"},
+		{"c.toml", "port = 8080\ntag = [\"a\", \"b\"]\ncache = \"y\"\n" +
+			"Note = \"z\"\n[db]\nhost = \"x\"\n"},
+	}
+	want := args{8080, []string{"a", "b"}, "x", "", ""}
+
+	for _, test := range tests {
+		p := NewParser()
+		p.AddConfigFile(writeConfig(t, test.name, test.config))
+
+		a := args{}
+		if err := p.Parse([]string{}, &a); err != nil {
+			t.Errorf("%s: Unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(a, want) {
+			t.Errorf("%s: got=%v want=%v", test.name, a, want)
+		}
+	}
+}
+
+func Test_ParserConfigIgnoredNested(t *testing.T) {
+	type dbArgs struct {
+		Host   string `arg-flag:"--host"`
+		Secret string `arg-flag:"--secret" arg-ignore:""`
+	}
+	type Common struct {
+		Token string `arg-flag:"--token" arg-ignore:""`
+	}
+	type args struct {
+		Common
+		DB    dbArgs `arg-prefix:"db"`
+		Cache struct {
+			Store dbArgs `arg-prefix:"store"`
+		} `arg-prefix:"cache"`
+	}
+
+	config := writeConfig(t, "c.json", `{"db": {"host": "x", "secret": "y"},
+		"DB.Secret": "y", "token": "t", "Token": "t",
+		"cache-store-secret": "z", "Cache.Store.Secret": "z"}`)
+
+	p := NewParser()
+	p.AddConfigFile(config)
+
+	a := args{}
+	if err := p.Parse([]string{}, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a.DB.Host != "x" || a.DB.Secret != "" || a.Token != "" ||
+		a.Cache.Store.Secret != "" {
+		t.Errorf("got=%+v", a)
+	}
+
+	// Keys of ignored fields are not composed with other prefixes
+	p = NewParser()
+	p.AddConfigFile(writeConfig(t, "d.json", `{"secret": "y"}`))
+	if err := p.Parse([]string{}, &args{}); err == nil {
+		t.Errorf("Expected error for unknown key")
+	}
+}
+
+func Test_ConfigFlag(t *testing.T) {
+	type args struct {
+		Config string `arg-flag:"-c --config" arg-config:"" arg-env:"CLEANARG_CONFIG"`
+		Port   int    `arg-flag:"-p --port" arg-default:"80"`
+		Name   string `arg-flag:"-n"`
+		Files  []string
+	}
+
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.json")
+	b := filepath.Join(dir, "b.toml")
+	os.WriteFile(a, []byte(`{"port": 1, "Name": "a", "config": "x"}`), 0644)
+	os.WriteFile(b, []byte("port = 2\n"), 0644)
+
+	tests := []struct {
+		slice []string
+		env   string
+		want  args
+	}{
+		{[]string{}, "", args{"", 80, "", nil}},
+		{[]string{"-c", a}, "", args{a, 1, "a", nil}},
+		{[]string{"x", "--config=" + a, "y"}, "",
+			args{a, 1, "a", []string{"x", "y"}}},
+		{[]string{"-p", "3", "-c", a}, "", args{a, 3, "a", nil}},
+		{[]string{"-c", b, "-n", "b"}, "", args{b, 2, "b", nil}},
+		{[]string{}, a, args{a, 1, "a", nil}},
+		{[]string{"-c", b}, a, args{b, 2, "", nil}},
+		{[]string{"--", "-c", a}, "", args{"", 80, "", []string{"-c", a}}},
+	}
+
+	for _, test := range tests {
+		t.Setenv("CLEANARG_CONFIG", test.env)
+
+		res := args{}
+		if err := FromSlice(test.slice, &res); err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.slice, res, test.want)
+		}
+	}
+
+	// Files named on the command line are read after registered ones
+	p := NewParser()
+	p.AddConfigFile(b)
+	res := args{}
+	if err := p.Parse([]string{"-c", a}, &res); err != nil || res.Port != 1 {
+		t.Errorf("Registered file: got=%v err=%v", res, err)
+	}
+
+	// Errors
+	if err := FromSlice([]string{"-c", filepath.Join(dir, "missing.json")},
+		&args{}); err == nil {
+		t.Errorf("Missing file: Expected error")
+	}
+	type intConfig struct {
+		Config int `arg-flag:"--config" arg-config:""`
+	}
+	if err := FromSlice([]string{}, &intConfig{}); err == nil {
+		t.Errorf("Int field: Expected error")
+	}
+	type posConfig struct {
+		Config string `arg-config:""`
+	}
+	if err := FromSlice([]string{}, &posConfig{}); err == nil {
+		t.Errorf("Positional field: Expected error")
+	}
+}
+
+func Test_ParserConfigErr(t *testing.T) {
+	tests := []struct {
+		name, config string
+	}{
+		{"c.json", `{"prot": 8080}`},
+		{"c.json", `{"port": "x"}`},
+		{"c.json", `{"port": [1, 2]}`},
+		{"c.json", `{"port": 1`},
+		{"c.json", `[1, 2]`},
+		{"c.json", `{"tag": [{"a": 1}]}`},
+		{"c.yaml", "prot: 8080\n"},
+		{"c.toml", "port = [1, 2]\n"},
+		{"c.ini", `port=1`},
+	}
+
+	for _, test := range tests {
+		p := NewParser()
+		p.AddConfigFile(writeConfig(t, test.name, test.config))
+		if err := p.Parse([]string{}, &configArgs{}); err == nil {
+			t.Errorf("%s: Expected error", test.config)
+		}
+	}
+
+	p := NewParser()
+	p.AddConfigFile(filepath.Join(t.TempDir(), "missing.json"))
+	if err := p.Parse([]string{}, &configArgs{}); err == nil {
+		t.Errorf("Missing file: Expected error")
+	}
+}
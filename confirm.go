@@ -0,0 +1,121 @@
+package cleanarg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// The prompt of an option tagged arg-confirm without prompt text.
+const defaultConfirmPrompt = "Continue?"
+
+// EnableConfirm makes the Parser ask for confirmation of boolean options
+// tagged arg-confirm (such as --yes) that are not supplied: the tag's text
+// is written to the error writer as a y/N prompt, and an answer is read
+// from standard input. If the answer is "y" or "yes" (in any case), the
+// field is set to true; otherwise, an error of kind ErrNotConfirmed is
+// returned. Without EnableConfirm(), such fields simply remain false (so
+// that scripts can rely on the flag).
+func (p *Parser) EnableConfirm() {
+	p.confirm = true
+}
+
+// CheckConfirmOptions takes a map of options, as built by analyzeStruct,
+// and checks the options that ask for confirmation (arg-confirm), if any:
+// they must be boolean fields (not slices).
+// Returns an error if one of them is not.
+func checkConfirmOptions(options map[string]fieldInfo) error {
+	for _, info := range options {
+		if !info.hasConfirm || info.isConst {
+			continue
+		}
+		if info.baseType != reflect.TypeOf(true) || info.isSlice {
+			return fmt.Errorf("%s requires bool field: %s", tagConfirm,
+				info.Name)
+		}
+	}
+	return nil
+}
+
+// ConfirmOptions takes a Parser, a map of options, the options retained
+// from the command line, and a reflect.Value representing the struct to
+// populate, and asks for confirmation of each option tagged arg-confirm
+// that was neither supplied on the command line nor set to true otherwise
+// (by its default, say), in the order of the fields. Confirmed options are
+// set to true.
+// Returns an error if an option is not confirmed, or if the answer cannot
+// be read.
+func confirmOptions(p *Parser, options map[string]fieldInfo,
+	retainedOpts []fieldInfo, v reflect.Value) error {
+
+	supplied := map[string]struct{}{}
+	for _, info := range retainedOpts {
+		supplied[info.Name] = struct{}{}
+	}
+
+	pending := []fieldInfo{}
+	for _, info := range options {
+		_, done := supplied[info.Name]
+		if done || !info.hasConfirm || info.isConst {
+			continue // negated flags are fixed-value flags of the field
+		}
+		if val, ok := currentValue(info, v); ok && val.Bool() {
+			continue
+		}
+		supplied[info.Name] = struct{}{}
+		pending = append(pending, info)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return slices.Compare(pending[i].Index, pending[j].Index) < 0
+	})
+
+	for _, info := range pending {
+		prompt := info.confirm
+		if prompt == "" {
+			prompt = defaultConfirmPrompt
+		}
+		fmt.Fprintf(p.errorWriter(), "%s [y/N] ", prompt)
+
+		answer, err := readLine(p.inputReader())
+		if err != nil {
+			return errorf("reading confirmation: %w", err)
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			fieldValue(info, v).SetBool(true)
+		default:
+			perr := newParseError(ErrNotConfirmed, "", -1, "%s not confirmed",
+				preferredFlag(info))
+			perr.Field, perr.Flag = info.Name, preferredFlag(info)
+			return perr
+		}
+	}
+	return nil
+}
+
+// ReadLine reads a single line from r (without the newline), one byte at a
+// time, so that nothing beyond the line is consumed. The end of input ends
+// the line as well.
+func readLine(r io.Reader) (string, error) {
+	var sb strings.Builder
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				return sb.String(), nil
+			}
+			sb.WriteByte(buf[0])
+		}
+		if errors.Is(err, io.EOF) {
+			return sb.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
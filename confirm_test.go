@@ -0,0 +1,127 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"bytes"
+	"errors"
+	"strings"
+)
+
+type confirmArgs struct {
+	Yes   bool `arg-flag:"-y --yes" arg-confirm:"Delete all files?" arg-negate:""`
+	Force bool `arg-flag:"--force" arg-confirm:""`
+	Files []string
+}
+
+func Test_ParseConfirm(t *testing.T) {
+	tests := []struct {
+		tokens   []string
+		input    string
+		enabled  bool
+		want     confirmArgs
+		prompts  string
+		wantKind error
+	}{
+		{[]string{"-y", "--force"}, "", true,
+			confirmArgs{Yes: true, Force: true, Files: []string{}}, "", nil},
+		{[]string{"--force", "a"}, "y\n", true,
+			confirmArgs{Yes: true, Force: true, Files: []string{"a"}},
+			"Delete all files? [y/N] ", nil},
+		{[]string{}, " YES \ny", true,
+			confirmArgs{Yes: true, Force: true, Files: []string{}},
+			"Delete all files? [y/N] Continue? [y/N] ", nil},
+		{[]string{"--no-yes", "--force"}, "", true,
+			confirmArgs{Force: true, Files: []string{}}, "", nil},
+		{[]string{"--force"}, "n\n", true, confirmArgs{},
+			"Delete all files? [y/N] ", ErrNotConfirmed},
+		{[]string{"--force"}, "", true, confirmArgs{},
+			"Delete all files? [y/N] ", ErrNotConfirmed},
+		{[]string{"-y"}, "y\n", false,
+			confirmArgs{Yes: true, Files: []string{}}, "", nil},
+	}
+
+	for _, test := range tests {
+		var stderr bytes.Buffer
+		p := NewParser(WithInputReader(strings.NewReader(test.input)),
+			WithErrorWriter(&stderr))
+		if test.enabled {
+			p.EnableConfirm()
+		}
+
+		data := confirmArgs{}
+		err := p.Parse(test.tokens, &data)
+		if test.wantKind != nil {
+			if !errors.Is(err, test.wantKind) {
+				t.Errorf("%v: got=%v want=%v", test.tokens, err, test.wantKind)
+			}
+		} else if err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.tokens, err)
+		} else if data.Yes != test.want.Yes || data.Force != test.want.Force ||
+			strings.Join(data.Files, " ") != strings.Join(test.want.Files, " ") {
+
+			t.Errorf("%v: got=%+v want=%+v", test.tokens, data, test.want)
+		}
+		if stderr.String() != test.prompts {
+			t.Errorf("%v: prompts=%q want=%q", test.tokens, stderr.String(),
+				test.prompts)
+		}
+	}
+}
+
+func Test_ParseConfirmError(t *testing.T) {
+	p := NewParser(WithConfirm(), WithInputReader(strings.NewReader("no\n")),
+		WithErrorWriter(&bytes.Buffer{}))
+
+	data := confirmArgs{}
+	err := p.Parse([]string{"--force"}, &data)
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Expected ParseError, got: %v", err)
+	}
+	if perr.Field != "Yes" || perr.Flag != "--yes" ||
+		err.Error() != "--yes not confirmed" {
+
+		t.Errorf("Unexpected error: %+v", perr)
+	}
+}
+
+func Test_ParseConfirmDefault(t *testing.T) {
+	var stderr bytes.Buffer
+	p := NewParser(WithConfirm(), WithErrorWriter(&stderr))
+
+	data := struct {
+		Yes bool `arg-flag:"--yes" arg-confirm:"Sure?" arg-default:"true"`
+	}{}
+	if err := p.Parse([]string{}, &data); err != nil || !data.Yes {
+		t.Errorf("Unexpected result: %v, %v", data.Yes, err)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Unexpected prompt: %q", stderr.String())
+	}
+}
+
+func Test_checkConfirmOptions(t *testing.T) {
+	tests := []struct {
+		data any
+		msg  string
+	}{
+		{&struct {
+			N int `arg-flag:"-n" arg-confirm:"Sure?"`
+		}{}, "arg-confirm requires bool field: N"},
+		{&struct {
+			Yes []bool `arg-flag:"-y" arg-confirm:"Sure?"`
+		}{}, "arg-confirm requires bool field: Yes"},
+		{&struct {
+			Yes bool `arg-confirm:"Sure?"`
+		}{}, "arg-confirm requires arg-flag: Yes"},
+	}
+
+	for _, test := range tests {
+		err := FromSlice([]string{}, test.data)
+		if err == nil || err.Error() != test.msg {
+			t.Errorf("%T: got=%v want=%s", test.data, err, test.msg)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package cleanarg
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Converters registered with RegisterConverter(), keyed on reflect.Type.
+var converters sync.Map
+
+// The type of the flag.Value interface of the standard library.
+var flagValueType = reflect.TypeOf((*flag.Value)(nil)).Elem()
+
+// RegisterConverter registers a function that converts command-line
+// tokens (as well as default values, environment variables, and values
+// from configuration files) to values of the given type, so that fields of
+// this type, and slices of it, can be populated by cleanarg. The function
+// must return a value of the given type (or of a type convertible to it),
+// or an error if the token is invalid. Registering a converter for a type
+// replaces any earlier registration for it.
+//
+// Register converters before parsing (typically in an init function).
+// Converters can not be registered for the types that cleanarg supports
+// natively (string, bool, int, float64, time.Time, time.Duration), nor for
+// pointer types: RegisterConverter panics if it is asked to.
+func RegisterConverter(t reflect.Type, f func(string) (any, error)) {
+	if _, ok := allowedTypes[t]; ok {
+		panic(fmt.Sprintf("cleanarg: converter for native type %s", t))
+	}
+	if t.Kind() == reflect.Pointer || f == nil {
+		panic(fmt.Sprintf("cleanarg: invalid converter for %s", t))
+	}
+
+	converters.Store(t, f)
+}
+
+// LookupConverter returns the converter registered for the given type, if
+// any.
+func lookupConverter(t reflect.Type) (func(string) (any, error), bool) {
+	f, ok := converters.Load(t)
+	if !ok {
+		return nil, false
+	}
+	return f.(func(string) (any, error)), true
+}
+
+// IsAllowedType returns true if fields of the given type can be populated:
+// the type is supported natively, a converter is registered for it, or it
+// implements flag.Value.
+func isAllowedType(t reflect.Type) bool {
+	if _, ok := allowedTypes[t]; ok {
+		return true
+	}
+	_, ok := lookupConverter(t)
+	return ok || isFlagValue(t)
+}
+
+// IsFlagValue returns true if a pointer to the given type implements the
+// flag.Value interface (and the type is neither supported natively, nor has
+// a registered converter, either of which would take precedence).
+func isFlagValue(t reflect.Type) bool {
+	if _, ok := allowedTypes[t]; ok {
+		return false
+	}
+	if _, ok := lookupConverter(t); ok {
+		return false
+	}
+	return t.Kind() != reflect.Pointer &&
+		reflect.PointerTo(t).Implements(flagValueType)
+}
+
+// SetFlagValue takes a fieldInfo of a (non-slice) field whose type
+// implements flag.Value, a reflect.Value, which must represent the struct
+// to populate, and the value to set, and passes the value to the field's
+// Set() method, as package flag does. (Each call operates on the current
+// value of the field, so that types that accumulate values keep working.)
+// Returns a ParseError if the value is not one of the permitted values
+// (arg-choices), if Set() fails (redacted for arg-secret), or if the new
+// value fails validation (see Validator).
+func setFlagValue(info fieldInfo, v reflect.Value, value string) error {
+	if err := checkChoices(info, value); err != nil {
+		return err
+	}
+
+	field := fieldValue(info, v)
+	if err := field.Addr().Interface().(flag.Value).Set(value); err != nil {
+		return valueError(ErrConversion, info, redactError(info, err))
+	}
+	if err := validateValue(field); err != nil {
+		err = errorf("invalid value for %s: %w", displayFlag(info), err)
+		return valueError(ErrValidation, info, err)
+	}
+	return nil
+}
+
+// ConvertCustom takes a fieldInfo of a type with a registered converter
+// (or of a type that implements flag.Value), and the value to convert, and
+// converts it using the converter (or the Set() method of a zero value).
+// Returns an error if the conversion fails (redacted for arg-secret), or
+// if the converter returns a value of the wrong type.
+func convertCustom(info fieldInfo, value string) (reflect.Value, error) {
+	f, ok := lookupConverter(info.baseType)
+	if !ok && isFlagValue(info.baseType) {
+		// Elements of slices start out as zero values
+		ptr := reflect.New(info.baseType)
+		if err := ptr.Interface().(flag.Value).Set(value); err != nil {
+			return reflect.Value{}, redactError(info, err)
+		}
+		return ptr.Elem(), nil
+	}
+	if !ok {
+		// Never get here
+		return reflect.Value{}, fmt.Errorf("invalid type")
+	}
+
+	x, err := f(value)
+	if err != nil {
+		return reflect.Value{}, redactError(info, err)
+	}
+
+	vv := reflect.ValueOf(x)
+	if !vv.IsValid() || !vv.Type().ConvertibleTo(info.baseType) {
+		return reflect.Value{}, fmt.Errorf("converter for %s returned %T",
+			info.baseType, x)
+	}
+	return vv.Convert(info.baseType), nil
+}
+
+// FormatCustom is the inverse of convertCustom: it takes a value of a type
+// with a registered converter (or of a type that implements flag.Value),
+// and returns its string representation, as given by its String() method
+// (possibly with pointer receiver), if any, or by the %v verb otherwise.
+func formatCustom(x any) string {
+	ptr := reflect.New(reflect.TypeOf(x))
+	ptr.Elem().Set(reflect.ValueOf(x))
+	if s, ok := ptr.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%v", x)
+}
@@ -0,0 +1,226 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ticketID is a custom type, such as "ABC-123".
+type ticketID struct {
+	project string
+	number  int
+}
+
+func (t ticketID) String() string {
+	return fmt.Sprintf("%s-%d", t.project, t.number)
+}
+
+// csvList is a slice type with a converter of its own.
+type csvList []string
+
+// level is a named type, whose converter returns the underlying type.
+type level int
+
+func init() {
+	RegisterConverter(reflect.TypeOf(ticketID{}), func(s string) (any, error) {
+		project, num, ok := strings.Cut(s, "-")
+		n, err := strconv.Atoi(num)
+		if !ok || project == "" || err != nil {
+			return nil, fmt.Errorf("invalid ticket: %s", s)
+		}
+		return ticketID{project, n}, nil
+	})
+	RegisterConverter(reflect.TypeOf(csvList{}), func(s string) (any, error) {
+		return csvList(strings.Split(s, ",")), nil
+	})
+	RegisterConverter(reflect.TypeOf(level(0)), func(s string) (any, error) {
+		switch s {
+		case "low":
+			return 1, nil
+		case "high":
+			return 2, nil
+		}
+		return nil, fmt.Errorf("invalid level: %s", s)
+	})
+}
+
+func Test_FromSliceConverter(t *testing.T) {
+	type converterArgs struct {
+		Ticket  ticketID   `arg-flag:"-t" arg-default:"OPS-1"`
+		Related []ticketID `arg-flag:"-r"`
+		Tags    csvList    `arg-flag:"--tags"`
+		Level   level      `arg-flag:"-l"`
+		Main    ticketID
+	}
+
+	tests := []struct {
+		slice   []string
+		want    converterArgs
+		wantErr string
+	}{
+		{[]string{"DEV-7"}, converterArgs{
+			Ticket: ticketID{"OPS", 1}, Main: ticketID{"DEV", 7}}, ""},
+		{[]string{"-t", "A-2", "-r", "B-3", "-r", "C-4", "--tags", "x,y",
+			"-l", "high", "DEV-7"}, converterArgs{
+			Ticket:  ticketID{"A", 2},
+			Related: []ticketID{{"B", 3}, {"C", 4}},
+			Tags:    csvList{"x", "y"},
+			Level:   2,
+			Main:    ticketID{"DEV", 7},
+		}, ""},
+		{[]string{"-t", "A", "DEV-7"}, converterArgs{}, "invalid ticket: A"},
+		{[]string{"-l", "medium", "DEV-7"}, converterArgs{},
+			"invalid level: medium"},
+	}
+
+	for _, test := range tests {
+		a := converterArgs{}
+
+		err := FromSlice(test.slice, &a)
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+		if test.wantErr != "" && !errors.Is(err, ErrConversion) {
+			t.Errorf("%v: got=%v want ErrConversion", test.slice, err)
+		}
+		if err == nil && !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.slice, a, test.want)
+		}
+	}
+}
+
+func Test_RegisterConverter(t *testing.T) {
+	// Converters for native types are rejected
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected panic for native type")
+			}
+		}()
+		RegisterConverter(reflect.TypeOf(0), func(s string) (any, error) {
+			return 0, nil
+		})
+	}()
+
+	// Unregistered types are still rejected
+	type unknownArgs struct {
+		C complex128 `arg-flag:"-c"`
+	}
+	if err := FromSlice([]string{}, &unknownArgs{}); err == nil {
+		t.Errorf("Expected error for unregistered type")
+	}
+
+	// Values of registered types are formatted for runtime defaults
+	info := fieldInfo{baseType: reflect.TypeOf(ticketID{})}
+	if s, err := formatValue(info, ticketID{"A", 1}); err != nil || s != "A-1" {
+		t.Errorf("got=%q, %v want=A-1", s, err)
+	}
+}
+
+// hostList is a flag.Value that accumulates comma-separated hosts.
+type hostList []string
+
+func (h *hostList) String() string { return strings.Join(*h, ",") }
+
+func (h *hostList) Set(s string) error {
+	if s == "" || strings.Contains(s, " ") {
+		return fmt.Errorf("invalid hosts: %q", s)
+	}
+	*h = append(*h, strings.Split(s, ",")...)
+	return nil
+}
+
+// mode is a flag.Value with a fixed set of values.
+type mode struct {
+	name string
+}
+
+func (m *mode) String() string { return m.name }
+
+func (m *mode) Set(s string) error {
+	if s != "fast" && s != "safe" {
+		return fmt.Errorf("unknown mode: %s", s)
+	}
+	m.name = s
+	return nil
+}
+
+func Test_FromSliceFlagValue(t *testing.T) {
+	type flagValueArgs struct {
+		Hosts hostList `arg-flag:"-H"`
+		Mode  mode     `arg-flag:"-m" arg-default:"safe"`
+		Modes []mode   `arg-flag:"-M"`
+	}
+
+	tests := []struct {
+		slice   []string
+		want    flagValueArgs
+		wantErr string
+	}{
+		{[]string{}, flagValueArgs{Mode: mode{"safe"}}, ""},
+		{[]string{"-H", "a,b", "-H", "c", "-m", "fast", "-M", "safe",
+			"-M", "fast"}, flagValueArgs{
+			Hosts: hostList{"a", "b", "c"},
+			Mode:  mode{"fast"},
+			Modes: []mode{{"safe"}, {"fast"}},
+		}, ""},
+		{[]string{"-m", "slow"}, flagValueArgs{}, "unknown mode: slow"},
+		{[]string{"-M", "slow"}, flagValueArgs{}, "unknown mode: slow"},
+		{[]string{"-H", "a b"}, flagValueArgs{}, `invalid hosts: "a b"`},
+	}
+
+	for _, test := range tests {
+		a := flagValueArgs{}
+
+		err := FromSlice(test.slice, &a)
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+		if test.wantErr != "" && !errors.Is(err, ErrConversion) {
+			t.Errorf("%v: got=%v want ErrConversion", test.slice, err)
+		}
+		if err == nil && !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.slice, a, test.want)
+		}
+	}
+
+	// Runtime defaults are formatted by String() (pointer receiver)
+	a := flagValueArgs{}
+	p := NewParser(WithDefault("Mode", mode{"fast"}))
+	if err := p.Parse([]string{}, &a); err != nil || a.Mode.name != "fast" {
+		t.Errorf("got=%v, %v want=fast", a.Mode, err)
+	}
+}
+
+func Test_FromSliceFlagValueChoices(t *testing.T) {
+	type choicesArgs struct {
+		Mode mode `arg-flag:"-m" arg-choices:"fast"`
+	}
+
+	a := choicesArgs{}
+	if err := FromSlice([]string{"-m", "fast"}, &a); err != nil ||
+		a.Mode.name != "fast" {
+		t.Errorf("got=%v, %v want=fast", a.Mode, err)
+	}
+
+	// Permitted values are checked before Set()
+	a = choicesArgs{}
+	err := FromSlice([]string{"-m", "safe"}, &a)
+	if !errors.Is(err, ErrInvalidChoice) {
+		t.Errorf("got=%v want ErrInvalidChoice", err)
+	}
+	if a.Mode.name != "" {
+		t.Errorf("got=%v want unset", a.Mode)
+	}
+}
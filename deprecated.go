@@ -0,0 +1,46 @@
+package cleanarg
+
+import (
+	"fmt"
+	"io"
+)
+
+// DeprecationHint returns the hint shown in usage messages for an option
+// tagged arg-deprecated: "deprecated", followed by the tag's text, if any.
+func deprecationHint(info fieldInfo) string {
+	if info.deprecated == "" {
+		return message("deprecated")
+	}
+	return messagef("deprecated: %s", info.deprecated)
+}
+
+// WarnDeprecated takes a Parser and the options retained from the command
+// line, and writes a warning for each flag of a deprecated option
+// (arg-deprecated) among them, once per flag, to the Parser's warning
+// writer. The option itself works as usual.
+func warnDeprecated(p *Parser, retainedOpts []fieldInfo) {
+	seen := map[string]struct{}{}
+	for _, info := range retainedOpts {
+		if !info.isDeprec {
+			continue
+		}
+		if _, ok := seen[info.flag]; ok {
+			continue
+		}
+		seen[info.flag] = struct{}{}
+
+		msg := fmt.Sprintf("warning: %s is deprecated", info.flag)
+		if info.deprecated != "" {
+			msg += ": " + info.deprecated
+		}
+		fmt.Fprintln(p.warningWriter(), msg)
+	}
+}
+
+// WarningWriter returns the writer for warnings (about deprecated flags).
+func (p *Parser) warningWriter() io.Writer {
+	if p.warnings == nil {
+		return p.errorWriter()
+	}
+	return p.warnings
+}
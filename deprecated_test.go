@@ -0,0 +1,91 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"bytes"
+	"io"
+	"strings"
+)
+
+type deprecatedArgs struct {
+	Name  string `arg-flag:"--name"`
+	Old   string `arg-flag:"-o --old-name" arg-deprecated:"use --name instead"`
+	Color bool   `arg-flag:"--color" arg-deprecated:"" arg-negate:""`
+	Files []string
+}
+
+func Test_ParseDeprecated(t *testing.T) {
+	tests := []struct {
+		tokens []string
+		want   string
+	}{
+		{[]string{"--name", "x", "a"}, ""},
+		{[]string{"--old-name", "x"},
+			"warning: --old-name is deprecated: use --name instead\n"},
+		{[]string{"-o", "x", "-o", "y", "--old-name=z"},
+			"warning: -o is deprecated: use --name instead\n" +
+				"warning: --old-name is deprecated: use --name instead\n"},
+		{[]string{"--no-color"}, "warning: --no-color is deprecated\n"},
+	}
+
+	for _, test := range tests {
+		var stderr, warnings bytes.Buffer
+		p := NewParser(WithErrorWriter(&stderr), WithWarningWriter(&warnings))
+
+		data := deprecatedArgs{}
+		if err := p.Parse(test.tokens, &data); err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.tokens, err)
+			continue
+		}
+		if warnings.String() != test.want {
+			t.Errorf("%v: got=%q want=%q", test.tokens, warnings.String(),
+				test.want)
+		}
+		if stderr.Len() != 0 {
+			t.Errorf("%v: Unexpected output: %q", test.tokens, stderr.String())
+		}
+	}
+
+	// The flag keeps working; warnings go to the error writer by default
+	var stderr bytes.Buffer
+	p := NewParser(WithErrorWriter(&stderr))
+	data := deprecatedArgs{}
+	if err := p.Parse([]string{"-o", "x"}, &data); err != nil || data.Old != "x" {
+		t.Errorf("Unexpected result: %+v, %v", data, err)
+	}
+	if !strings.HasPrefix(stderr.String(), "warning: -o is deprecated") {
+		t.Errorf("Unexpected output: %q", stderr.String())
+	}
+	p = NewParser(WithWarningWriter(io.Discard))
+	if err := p.Parse([]string{"-o", "x"}, &data); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func Test_WriteUsageDeprecated(t *testing.T) {
+	sb := strings.Builder{}
+	WriteUsage(&sb, &deprecatedArgs{})
+	if !strings.Contains(sb.String(),
+		"-o --old-name [string]\n       (deprecated: use --name instead)\n") ||
+		!strings.Contains(sb.String(), "--color \n       (deprecated)\n") {
+		t.Errorf("Unexpected usage:\n%s", sb.String())
+	}
+
+	spec, err := Analyze(&deprecatedArgs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, o := range spec.Options {
+		if o.Name == "Old" && (!o.Deprecated || o.Notice != "use --name instead") {
+			t.Errorf("Unexpected spec: %+v", o)
+		}
+	}
+
+	err = FromSlice([]string{}, &struct {
+		Src string `arg-deprecated:""`
+	}{})
+	if err == nil || err.Error() != "arg-deprecated requires arg-flag: Src" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
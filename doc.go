@@ -16,21 +16,76 @@ tokens will automatically be converted to the appropriate type:
   string
   time.Time
   time.Duration
+  ByteSize (eg. 512, 10MB, 1.5GiB)
+  []byte (decoded according to arg-encoding, if any)
+  os.FileMode (octal, eg. 0644)
+  rune (a single character; also int32)
+  net.IP, netip.Addr
+  net.IPNet, netip.Prefix
+  netip.AddrPort
 
 It is also possible to use a slice of any of the above types to allow
 for repeated flags, or to allow for a variable and/or unknown number of
-arguments.
+arguments. A pointer to any of the above types (eg. *int) remains nil
+unless a value is supplied; similarly, an Optional[T] records whether a
+value was supplied (see its IsSet() method). Other types can be used after
+registering a converter for them with RegisterConverter(). Types that
+implement flag.Value can be used directly: their Set() method is called
+for each occurrence of the flag.
 
 
 # Struct Tags
 
 The following struct tags may be used:
 
-  arg-flag    : The command-line flags to set this field, as a whitespace separated string.
+  arg-flag    : The command-line flags to set this field, as a whitespace separated string ("auto" derives a long flag from the field name).
+  arg-alias   : Further flags for this field, which work like those of arg-flag, but are not listed in usage messages.
   arg-help    : A help text that will be displayed by PrintUsage().
+  arg-name    : A placeholder for the field's value in usage messages (eg. "SOURCE"), instead of its type.
   arg-default : A default value for this field, in case it is not set explicitly on the command line.
-  arg-format  : A custom format string (only used for fields of type time.Time).
+  arg-format  : A custom format string (for time.Time), or formatting keywords (for numeric types).
+  arg-location : The time zone for time.Time values without zone (eg. "Europe/Berlin" or "Local"; default UTC).
+  arg-encoding : The encoding of a []byte field: hex, base64, or base64url.
+  arg-relative : This time.Time field also accepts relative expressions, such as now, yesterday, now-2h, or -3d.
   arg-ignore  : Ignore this field, do not populate it, do not treat it as positional argument.
+  arg-hidden  : This option is parsed as usual, but omitted from usage messages, man pages, and completion.
+  arg-deprecated : This option still works, but a warning (with the tag's text) is written when it is used.
+  arg-secret  : The value of this field is sensitive, and is shown as "********" (or not at all) in error messages and other output.
+  arg-group   : A heading under which PrintUsage() lists this option, together with other options of the group.
+  arg-url     : A link to further documentation, that will be displayed by PrintUsage().
+  arg-choices : The permitted values for this field, as a whitespace separated string; other values are rejected.
+  arg-min     : The smallest permitted value of a numeric (or time.Duration) field.
+  arg-max     : The largest permitted value of a numeric (or time.Duration) field.
+  arg-pattern : A regular expression that the values of a string field must match.
+  arg-const   : Flags that set this field to a fixed value, as a whitespace separated string of flag=value pairs.
+  arg-count   : Each occurrence of one of the option's flags increments this int field (the flags take no argument).
+  arg-number  : This int option is also set by bare numbers used as flags, as in "tail -20".
+  arg-once    : The option's flags may be given at most once; repeating them is an error.
+  arg-max-count : The maximum number of times the flags of a slice (or arg-count) option may be given.
+  arg-sep     : A separator that splits each value of a slice field into several elements (eg. ",").
+  arg-reset   : A value that empties a slice field, rather than being appended to it (eg. "none").
+  arg-unique  : Duplicate elements are removed from this slice field (the first occurrence is kept).
+  arg-sorted  : The elements of this slice field are sorted (numbers, strings, times, IP addresses).
+  arg-negate  : Flags that set this boolean option to false (if empty: --no-xx for each long flag --xx, -x for each flag +x).
+  arg-confirm : This bool option confirms a destructive action; if it is not supplied, Parser.EnableConfirm() prompts for it.
+  arg-xor     : The name of a group of options, at most one of which may be supplied on the command line.
+  arg-required : This option must be set (on the command line, in a config file, or by its environment variable).
+  arg-require-one : The name of a group of options, at least one of which must be supplied on the command line.
+  arg-together : The name of a group of options that must be supplied together on the command line (all or none).
+  arg-requires : Flags of other options, as a whitespace separated string, that are required if this option is supplied.
+  arg-assign  : Collect NAME=value tokens in this field, which must be of type map[string]string.
+  arg-command : This field defines a subcommand with the given name (and optional aliases), and must be a struct or ptr to struct.
+  arg-default-command : This subcommand is selected if the first positional token does not name one.
+  arg-persistent : This option may also be given after the name of a subcommand (and still populates its own struct).
+  arg-env     : An environment variable, used if the option is not supplied on the command line (before arg-default); "-" for none.
+  arg-prefix  : This field is a nested struct, whose options are added with prefixed long flags (--db-host).
+  arg-config  : This option (string or []string) names a configuration file, read before the command line is applied.
+  arg-rest    : Collect all tokens following "--" in this field, verbatim, which must be of type []string.
+  arg-optional : This (trailing, non-slice) positional argument may be omitted; it then receives its arg-default value.
+  arg-section : This positional field starts a new section of positionals, which follow the given separator token.
+
+Tag the options of a group with both arg-xor and arg-require-one to require
+exactly one of them.
 
 Positional fields do not need to be indicated explicitly.
 
@@ -38,9 +93,26 @@ The default date format is "YYYY-MM-DD hh:mm:ss" ("2006-01-02 15:04:05"),
 without timezone indicator. To support a different date format, set the
 arg-format tag to a value that is recognized by the time.Parse() function.
 
+For numeric fields, the arg-format tag holds a whitespace-separated list
+of keywords that enable alternative input formats:
+
+  decimal-comma : float64 values may use a decimal comma ("3,14") instead of a decimal point.
+  si            : int values may carry a decimal SI suffix (k, M, G, T, P, E), as in "2k" or "1.5M" (for byte sizes, use ByteSize).
+  percent       : float64 values are percentages: "75%" and "75" both yield 0.75.
+  fraction      : float64 values are percentages or fractions: "75%" and "0.75" both yield 0.75.
+
+Percentages (with either the percent or the fraction keyword) must lie
+between 0% and 100%.
+
+For types with a non-obvious input syntax (time.Time, time.Duration, and
+numeric fields with formatting keywords), the usage messages created by
+PrintUsage() include a brief hint on the accepted format.
+
 If the help text contains a substring enclosed by a pair of "*", then the
 first occurrence of such a substring will be substituted for the field's
 type in the usage messages created by PrintUsage() and related functions.
+A placeholder given by the arg-name tag takes precedence over such a
+substring.
 
 Remember that struct fields must be public (ie. upper-case) to be
 accessible!
@@ -53,8 +125,28 @@ Both short (single character) and long flags can be used.
 Short flags must begin with either "-" or "-", long flags must
 begin with "--". It is possible to define multiple flags for a single
 field as white-space separated string, following the arg-flag tag.
-All flags for a single field will be treated equally; it is not possible
-to assign different semantics to different flags.
+All flags for a single field will be treated equally. The word "auto" in
+the arg-flag tag stands for a long flag derived from the field name (as in
+--max-retries for MaxRetries), and "auto-short" stands for that long flag
+together with a short flag (the first letter of the field name, as in
+-m).
+
+To assign different fixed values to a field using different flags, use
+the arg-const tag: each flag in the tag sets the field to its value, and
+takes no argument (like a boolean flag). The arg-const tag may be combined
+with the arg-flag tag:
+
+    type Config struct {
+        Level int `arg-flag:"--level" arg-const:"-q=0 -v=2 --debug=3" arg-default:"1"`
+    }
+
+Boolean options can be switched off by the flags given in the arg-negate
+tag, or, if the tag is empty, by --no-xx for each long flag --xx (and by
+-x for each flag +x, so that "+x" and "-x" toggle the option):
+
+    type Config struct {
+        Color bool `arg-flag:"--color" arg-negate:"" arg-default:"true"`
+    }
 
 Digits, lower and upper case characters may be used as flags; long
 flags may also contain a hyphen (but not as first character after
@@ -66,7 +158,31 @@ but can be chosen arbitrarily (as long as they are well-formatted). This
 avoids confusion about upper- vs lower-case field names and their
 associated flags.
 
-Unrecognized flags are treated as positional arguments.
+Unrecognized flags are treated as positional arguments, unless strict
+mode is enabled (see Parser.EnableStrict()), in which case they are
+reported as errors. Either way, errors caused by a mistyped flag include
+suggestions for the closest known flags ("unknown flag --verbse, did you
+mean --verbose?").
+Tokens that start with a sign followed by a digit (as in -5, -1.5, or
+-1h30m) are never taken for unknown flags, but are treated as (negative)
+values of positional fields.
+An int option tagged arg-number is set by bare numbers used as flags
+instead (so that "-20" means "-n 20", as for tail and head).
+
+Flags and positionals may be interspersed, unless POSIX ordering is
+enabled (see Parser.EnablePosixOrdering()), in which case the first
+positional token ends the flags, and all following tokens are treated as
+positionals.
+
+With single-dash long flags enabled (see Parser.EnableSingleDashLong()),
+long flags may also be given with a single dash, as in "-name" for
+"--name" (as for find or java). A defined long flag takes precedence over
+short flags, so "-vx" means "--vx" if that is defined, and "-v -x" if not.
+
+With Windows-style flags enabled (see Parser.EnableWindowsFlags()), flags
+may also be given with a slash, and their arguments following a colon, as
+in "/v" or "/name:x". Only defined flags are recognized this way, so that
+paths like "/tmp" remain positionals.
 
 
 # Flag Processing
@@ -76,9 +192,15 @@ All flags, except those belonging boolean fields, require an argument.
 If a flag takes an argument, the argument may normally either be separated
 from the flag by whitespace (eg. "-c 9" or "--counter 9"") or follow the
 flag without whitespace (eg. "-c9" or "--counter=9"). Note that long flags
-names require an additional equality sign in the latter case. If a flag does
-not appear in the slice of tokens, its corresponding field will be set to the
-value defined by the arg-default tag, or to the null value of its type.
+names require an additional equality sign in the latter case. Short flags
+may also be followed by an equality sign, so that "-c=9" is the same as
+"-c9". If a flag does not appear in the slice of tokens, its corresponding
+field will be set to the value defined by the arg-default tag, or to the
+null value of its type.
+
+Boolean flags set their field to true. A value may be given explicitly,
+following an equality sign (eg. "--color=false" or "-b=no"); the values
+true, yes, on, 1 and false, no, off, 0 are accepted, regardless of case.
 
 There is an alternative, "fused" mode of processing tokens. In fused mode,
 a flag's argument must be fused to the flag without intervening whitespace
@@ -90,7 +212,10 @@ present, the field is set to that value.
 
 The special token "--" indicates that all following command-line
 arguments should be treated as positionals. If more than one "--"
-is present, the left-most one prevails.
+is present, the left-most one prevails, and later ones are kept as
+positionals. Additional terminators may be registered with
+Parser.AddTerminator(), as in "wrap -v ::: prog -- file"; again, only the
+left-most terminator is special.
 
 Short flags (like "-a -b -c") may be combined into compound flags
 (like "-abc") on the command-line. All flags, except the last one,
@@ -99,26 +224,326 @@ as soon as a non-boolean flag is encountered, processing stops, and the
 remaining characters are considered the argument to this non-boolean flag.
 
 
-# Slices, Repeated Arguments, and Trailing Positionals
+# Assignments
 
-If a struct field is a slice of one of the permitted data types,
-the corresponding flag may be repeated on the command line. In this
-case, each occurrence appends the supplied value to the slice.
+If a field of type map[string]string is tagged arg-assign, command-line
+tokens of the form NAME=value (as in "make target DEBUG=1 PREFIX=/opt")
+are not treated as positionals, but are stored in this field instead,
+with NAME as key. NAME must consist of letters, digits, and underscores,
+and may not begin with a digit. Tokens following "--" are always treated
+as positionals.
+
+If a field of type []string is tagged arg-rest, all tokens following the
+"--" token are stored in this field, verbatim, rather than being treated
+as positionals (as in "mytool run -- cmd -v args").
+
+
+# Nested Structs
+
+A field of struct type, tagged arg-prefix, contributes the options of the
+nested struct to the enclosing struct, with their long flags prefixed by
+the tag's value:
 
-For example, to allow repeated use of the "-v" to indicate increased
-verbosity level, use the following idiom:
+    type Config struct {
+        Primary DBConfig `arg-prefix:"db"`      // --db-host, --db-port
+        Replica DBConfig `arg-prefix:"replica"` // --replica-host, ...
+    }
+
+Short flags can not be prefixed, and are dropped. Nested structs may not
+contain positional fields, subcommands, or assignments. Runtime defaults
+refer to nested fields by their path (eg. "Primary.Port").
+
+
+# Embedded Structs
+
+Anonymous (embedded) struct fields are flattened: their options and
+positional fields are treated as if they were declared in the enclosing
+struct, so that a common set of flags can be shared between structs.
+Embedded fields keep their own names, as for promoted fields in Go.
 
-    import "github.com/janert/cleanarg"
+
+# Subcommands
+
+A field tagged arg-command defines a subcommand (as in "git clone"). The
+field must be a struct, or a pointer to a struct, which is populated from
+the tokens following the subcommand name:
 
     type Config struct {
-        VerbosityFlags []bool `arg-flag:"-v"`
-    	VerbosityLevel int    `arg-ignore:""`
+        Verbose bool   `arg-flag:"-v"`
+        Clone   *Clone `arg-command:"clone" arg-help:"Clone a repository"`
+        Push    *Push  `arg-command:"push"`
     }
 
+The first token that is neither a flag nor the argument of a flag selects
+the subcommand; the preceding tokens populate the top-level struct. Pointer
+fields are only allocated when their subcommand is selected, so that the
+selected subcommand can be identified by checking for nil. If the first
+positional token does not name a subcommand, an error is returned (which
+lists the available subcommands, and suggests the closest ones), unless
+the top-level struct has positional fields of its own. Runtime defaults
+apply only to the top-level struct. Subcommands may have subcommands of
+their own, to any depth; help messages show the path of the selected
+commands, and usage messages list the subcommands of each subcommand.
+
+Options tagged arg-persistent are accepted after the subcommand name as
+well (as in "tool clone --verbose url"), unless the subcommand defines the
+same flag, and populate the struct that defines them. Compound flags may
+mix persistent flags with flags of the subcommand (as in "-vq").
+
+The arg-command tag may list aliases after the name (as in
+arg-command:"clone cl"). One subcommand may be tagged arg-default-command:
+it is selected, and receives the tokens from the first positional token
+on, if that token does not name a subcommand (or if there is none). A
+default subcommand is not permitted with top-level positional fields.
+
+ParsePartial() populates a struct from the leading tokens only, up to the
+first token that is not one of its flags (or a subcommand name), and
+returns the remaining tokens, so that they can be passed on or dispatched
+manually.
+
+Subcommand structs may implement Runner (Run(ctx context.Context) error).
+Execute() populates a struct from the command line, with automatic help
+(exiting with code 0 if help is requested), and invokes the Run method of
+the innermost selected subcommand that implements Runner (or of the
+struct itself); Parser.Execute() does the same for a slice of tokens.
+
+FromCommandLineMultiCall() and Parser.ParseMultiCall() support multi-call
+binaries (as busybox): if the base name of the program (os.Args[0])
+names a subcommand, that subcommand is selected, and populated from the
+remaining arguments.
+
+
+# Parsers and Runtime Defaults
+
+The Parser type populates structs just like FromSlice() and
+FromCommandLine(), but can carry configuration that applies to all
+structs parsed with it. The zero value (or NewParser()) behaves exactly
+like FromSlice(). NewParser() accepts options (such as WithStrict(),
+WithHelp(), or WithErrorWriter()), which configure the parser just like
+its methods do:
+
+    p := cleanarg.NewParser(cleanarg.WithStrict(), cleanarg.WithHelp())
+
+Default values that are only known at runtime can be set using
+Parser.SetDefault(). The value replaces the field's arg-default tag, and
+may be given either as a string (interpreted like an arg-default tag),
+or as a value of the field's type:
+
+    p := cleanarg.NewParser()
+    p.SetDefault("Threads", runtime.NumCPU())
+
     c := Config{}
-    cleanarg.FromCommandLine(&c)
+    err := p.ParseCommandLine(&c)
+
+Functions registered with Parser.AddPreprocessor() transform the slice of
+tokens before it is parsed (to expand aliases, inject profiles, or rewrite
+legacy syntax, for example).
+
+Parser.EnableStickyDefaults() treats values already present in the struct
+as defaults: fields holding a non-zero value keep it (instead of receiving
+their arg-default value), unless they are mentioned on the command line,
+and slices are replaced (rather than appended to) by command-line values.
+
+Parser.EnableHelp() turns on automatic help handling: if the command line
+contains -h or --help, the usage message is written to standard error, and
+parsing stops with ErrHelp. FromCommandLineWithHelp() is a shortcut for
+this case. With subcommands, "tool clone --help" and "tool help clone"
+both write the usage message of the subcommand.
+
+Parser.SetVersion() makes the parser handle --version in the same way: the
+version string is written to standard output, and parsing stops with
+ErrVersion. An empty version string is replaced by the version of the main
+module, from the build information embedded in the binary.
+
+Parser.SetEnvPrefix() (or WithEnvPrefix()) derives the environment
+variables of options without an arg-env tag from their field names: with
+prefix "MYAPP", MaxRetries is populated from MYAPP_MAX_RETRIES (unless
+tagged arg-env:"-"). Slices are exempt.
+
+
+# Configuration Files
+
+Parser.AddConfigFile() registers a configuration file (JSON, YAML, or TOML,
+depending on its extension), which populates the struct's options before
+the command line does. Keys are the long flags
+of the options (without the leading "--"), or their field names; nested
+objects (or tables) are flattened by joining their keys with "-". Keys of
+fields tagged arg-ignore are skipped. The command line
+takes precedence over environment variables, which take precedence over
+configuration files, which take precedence over default values.
+FromJSONFile() reads a JSON file and then the command line.
+
+An option tagged arg-config (eg. --config) names a configuration file on
+the command line. The command line is parsed twice: the first pass finds
+the file, which is read after the registered ones, and the second pass
+applies the command line on top of the file's values.
+
+Parser.AddUserConfigFile() (or WithUserConfigFile()) registers the
+configuration files of the given name that exist in the application's
+conventional configuration directories ($XDG_CONFIG_DIRS and
+$XDG_CONFIG_HOME, ~/Library/Application Support, or %APPDATA%; see
+ConfigFilePaths()), with the user's file taking precedence.
+
+WriteConfigTemplate() writes a configuration file template for a struct
+(JSON, YAML, or TOML), with the options' default values, and their help
+texts as comments (except in JSON).
+
+
+# Parse Reports
+
+Parser.ParseWithReport() and FromSliceWithReport() return a ParseReport,
+which tells which fields were set from the command line (as opposed to
+having their default values), how often, using which flags, and at which
+token positions. It also records where each field's final value came from
+(the command line, an environment variable, a configuration file, or a
+default value): see ParseReport.Provenance() and PrintValuesWithReport().
+
+
+# Validation
 
-    c.VerbosityLevel = len(c.VerbosityFlags)
+If the struct (or the type of a field) implements Validator, its Validate()
+method is called after the struct is populated (or on every value converted
+for the field, respectively). Errors it returns are reported as ParseErrors
+of kind ErrValidation.
+
+
+# Errors
+
+Errors caused by the command line are of type *ParseError (possibly
+wrapped), which carries the field name, the flag, the offending token,
+and its index. Use errors.Is() with one of the Err... values (such as
+ErrUnknownFlag or ErrConversion) to test for a kind of error. Errors
+caused by the struct definition are returned as plain errors.
+
+MustFromCommandLine() writes the error and the short usage message to
+standard error, and exits with code 2, if parsing fails. A Parser's
+error handling (ContinueOnError, ExitOnError, or PanicOnError) is set
+with Parser.SetErrorHandling(), its exit code with Parser.SetExitCode();
+Parser.MustParse() and Parser.MustParseCommandLine() always exit on
+errors.
+
+
+# Interactive Use
+
+ParseLines() reads lines from an io.Reader, splits each line into tokens
+following the quoting rules of the shell, and parses the tokens into a
+fresh struct, which is passed to a callback (together with the parse
+error, if any). This is useful for interactive shells and debug consoles.
+
+FromReader() reads a single argument list from an io.Reader (tokens are
+separated by whitespace and newlines), for xargs-style pipelines. With
+Parser.EnableStdinArgs(), the token "-@" stands for the tokens read from
+standard input.
+
+A bool option tagged arg-confirm (such as --yes) confirms a destructive
+action. With Parser.EnableConfirm(), an option that is not supplied is
+asked for interactively: the tag's text is written to standard error as a
+y/N prompt, and an answer of "y" or "yes" sets the field to true; other
+answers fail with ErrNotConfirmed.
+
+
+# Usage Messages
+
+PrintUsage() and PrintShortUsage() list the options in the sorted order of
+their flags; UsageString() and ShortUsageString() return the messages as
+strings instead. The short usage line begins with the program name (as in
+"usage: mytool [-b] [-s string]"), which is the base name of os.Args[0],
+unless the Program of UsageOptions is set. WriteUsageWith() and
+WriteShortUsageWith() take UsageOptions that tune the messages: with
+SortOrder SortByDeclaration, options are listed in the order in which
+their fields are declared. Further fields select the grouping of options
+(GroupBy), the width to which help texts are wrapped (Columns), and
+whether default values, environment variables, and hidden options are
+shown (ShowDefaults, ShowEnv, ShowHidden). The Synopsis and Prologue of
+UsageOptions are shown before the options, the Examples and the Epilogue
+after them. Options tagged arg-required are marked as "(required)", and
+are not enclosed in brackets in the short usage line. A Parser with
+automatic help uses the options set with Parser.SetUsageOptions().
+
+
+# Translated Messages
+
+SetMessages() sets translations of the messages that users of a program
+see: parse errors, errors in input values, and the headings and hints of
+usage messages and man pages. Messages are keyed on their English text
+(the format string, for messages with values, as in "flag %s is
+required"); an empty translation restores the English message. Errors in
+the struct definition are not translated.
+
+
+# Rendering Command Lines
+
+ToSlice() is the inverse of FromSlice(): it returns the tokens that
+reproduce a populated struct (omitting options that hold their default
+value). ToShellString() returns them as a single string, quoted for the
+shell, with the values of secret fields (arg-secret) redacted.
+
+
+# Shell Completion
+
+WriteBashCompletion() writes a bash completion script for a struct. The
+script completes flags, and the arguments of flags with an arg-choices
+tag; other arguments are completed as file names. WriteZshCompletion() and
+WriteFishCompletion() write scripts for zsh and fish, which also describe
+each flag by its help text, and complete only string arguments as file
+names. WritePowerShellCompletion() writes a script for PowerShell, which
+completes flags and choices (arg-choices). Complete() implements
+the same logic at runtime: given the tokens typed so far, it returns the
+candidates for the last one.
+
+
+# Man Pages
+
+WriteManPage() writes a man page (in roff format) for a struct, using the
+options' flags, help texts, default values, and environment variables. The
+program's name, manual section, and description are given by a
+ProgramInfo.
+
+# Code Generation
+
+The cleanarg-gen tool generates a function that populates a struct just
+like FromSlice(), but without reflection:
+
+	//go:generate go run github.com/janert/cleanarg/cmd/cleanarg-gen -type Config
+
+Only structs that do not use environment variables, configuration files,
+subcommands, groups, assignments, nested structs, or numeric formats are
+supported.
+
+
+# Slices, Repeated Arguments, and Trailing Positionals
+
+If a struct field is a slice of one of the permitted data types,
+the corresponding flag may be repeated on the command line. In this
+case, each occurrence appends the supplied value to the slice.
+If the field is tagged with a separator (as in arg-sep:","), each value
+is split at the separator, and each part is appended ("--tags a,b,c");
+a backslash escapes a separator that is part of a value ("a\,b").
+The arg-default value of a slice may hold several elements, separated by
+its separator, or else by commas (as in arg-default:"a,b,c"); values from
+the command line replace these default elements.
+A slice tagged with a reset value (as in arg-reset:"none") is emptied by
+that value, so that users can remove default elements (or elements from a
+configuration file) from the command line.
+Slices tagged arg-unique hold no duplicate elements, and slices tagged
+arg-sorted are sorted, once all of their values have been collected.
+
+To count the occurrences of a flag instead (as in "-v -v" or "-vvv", to
+indicate increased verbosity level), tag an int field with arg-count. Each
+occurrence of one of its flags increments the field, which starts out at
+its arg-default value (or zero):
+
+    type Config struct {
+        Verbosity int `arg-flag:"-v --verbose" arg-count:""`
+    }
+
+Repeating a flag is permitted by default (for non-slice options, the last
+value takes effect), unless the option is tagged arg-once, or the number
+of occurrences exceeds its arg-max-count.
+Parser.SetDuplicatePolicy() changes the behavior for all non-slice
+options: the first occurrence may take effect instead of the last one
+(DuplicateFirstWins), or repetitions may be reported as errors
+(DuplicateError).
 
 At most one positional argument may be a slice. In this case,
 all command-line tokens that cannot be assigned unambiguously to
@@ -131,5 +556,14 @@ Command-line tokens will be assigned to the non-slice fields
 before and after the slice first, starting from the beginning
 or the end of the command line, respectively. Any remaining
 tokens in the middle will be assigned to the slice.
+
+Alternatively, trailing non-slice positional fields may be tagged
+arg-optional: if their tokens are missing, they receive their arg-default
+value (if any), rather than causing an error.
+
+Positionals may be split into sections by literal separator tokens (as in
+"cmd in1 in2 :: out1 out2"): a positional field tagged arg-section starts
+a new section, whose tokens follow the separator given in the tag. Each
+section may contain a slice of its own.
 */
 package cleanarg
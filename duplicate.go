@@ -0,0 +1,56 @@
+package cleanarg
+
+// DuplicatePolicy determines how a Parser treats flags of non-slice options
+// that are given more than once on the command line.
+type DuplicatePolicy int
+
+const (
+	// DuplicateLastWins uses the value of the last occurrence (the default)
+	DuplicateLastWins DuplicatePolicy = iota
+
+	// DuplicateFirstWins uses the value of the first occurrence, and
+	// ignores all later ones
+	DuplicateFirstWins
+
+	// DuplicateError reports a repeated flag as an error (like arg-once)
+	DuplicateError
+)
+
+// SetDuplicatePolicy sets the policy for flags of non-slice options
+// (including booleans and fixed-value flags, but not counters) that are
+// given more than once: the last occurrence takes effect (the default), the
+// first one does, or the repetition is reported as an error. Options tagged
+// arg-once always report repetitions as errors.
+func (p *Parser) SetDuplicatePolicy(policy DuplicatePolicy) {
+	p.duplicates = policy
+}
+
+// ApplyDuplicatePolicy takes a policy and the options that were supplied
+// on the command line, in order, and returns the options that take effect:
+// with DuplicateFirstWins, later occurrences of non-slice options are
+// removed; with DuplicateError, non-slice options are restricted to a
+// single occurrence, which is checked by validateRepeats().
+func applyDuplicatePolicy(policy DuplicatePolicy,
+	supplied []fieldInfo) []fieldInfo {
+
+	if policy == DuplicateLastWins {
+		return supplied
+	}
+
+	out, seen := []fieldInfo{}, map[string]bool{}
+	for _, info := range supplied {
+		isScalar := !info.isSlice && !info.isCount
+
+		switch {
+		case isScalar && policy == DuplicateFirstWins && seen[info.Name]:
+			continue
+		case isScalar && policy == DuplicateError:
+			info.maxCount = 1
+		}
+
+		seen[info.Name] = true
+		out = append(out, info)
+	}
+
+	return out
+}
@@ -0,0 +1,76 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"reflect"
+)
+
+type duplicateArgs struct {
+	Output  string   `arg-flag:"-o"`
+	Level   int      `arg-flag:"-l" arg-const:"-q=0"`
+	Verbose bool     `arg-flag:"-v"`
+	Tags    []string `arg-flag:"-t"`
+	Count   int      `arg-flag:"-c" arg-count:""`
+}
+
+func Test_ParserDuplicatePolicy(t *testing.T) {
+	slice := []string{"-o", "a", "-l", "2", "-t", "x", "-cc", "-q",
+		"-o", "b", "-t", "y"}
+
+	tests := []struct {
+		policy DuplicatePolicy
+		want   duplicateArgs
+	}{
+		{DuplicateLastWins, duplicateArgs{"b", 0, false,
+			[]string{"x", "y"}, 2}},
+		{DuplicateFirstWins, duplicateArgs{"a", 2, false,
+			[]string{"x", "y"}, 2}},
+	}
+
+	for _, test := range tests {
+		p := NewParser(WithDuplicatePolicy(test.policy))
+
+		a := duplicateArgs{}
+		if err := p.Parse(slice, &a); err != nil {
+			t.Errorf("%d: Unexpected error: %v", test.policy, err)
+			continue
+		}
+		if !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%d: got=%v want=%v", test.policy, a, test.want)
+		}
+	}
+
+	// With DuplicateError, only slices and counters may be repeated
+	p := NewParser(WithDuplicatePolicy(DuplicateError))
+	errs := map[string][]string{
+		"flag -q may be given only once": {"-l", "1", "-q"},
+		"flag -v may be given only once": {"-v", "-v"},
+		"":                               {"-t", "x", "-t", "y", "-cc", "-o", "a"},
+	}
+	for want, slice := range errs {
+		err := p.Parse(slice, &duplicateArgs{})
+		if want == "" && err != nil {
+			t.Errorf("%v: Unexpected error: %v", slice, err)
+		}
+		if want != "" && (!errors.Is(err, ErrRepeated) || err.Error() != want) {
+			t.Errorf("%v: got=%v want=%s", slice, err, want)
+		}
+	}
+}
+
+func Test_ParserDuplicatePolicyReport(t *testing.T) {
+	// The report covers all occurrences, even those that are ignored
+	p := NewParser(WithDuplicatePolicy(DuplicateFirstWins))
+	a := duplicateArgs{}
+	r, err := p.ParseWithReport([]string{"-o", "a", "-v", "-o", "b"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f := r.Fields["Output"]
+	if a.Output != "a" || f == nil || f.Count != 2 ||
+		!reflect.DeepEqual(f.Positions, []int{0, 3}) || f.Origin != "-o" {
+		t.Errorf("got=%q %+v", a.Output, f)
+	}
+}
@@ -0,0 +1,91 @@
+package cleanarg
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+// The type of []byte fields, which are supported natively (rather than as
+// slices of bytes).
+var typeBytes = reflect.TypeOf([]byte{})
+
+// Encodings of []byte values (arg-encoding).
+const (
+	encodingHex       = "hex"
+	encodingBase64    = "base64"
+	encodingBase64URL = "base64url"
+)
+
+// CheckEncodingTag takes a fieldInfo, and checks its encoding (arg-encoding),
+// if any: it is only permitted on []byte fields, and must be one of "hex",
+// "base64", or "base64url". Returns an error if it is not.
+func checkEncodingTag(info fieldInfo) error {
+	if info.encoding == "" {
+		return nil
+	}
+
+	if info.baseType != typeBytes {
+		return fmt.Errorf("%s requires []byte field: %s", tagEncoding, info.Name)
+	}
+
+	switch info.encoding {
+	case encodingHex, encodingBase64, encodingBase64URL:
+		return nil
+	}
+	return fmt.Errorf("malformed %s: %s", tagEncoding, info.Name)
+}
+
+// DecodeBytes takes a fieldInfo of a []byte field, and the value to
+// convert, and decodes the value according to the field's encoding
+// (arg-encoding). Without encoding, the bytes of the value are used as is.
+// Base64 values may be given with or without padding.
+// Returns an error if the value is not validly encoded (redacted for
+// arg-secret).
+func decodeBytes(info fieldInfo, value string) ([]byte, error) {
+	var b []byte
+	var err error
+
+	switch info.encoding {
+	case encodingHex:
+		b, err = hex.DecodeString(value)
+	case encodingBase64:
+		b, err = decodeBase64(base64.StdEncoding, value)
+	case encodingBase64URL:
+		b, err = decodeBase64(base64.URLEncoding, value)
+	default:
+		return []byte(value), nil
+	}
+
+	if err != nil {
+		err = errorf("invalid %s value for %s: %v", info.encoding,
+			info.Name, err)
+		return nil, redactError(info, err)
+	}
+	return b, nil
+}
+
+// DecodeBase64 decodes a value with the given (padded) encoding, or with
+// its unpadded variant, if the value has no padding.
+func decodeBase64(enc *base64.Encoding, value string) ([]byte, error) {
+	if len(value)%4 != 0 {
+		return enc.WithPadding(base64.NoPadding).DecodeString(value)
+	}
+	return enc.DecodeString(value)
+}
+
+// EncodeBytes is the inverse of decodeBytes: it takes a fieldInfo of a
+// []byte field and a value, and encodes the value according to the field's
+// encoding (with padding, for base64).
+func encodeBytes(info fieldInfo, b []byte) string {
+	switch info.encoding {
+	case encodingHex:
+		return hex.EncodeToString(b)
+	case encodingBase64:
+		return base64.StdEncoding.EncodeToString(b)
+	case encodingBase64URL:
+		return base64.URLEncoding.EncodeToString(b)
+	}
+	return string(b)
+}
@@ -0,0 +1,107 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"reflect"
+	"strings"
+)
+
+func Test_FromSliceBytes(t *testing.T) {
+	type bytesArgs struct {
+		Key   []byte   `arg-flag:"-k" arg-encoding:"hex"`
+		Salt  []byte   `arg-flag:"-s" arg-encoding:"base64" arg-default:"AAEC"`
+		Token []byte   `arg-flag:"-t" arg-encoding:"base64url" arg-secret:""`
+		Raw   []byte   `arg-flag:"-r"`
+		Keys  [][]byte `arg-flag:"-K" arg-encoding:"hex"`
+	}
+
+	tests := []struct {
+		slice   []string
+		want    bytesArgs
+		wantErr string
+	}{
+		{[]string{}, bytesArgs{Salt: []byte{0, 1, 2}}, ""},
+		{[]string{"-k", "DEADbeef", "-s", "aGk=", "-t", "-_8", "-r", "abc",
+			"-K", "01", "-K", "0203"}, bytesArgs{
+			Key:   []byte{0xde, 0xad, 0xbe, 0xef},
+			Salt:  []byte("hi"),
+			Token: []byte{0xfb, 0xff},
+			Raw:   []byte("abc"),
+			Keys:  [][]byte{{1}, {2, 3}},
+		}, ""},
+		{[]string{"-s", "aGk"}, bytesArgs{Salt: []byte("hi")}, ""},
+		{[]string{"-k", "abc"}, bytesArgs{},
+			"invalid hex value for Key: encoding/hex: odd length hex string"},
+		{[]string{"-k", "xy"}, bytesArgs{},
+			"invalid hex value for Key: encoding/hex: invalid byte: U+0078 'x'"},
+		{[]string{"-s", "a$=="}, bytesArgs{},
+			"invalid base64 value for Salt: illegal base64 data at input byte 1"},
+		{[]string{"-t", "sec$ret"}, bytesArgs{},
+			"invalid value for Token (value redacted)"},
+	}
+
+	for _, test := range tests {
+		a := bytesArgs{}
+
+		err := FromSlice(test.slice, &a)
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+		if test.wantErr != "" && !errors.Is(err, ErrConversion) {
+			t.Errorf("%v: got=%v want ErrConversion", test.slice, err)
+		}
+		if err == nil && !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.slice, a, test.want)
+		}
+	}
+}
+
+func Test_checkEncodingTag(t *testing.T) {
+	type stringEncoding struct {
+		S string `arg-flag:"-s" arg-encoding:"hex"`
+	}
+	type badEncoding struct {
+		B []byte `arg-flag:"-b" arg-encoding:"base32"`
+	}
+
+	tests := []struct {
+		data    any
+		wantErr string
+	}{
+		{&stringEncoding{}, "arg-encoding requires []byte field: S"},
+		{&badEncoding{}, "malformed arg-encoding: B"},
+	}
+
+	for _, test := range tests {
+		err := FromSlice([]string{}, test.data)
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("%T: got=%v want=%s", test.data, err, test.wantErr)
+		}
+	}
+}
+
+func Test_encodeBytes(t *testing.T) {
+	b := []byte{0xfb, 0xff, 0x00}
+
+	for _, enc := range []string{"", "hex", "base64", "base64url"} {
+		info := fieldInfo{baseType: typeBytes, encoding: enc}
+		s, err := formatValue(info, b)
+		if err != nil {
+			t.Errorf("%q: Unexpected error: %v", enc, err)
+		}
+		got, err := decodeBytes(info, s)
+		if err != nil || !reflect.DeepEqual(got, b) {
+			t.Errorf("%q: got=%v, %v want=%v", enc, got, err, b)
+		}
+	}
+
+	info := fieldInfo{baseType: typeBytes, encoding: "hex"}
+	if s := formatHint(info); !strings.Contains(s, "hex") {
+		t.Errorf("got hint=%q", s)
+	}
+}
@@ -0,0 +1,61 @@
+package cleanarg
+
+import (
+	"strings"
+)
+
+// Value of the arg-env tag that exempts an option from the environment
+// variables derived with an environment prefix
+const noEnvName = "-"
+
+// SetEnvPrefix sets a prefix for environment variables: every option that
+// has no arg-env tag is populated from the environment variable named by
+// the prefix and the field name, in upper case, with words separated by
+// underscores (as in MYAPP_MAX_RETRIES for prefix "MYAPP" and field
+// MaxRetries; fields of nested structs, as in MYAPP_SERVER_HOST). An
+// arg-env tag names the variable explicitly, and arg-env:"-" exempts the
+// option. Slices, which can not be set from the environment, are exempt
+// as well. An empty prefix turns off the derived variables.
+func (p *Parser) SetEnvPrefix(prefix string) {
+	p.envPrefix = strings.TrimSuffix(prefix, "_")
+}
+
+// WithEnvPrefix sets a prefix for environment variables derived from field
+// names (see Parser.SetEnvPrefix()).
+func WithEnvPrefix(prefix string) Option {
+	return func(p *Parser) { p.SetEnvPrefix(prefix) }
+}
+
+// WithEnvPrefix takes a map of options, as returned by analyzeStruct, and
+// an environment prefix, and returns the options, with the environment
+// variable derived from the prefix and the field name (see envName()) set
+// for each option that has none, and is neither exempt (arg-env:"-") nor a
+// slice. Returns the options unchanged if the prefix is empty.
+func withEnvPrefix(options map[string]fieldInfo,
+	prefix string) map[string]fieldInfo {
+
+	if prefix == "" {
+		return options
+	}
+
+	out := copyOptions(options)
+	for k, info := range out {
+		if info.env == "" && !info.noEnv && !info.isSlice {
+			info.env = envName(prefix, info.Name)
+			out[k] = info
+		}
+	}
+	return out
+}
+
+// EnvName returns the name of the environment variable for the field with
+// the given name (which may be the path of a field in a nested struct, as
+// in Server.Port): the prefix, followed by the words of the name, all in
+// upper case, and separated by underscores (as in MYAPP_SERVER_PORT).
+func envName(prefix, name string) string {
+	words := []string{prefix}
+	for _, part := range strings.Split(name, ".") {
+		words = append(words, strings.Split(kebabCase(part), "-")...)
+	}
+	return strings.ToUpper(strings.Join(words, "_"))
+}
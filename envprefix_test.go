@@ -0,0 +1,104 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"bytes"
+	"strings"
+)
+
+type envServer struct {
+	Host string `arg-flag:"--host"`
+}
+
+type envPrefixArgs struct {
+	MaxRetries int       `arg-flag:"--retries"`
+	Verbose    bool      `arg-flag:"-v"`
+	Token      string    `arg-flag:"--token" arg-env:"API_TOKEN"`
+	Secret     string    `arg-flag:"--secret" arg-env:"-"`
+	Tags       []string  `arg-flag:"-t"`
+	Server     envServer `arg-prefix:"server"`
+	Name       string
+}
+
+func Test_envName(t *testing.T) {
+	tests := []struct {
+		prefix string
+		name   string
+		want   string
+	}{
+		{"MYAPP", "MaxRetries", "MYAPP_MAX_RETRIES"},
+		{"MYAPP", "Server.Host", "MYAPP_SERVER_HOST"},
+		{"app", "HTTPPort", "APP_HTTP_PORT"},
+	}
+
+	for _, test := range tests {
+		if got := envName(test.prefix, test.name); got != test.want {
+			t.Errorf("%s: got=%s want=%s", test.name, got, test.want)
+		}
+	}
+}
+
+func Test_ParseEnvPrefix(t *testing.T) {
+	t.Setenv("MYAPP_MAX_RETRIES", "5")
+	t.Setenv("MYAPP_VERBOSE", "true")
+	t.Setenv("MYAPP_TOKEN", "ignored")
+	t.Setenv("API_TOKEN", "tok")
+	t.Setenv("MYAPP_SECRET", "ignored")
+	t.Setenv("MYAPP_TAGS", "ignored")
+	t.Setenv("MYAPP_SERVER_HOST", "example.com")
+	t.Setenv("MYAPP_NAME", "ignored")
+
+	a := envPrefixArgs{}
+	err := NewParser(WithEnvPrefix("MYAPP_")).Parse([]string{"x"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a.MaxRetries != 5 || !a.Verbose || a.Token != "tok" || a.Secret != "" ||
+		len(a.Tags) != 0 || a.Server.Host != "example.com" || a.Name != "x" {
+
+		t.Errorf("Unexpected result: %+v", a)
+	}
+
+	// The command line takes precedence
+	a = envPrefixArgs{}
+	err = NewParser(WithEnvPrefix("MYAPP")).Parse(
+		[]string{"--retries", "2", "x"}, &a)
+	if err != nil || a.MaxRetries != 2 {
+		t.Errorf("Unexpected result: %v, %+v", err, a)
+	}
+
+	// Without a prefix, only arg-env applies
+	a = envPrefixArgs{}
+	if err := NewParser().Parse([]string{"x"}, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a.MaxRetries != 0 || a.Verbose || a.Token != "tok" {
+		t.Errorf("Unexpected result: %+v", a)
+	}
+
+	t.Setenv("MYAPP_MAX_RETRIES", "many")
+	err = NewParser(WithEnvPrefix("MYAPP")).Parse([]string{"x"},
+		&envPrefixArgs{})
+	if err == nil || !strings.Contains(err.Error(), "MYAPP_MAX_RETRIES") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func Test_WriteHelpEnvPrefix(t *testing.T) {
+	var stderr bytes.Buffer
+	p := NewParser(WithHelp(), WithErrorWriter(&stderr),
+		WithEnvPrefix("MYAPP"))
+	p.Parse([]string{"-h"}, &envPrefixArgs{})
+
+	for _, want := range []string{"(env: MYAPP_MAX_RETRIES)",
+		"(env: API_TOKEN)", "(env: MYAPP_SERVER_HOST)"} {
+
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("missing %q in:\n%s", want, stderr.String())
+		}
+	}
+	if strings.Contains(stderr.String(), "MYAPP_SECRET") {
+		t.Errorf("Unexpected MYAPP_SECRET in:\n%s", stderr.String())
+	}
+}
@@ -0,0 +1,112 @@
+package cleanarg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrorHandling determines what the parse methods of a Parser do if parsing
+// fails, like the ErrorHandling of the flag package.
+type ErrorHandling int
+
+const (
+	// ContinueOnError returns the error to the caller (the default)
+	ContinueOnError ErrorHandling = iota
+
+	// ExitOnError writes the error and the short usage message to the
+	// error writer, and exits the program with the Parser's exit code (or
+	// with code 0, for ErrHelp and ErrVersion)
+	ExitOnError
+
+	// PanicOnError panics with the error
+	PanicOnError
+)
+
+// The exit code of ExitOnError, unless set with SetExitCode().
+const defaultExitCode = 2
+
+// Exits the program, replaced in tests.
+var osExit = os.Exit
+
+// SetErrorHandling sets what the parse methods of the Parser (Parse(),
+// ParseCommandLine(), ParsePartial(), ParseReader(), and ParseWithReport())
+// do if parsing fails: return the error (ContinueOnError, the default),
+// write it together with the short usage message and exit the program
+// (ExitOnError), or panic (PanicOnError). Help and version requests (see
+// EnableHelp() and SetVersion()) exit with code 0 under ExitOnError, since
+// their output has already been written.
+func (p *Parser) SetErrorHandling(h ErrorHandling) {
+	p.onError = h
+}
+
+// SetExitCode sets the code with which the program exits if parsing fails
+// under ExitOnError (default: 2, as for the flag package).
+func (p *Parser) SetExitCode(code int) {
+	p.exitCode = code
+}
+
+// MustParse takes a slice of string tokens and a pointer to a struct, and
+// populates the struct from the tokens, just like Parse(), but handles
+// errors as with ExitOnError, whatever the Parser's error handling: the
+// error and the short usage message are written to the error writer, and
+// the program exits with the Parser's exit code.
+func (p *Parser) MustParse(tokens []string, data any) {
+	handleError(p, ExitOnError, populateFromSlice(tokens, data, p), data)
+}
+
+// MustParseCommandLine takes a pointer to a struct and populates the struct
+// with the command-line arguments, just like ParseCommandLine(), but
+// handles errors as with ExitOnError (see MustParse()).
+func (p *Parser) MustParseCommandLine(data any) {
+	p.MustParse(os.Args[1:], data)
+}
+
+// MustFromCommandLine takes a pointer to a struct and populates the struct
+// with the command-line arguments, with automatic help handling (see
+// FromCommandLineWithHelp()). If parsing fails, the error and the short
+// usage message are written to standard error, and the program exits with
+// code 2. This replaces the error handling in a typical main():
+//
+//	c := Config{}
+//	cleanarg.MustFromCommandLine(&c)
+func MustFromCommandLine(data any) {
+	NewParser(WithHelp()).MustParseCommandLine(data)
+}
+
+// HandleError takes a Parser, an error handling, the error returned by a
+// parse (or nil), and the pointer to the struct that was parsed, and
+// handles the error as described for SetErrorHandling(). Returns the
+// error, unless the program exits or panics.
+func handleError(p *Parser, h ErrorHandling, err error, data any) error {
+	if err == nil {
+		return nil
+	}
+
+	switch h {
+	case ExitOnError:
+		if errors.Is(err, ErrHelp) || errors.Is(err, ErrVersion) {
+			osExit(0)
+			return err
+		}
+
+		w := p.errorWriter()
+		fmt.Fprintf(w, "%s\n", err)
+
+		opts := p.usage
+		if opts.Program == "" {
+			opts.Program = p.program
+		}
+		writeShortUsage(w, data, opts)
+
+		code := p.exitCode
+		if code == 0 {
+			code = defaultExitCode
+		}
+		osExit(code)
+
+	case PanicOnError:
+		panic(err)
+	}
+	return err
+}
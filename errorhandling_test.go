@@ -0,0 +1,112 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+)
+
+type handlingArgs struct {
+	Count int `arg-flag:"-c"`
+}
+
+// exitCodes replaces osExit for the duration of the test, and records the
+// exit codes.
+func exitCodes(t *testing.T) *[]int {
+	codes := []int{}
+	osExit = func(code int) { codes = append(codes, code) }
+	t.Cleanup(func() { osExit = os.Exit })
+	return &codes
+}
+
+func Test_ErrorHandling(t *testing.T) {
+	const msg = "invalid value \"x\" for -c\n"
+
+	tests := []struct {
+		tokens  []string
+		opts    []Option
+		codes   []int
+		wantOut string
+	}{
+		{[]string{"-c", "1"}, nil, []int{}, ""},
+		{[]string{"-c", "x"}, nil, []int{}, ""}, // ContinueOnError
+		{[]string{"-c", "x"}, []Option{WithErrorHandling(ExitOnError)},
+			[]int{2}, msg + "usage: prog [-c int] \n"},
+		{[]string{"-c", "x"}, []Option{WithErrorHandling(ExitOnError),
+			WithExitCode(64)}, []int{64}, msg},
+		{[]string{"-h"}, []Option{WithErrorHandling(ExitOnError), WithHelp()},
+			[]int{0}, "Usage: prog [-c int] \n"},
+	}
+
+	for _, test := range tests {
+		codes := exitCodes(t)
+		var stderr bytes.Buffer
+		opts := append([]Option{WithErrorWriter(&stderr),
+			WithProgramName("prog")}, test.opts...)
+		p := NewParser(opts...)
+
+		p.Parse(test.tokens, &handlingArgs{})
+		if len(*codes) != len(test.codes) ||
+			(len(test.codes) > 0 && (*codes)[0] != test.codes[0]) {
+
+			t.Errorf("%v: codes=%v want=%v", test.tokens, *codes, test.codes)
+		}
+		if !strings.HasPrefix(stderr.String(), test.wantOut) {
+			t.Errorf("%v: got=%q want=%q", test.tokens, stderr.String(),
+				test.wantOut)
+		}
+	}
+}
+
+func Test_ErrorHandlingPanic(t *testing.T) {
+	p := NewParser(WithErrorHandling(PanicOnError))
+
+	defer func() {
+		r := recover()
+		if err, ok := r.(error); !ok || !errors.Is(err, ErrConversion) {
+			t.Errorf("Unexpected panic: %v", r)
+		}
+	}()
+	p.Parse([]string{"-c", "x"}, &handlingArgs{})
+	t.Errorf("Expected panic")
+}
+
+func Test_MustParse(t *testing.T) {
+	codes := exitCodes(t)
+	var stderr bytes.Buffer
+	p := NewParser(WithErrorWriter(&stderr),
+		WithUsageOptions(UsageOptions{Program: "tool"}))
+
+	data := handlingArgs{}
+	p.MustParse([]string{"-c", "3"}, &data)
+	if data.Count != 3 || len(*codes) != 0 {
+		t.Errorf("Unexpected result: %v, %v", data.Count, *codes)
+	}
+
+	p.MustParse([]string{"extra"}, &data)
+	want := "number of positional fields does not match number of tokens\n" +
+		"usage: tool [-c int] \n"
+	if len(*codes) != 1 || (*codes)[0] != 2 || stderr.String() != want {
+		t.Errorf("got=%v, %q want=%q", *codes, stderr.String(), want)
+	}
+}
+
+func Test_ErrorHandlingReport(t *testing.T) {
+	codes := exitCodes(t)
+	p := NewParser(WithErrorHandling(ExitOnError),
+		WithErrorWriter(&bytes.Buffer{}))
+
+	tokens := []string{"-c", "x"}
+	if _, err := p.ParseWithReport(tokens, &handlingArgs{}); err == nil {
+		t.Errorf("Expected error")
+	}
+	if _, err := p.ParsePartial(tokens, &handlingArgs{}); err == nil {
+		t.Errorf("Expected error")
+	}
+	if len(*codes) != 2 {
+		t.Errorf("codes=%v want two exits", *codes)
+	}
+}
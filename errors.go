@@ -0,0 +1,133 @@
+package cleanarg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of errors caused by the command line (rather than by the struct
+// definition). Use errors.Is() to test for a kind of error, and errors.As()
+// with a *ParseError to obtain the details.
+var (
+	ErrUnknownFlag        = errors.New("unknown flag")
+	ErrUnknownCommand     = errors.New("unknown command")
+	ErrMissingCommand     = errors.New("missing command")
+	ErrMissingValue       = errors.New("missing value")
+	ErrConversion         = errors.New("invalid value")
+	ErrInvalidChoice      = errors.New("value not permitted")
+	ErrOutOfRange         = errors.New("value out of range")
+	ErrPatternMismatch    = errors.New("value does not match pattern")
+	ErrTooFewPositionals  = errors.New("too few positional arguments")
+	ErrTooManyPositionals = errors.New("too many positional arguments")
+	ErrExclusive          = errors.New("mutually exclusive flags")
+	ErrRequired           = errors.New("required flag missing")
+	ErrRepeated           = errors.New("flag repeated too often")
+	ErrValidation         = errors.New("validation failed")
+	ErrNotConfirmed       = errors.New("not confirmed")
+)
+
+// ParseError describes an error caused by the command line, as opposed to
+// errors caused by an improper struct definition (such as malformed tags
+// or unsupported types), which are returned as plain errors. All errors
+// caused by the command line are of type *ParseError (possibly wrapped),
+// so that applications can tell user errors from programmer errors.
+type ParseError struct {
+	Kind  error  // The kind of error, one of the Err... values
+	Field string // Name of the struct field, if known
+	Flag  string // The flag, if known
+	Token string // The offending token or value (empty for arg-secret)
+	Index int    // Index of the offending token, or -1 if not known
+	Err   error  // The underlying error, if any
+
+	msg string
+}
+
+// Error returns the error message.
+func (e *ParseError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the kind of the error and the underlying error (if any),
+// so that errors.Is() and errors.As() can examine both.
+func (e *ParseError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// NewParseError returns a new ParseError of the given kind, for the given
+// token and its index (or -1), with a formatted message.
+func newParseError(kind error, token string, index int,
+	format string, args ...any) *ParseError {
+
+	return &ParseError{
+		Kind:  kind,
+		Token: token,
+		Index: index,
+		msg:   fmt.Sprintf(message(format), args...),
+	}
+}
+
+// ValueError takes the kind of error, a fieldInfo, and the error that
+// occurred when converting its value, and returns a ParseError that
+// describes the field, its flag, and the value (unless the field is tagged
+// arg-secret). The message of the error is redacted for secrets.
+func valueError(kind error, info fieldInfo, err error) *ParseError {
+	value := info.value
+	if value == "" {
+		value = info.defaultval
+	}
+	if info.secret {
+		value = ""
+	}
+
+	err = redactError(info, err)
+
+	return &ParseError{
+		Kind:  kind,
+		Field: info.Name,
+		Flag:  info.flag,
+		Token: value,
+		Index: info.index,
+		Err:   err,
+		msg:   err.Error(),
+	}
+}
+
+// MapIndex replaces the token index of the ParseError wrapped by err (if
+// any, and if its index is known) by the index it maps to (see
+// mappedIndex), and returns err. This is used to make indices of tokens
+// that were rearranged (see splitPersistent) refer to the tokens as given.
+// A nil map leaves the index alone.
+func mapIndex(err error, index []int) error {
+	var pe *ParseError
+	if index != nil && errors.As(err, &pe) && pe.Index >= 0 {
+		pe.Index = mappedIndex(pe.Index, index)
+	}
+	return err
+}
+
+// MappedIndex takes a token index and a map of token indices, and returns
+// the index the token index maps to. Indices past the end of the map
+// follow its last index (or start at 0, if the map is empty).
+func mappedIndex(k int, index []int) int {
+	switch {
+	case k < len(index):
+		return index[k]
+	case len(index) == 0:
+		return k
+	}
+	return index[len(index)-1] + k - len(index) + 1
+}
+
+// OffsetIndex adds the offset to the token index of the ParseError wrapped
+// by err (if any, and if its index is known), and returns err. This is used
+// to make indices of subcommand tokens relative to the entire command line.
+func offsetIndex(err error, offset int) error {
+	var pe *ParseError
+	if errors.As(err, &pe) && pe.Index >= 0 {
+		pe.Index += offset
+	}
+	return err
+}
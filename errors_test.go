@@ -0,0 +1,156 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"strconv"
+	"time"
+)
+
+func Test_ParseError(t *testing.T) {
+	type errorArgs struct {
+		Count int    `arg-flag:"-c --count"`
+		Quiet bool   `arg-flag:"-q"`
+		Mode  string `arg-flag:"-m" arg-choices:"a b"`
+		Key   int    `arg-flag:"-k" arg-secret:""`
+		File  string `arg-flag:"-f" arg-xor:"in"`
+		URL   string `arg-flag:"-u" arg-xor:"in"`
+		Size  int
+	}
+
+	tests := []struct {
+		slice []string
+		kind  error
+		field string
+		flag  string
+		token string
+		index int
+	}{
+		{[]string{"-c", "x", "1"}, ErrConversion, "Count", "-c", "x", 0},
+		{[]string{"1", "-q", "--count=y"}, ErrConversion, "Count", "--count", "y", 2},
+		{[]string{"-qcz", "1"}, ErrConversion, "Count", "-c", "z", 0},
+		{[]string{"1", "-c"}, ErrMissingValue, "Count", "-c", "-c", 1},
+		{[]string{"-qx", "1"}, ErrUnknownFlag, "", "", "-x", 0},
+		{[]string{"-m", "c", "1"}, ErrInvalidChoice, "Mode", "-m", "c", 0},
+		{[]string{"-k", "hunter2", "1"}, ErrConversion, "Key", "-k", "", 0},
+		{[]string{"-f", "a", "-u", "b", "1"}, ErrExclusive, "", "-u", "", -1},
+		{[]string{}, ErrTooFewPositionals, "", "", "", -1},
+		{[]string{"1", "2"}, ErrTooManyPositionals, "", "", "", -1},
+		{[]string{"x"}, ErrConversion, "Size", "", "x", -1},
+	}
+
+	for _, test := range tests {
+		err := FromSlice(test.slice, &errorArgs{})
+		if !errors.Is(err, test.kind) {
+			t.Errorf("%v: got=%v want=%v", test.slice, err, test.kind)
+			continue
+		}
+
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("%v: Expected ParseError, got: %T", test.slice, err)
+			continue
+		}
+		if pe.Field != test.field || pe.Flag != test.flag ||
+			pe.Token != test.token || pe.Index != test.index {
+			t.Errorf("%v: got=%q,%q,%q,%d want=%q,%q,%q,%d", test.slice,
+				pe.Field, pe.Flag, pe.Token, pe.Index,
+				test.field, test.flag, test.token, test.index)
+		}
+	}
+}
+
+func Test_ParseErrorConversion(t *testing.T) {
+	type conversionArgs struct {
+		Count int           `arg-flag:"-c --count"`
+		Ratio float64       `arg-flag:"-r"`
+		Since time.Time     `arg-flag:"--since"`
+		Wait  time.Duration `arg-flag:"-w"`
+		Key   int           `arg-flag:"-k" arg-secret:""`
+	}
+
+	tests := []struct {
+		slice   []string
+		wantErr string
+	}{
+		{[]string{"-c", "x"}, `invalid value "x" for --count`},
+		{[]string{"-r", "1/2"}, `invalid value "1/2" for -r`},
+		{[]string{"--since", "yesterday"},
+			`invalid value "yesterday" for --since`},
+		{[]string{"-w", "z"}, `invalid value "z" for -w`},
+		{[]string{"-k", "hunter2"}, "invalid value for Key (value redacted)"},
+	}
+
+	for _, test := range tests {
+		err := FromSlice(test.slice, &conversionArgs{})
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+		if !errors.Is(err, ErrConversion) {
+			t.Errorf("%v: got=%v want ErrConversion", test.slice, err)
+		}
+	}
+
+	// The error of the conversion is kept
+	var ne *strconv.NumError
+	err := FromSlice([]string{"-c", "x"}, &conversionArgs{})
+	if !errors.As(err, &ne) || ne.Num != "x" {
+		t.Errorf("Expected strconv.NumError, got: %v", err)
+	}
+
+	var te *time.ParseError
+	err = FromSlice([]string{"--since", "yesterday"}, &conversionArgs{})
+	if !errors.As(err, &te) || te.Value != "yesterday" {
+		t.Errorf("Expected time.ParseError, got: %v", err)
+	}
+}
+
+func Test_ParseErrorOther(t *testing.T) {
+	var pe *ParseError
+
+	// Errors in the struct definition are not ParseErrors
+	bad := []any{
+		&struct {
+			A int `arg-flag:"x"`
+		}{},
+		&struct {
+			A int `arg-flag:"-a" arg-default:"x"`
+		}{},
+		struct{}{},
+	}
+	for i, data := range bad {
+		if err := FromSlice([]string{}, data); err == nil || errors.As(err, &pe) {
+			t.Errorf("%d: Expected plain error, got: %v", i, err)
+		}
+	}
+
+	// Strict mode, subcommands: indices refer to the entire command line
+	p := NewParser()
+	p.EnableStrict()
+	err := p.Parse([]string{"-v", "clone", "--depth", "1", "--dpth"}, &commandArgs{})
+	if !errors.Is(err, ErrUnknownFlag) || !errors.As(err, &pe) ||
+		pe.Index != 4 || pe.Flag != "--dpth" {
+		t.Errorf("Strict: got=%v %+v", err, pe)
+	}
+
+	err = FromSlice([]string{"-C", "x", "pull"}, &commandArgs{})
+	if !errors.Is(err, ErrUnknownCommand) || !errors.As(err, &pe) ||
+		pe.Index != 2 || pe.Token != "pull" {
+		t.Errorf("Command: got=%v %+v", err, pe)
+	}
+
+	err = FromSlice([]string{"clone", "--depth", "x", "url"}, &commandArgs{})
+	if !errors.Is(err, ErrConversion) || !errors.As(err, &pe) || pe.Index != 1 {
+		t.Errorf("Subcommand: got=%v %+v", err, pe)
+	}
+
+	// Environment variables
+	t.Setenv("CLEANARG_COUNT", "x")
+	err = FromSlice([]string{}, &struct {
+		Count int `arg-flag:"-c" arg-env:"CLEANARG_COUNT"`
+	}{})
+	if !errors.Is(err, ErrConversion) {
+		t.Errorf("Environment: got=%v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package cleanarg
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The type of os.FileMode fields.
+var typeFileMode = reflect.TypeOf(os.FileMode(0))
+
+// ParseFileMode converts its argument, an octal number as accepted by
+// chmod (eg. "644", "0644", "0o755", or "4755"), to an os.FileMode. The
+// setuid, setgid, and sticky bits (04000, 02000, 01000) are mapped to the
+// corresponding os.FileMode bits.
+// Returns an error if the string is not an octal number, or if it is
+// larger than 07777.
+func parseFileMode(s string) (os.FileMode, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "0o"), "0O")
+	n, err := strconv.ParseUint(digits, 8, 32)
+	if err != nil {
+		return 0, errorf("invalid file mode (must be octal): %s", s)
+	}
+	if n > 07777 {
+		return 0, errorf("invalid file mode (at most 07777): %s", s)
+	}
+
+	mode := os.FileMode(n & 0777)
+	if n&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if n&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if n&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode, nil
+}
+
+// FormatFileMode is the inverse of parseFileMode: it returns the octal
+// representation of the permission bits of an os.FileMode (and of its
+// setuid, setgid, and sticky bits), with a leading zero, as in "0644".
+func formatFileMode(mode os.FileMode) string {
+	n := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		n |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		n |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		n |= 01000
+	}
+	return fmt.Sprintf("%04o", n)
+}
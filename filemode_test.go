@@ -0,0 +1,71 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"os"
+)
+
+func Test_parseFileMode(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{"644", 0644, false},
+		{"0644", 0644, false},
+		{"0o755", 0755, false},
+		{"0", 0, false},
+		{"4755", 0755 | os.ModeSetuid, false},
+		{"2775", 0775 | os.ModeSetgid, false},
+		{"1777", 0777 | os.ModeSticky, false},
+		{"0o7777", 0777 | os.ModeSetuid | os.ModeSetgid | os.ModeSticky, false},
+		{"0648", 0, true},
+		{"10000", 0, true},
+		{"rwxr-xr-x", 0, true},
+		{"-644", 0, true},
+		{"+644", 0, true},
+		{"0o", 0, true},
+		{"", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := parseFileMode(test.s)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: Unexpected error: %v", test.s, err)
+		}
+		if err == nil && got != test.want {
+			t.Errorf("%q: got=%v want=%v", test.s, got, test.want)
+		}
+		if err == nil {
+			if m, _ := parseFileMode(formatFileMode(got)); m != got {
+				t.Errorf("%q: round trip got=%v want=%v", test.s, m, got)
+			}
+		}
+	}
+}
+
+func Test_FromSliceFileMode(t *testing.T) {
+	type modeArgs struct {
+		Mode    os.FileMode `arg-flag:"-m --mode" arg-default:"0644"`
+		DirMode os.FileMode `arg-flag:"--dir-mode" arg-default:"755"`
+	}
+
+	a := modeArgs{}
+	if err := FromSlice([]string{"-m", "0600"}, &a); err != nil ||
+		a.Mode != 0600 || a.DirMode != 0755 {
+		t.Errorf("got=%v, %v", a, err)
+	}
+
+	err := FromSlice([]string{"--mode", "999"}, &modeArgs{})
+	if !errors.Is(err, ErrConversion) || err.Error() !=
+		"invalid file mode (must be octal): 999" {
+		t.Errorf("got=%v want ErrConversion", err)
+	}
+
+	info := fieldInfo{baseType: typeFileMode}
+	if s, err := formatValue(info, os.FileMode(0640)); err != nil || s != "0640" {
+		t.Errorf("got=%q, %v want=0640", s, err)
+	}
+}
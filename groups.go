@@ -0,0 +1,62 @@
+package cleanarg
+
+import (
+	"slices"
+	"sort"
+)
+
+// Headings of the detailed usage message, if options are grouped
+// (arg-group): options without group, positional fields, and subcommands;
+// and the heading of the examples (see UsageOptions).
+const (
+	optionsHeading   = "Options"
+	argumentsHeading = "Arguments"
+	commandsHeading  = "Commands"
+	examplesHeading  = "Examples"
+
+	environmentHeading = "Environment"
+)
+
+// UsageGroups takes a map of options, as built by analyzeStruct, and
+// returns the groups (arg-group) of the options shown in usage messages,
+// in a stable order: the empty group of options without arg-group first
+// (if there are any), followed by the named groups, in the order of their
+// first field.
+func usageGroups(options map[string]fieldInfo) []string {
+	first, ungrouped := map[string][]int{}, false
+	for _, info := range options {
+		if info.hidden || info.isAlias {
+			continue
+		}
+		if info.group == "" {
+			ungrouped = true
+			continue
+		}
+		idx, ok := first[info.group]
+		if !ok || slices.Compare(info.Index, idx) < 0 {
+			first[info.group] = info.Index
+		}
+	}
+
+	groups := []string{}
+	for g := range first {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return slices.Compare(first[groups[i]], first[groups[j]]) < 0
+	})
+
+	if ungrouped {
+		groups = append([]string{""}, groups...)
+	}
+	return groups
+}
+
+// GroupHeading returns the heading of a group of options in the detailed
+// usage message: the name of the group, or "Options" for the empty group.
+func groupHeading(group string) string {
+	if group == "" {
+		return message(optionsHeading)
+	}
+	return group
+}
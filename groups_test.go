@@ -0,0 +1,94 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+)
+
+type groupArgs struct {
+	Verbose bool   `arg-flag:"-v"`
+	Port    int    `arg-flag:"--port" arg-group:"Network"`
+	Color   bool   `arg-flag:"--color" arg-group:"Output" arg-negate:""`
+	Host    string `arg-flag:"--host" arg-group:"Network"`
+	Debug   bool   `arg-flag:"--debug" arg-group:"Output" arg-hidden:""`
+	Files   []string
+}
+
+func Test_usageGroups(t *testing.T) {
+	tests := []struct {
+		data any
+		want []string
+	}{
+		{&groupArgs{}, []string{"", "Network", "Output"}},
+		{&struct {
+			A bool `arg-flag:"-a" arg-group:"Z"`
+			B bool `arg-flag:"-b" arg-group:"Y"`
+		}{}, []string{"Z", "Y"}},
+		{&struct {
+			A bool `arg-flag:"-a"`
+		}{}, []string{""}},
+		{&struct{}{}, []string{}},
+	}
+
+	for _, test := range tests {
+		v, _ := unwrap(test.data)
+		options, _, err := analyzeStruct(v)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got := usageGroups(options); !slices.Equal(got, test.want) {
+			t.Errorf("%T: got=%q want=%q", test.data, got, test.want)
+		}
+	}
+}
+
+func Test_WriteUsageGroups(t *testing.T) {
+	sb := strings.Builder{}
+	if err := WriteUsage(&sb, &groupArgs{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := "Options:\n" +
+		"    -v \n" +
+		"Network:\n" +
+		"    --host [string]\n" +
+		"    --port [int]\n" +
+		"Output:\n" +
+		"    --color \n" +
+		"    --no-color \n" +
+		"       (sets value false)\n" +
+		"Arguments:\n" +
+		"    [string] (repeatable) Files\n"
+	if sb.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", sb.String(), want)
+	}
+
+	// Automatic flags are shown with the options without group
+	var stderr bytes.Buffer
+	p := NewParser(WithHelp(), WithErrorWriter(&stderr))
+	err := p.Parse([]string{"-h"}, &struct {
+		Port int       `arg-flag:"--port" arg-group:"Network"`
+		Cmd  *struct{} `arg-command:"run"`
+	}{})
+	if !errors.Is(err, ErrHelp) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(stderr.String(), "\nOptions:\n"+
+		"    -h --help \n       Show this help message\n"+
+		"Network:\n    --port [int]\n"+
+		"Commands:\n    run ...\n") {
+		t.Errorf("Unexpected help:\n%s", stderr.String())
+	}
+
+	// Only options may be grouped
+	err = FromSlice([]string{}, &struct {
+		Src string `arg-group:"Input"`
+	}{})
+	if err == nil || err.Error() != "arg-group requires arg-flag: Src" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
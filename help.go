@@ -0,0 +1,223 @@
+package cleanarg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"runtime/debug"
+	"strings"
+)
+
+// ErrHelp is returned by Parsers with automatic help handling enabled
+// (see Parser.EnableHelp()), if the command line contains -h or --help.
+// The usage message has already been written when ErrHelp is returned.
+var ErrHelp = errors.New("help requested")
+
+// ErrVersion is returned by Parsers with a version string (see
+// Parser.SetVersion()), if the command line contains --version. The version
+// string has already been written when ErrVersion is returned.
+var ErrVersion = errors.New("version requested")
+
+// Flags that request the usage message, if automatic help is enabled
+var helpFlags = map[string]struct{}{"-h": {}, "--help": {}}
+
+// Flag that requests the version string, if one has been set
+const versionFlag = "--version"
+
+// Pseudo-subcommand that requests the usage message of a subcommand (as in
+// "tool help clone"), if automatic help is enabled
+const helpCommand = "help"
+
+// EnableHelp turns on automatic help handling: if the command line contains
+// -h or --help (before "--", and before the name of a subcommand, if any),
+// the usage message of the struct (or of the selected subcommand) is
+// written to standard error, and parsing stops with ErrHelp. Flags -h and
+// --help that are defined by the struct itself take precedence. For structs
+// with subcommands, "help" in place of a subcommand name writes the usage
+// message of the subcommands named after it (as in "tool help remote add"),
+// unless one of the subcommands is named "help" itself.
+func (p *Parser) EnableHelp() {
+	p.help = true
+}
+
+// SetVersion sets the version string of the program, and turns on
+// automatic version handling: if the command line contains --version
+// (before "--", and before the name of a subcommand, if any), the version
+// string is written to standard output, and parsing stops with ErrVersion.
+// If the version string is empty, the version of the main module is taken
+// from the build information embedded in the binary (as set by "go install
+// module@version"). A --version flag defined by the struct itself takes
+// precedence.
+func (p *Parser) SetVersion(version string) {
+	if version == "" {
+		version = buildVersion()
+	}
+	p.version = version
+}
+
+// BuildVersion returns the version of the main module, as recorded in the
+// build information embedded in the binary, or "unknown" if there is none.
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "unknown"
+}
+
+// FromCommandLineWithHelp takes a pointer to a struct and populates the
+// struct with the command-line arguments, just like FromCommandLine(), but
+// with automatic help handling: if the command line contains -h or --help,
+// the usage message is written to standard error, and ErrHelp is returned.
+func FromCommandLineWithHelp(data any) error {
+	p := NewParser()
+	p.EnableHelp()
+	return p.ParseCommandLine(data)
+}
+
+// ErrorWriter returns the writer for usage and error messages.
+func (p *Parser) errorWriter() io.Writer {
+	if p.stderr == nil {
+		return os.Stderr
+	}
+	return p.stderr
+}
+
+// OutputWriter returns the writer for regular output (the version string).
+func (p *Parser) outputWriter() io.Writer {
+	if p.stdout == nil {
+		return os.Stdout
+	}
+	return p.stdout
+}
+
+// CheckSpecialFlags takes a Parser, the state of the parse, a reflect.Value,
+// which must represent a struct, the struct's options (as returned by
+// analyzeStruct), and a slice of tokens. If one of the tokens that precede
+// the subcommand name (if any) is an unrecognized -h or --help flag, and
+// automatic help is enabled, the usage message for the struct is written,
+// and ErrHelp is returned. If the first positional token is "help" (and
+// not the name of a subcommand), the usage message for the subcommand
+// named by the following tokens is written instead (see
+// writeCommandHelp()). If it is an unrecognized --version flag, and a
+// version string has been set, the version string is written, and
+// ErrVersion is returned.
+// Returns nil otherwise.
+func checkSpecialFlags(p *Parser, st *parseState, v reflect.Value,
+	options map[string]fieldInfo, tokens []string, isFused bool) error {
+
+	if !p.help && p.version == "" {
+		return nil
+	}
+
+	commands, err := findCommands(v)
+	if err != nil {
+		return err
+	}
+	for n, i := range positionalIndices(options, tokens, isFused) {
+		if lookupCommand(commands, tokens[i]) != nil {
+			return nil
+		}
+		if n == 0 && tokens[i] == helpCommand && p.help && len(commands) > 0 {
+			return writeCommandHelp(p, st, v, tokens, i+1)
+		}
+		if _, ok := helpFlags[tokens[i]]; ok && p.help {
+			return writeHelp(p, st.command, options, v.Addr().Interface())
+		}
+		if tokens[i] == versionFlag && p.version != "" {
+			fmt.Fprintf(p.outputWriter(), "%s\n", p.version)
+			return ErrVersion
+		}
+	}
+
+	return nil
+}
+
+// WriteCommandHelp takes a Parser, the state of the parse, a reflect.Value,
+// which must represent a struct, and a slice of tokens, and writes the
+// usage message for the subcommand named by the tokens from the given index
+// on (each one naming a subcommand of the previous one), or for the struct
+// itself, if there are no such tokens (see writeHelp()).
+// Returns ErrHelp, or an error if one of the tokens does not name a
+// subcommand.
+func writeCommandHelp(p *Parser, st *parseState, v reflect.Value,
+	tokens []string, index int) error {
+
+	command := st.command
+	for i := index; i < len(tokens); i++ {
+		commands, err := findCommands(v)
+		if err != nil {
+			return err
+		}
+		cmd := lookupCommand(commands, tokens[i])
+		if cmd == nil {
+			return unknownCommandError(commands, tokens, i)
+		}
+
+		t := cmd.Type
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		v = reflect.New(t).Elem()
+		command += cmd.name + " "
+	}
+
+	options, _, err := analyzeStruct(v)
+	if err != nil {
+		return err
+	}
+	return writeHelp(p, command, options, v.Addr().Interface())
+}
+
+// WriteHelp writes the short and the detailed usage message for the struct
+// that data points to, including the flags that are handled automatically
+// by the Parser (unless the struct's options define them), and returns
+// ErrHelp (or an error, if the usage message cannot be generated). If the
+// Parser has a program name, the short usage message is preceded by the
+// program name and the names of the selected subcommands.
+func writeHelp(p *Parser, command string, options map[string]fieldInfo,
+	data any) error {
+
+	w := p.errorWriter()
+
+	program := p.program
+	if program == "" {
+		program = p.usage.Program
+	}
+
+	fmt.Fprintf(w, "%s ", message("Usage:"))
+	if program != "" {
+		fmt.Fprintf(w, "%s ", program)
+	}
+	fmt.Fprintf(w, "%s", command)
+	opts := p.usage
+	opts.envPrefix = p.envPrefix
+	if err := writeShortUsageList(w, data, opts); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\n")
+
+	// Automatic flags, unless shadowed by the struct's own options
+	auto := strings.Builder{}
+	flags := []string{}
+	for _, f := range []string{"-h", "--help"} {
+		if _, ok := options[f]; !ok {
+			flags = append(flags, f)
+		}
+	}
+	if len(flags) > 0 {
+		fmt.Fprintf(&auto, "    %s \n       %s\n", strings.Join(flags, " "),
+			message("Show this help message"))
+	}
+	if _, ok := options[versionFlag]; !ok && p.version != "" {
+		fmt.Fprintf(&auto, "    %s \n       %s\n", versionFlag,
+			message("Show version information"))
+	}
+
+	if err := writeUsage(w, data, opts, auto.String()); err != nil {
+		return err
+	}
+
+	return ErrHelp
+}
@@ -0,0 +1,126 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"strings"
+)
+
+func Test_ParserHelp(t *testing.T) {
+	type helpArgs struct {
+		Count int `arg-flag:"-c" arg-help:"Number of items"`
+		Files []string
+	}
+
+	tests := []struct {
+		slice []string
+		help  bool
+	}{
+		{[]string{}, false},
+		{[]string{"-h"}, true},
+		{[]string{"--help"}, true},
+		{[]string{"-c", "x", "a", "--help"}, true},
+		{[]string{"a", "--", "-h"}, false},
+		{[]string{"-c", "-h"}, false},
+	}
+
+	for _, test := range tests {
+		sb := strings.Builder{}
+		p := &Parser{help: true, stderr: &sb}
+
+		err := p.Parse(test.slice, &helpArgs{})
+		if errors.Is(err, ErrHelp) != test.help {
+			t.Errorf("%v: got=%v want help=%v", test.slice, err, test.help)
+		}
+		if test.help && !strings.Contains(sb.String(), "Number of items") {
+			t.Errorf("%v: Missing usage:\n%s", test.slice, sb.String())
+		}
+		if !test.help && sb.String() != "" {
+			t.Errorf("%v: Unexpected output:\n%s", test.slice, sb.String())
+		}
+	}
+
+	// Without EnableHelp(), -h is just a positional
+	a := helpArgs{}
+	if err := FromSlice([]string{"-h"}, &a); err != nil || a.Files[0] != "-h" {
+		t.Errorf("Disabled: got=%v err=%v", a, err)
+	}
+
+	// Flags defined by the struct take precedence
+	b := struct {
+		Host string `arg-flag:"-h"`
+	}{}
+	p := &Parser{help: true, stderr: &strings.Builder{}}
+	if err := p.Parse([]string{"-h", "x"}, &b); err != nil || b.Host != "x" {
+		t.Errorf("Defined: got=%v err=%v", b, err)
+	}
+}
+
+func Test_ParserHelpCommands(t *testing.T) {
+	tests := []struct {
+		slice []string
+		want  string
+	}{
+		{[]string{"-h"}, "{clone|push}"},
+		{[]string{"-v", "clone", "--help"}, "--depth"},
+		{[]string{"push", "-h", "--force"}, "--force"},
+	}
+
+	for _, test := range tests {
+		sb := strings.Builder{}
+		p := &Parser{help: true, stderr: &sb}
+
+		err := p.Parse(test.slice, &commandArgs{})
+		if !errors.Is(err, ErrHelp) {
+			t.Errorf("%v: Expected ErrHelp, got: %v", test.slice, err)
+		}
+		if !strings.HasPrefix(sb.String(), "Usage: ") ||
+			!strings.Contains(sb.String(), test.want) {
+			t.Errorf("%v: want=%s\ngot=%s", test.slice, test.want, sb.String())
+		}
+	}
+}
+
+func Test_ParserVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		slice   []string
+		want    bool
+	}{
+		{"", []string{"--version"}, false},
+		{"1.2.3", []string{"--version"}, true},
+		{"1.2.3", []string{"-v", "--version"}, true},
+		{"1.2.3", []string{"push", "--version"}, true},
+		{"1.2.3", []string{"--", "--version"}, false},
+	}
+
+	for _, test := range tests {
+		out, errs := strings.Builder{}, strings.Builder{}
+		p := &Parser{version: test.version, stdout: &out, stderr: &errs}
+
+		err := p.Parse(test.slice, &commandArgs{})
+		if errors.Is(err, ErrVersion) != test.want {
+			t.Errorf("%v: got=%v want version=%v", test.slice, err, test.want)
+		}
+		if test.want && out.String() != test.version+"\n" {
+			t.Errorf("%v: got=%q", test.slice, out.String())
+		}
+	}
+
+	// Version from build info (not available in tests)
+	p := NewParser()
+	p.SetVersion("")
+	if p.version == "" {
+		t.Errorf("Expected version from build info")
+	}
+
+	// Automatic flags are listed in the usage message
+	sb := strings.Builder{}
+	p = &Parser{help: true, version: "1.0", stderr: &sb}
+	if err := p.Parse([]string{"-h"}, &commandArgs{}); !errors.Is(err, ErrHelp) ||
+		!strings.Contains(sb.String(), "    -h --help \n") ||
+		!strings.Contains(sb.String(), "    --version \n") {
+		t.Errorf("Missing automatic flags (%v):\n%s", err, sb.String())
+	}
+}
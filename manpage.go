@@ -0,0 +1,206 @@
+package cleanarg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ProgramInfo describes a program, for the parts of a man page that can
+// not be derived from the struct.
+type ProgramInfo struct {
+	Name        string // Name of the program
+	Section     string // Manual section (default: "1")
+	Synopsis    string // One-line description, for the NAME section
+	Description string // Longer description; blank lines separate paragraphs
+}
+
+// WriteManPage takes a writer, a pointer to a struct, and a description of
+// the program, and writes a man page for the program to w, in roff format
+// (as used by man(1)). The SYNOPSIS section contains the short usage line;
+// the OPTIONS, ARGUMENTS, and COMMANDS sections contain the options,
+// positional fields, and subcommands, with their help texts, default
+// values, and links; the ENVIRONMENT section lists the variables given by
+// arg-env tags.
+// Returns an error if the struct contains unsupported types.
+func WriteManPage(w io.Writer, data any, info ProgramInfo) error {
+	v, err := unwrap(data)
+	if err != nil {
+		return err
+	}
+
+	options, positionals, err := analyzeStruct(v)
+	if err != nil {
+		return err
+	}
+	commands, err := findCommands(v)
+	if err != nil {
+		return err
+	}
+
+	usage := bytes.Buffer{}
+	if err := writeShortUsageList(&usage, data, UsageOptions{}); err != nil {
+		return err
+	}
+
+	section := info.Section
+	if section == "" {
+		section = "1"
+	}
+
+	fmt.Fprintf(w, ".TH %s %s\n", roffEscape(strings.ToUpper(info.Name)),
+		roffEscape(section))
+
+	fmt.Fprintf(w, ".SH NAME\n")
+	if info.Synopsis != "" {
+		fmt.Fprintf(w, "%s \\- %s\n", roffEscape(info.Name),
+			roffEscape(info.Synopsis))
+	} else {
+		fmt.Fprintf(w, "%s\n", roffEscape(info.Name))
+	}
+
+	fmt.Fprintf(w, ".SH SYNOPSIS\n")
+	fmt.Fprintf(w, ".B %s\n", roffEscape(info.Name))
+	if s := strings.TrimSpace(usage.String()); s != "" {
+		fmt.Fprintf(w, "%s\n", roffText(s))
+	}
+
+	if info.Description != "" {
+		fmt.Fprintf(w, ".SH DESCRIPTION\n")
+		for i, par := range strings.Split(strings.TrimSpace(info.Description),
+			"\n\n") {
+			if i > 0 {
+				fmt.Fprintf(w, ".PP\n")
+			}
+			fmt.Fprintf(w, "%s\n", roffText(par))
+		}
+	}
+
+	// Options
+	env := []fieldInfo{}
+	if len(visibleFlags(options)) > 0 {
+		fmt.Fprintf(w, ".SH OPTIONS\n")
+	}
+	for _, opt := range uniqueOptions(options) {
+		if opt.hidden {
+			continue
+		}
+		flags := []string{}
+		for _, f := range opt.allFlags {
+			flags = append(flags, "\\fB"+roffEscape(f)+"\\fR")
+		}
+
+		help, argname := formatHelp(opt, false)
+		argname = choicesArg(opt, argname)
+
+		fmt.Fprintf(w, ".TP\n")
+		fmt.Fprintf(w, "%s", strings.Join(flags, ", "))
+		if takesArgument(opt) {
+			fmt.Fprintf(w, " \\fI%s\\fR", roffEscape(argname))
+		}
+		fmt.Fprintf(w, "\n")
+
+		if opt.isConst {
+			help = appendHint(help, messagef("sets value %s", opt.constval))
+		} else {
+			help = appendHint(help, formatHint(opt))
+		}
+		if opt.isSlice {
+			help = appendHint(help, message("repeatable"))
+		}
+		if opt.isDeprec {
+			help = appendHint(help, deprecationHint(opt))
+		}
+		if opt.required && !opt.isConst {
+			help = appendHint(help, message("required"))
+		}
+		if help != "" {
+			fmt.Fprintf(w, "%s\n", roffText(help))
+		}
+		if opt.defaultval != "" && !opt.isConst {
+			fmt.Fprintf(w, ".br\n%s\n",
+				roffEscape(messagef("Default: %s", displayDefault(opt))))
+		}
+		if opt.env != "" && !opt.isConst {
+			fmt.Fprintf(w, ".br\n%s\n",
+				roffEscape(messagef("Environment: %s", opt.env)))
+			env = append(env, opt)
+		}
+		if opt.url != "" {
+			fmt.Fprintf(w, ".br\n%s\n", roffEscape(messagef("See: %s", opt.url)))
+		}
+	}
+
+	// Positionals
+	if len(positionals) > 0 {
+		fmt.Fprintf(w, ".SH ARGUMENTS\n")
+	}
+	for _, p := range positionals {
+		help, argname := formatHelp(p, true)
+		argname = choicesArg(p, argname)
+		help = appendHint(help, formatHint(p))
+		if p.isSlice {
+			help = appendHint(help, message("repeatable"))
+		}
+		if p.mayOmit {
+			help = appendHint(help, message("optional"))
+		}
+
+		fmt.Fprintf(w, ".TP\n")
+		fmt.Fprintf(w, "\\fI%s\\fR\n", roffEscape(argname))
+		fmt.Fprintf(w, "%s\n", roffText(help))
+		if p.url != "" {
+			fmt.Fprintf(w, ".br\n%s\n", roffEscape(messagef("See: %s", p.url)))
+		}
+	}
+
+	// Subcommands
+	if len(commands) > 0 {
+		fmt.Fprintf(w, ".SH COMMANDS\n")
+	}
+	for _, cmd := range commands {
+		fmt.Fprintf(w, ".TP\n")
+		fmt.Fprintf(w, "\\fB%s\\fR\n", roffEscape(cmd.name))
+		if help := commandHelp(cmd); help != "" {
+			fmt.Fprintf(w, "%s\n", roffText(help))
+		}
+	}
+
+	// Environment variables
+	if len(env) > 0 {
+		fmt.Fprintf(w, ".SH ENVIRONMENT\n")
+	}
+	for _, opt := range env {
+		fmt.Fprintf(w, ".TP\n")
+		fmt.Fprintf(w, "\\fB%s\\fR\n", roffEscape(opt.env))
+		fmt.Fprintf(w, "%s\n", roffEscape(messagef(
+			"Used for %s, if not given on the command line.",
+			strings.Join(opt.allFlags, ", "))))
+	}
+
+	return nil
+}
+
+// RoffEscape escapes the characters with a special meaning in roff text:
+// backslashes, and hyphens (which would otherwise not be rendered as the
+// minus signs of flags).
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\e")
+	return strings.ReplaceAll(s, "-", "\\-")
+}
+
+// RoffText escapes a (possibly multi-line) text, and protects lines that
+// would otherwise be taken for roff requests (those beginning with "." or
+// "'").
+func roffText(s string) string {
+	lines := strings.Split(roffEscape(s), "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			line = "\\&" + line
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,76 @@
+package cleanarg
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_WriteManPage(t *testing.T) {
+	type manArgs struct {
+		Verbose bool      `arg-flag:"-v --verbose" arg-help:"Be verbose"`
+		Format  string    `arg-flag:"-f --format" arg-choices:"json yaml" arg-default:"json"`
+		Port    int       `arg-flag:"--port" arg-help:"The *port* to use" arg-env:"APP_PORT"`
+		Tags    []string  `arg-flag:"--tag" arg-url:"https://example.com/tags"`
+		Files   []string  `arg-help:"Input *files*"`
+		Clone   *struct{} `arg-command:"clone" arg-help:"Clone a repository"`
+	}
+
+	sb := strings.Builder{}
+	err := WriteManPage(&sb, &manArgs{}, ProgramInfo{
+		Name:        "my-tool",
+		Synopsis:    "do things",
+		Description: "First paragraph,\nsecond line.\n\n.Second paragraph",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	page := sb.String()
+
+	for _, want := range []string{
+		".TH MY\\-TOOL 1\n",
+		".SH NAME\nmy\\-tool \\- do things\n",
+		".SH SYNOPSIS\n.B my\\-tool\n[\\-f|\\-\\-format {json|yaml}] ",
+		".SH DESCRIPTION\nFirst paragraph,\nsecond line.\n.PP\n\\&.Second paragraph\n",
+		".TP\n\\fB\\-v\\fR, \\fB\\-\\-verbose\\fR\nBe verbose\n",
+		".TP\n\\fB\\-f\\fR, \\fB\\-\\-format\\fR \\fI{json|yaml}\\fR\n.br\nDefault: json\n",
+		".TP\n\\fB\\-\\-port\\fR \\fIport\\fR\nThe port to use\n.br\nEnvironment: APP_PORT\n",
+		"(repeatable)\n.br\nSee: https://example.com/tags\n",
+		".SH ARGUMENTS\n.TP\n\\fIfiles\\fR\nInput files (repeatable)\n",
+		".SH COMMANDS\n.TP\n\\fBclone\\fR\nClone a repository\n",
+		".SH ENVIRONMENT\n.TP\n\\fBAPP_PORT\\fR\n",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Missing %q:\n%s", want, page)
+		}
+	}
+
+	// Default values of secrets are redacted
+	sb.Reset()
+	err = WriteManPage(&sb, &struct {
+		Key string `arg-flag:"--key" arg-secret:"" arg-default:"dev-key"`
+	}{}, ProgramInfo{Name: "x"})
+	if err != nil || strings.Contains(sb.String(), "dev\\-key") ||
+		!strings.Contains(sb.String(), "Default: ********") {
+		t.Errorf("Unexpected man page (%v):\n%s", err, sb.String())
+	}
+
+	// Hidden options are omitted
+	sb.Reset()
+	err = WriteManPage(&sb, &struct {
+		Debug bool `arg-flag:"--debug" arg-hidden:"" arg-env:"APP_DEBUG"`
+	}{}, ProgramInfo{Name: "x"})
+	if err != nil || strings.Contains(sb.String(), "debug") ||
+		strings.Contains(sb.String(), ".SH OPTIONS") {
+		t.Errorf("Unexpected man page (%v):\n%s", err, sb.String())
+	}
+
+	// Section
+	sb.Reset()
+	if err := WriteManPage(&sb, &struct{}{}, ProgramInfo{Name: "x",
+		Section: "8"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := ".TH X 8\n.SH NAME\nx\n.SH SYNOPSIS\n.B x\n"; sb.String() != want {
+		t.Errorf("got=%q want=%q", sb.String(), want)
+	}
+}
@@ -0,0 +1,53 @@
+package cleanarg
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Translations set with SetMessages(), keyed on the English message.
+var messages sync.Map
+
+// SetMessages sets translations of the messages that cleanarg shows to the
+// users of a program: the messages of parse errors and of errors in input
+// values, and the headings and hints of usage messages and man pages.
+// Messages are keyed on their English text; for messages that contain
+// values, this is the format string, as in "flag %s is required" or
+// "unknown flag: %s". A translation must contain the same verbs as the
+// English format string (use explicit argument indexes, such as %[2]s, to
+// change their order). Translations replace those set earlier for the same
+// messages; an empty translation restores the English message.
+//
+// Set translations before parsing (typically in an init function). Errors
+// in the struct definition (malformed tags, say) are meant for the
+// programmer, and are not translated.
+func SetMessages(catalog map[string]string) {
+	for key, text := range catalog {
+		if text == "" {
+			messages.Delete(key)
+		} else {
+			messages.Store(key, text)
+		}
+	}
+}
+
+// Message returns the translation of the English message s (or format
+// string) set with SetMessages(), or s itself if there is none.
+func message(s string) string {
+	if text, ok := messages.Load(s); ok {
+		return text.(string)
+	}
+	return s
+}
+
+// Messagef formats the translation of the English format string (see
+// SetMessages()) with the given arguments.
+func messagef(format string, args ...any) string {
+	return fmt.Sprintf(message(format), args...)
+}
+
+// Errorf is like fmt.Errorf(), but translates the format string first
+// (see SetMessages()).
+func errorf(format string, args ...any) error {
+	return fmt.Errorf(message(format), args...)
+}
@@ -0,0 +1,91 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"strings"
+)
+
+func Test_SetMessages(t *testing.T) {
+	german := map[string]string{
+		"unknown command: %s (available: %s)": "unbekannter Befehl: %s " +
+			"(verfügbar: %s)",
+		"flag %s is required":       "Option %s fehlt",
+		"invalid boolean value: %s": "ungültiger Wahrheitswert: %s",
+		"invalid value %q for %s":   "ungültiger Wert %q für %s",
+		"repeatable":                "wiederholbar",
+		"required":                  "erforderlich",
+		"Options":                   "Optionen",
+		"Commands":                  "Befehle",
+		"usage: %s":                 "Aufruf: %s",
+	}
+	SetMessages(german)
+	defer func() {
+		for k := range german {
+			german[k] = ""
+		}
+		SetMessages(german)
+	}()
+
+	type args struct {
+		Name  string   `arg-flag:"-n" arg-required:"" arg-group:"Input"`
+		Tags  []string `arg-flag:"-t"`
+		Count int      `arg-flag:"-c"`
+		Quiet bool     `arg-flag:"-q"`
+		Init  struct{} `arg-command:"init"`
+	}
+
+	tests := []struct {
+		slice   []string
+		wantErr string
+	}{
+		{[]string{"-n", "a", "push"},
+			"unbekannter Befehl: push (verfügbar: init)"},
+		{[]string{"-q"}, "Option -n fehlt"},
+		{[]string{"-n", "a", "-q=maybe"},
+			"ungültiger Wahrheitswert: maybe"},
+		{[]string{"-n", "a", "-c", "x"}, `ungültiger Wert "x" für -c`},
+	}
+	for _, test := range tests {
+		err := FromSlice(test.slice, &args{})
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+	}
+
+	// The kind of error does not depend on the language
+	err := FromSlice([]string{"-q"}, &args{})
+	if !errors.Is(err, ErrRequired) {
+		t.Errorf("Expected ErrRequired, got: %v", err)
+	}
+
+	sb := strings.Builder{}
+	if err := WriteUsage(&sb, &args{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "Optionen:\n" +
+		"    -c [int]\n" +
+		"    -q \n" +
+		"    -t [string] (wiederholbar)\n" +
+		"Input:\n" +
+		"    -n [string]\n       (erforderlich)\n" +
+		"Befehle:\n" +
+		"    init ...\n"
+	if sb.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", sb.String(), want)
+	}
+
+	sb.Reset()
+	WriteShortUsageWith(&sb, &args{}, UsageOptions{Program: "tool"})
+	if !strings.HasPrefix(sb.String(), "Aufruf: tool ") {
+		t.Errorf("Unexpected short usage: %q", sb.String())
+	}
+
+	// Empty translations restore the English messages
+	SetMessages(map[string]string{"unknown command: %s (available: %s)": ""})
+	err = FromSlice([]string{"-n", "a", "push"}, &args{})
+	if err == nil || err.Error() != "unknown command: push (available: init)" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package cleanarg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FromCommandLineMultiCall takes a pointer to a struct with subcommands,
+// and populates the struct with the command-line arguments, like
+// FromCommandLine(), but selects the subcommand by the name under which the
+// program was invoked (see Parser.ParseMultiCall()).
+func FromCommandLineMultiCall(data any) error {
+	return NewParser().ParseMultiCall(os.Args, data)
+}
+
+// ParseMultiCallCommandLine takes a pointer to a struct with subcommands,
+// and populates the struct with the command-line arguments, just like
+// FromCommandLineMultiCall(), but taking the configuration of the Parser
+// into account.
+func (p *Parser) ParseMultiCallCommandLine(data any) error {
+	return p.ParseMultiCall(os.Args, data)
+}
+
+// ParseMultiCall takes a slice of command-line arguments, including the
+// name of the program (as in os.Args), and a pointer to a struct with
+// subcommands, for "multi-call" binaries, which are installed (or
+// symlinked) under several names, and behave differently depending on the
+// name they were invoked as (as busybox does). If the base name of the
+// program (without directory, and without a trailing ".exe") is the name
+// or an alias of one of the struct's subcommands, that subcommand is
+// selected, and populated from the remaining arguments. Otherwise, the
+// struct is populated from the remaining arguments, just like Parse(), so
+// that the subcommand can also be named explicitly (as in "tool clone").
+// Token indices in errors are relative to the remaining arguments.
+// Returns an error if the struct is malformed, or if the arguments cannot
+// be assigned to the struct (handled according to the Parser's error
+// handling).
+func (p *Parser) ParseMultiCall(args []string, data any) error {
+	if len(args) == 0 {
+		return p.Parse(args, data)
+	}
+
+	v, err := unwrap(data)
+	if err != nil {
+		return handleError(p, p.onError, err, data)
+	}
+	commands, err := findCommands(v)
+	if err != nil {
+		return handleError(p, p.onError, err, data)
+	}
+
+	cmd := lookupCommand(commands, multiCallName(args[0]))
+	if cmd == nil {
+		return p.Parse(args[1:], data)
+	}
+
+	tokens := append([]string{cmd.name}, args[1:]...)
+	err = offsetIndex(populateFromSlice(tokens, data, p), -1)
+	return handleError(p, p.onError, err, data)
+}
+
+// MultiCallName returns the name under which the program was invoked,
+// given its path (as in os.Args[0]): the base name, without a trailing
+// ".exe" (in any case).
+func multiCallName(program string) string {
+	name := filepath.Base(program)
+	if strings.EqualFold(filepath.Ext(name), ".exe") {
+		name = name[:len(name)-len(".exe")]
+	}
+	return name
+}
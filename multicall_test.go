@@ -0,0 +1,73 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+)
+
+type multiCallArgs struct {
+	Verbose bool      `arg-flag:"-v" arg-persistent:""`
+	Clone   *cloneCmd `arg-command:"clone cl"`
+	Push    *pushCmd  `arg-command:"push"`
+}
+
+func Test_ParseMultiCall(t *testing.T) {
+	tests := []struct {
+		args    []string
+		verbose bool
+		clone   *cloneCmd
+		push    bool
+	}{
+		{[]string{"/usr/bin/clone", "--depth", "1", "url"}, false,
+			&cloneCmd{1, "url"}, false},
+		{[]string{"cl", "-v", "url"}, true, &cloneCmd{0, "url"}, false},
+		{[]string{"bin/push.EXE", "-f"}, false, nil, true},
+		{[]string{"./tool", "-v", "push"}, true, nil, true},
+		{[]string{"tool", "clone", "url"}, false, &cloneCmd{0, "url"}, false},
+	}
+
+	for _, test := range tests {
+		a := multiCallArgs{}
+		if err := NewParser().ParseMultiCall(test.args, &a); err != nil {
+			t.Fatalf("%v: Unexpected error: %v", test.args, err)
+		}
+		if a.Verbose != test.verbose || (a.Push != nil) != test.push ||
+			(a.Clone == nil) != (test.clone == nil) ||
+			(a.Clone != nil && *a.Clone != *test.clone) {
+
+			t.Errorf("%v: got=%+v %+v", test.args, a, a.Clone)
+		}
+	}
+
+	// Token indices are relative to the arguments after the program name
+	err := NewParser(WithStrict()).ParseMultiCall(
+		[]string{"clone", "url", "--dept"}, &multiCallArgs{})
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Index != 1 || pe.Token != "--dept" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	err = NewParser().ParseMultiCall([]string{"tool"}, &struct{ X chan int }{})
+	if err == nil {
+		t.Errorf("Expected error for malformed struct")
+	}
+}
+
+func Test_multiCallName(t *testing.T) {
+	tests := []struct {
+		program string
+		want    string
+	}{
+		{"clone", "clone"},
+		{"/usr/local/bin/clone", "clone"},
+		{"./push.exe", "push"},
+		{"tool.sh", "tool.sh"},
+	}
+
+	for _, test := range tests {
+		if got := multiCallName(test.program); got != test.want {
+			t.Errorf("%s: got=%s want=%s", test.program, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package cleanarg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// AnalyzeNested takes a struct field, which must be a struct, and a prefix
+// (from the field's arg-prefix tag), and adds the options of the nested
+// struct to the map of options, as returned by analyzeStruct. The flags
+// (and aliases) of the nested options are composed from the prefix and
+// their own long flags (eg. --host becomes --db-host for prefix "db");
+// short flags can not be prefixed, and are dropped. With an empty prefix,
+// all flags are kept unchanged. The field names of the nested options are
+// composed as well (eg. DB.Host), as are their indices.
+// Returns an error if the field is not a struct, if the prefix is
+// malformed, if the nested struct has positional fields, subcommands, or
+// assignments, if an option has no flags left after prefixing, or if
+// a composed flag duplicates an existing one.
+func analyzeNested(field reflect.StructField, prefix string,
+	options map[string]fieldInfo) error {
+
+	if field.Type.Kind() != reflect.Struct {
+		return fmt.Errorf("%s must be struct: %s", tagPrefix, field.Name)
+	}
+
+	prefix = strings.TrimSpace(prefix)
+	if prefix != "" && !longFlagRE.MatchString("--"+prefix+"-x") {
+		return fmt.Errorf("malformed prefix: %q", prefix)
+	}
+
+	inner := reflect.New(field.Type).Elem()
+
+	innerOpts, innerPos, err := analyzeStruct(inner)
+	if err != nil {
+		return err
+	}
+	if len(innerPos) > 0 {
+		return fmt.Errorf("positional %s not permitted in nested struct %s",
+			innerPos[0].Name, field.Name)
+	}
+	if cmds, err := findCommands(inner); err != nil || len(cmds) > 0 {
+		return fmt.Errorf("subcommands not permitted in nested struct %s",
+			field.Name)
+	}
+	if _, ok, err := findAssignField(inner); err != nil || ok {
+		return fmt.Errorf("assignments not permitted in nested struct %s",
+			field.Name)
+	}
+
+	for _, info := range uniqueOptions(innerOpts) {
+		flags := prefixFlags(info.allFlags, prefix)
+		if len(flags) == 0 {
+			return fmt.Errorf("no long flag for %s in nested struct %s",
+				info.Name, field.Name)
+		}
+
+		info.allFlags = flags
+		info.Name = field.Name + "." + info.Name
+		info.Index = append(append([]int{}, field.Index...), info.Index...)
+
+		for _, f := range flags {
+			if _, ok := options[f]; ok {
+				return fmt.Errorf("duplicate flag: %s", f)
+			}
+			options[f] = info
+		}
+	}
+
+	// Aliases (arg-alias) are prefixed like the flags
+	for a, info := range innerOpts {
+		if !info.isAlias {
+			continue
+		}
+
+		info.allFlags = prefixFlags(info.allFlags, prefix)
+		info.Name = field.Name + "." + info.Name
+		info.Index = append(append([]int{}, field.Index...), info.Index...)
+
+		for _, f := range prefixFlags([]string{a}, prefix) {
+			if _, ok := options[f]; ok {
+				return fmt.Errorf("duplicate flag: %s", f)
+			}
+			options[f] = info
+		}
+	}
+
+	return nil
+}
+
+// AnalyzeEmbedded takes an anonymous struct field (an embedded struct),
+// and adds the options of the embedded struct to the map of options, as
+// returned by analyzeStruct. The flags and field names of the options are
+// kept unchanged (as for promoted fields in Go), but their indices are
+// composed. Returns the positional fields of the embedded struct, in order.
+// Returns an error if the embedded struct is improper, if it has
+// subcommands or assignments, or if one of its flags duplicates an
+// existing one.
+func analyzeEmbedded(field reflect.StructField,
+	options map[string]fieldInfo) ([]fieldInfo, error) {
+
+	inner := reflect.New(field.Type).Elem()
+
+	innerOpts, innerPos, err := analyzeStruct(inner)
+	if err != nil {
+		return nil, err
+	}
+	if cmds, err := findCommands(inner); err != nil || len(cmds) > 0 {
+		return nil, fmt.Errorf("subcommands not permitted in embedded struct %s",
+			field.Name)
+	}
+	if _, ok, err := findAssignField(inner); err != nil || ok {
+		return nil, fmt.Errorf("assignments not permitted in embedded struct %s",
+			field.Name)
+	}
+
+	for f, info := range innerOpts {
+		if _, ok := options[f]; ok {
+			return nil, fmt.Errorf("duplicate flag: %s", f)
+		}
+		info.Index = append(append([]int{}, field.Index...), info.Index...)
+		options[f] = info
+	}
+
+	for i := range innerPos {
+		innerPos[i].Index = append(append([]int{}, field.Index...),
+			innerPos[i].Index...)
+	}
+
+	return innerPos, nil
+}
+
+// PrefixFlags takes a slice of flags and a prefix, and returns the long
+// flags with the prefix inserted after the leading dashes (eg. --db-host),
+// in sorted order. Short flags are dropped. If the prefix is empty, the
+// flags are returned unchanged.
+func prefixFlags(flags []string, prefix string) sortableFlags {
+	out := sortableFlags{}
+
+	for _, f := range flags {
+		switch {
+		case prefix == "":
+			out = append(out, f)
+		case strings.HasPrefix(f, "--"):
+			out = append(out, "--"+prefix+"-"+f[2:])
+		}
+	}
+	sort.Sort(out)
+
+	return out
+}
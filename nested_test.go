@@ -0,0 +1,234 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+type poolConfig struct {
+	Size int `arg-flag:"--size" arg-default:"4"`
+}
+
+type dbConfig struct {
+	Host string     `arg-flag:"-H --host" arg-default:"localhost"`
+	Port int        `arg-flag:"--port"`
+	Pool poolConfig `arg-prefix:"pool"`
+}
+
+type nestedArgs struct {
+	Verbose bool     `arg-flag:"-v"`
+	DB      dbConfig `arg-prefix:"db"`
+	Cache   dbConfig `arg-prefix:"cache"`
+	Files   []string
+}
+
+func Test_FromSliceNested(t *testing.T) {
+	tests := []struct {
+		slice []string
+		db    dbConfig
+		cache dbConfig
+	}{
+		{[]string{},
+			dbConfig{"localhost", 0, poolConfig{4}},
+			dbConfig{"localhost", 0, poolConfig{4}}},
+		{[]string{"--db-host", "db1", "--db-port=5432", "--cache-pool-size", "8"},
+			dbConfig{"db1", 5432, poolConfig{4}},
+			dbConfig{"localhost", 0, poolConfig{8}}},
+	}
+
+	for _, test := range tests {
+		a := nestedArgs{}
+
+		if err := FromSlice(test.slice, &a); err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+		if a.DB != test.db || a.Cache != test.cache {
+			t.Errorf("%v: got=%v,%v want=%v,%v",
+				test.slice, a.DB, a.Cache, test.db, test.cache)
+		}
+	}
+
+	// Aliases are prefixed like flags
+	aliased := struct {
+		Log struct {
+			Level string `arg-flag:"--level" arg-alias:"-L --verbosity"`
+		} `arg-prefix:"log"`
+	}{}
+	err := FromSlice([]string{"--log-verbosity", "debug"}, &aliased)
+	if err != nil || aliased.Log.Level != "debug" {
+		t.Errorf("Alias: got=%v err=%v", aliased, err)
+	}
+
+	// Short flags are dropped
+	a := nestedArgs{}
+	if err := FromSlice([]string{"-H", "x"}, &a); err != nil ||
+		a.DB.Host != "localhost" || len(a.Files) != 2 {
+		t.Errorf("Short flag: got=%v err=%v", a, err)
+	}
+
+	// Runtime defaults use composed field names
+	p := NewParser()
+	p.SetDefault("DB.Pool.Size", 16)
+	a = nestedArgs{}
+	if err := p.Parse([]string{}, &a); err != nil || a.DB.Pool.Size != 16 {
+		t.Errorf("Runtime default: got=%v err=%v", a, err)
+	}
+}
+
+func Test_analyzeNested(t *testing.T) {
+	tests := []struct {
+		data    any
+		flags   []string
+		wantErr bool
+	}{
+		{struct {
+			A poolConfig `arg-prefix:"a"`
+		}{}, []string{"--a-size"}, false},
+		{struct {
+			A poolConfig `arg-prefix:""`
+		}{}, []string{"--size"}, false},
+		{struct {
+			A dbConfig `arg-prefix:"x"`
+		}{}, []string{"--x-host", "--x-pool-size", "--x-port"}, false},
+		{struct {
+			A int `arg-prefix:"a"`
+		}{}, nil, true},
+		{struct {
+			A poolConfig `arg-prefix:"-a"`
+		}{}, nil, true},
+		{struct {
+			A struct{ B int } `arg-prefix:"a"`
+		}{}, nil, true},
+		{struct {
+			A struct {
+				B bool `arg-flag:"-b"`
+			} `arg-prefix:"a"`
+		}{}, nil, true},
+		{struct {
+			A poolConfig `arg-prefix:"a"`
+			B poolConfig `arg-prefix:"a"`
+		}{}, nil, true},
+	}
+
+	for i, test := range tests {
+		options, _, err := analyzeStruct(reflect.ValueOf(test.data))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%d: Unexpected error: %v", i, err)
+			continue
+		}
+		if test.wantErr {
+			continue
+		}
+
+		flags := []string{}
+		for f := range options {
+			flags = append(flags, f)
+		}
+		slices.Sort(flags)
+		if !slices.Equal(flags, test.flags) {
+			t.Errorf("%d: got=%v want=%v", i, flags, test.flags)
+		}
+	}
+}
+
+func Test_WriteUsageNested(t *testing.T) {
+	sb := strings.Builder{}
+	WriteShortUsage(&sb, &nestedArgs{})
+	want := shortUsagePrefix + "[-v] [--db-host string] [--db-port int] " +
+		"[--cache-host string] [--cache-port int] " +
+		"[--db-pool-size int] [--cache-pool-size int] [string]+ \n"
+	if sb.String() != want {
+		t.Errorf("want=%s\ngot=%s", want, sb.String())
+	}
+}
+
+type CommonFlags struct {
+	Verbose bool   `arg-flag:"-v --verbose"`
+	Config  string `arg-flag:"-c" arg-default:"app.conf"`
+}
+
+type commonInput struct {
+	Input string
+}
+
+type embeddedArgs struct {
+	CommonFlags
+	commonInput
+	Force  bool `arg-flag:"-f"`
+	Output string
+}
+
+func Test_FromSliceEmbedded(t *testing.T) {
+	tests := []struct {
+		slice []string
+		want  embeddedArgs
+	}{
+		{[]string{"in", "out"},
+			embeddedArgs{CommonFlags{false, "app.conf"}, commonInput{"in"},
+				false, "out"}},
+		{[]string{"-vf", "-c", "x.conf", "in", "out"},
+			embeddedArgs{CommonFlags{true, "x.conf"}, commonInput{"in"},
+				true, "out"}},
+	}
+
+	for _, test := range tests {
+		a := embeddedArgs{}
+
+		if err := FromSlice(test.slice, &a); err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+		if a != test.want {
+			t.Errorf("%v: got=%v want=%v", test.slice, a, test.want)
+		}
+	}
+
+	// Promoted fields keep their names
+	p := NewParser()
+	p.SetDefault("Config", "y.conf")
+	a := embeddedArgs{}
+	if err := p.Parse([]string{"in", "out"}, &a); err != nil ||
+		a.Config != "y.conf" {
+		t.Errorf("Runtime default: got=%v err=%v", a, err)
+	}
+
+	// Embedded time.Time is a regular field
+	b := struct{ time.Time }{}
+	if err := FromSlice([]string{"2024-01-02 03:04:05"}, &b); err != nil ||
+		b.Year() != 2024 {
+		t.Errorf("Embedded time: got=%v err=%v", b, err)
+	}
+}
+
+func Test_analyzeEmbeddedErr(t *testing.T) {
+	tests := []any{
+		struct {
+			CommonFlags
+			Verbose bool `arg-flag:"-c"`
+		}{},
+		struct {
+			CommonFlags
+			pushCmd
+			Verbose bool `arg-flag:"--force"`
+		}{},
+		struct {
+			commonInput
+			Files []string
+			Rest  []string
+		}{},
+		struct {
+			commandArgs
+		}{},
+	}
+
+	for i, test := range tests {
+		if _, _, err := analyzeStruct(reflect.ValueOf(test)); err == nil {
+			t.Errorf("%d: Expected error", i)
+		}
+	}
+}
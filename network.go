@@ -0,0 +1,88 @@
+package cleanarg
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"reflect"
+)
+
+// Network address types, which are supported natively.
+var (
+	typeIP       = reflect.TypeOf(net.IP{})
+	typeIPNet    = reflect.TypeOf(net.IPNet{})
+	typeAddr     = reflect.TypeOf(netip.Addr{})
+	typePrefix   = reflect.TypeOf(netip.Prefix{})
+	typeAddrPort = reflect.TypeOf(netip.AddrPort{})
+
+	networkTypes = []reflect.Type{
+		typeIP, typeIPNet, typeAddr, typePrefix, typeAddrPort,
+	}
+)
+
+// ConvertNetwork takes a fieldInfo of one of the network address types,
+// and the value to convert, and returns the parsed address: an IP address
+// (net.IP, netip.Addr), a CIDR prefix (net.IPNet, netip.Prefix), or an
+// address with port (netip.AddrPort).
+// Returns an error that names the kind of address expected, if the value
+// is not a valid address of the field's type (redacted for arg-secret).
+func convertNetwork(info fieldInfo, value string) (reflect.Value, error) {
+	var x any
+	var err error
+	kind := "IP address"
+
+	switch info.baseType {
+	case typeIP:
+		ip := net.ParseIP(value)
+		if ip == nil {
+			err = errorf("invalid IP")
+		}
+		x = ip
+	case typeIPNet:
+		var n *net.IPNet
+		if _, n, err = net.ParseCIDR(value); err == nil {
+			x = *n
+		}
+		kind = "CIDR address"
+	case typeAddr:
+		x, err = netip.ParseAddr(value)
+	case typePrefix:
+		x, err = netip.ParsePrefix(value)
+		kind = "CIDR address"
+	case typeAddrPort:
+		x, err = netip.ParseAddrPort(value)
+		kind = "address with port"
+	default:
+		// Never get here
+		return reflect.Value{}, fmt.Errorf("invalid type")
+	}
+
+	if err != nil {
+		err = errorf("invalid %s: %s", kind, value)
+		return reflect.Value{}, redactError(info, err)
+	}
+	return reflect.ValueOf(x), nil
+}
+
+// FormatNetwork is the inverse of convertNetwork: it takes a value of one
+// of the network address types, and returns its string representation.
+func formatNetwork(x any) string {
+	if n, ok := x.(net.IPNet); ok {
+		return n.String() // pointer receiver
+	}
+	return fmt.Sprintf("%v", x)
+}
+
+// NetworkHint returns a brief hint on the syntax of the given network
+// address type, for usage messages.
+func networkHint(t reflect.Type) string {
+	switch t {
+	case typeIP, typeAddr:
+		return message("e.g. 192.168.0.1, ::1")
+	case typeIPNet, typePrefix:
+		return message("e.g. 10.0.0.0/8, fd00::/8")
+	case typeAddrPort:
+		return message("e.g. 127.0.0.1:8080, [::1]:8080")
+	}
+	return ""
+}
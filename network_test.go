@@ -0,0 +1,103 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"net"
+	"net/netip"
+	"reflect"
+)
+
+func Test_FromSliceNetwork(t *testing.T) {
+	type networkArgs struct {
+		Bind   net.IP           `arg-flag:"-b" arg-default:"127.0.0.1"`
+		Subnet net.IPNet        `arg-flag:"-s"`
+		Peers  []netip.Addr     `arg-flag:"-p"`
+		Allow  netip.Prefix     `arg-flag:"-a"`
+		Listen netip.AddrPort   `arg-flag:"-l"`
+		DNS    []net.IP         `arg-flag:"-d"`
+		Backup []netip.AddrPort `arg-flag:"-B"`
+	}
+
+	_, subnet, _ := net.ParseCIDR("10.1.0.0/16")
+
+	tests := []struct {
+		slice   []string
+		want    networkArgs
+		wantErr string
+	}{
+		{[]string{}, networkArgs{Bind: net.ParseIP("127.0.0.1")}, ""},
+		{[]string{"-b", "::1", "-s", "10.1.2.3/16", "-p", "192.168.0.1",
+			"-p", "fe80::1", "-a", "fd00::/8", "-l", "[::1]:8080",
+			"-d", "1.1.1.1", "-d", "8.8.8.8", "-B", "10.0.0.1:22"},
+			networkArgs{
+				Bind:   net.ParseIP("::1"),
+				Subnet: *subnet,
+				Peers: []netip.Addr{netip.MustParseAddr("192.168.0.1"),
+					netip.MustParseAddr("fe80::1")},
+				Allow:  netip.MustParsePrefix("fd00::/8"),
+				Listen: netip.MustParseAddrPort("[::1]:8080"),
+				DNS:    []net.IP{net.ParseIP("1.1.1.1"), net.ParseIP("8.8.8.8")},
+				Backup: []netip.AddrPort{netip.MustParseAddrPort("10.0.0.1:22")},
+			}, ""},
+		{[]string{"-b", "300.1.1.1"}, networkArgs{},
+			"invalid IP address: 300.1.1.1"},
+		{[]string{"-s", "10.1.2.3"}, networkArgs{},
+			"invalid CIDR address: 10.1.2.3"},
+		{[]string{"-p", "example.com"}, networkArgs{},
+			"invalid IP address: example.com"},
+		{[]string{"-a", "10.0.0.0/33"}, networkArgs{},
+			"invalid CIDR address: 10.0.0.0/33"},
+		{[]string{"-l", "127.0.0.1"}, networkArgs{},
+			"invalid address with port: 127.0.0.1"},
+	}
+
+	for _, test := range tests {
+		a := networkArgs{}
+
+		err := FromSlice(test.slice, &a)
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+		if test.wantErr != "" && !errors.Is(err, ErrConversion) {
+			t.Errorf("%v: got=%v want ErrConversion", test.slice, err)
+		}
+		if err == nil && !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.slice, a, test.want)
+		}
+	}
+}
+
+func Test_formatNetwork(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("10.1.0.0/16")
+
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{net.ParseIP("10.0.0.1"), "10.0.0.1"},
+		{*subnet, "10.1.0.0/16"},
+		{netip.MustParseAddr("::1"), "::1"},
+		{netip.MustParsePrefix("fd00::/8"), "fd00::/8"},
+		{netip.MustParseAddrPort("[::1]:80"), "[::1]:80"},
+	}
+
+	for _, test := range tests {
+		info := fieldInfo{baseType: reflect.TypeOf(test.value)}
+		got, err := formatValue(info, test.value)
+		if err != nil || got != test.want {
+			t.Errorf("%v: got=%q, %v want=%q", test.value, got, err, test.want)
+		}
+
+		// Formatted values convert back to the same value
+		info.value = got
+		vv, err := convertToType(info)
+		if err != nil || !reflect.DeepEqual(vv.Interface(), test.value) {
+			t.Errorf("%v: got=%v, %v", test.value, vv, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cleanarg
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// A bare number used as a flag (as in "tail -20"), see arg-number.
+var numberFlagRE = regexp.MustCompile(`^-[0-9]+$`)
+
+// CheckNumberOptions takes a map of options, as built by analyzeStruct,
+// and checks the option that is set by bare numbers (arg-number), if any:
+// it must be an int field (not a slice), and there must be at most one.
+// Returns an error if one of these conditions is violated.
+func checkNumberOptions(options map[string]fieldInfo) error {
+	name := ""
+	for _, info := range options {
+		if !info.isNumber || info.isConst {
+			continue
+		}
+		if info.baseType != reflect.TypeOf(0) || info.isSlice || info.isCount {
+			return fmt.Errorf("%s requires int field: %s", tagNumber, info.Name)
+		}
+		if name != "" && name != info.Name {
+			return fmt.Errorf("%s permitted on one field only: %s, %s",
+				tagNumber, name, info.Name)
+		}
+		name = info.Name
+	}
+	return nil
+}
+
+// RewriteNumbers takes a map of options and a slice of tokens, and returns
+// the tokens with each bare number (such as "-20"), that is neither a flag
+// nor the argument of a flag, replaced by the first flag of the option
+// tagged arg-number, with the number as its argument ("-n20", or
+// "--lines=20"). If untilPositional is true (for structs with subcommands,
+// and with POSIX ordering), only the numbers preceding the first other
+// positional token (the subcommand's name, or the token that ends the
+// flags) are replaced.
+// Returns the tokens unchanged if there is no such option.
+func rewriteNumbers(options map[string]fieldInfo, tokens []string,
+	isFused, untilPositional bool) []string {
+
+	info, found := numberOption(options)
+	if !found {
+		return tokens
+	}
+
+	flag := info.allFlags[0]
+	out := append([]string{}, tokens...)
+	for _, i := range positionalIndices(options, tokens, isFused) {
+		if !numberFlagRE.MatchString(tokens[i]) {
+			if untilPositional {
+				break
+			}
+			continue
+		}
+
+		if strings.HasPrefix(flag, "--") {
+			out[i] = flag + "=" + tokens[i][1:]
+		} else {
+			out[i] = flag + tokens[i][1:]
+		}
+	}
+	return out
+}
+
+// NumberOption returns the option tagged arg-number, if there is one.
+func numberOption(options map[string]fieldInfo) (fieldInfo, bool) {
+	for _, info := range options {
+		if info.isNumber && !info.isConst {
+			return info, true
+		}
+	}
+	return fieldInfo{}, false
+}
+
+// IsNumberToken returns true if the token is a bare number (such as "-20"),
+// and one of the options is tagged arg-number, so that the token is taken
+// for a flag, rather than for a positional.
+func isNumberToken(options map[string]fieldInfo, token string) bool {
+	_, found := numberOption(options)
+	return found && numberFlagRE.MatchString(token)
+}
+
+// NumberHint returns a brief hint on the bare-number syntax of the option
+// tagged arg-number, for usage messages, or the empty string otherwise.
+func numberHint(info fieldInfo) string {
+	if !info.isNumber {
+		return ""
+	}
+	return message("also as -N")
+}
@@ -0,0 +1,101 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"reflect"
+	"strings"
+)
+
+func Test_rewriteNumbers(t *testing.T) {
+	type numArgs struct {
+		Lines   int  `arg-flag:"-n --lines" arg-number:""`
+		Verbose bool `arg-flag:"-v"`
+		Count   int  `arg-flag:"-c"`
+		Files   []string
+	}
+
+	v, _ := unwrap(&numArgs{})
+	options, _, _ := analyzeStruct(v)
+
+	tests := []struct {
+		tokens, want []string
+		commands     bool
+	}{
+		{[]string{"-20"}, []string{"-n20"}, false},
+		{[]string{"-v", "-5", "f"}, []string{"-v", "-n5", "f"}, false},
+		{[]string{"f", "-5"}, []string{"f", "-n5"}, false},
+		{[]string{"f", "-5"}, []string{"f", "-5"}, true},
+		{[]string{"-c", "-5"}, []string{"-c", "-5"}, false},
+		{[]string{"-1.5", "-5x"}, []string{"-1.5", "-5x"}, false},
+		{[]string{"--", "-5"}, []string{"--", "-5"}, false},
+	}
+
+	for _, test := range tests {
+		got := rewriteNumbers(options, test.tokens, false, test.commands)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.tokens, got, test.want)
+		}
+	}
+}
+
+func Test_FromSliceNumber(t *testing.T) {
+	type tailArgs struct {
+		Lines  int  `arg-flag:"--lines" arg-number:"" arg-default:"10"`
+		Follow bool `arg-flag:"-f"`
+		Files  []string
+	}
+
+	tests := []struct {
+		tokens []string
+		lines  int
+		files  []string
+	}{
+		{[]string{"a"}, 10, []string{"a"}},
+		{[]string{"-20", "a"}, 20, []string{"a"}},
+		{[]string{"-f", "a", "-3"}, 3, []string{"a"}},
+		{[]string{"--lines", "7", "--", "-3"}, 7, []string{"-3"}},
+	}
+
+	for _, test := range tests {
+		a := tailArgs{}
+		if err := FromSlice(test.tokens, &a); err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.tokens, err)
+			continue
+		}
+		if a.Lines != test.lines || !reflect.DeepEqual(a.Files, test.files) {
+			t.Errorf("%v: got=%+v", test.tokens, a)
+		}
+	}
+
+	// With POSIX ordering, numbers after the first positional are positionals
+	a := tailArgs{}
+	err := NewParser(WithPosixOrdering()).Parse([]string{"-5", "a", "-20"}, &a)
+	if err != nil || a.Lines != 5 ||
+		!reflect.DeepEqual(a.Files, []string{"a", "-20"}) {
+
+		t.Errorf("POSIX: got=%+v, %v", a, err)
+	}
+
+	bad := []struct {
+		data any
+		text string
+	}{
+		{&struct {
+			N string `arg-flag:"-n" arg-number:""`
+		}{}, "requires int field"},
+		{&struct {
+			N int `arg-number:""`
+		}{}, "requires arg-flag"},
+		{&struct {
+			N int `arg-flag:"-n" arg-number:""`
+			M int `arg-flag:"-m" arg-number:""`
+		}{}, "permitted on one field only"},
+	}
+	for _, test := range bad {
+		err := FromSlice([]string{}, test.data)
+		if err == nil || !strings.Contains(err.Error(), test.text) {
+			t.Errorf("Expected error %q: got=%v", test.text, err)
+		}
+	}
+}
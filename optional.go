@@ -0,0 +1,89 @@
+package cleanarg
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Optional holds an optional value of one of the permitted types: fields of
+// type Optional[T] (eg. Optional[int]) are populated like fields of type T,
+// but also record whether a value was supplied at all (on the command line,
+// through arg-env, or as arg-default). This allows to tell an absent flag
+// apart from a flag that was set to the zero value, without pointers.
+// The zero value of an Optional is not set.
+type Optional[T any] struct {
+	value T
+	set   bool
+}
+
+// Some returns an Optional that is set to the given value (eg. for values
+// preset before parsing, see EnableStickyDefaults).
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, set: true}
+}
+
+// IsSet reports whether a value was supplied.
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// Get returns the value, or the zero value of T if no value was supplied.
+func (o Optional[T]) Get() T {
+	return o.value
+}
+
+// Or returns the value, or the given fallback if no value was supplied.
+func (o Optional[T]) Or(fallback T) T {
+	if !o.set {
+		return fallback
+	}
+	return o.value
+}
+
+// String returns the value as formatted by the fmt package, or "<unset>"
+// if no value was supplied.
+func (o Optional[T]) String() string {
+	if !o.set {
+		return "<unset>"
+	}
+	return fmt.Sprintf("%v", o.value)
+}
+
+// OptionType returns the type of the values held by the Optional.
+func (o Optional[T]) optionType() reflect.Type {
+	return reflect.TypeOf(&o.value).Elem()
+}
+
+// SetValue marks the Optional as set, and returns its value, which the
+// parser populates in place.
+func (o *Optional[T]) setValue() reflect.Value {
+	o.set = true
+	return reflect.ValueOf(&o.value).Elem()
+}
+
+// GetValue returns the value, and whether it was supplied (without marking
+// the Optional as set).
+func (o *Optional[T]) getValue() (reflect.Value, bool) {
+	return reflect.ValueOf(&o.value).Elem(), o.set
+}
+
+// The methods of Optional[T] used by the parser, which are the same for all
+// type parameters.
+type optionalValue interface {
+	optionType() reflect.Type
+	setValue() reflect.Value
+	getValue() (reflect.Value, bool)
+}
+
+// OptionElem takes a type, and returns the type of the values held by it,
+// if it is an Optional[T]. The boolean return value is false otherwise.
+func optionalElem(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	o, ok := reflect.New(t).Interface().(optionalValue)
+	if !ok {
+		return nil, false
+	}
+	return o.optionType(), true
+}
@@ -0,0 +1,84 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"reflect"
+	"strings"
+	"time"
+)
+
+func Test_Optional(t *testing.T) {
+	var o Optional[int]
+	if o.IsSet() || o.Get() != 0 || o.Or(5) != 5 || o.String() != "<unset>" {
+		t.Errorf("Zero value: got=%v", o)
+	}
+
+	o = Some(0)
+	if !o.IsSet() || o.Get() != 0 || o.Or(5) != 0 || o.String() != "0" {
+		t.Errorf("Some(0): got=%v", o)
+	}
+}
+
+func Test_optionalElem(t *testing.T) {
+	tests := []struct {
+		t    any
+		want any
+		ok   bool
+	}{
+		{Optional[int]{}, 0, true},
+		{Optional[string]{}, "", true},
+		{Optional[[]int]{}, []int{}, true},
+		{Optional[time.Duration]{}, time.Duration(0), true},
+		{0, nil, false},
+		{struct{}{}, nil, false},
+		{time.Time{}, nil, false},
+	}
+
+	for _, test := range tests {
+		got, ok := optionalElem(reflect.TypeOf(test.t))
+		if ok != test.ok || (ok && got != reflect.TypeOf(test.want)) {
+			t.Errorf("%T: got=%v, %v", test.t, got, ok)
+		}
+	}
+}
+
+func Test_FromSliceOptional(t *testing.T) {
+	type optArgs struct {
+		Num     Optional[int]           `arg-flag:"-n"`
+		Verbose Optional[bool]          `arg-flag:"-v" arg-negate:"--quiet"`
+		Name    Optional[string]        `arg-flag:"--name" arg-choices:"a b"`
+		Wait    Optional[time.Duration] `arg-flag:"-w" arg-default:"1s"`
+	}
+
+	a := optArgs{}
+	if err := FromSlice([]string{}, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a.Num.IsSet() || a.Verbose.IsSet() || a.Name.IsSet() ||
+		!a.Wait.IsSet() || a.Wait.Get() != time.Second {
+		t.Errorf("Unexpected values: got=%+v", a)
+	}
+
+	a = optArgs{}
+	err := FromSlice([]string{"-n", "0", "--quiet", "--name", "b"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !a.Num.IsSet() || a.Num.Get() != 0 || !a.Verbose.IsSet() ||
+		a.Verbose.Get() || a.Name.Get() != "b" {
+		t.Errorf("Unexpected values: got=%+v", a)
+	}
+
+	if err := FromSlice([]string{"--name", "c"}, &optArgs{}); err == nil {
+		t.Errorf("Expected error for invalid choice")
+	}
+
+	type badArgs struct {
+		List Optional[[]int] `arg-flag:"-l"`
+	}
+	err = FromSlice([]string{}, &badArgs{})
+	if err == nil || !strings.Contains(err.Error(), "not permitted") {
+		t.Errorf("Expected error for Optional slice: got=%v", err)
+	}
+}
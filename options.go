@@ -0,0 +1,148 @@
+package cleanarg
+
+import (
+	"io"
+)
+
+// An Option configures a Parser, when passed to NewParser(). Each option
+// has the same effect as the corresponding method of Parser.
+type Option func(*Parser)
+
+// WithFused makes the Parser use fused mode, like FromSliceFused(): the
+// argument of a flag must be fused to the flag (as in -c9 or --counter=9).
+func WithFused() Option {
+	return func(p *Parser) { p.fused = true }
+}
+
+// WithStrict makes the Parser report unknown flags as errors (see
+// Parser.EnableStrict()).
+func WithStrict() Option {
+	return func(p *Parser) { p.EnableStrict() }
+}
+
+// WithHelp turns on automatic help handling (see Parser.EnableHelp()).
+func WithHelp() Option {
+	return func(p *Parser) { p.EnableHelp() }
+}
+
+// WithVersion sets the version string, and turns on automatic version
+// handling (see Parser.SetVersion()).
+func WithVersion(version string) Option {
+	return func(p *Parser) { p.SetVersion(version) }
+}
+
+// WithStickyDefaults turns on sticky defaults (see
+// Parser.EnableStickyDefaults()).
+func WithStickyDefaults() Option {
+	return func(p *Parser) { p.EnableStickyDefaults() }
+}
+
+// WithPosixOrdering makes the first positional token end the flags (see
+// Parser.EnablePosixOrdering()).
+func WithPosixOrdering() Option {
+	return func(p *Parser) { p.EnablePosixOrdering() }
+}
+
+// WithSingleDashLong lets long flags be given with a single dash, as in
+// -name (see Parser.EnableSingleDashLong()).
+func WithSingleDashLong() Option {
+	return func(p *Parser) { p.EnableSingleDashLong() }
+}
+
+// WithWindowsFlags lets flags be given Windows-style, as in /name or
+// /name:value (see Parser.EnableWindowsFlags()).
+func WithWindowsFlags() Option {
+	return func(p *Parser) { p.EnableWindowsFlags() }
+}
+
+// WithDuplicatePolicy sets the policy for repeated flags of non-slice
+// options (see Parser.SetDuplicatePolicy()).
+func WithDuplicatePolicy(policy DuplicatePolicy) Option {
+	return func(p *Parser) { p.SetDuplicatePolicy(policy) }
+}
+
+// WithDefault sets a runtime default value for the struct field with the
+// given name (see Parser.SetDefault()).
+func WithDefault(name string, value any) Option {
+	return func(p *Parser) { p.SetDefault(name, value) }
+}
+
+// WithPreprocessor registers a function that transforms the tokens before
+// they are parsed (see Parser.AddPreprocessor()).
+func WithPreprocessor(f func([]string) ([]string, error)) Option {
+	return func(p *Parser) { p.AddPreprocessor(f) }
+}
+
+// WithTerminator registers an additional token that ends the flags, like
+// "--" (see Parser.AddTerminator()).
+func WithTerminator(term string) Option {
+	return func(p *Parser) { p.AddTerminator(term) }
+}
+
+// WithConfigFile registers a configuration file (see
+// Parser.AddConfigFile()).
+func WithConfigFile(path string) Option {
+	return func(p *Parser) { p.AddConfigFile(path) }
+}
+
+// WithStdinArgs makes the token "-@" stand for the tokens read from
+// standard input (see Parser.EnableStdinArgs()).
+func WithStdinArgs() Option {
+	return func(p *Parser) { p.EnableStdinArgs() }
+}
+
+// WithUsageOptions sets the options for the help message written by the
+// Parser (see Parser.SetUsageOptions()).
+func WithUsageOptions(opts UsageOptions) Option {
+	return func(p *Parser) { p.SetUsageOptions(opts) }
+}
+
+// WithConfirm makes the Parser ask for confirmation of boolean options
+// tagged arg-confirm that are not supplied (see Parser.EnableConfirm()).
+func WithConfirm() Option {
+	return func(p *Parser) { p.EnableConfirm() }
+}
+
+// WithInputReader sets the reader for the tokens that "-@" stands for (and
+// for answers to confirmation prompts), instead of standard input.
+func WithInputReader(r io.Reader) Option {
+	return func(p *Parser) { p.stdin = r }
+}
+
+// WithErrorWriter sets the writer for usage and error messages written by
+// the Parser (such as the help message), instead of standard error.
+func WithErrorWriter(w io.Writer) Option {
+	return func(p *Parser) { p.stderr = w }
+}
+
+// WithWarningWriter sets the writer for warnings about deprecated flags
+// (arg-deprecated), instead of the error writer. Use io.Discard to silence
+// them.
+func WithWarningWriter(w io.Writer) Option {
+	return func(p *Parser) { p.warnings = w }
+}
+
+// WithOutputWriter sets the writer for regular output written by the
+// Parser (such as the version string), instead of standard output.
+func WithOutputWriter(w io.Writer) Option {
+	return func(p *Parser) { p.stdout = w }
+}
+
+// WithErrorHandling sets what the parse methods of the Parser do if
+// parsing fails (see Parser.SetErrorHandling()).
+func WithErrorHandling(h ErrorHandling) Option {
+	return func(p *Parser) { p.SetErrorHandling(h) }
+}
+
+// WithExitCode sets the exit code of ExitOnError (see
+// Parser.SetExitCode()).
+func WithExitCode(code int) Option {
+	return func(p *Parser) { p.SetExitCode(code) }
+}
+
+// WithProgramName sets the name of the program, which is shown in the help
+// message written by the Parser (as in "Usage: prog [-v] ...", followed by
+// the name of the selected subcommand, if any).
+func WithProgramName(name string) Option {
+	return func(p *Parser) { p.program = name }
+}
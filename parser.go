@@ -0,0 +1,181 @@
+package cleanarg
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+)
+
+// A Parser populates structs from command-line tokens, like FromSlice()
+// does, but can carry configuration that applies to all structs parsed
+// with it (such as default values that are only known at runtime).
+// The zero value is ready to use, and behaves like FromSlice().
+type Parser struct {
+	fused         bool
+	strict        bool            // report unknown flags as errors
+	help          bool            // handle -h and --help automatically
+	sticky        bool            // treat pre-set field values as defaults
+	posix         bool            // stop parsing flags at the first positional
+	singleDash    bool            // accept long flags with a single dash
+	windows       bool            // accept flags like /name and /name:value
+	duplicates    DuplicatePolicy // repeated flags of non-slice options
+	version       string          // handle --version automatically, if set
+	program       string          // program name, for the help message
+	defaults      map[string]any  // runtime defaults, keyed on field name
+	preprocessors []func([]string) ([]string, error)
+	configs       []configSource // configuration files, in order
+	terminators   []string       // end the flags, in addition to "--"
+	stdinArgs     bool           // replace "-@" by tokens from standard input
+	confirm       bool           // ask for confirmation (arg-confirm)
+	usage         UsageOptions   // layout of the help message
+	onError       ErrorHandling  // what parse methods do with errors
+	exitCode      int            // exit code of ExitOnError (0: 2)
+	envPrefix     string         // prefix of derived environment variables
+
+	stdin  io.Reader // tokens for "-@", and answers (nil: os.Stdin)
+	stdout io.Writer // regular output (nil: os.Stdout)
+	stderr io.Writer // usage and error messages (nil: os.Stderr)
+
+	warnings io.Writer // deprecation warnings (nil: the error writer)
+}
+
+// NewParser returns a new Parser, configured by the supplied options (if
+// any), which are applied in order:
+//
+//	p := cleanarg.NewParser(cleanarg.WithStrict(), cleanarg.WithHelp())
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// SetDefault sets a default value for the struct field with the given
+// name, which replaces the default value given by the field's arg-default
+// tag (if any). The value may either be a string, which is interpreted
+// just like an arg-default tag, or a value of the field's type (or base
+// type, for slices; a slice provides several elements).
+// Unknown field names and values of the wrong type are reported as errors
+// when a struct is parsed.
+func (p *Parser) SetDefault(name string, value any) {
+	if p.defaults == nil {
+		p.defaults = map[string]any{}
+	}
+	p.defaults[name] = value
+}
+
+// AddPreprocessor registers a function that transforms the slice of tokens
+// before it is parsed (to expand aliases, inject profiles, or rewrite
+// legacy syntax, say). Preprocessors are applied in the order in which they
+// were registered, each receiving the output of the previous one. If a
+// preprocessor returns an error, parsing stops and the error is returned.
+// Preprocessors receive a copy of the tokens, and may modify it freely.
+func (p *Parser) AddPreprocessor(f func([]string) ([]string, error)) {
+	p.preprocessors = append(p.preprocessors, f)
+}
+
+// Preprocess applies all registered preprocessors to the tokens, in order,
+// and then replaces the left-most additional terminator (if any) by "--",
+// and "-@" by the tokens read from standard input (if enabled).
+func (p *Parser) preprocess(tokens []string) ([]string, error) {
+	for _, f := range p.preprocessors {
+		var err error
+		tokens, err = f(append([]string{}, tokens...))
+		if err != nil {
+			return nil, err
+		}
+	}
+	tokens = replaceTerminator(tokens, p.terminators)
+
+	if p.stdinArgs {
+		return expandStdinArgs(p, tokens)
+	}
+	return tokens, nil
+}
+
+// Parse takes a slice of string tokens and a pointer to a struct, and
+// populates the struct from the tokens, just like FromSlice(), but taking
+// the configuration of the Parser into account.
+func (p *Parser) Parse(tokens []string, data any) error {
+	err := populateFromSlice(tokens, data, p)
+	return handleError(p, p.onError, err, data)
+}
+
+// ParseCommandLine takes a pointer to a struct and populates the struct
+// with the command-line arguments, just like FromCommandLine(), but taking
+// the configuration of the Parser into account.
+func (p *Parser) ParseCommandLine(data any) error {
+	return p.Parse(os.Args[1:], data)
+}
+
+// FormatDefault takes a fieldInfo and a runtime default value, and returns
+// its string representation (see formatValue). The default of a slice field
+// may also be a slice, whose elements are joined with the field's separator
+// (see defaultSeparator).
+// Returns an error if the value is not of the field's type.
+func formatDefault(info fieldInfo, value any) (string, error) {
+	rv := reflect.ValueOf(value)
+	if !info.isSlice || !rv.IsValid() ||
+		rv.Type() != reflect.SliceOf(info.baseType) {
+		return formatValue(info, value)
+	}
+
+	elems := []string{}
+	for i := 0; i < rv.Len(); i++ {
+		s, err := formatValue(info, rv.Index(i).Interface())
+		if err != nil {
+			return "", err
+		}
+		elems = append(elems, s)
+	}
+	return joinValues(elems, defaultSeparator(info)), nil
+}
+
+// ApplyDefaults takes the map of options and the slice of positionals, as
+// returned by analyzeStruct, and a map of default values keyed on field
+// name, and replaces the default value of each named field. The options
+// and positionals are updated in place.
+// Returns an error if a field name is unknown, or if a value cannot be
+// represented as a default for its field.
+func applyDefaults(options map[string]fieldInfo, positionals []fieldInfo,
+	defaults map[string]any) error {
+
+	for name, value := range defaults {
+		found := false
+
+		for flag, info := range options {
+			if info.Name != name {
+				continue
+			}
+
+			s, err := formatDefault(info, value)
+			if err != nil {
+				return err
+			}
+			info.defaultval = s
+			options[flag] = info
+			found = true
+		}
+
+		for i, info := range positionals {
+			if info.Name != name {
+				continue
+			}
+
+			s, err := formatDefault(info, value)
+			if err != nil {
+				return err
+			}
+			positionals[i].defaultval = s
+			found = true
+		}
+
+		if !found {
+			return fmt.Errorf("cannot set default, unknown field: %s", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+func Test_ParserSetDefault(t *testing.T) {
+	type args struct {
+		Counter int           `arg-flag:"-c" arg-default:"2"`
+		Name    string        `arg-flag:"-n" arg-default:"nobody"`
+		Ratio   float64       `arg-flag:"-r" arg-format:"percent"`
+		Wait    time.Duration `arg-flag:"-w"`
+		When    time.Time     `arg-flag:"-t" arg-format:"2006-01-02"`
+		Flag    bool          `arg-flag:"-f" arg-default:"true"`
+	}
+
+	p := NewParser()
+	p.SetDefault("Counter", 8)
+	p.SetDefault("Name", "somebody")
+	p.SetDefault("Ratio", 0.25)
+	p.SetDefault("Wait", 3*time.Second)
+	p.SetDefault("When", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	p.SetDefault("Flag", false)
+
+	a := args{}
+	if err := p.Parse([]string{}, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := args{8, "somebody", 0.25, 3 * time.Second,
+		time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), false}
+	if a != want {
+		t.Errorf("Defaults: got=%v want=%v", a, want)
+	}
+
+	// Command line prevails
+	a = args{}
+	if err := p.Parse([]string{"-c", "3", "-f"}, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a.Counter != 3 || a.Flag != true || a.Name != "somebody" {
+		t.Errorf("Command line: got=%v", a)
+	}
+
+	// String values are treated like arg-default tags
+	p = NewParser()
+	p.SetDefault("Counter", "5")
+	a = args{}
+	if err := p.Parse([]string{}, &a); err != nil || a.Counter != 5 {
+		t.Errorf("String default: got=%v err=%v", a.Counter, err)
+	}
+
+	// Fused mode uses runtime default, if flag is present without value
+	p = &Parser{fused: true}
+	p.SetDefault("Counter", 9)
+	a = args{}
+	if err := p.Parse([]string{"-c"}, &a); err != nil || a.Counter != 9 {
+		t.Errorf("Fused default: got=%v err=%v", a.Counter, err)
+	}
+}
+
+func Test_ParserSetDefaultErr(t *testing.T) {
+	type args struct {
+		Counter int `arg-flag:"-c"`
+		Source  string
+	}
+
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"NoSuchField", 1},
+		{"Counter", 1.5},
+		{"Counter", "x"},
+		{"Counter", int64(1)},
+	}
+
+	for _, test := range tests {
+		p := NewParser()
+		p.SetDefault(test.name, test.value)
+
+		a := args{}
+		if err := p.Parse([]string{"src"}, &a); err == nil {
+			t.Errorf("%s=%v: Expected error", test.name, test.value)
+		}
+	}
+}
+
+func Test_ParserPreprocessor(t *testing.T) {
+	type args struct {
+		Verbose bool   `arg-flag:"-v --verbose"`
+		Name    string `arg-flag:"-n --name"`
+		Rest    []string
+	}
+
+	// Expand an alias, then rewrite a legacy flag
+	p := NewParser()
+	p.AddPreprocessor(func(tokens []string) ([]string, error) {
+		out := []string{}
+		for _, t := range tokens {
+			if t == "--loud" {
+				out = append(out, "-v", "--name", "LOUD")
+				continue
+			}
+			out = append(out, t)
+		}
+		return out, nil
+	})
+	p.AddPreprocessor(func(tokens []string) ([]string, error) {
+		for i, t := range tokens {
+			if name, ok := strings.CutPrefix(t, "-name:"); ok {
+				tokens[i] = "--name=" + name
+			}
+		}
+		return tokens, nil
+	})
+
+	tokens := []string{"a", "--loud", "-name:x"}
+	a := args{}
+	if err := p.Parse(tokens, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !a.Verbose || a.Name != "x" || !slices.Equal(a.Rest, []string{"a"}) {
+		t.Errorf("got=%v", a)
+	}
+	if tokens[2] != "-name:x" {
+		t.Errorf("Caller's tokens modified: %v", tokens)
+	}
+
+	// Errors are passed through
+	p.AddPreprocessor(func(tokens []string) ([]string, error) {
+		return nil, fmt.Errorf("rejected")
+	})
+	if err := p.Parse([]string{}, &a); err == nil || err.Error() != "rejected" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func Test_NewParserOptions(t *testing.T) {
+	type optArgs struct {
+		Count   int  `arg-flag:"-c --count" arg-default:"1"`
+		Verbose bool `arg-flag:"-v"`
+		Files   []string
+		Clone   *struct {
+			Depth int `arg-flag:"--depth"`
+		} `arg-command:"clone"`
+	}
+
+	// Fused mode
+	a := optArgs{}
+	p := NewParser(WithFused())
+	if err := p.Parse([]string{"-c", "x"}, &a); err != nil || a.Count != 1 ||
+		!slices.Equal(a.Files, []string{"x"}) {
+		t.Errorf("Fused: got=%v err=%v", a, err)
+	}
+
+	// Strict mode
+	p = NewParser(WithStrict())
+	if err := p.Parse([]string{"--cuont", "2"}, &optArgs{}); err == nil {
+		t.Errorf("Strict: Expected error")
+	}
+
+	// Runtime defaults and preprocessors, applied in order
+	a = optArgs{}
+	p = NewParser(WithDefault("Count", 5),
+		WithPreprocessor(func(tokens []string) ([]string, error) {
+			return append(tokens, "-v"), nil
+		}))
+	if err := p.Parse([]string{}, &a); err != nil || a.Count != 5 ||
+		!a.Verbose {
+		t.Errorf("Defaults: got=%v err=%v", a, err)
+	}
+
+	// Help, with program name and writers
+	errs, out := strings.Builder{}, strings.Builder{}
+	p = NewParser(WithHelp(), WithVersion("1.0"), WithProgramName("prog"),
+		WithErrorWriter(&errs), WithOutputWriter(&out))
+	if err := p.Parse([]string{"-h"}, &optArgs{}); !errors.Is(err, ErrHelp) ||
+		!strings.HasPrefix(errs.String(), "Usage: prog [-c|--count int] ") {
+		t.Errorf("Help: got=%q err=%v", errs.String(), err)
+	}
+	errs.Reset()
+	if err := p.Parse([]string{"clone", "-h"}, &optArgs{}); !errors.Is(err,
+		ErrHelp) || !strings.HasPrefix(errs.String(),
+		"Usage: prog clone [--depth int]") {
+		t.Errorf("Help (command): got=%q err=%v", errs.String(), err)
+	}
+	if err := p.Parse([]string{"--version"}, &optArgs{}); !errors.Is(err,
+		ErrVersion) || out.String() != "1.0\n" {
+		t.Errorf("Version: got=%q err=%v", out.String(), err)
+	}
+
+	// Without a program name, the usage message is unchanged
+	errs.Reset()
+	p = NewParser(WithHelp(), WithErrorWriter(&errs))
+	p.Parse([]string{"-h"}, &optArgs{})
+	if !strings.HasPrefix(errs.String(), "Usage: [-c|--count int] ") {
+		t.Errorf("No program name: got=%q", errs.String())
+	}
+}
@@ -0,0 +1,114 @@
+package cleanarg
+
+import (
+	"reflect"
+	"slices"
+)
+
+// ParsePartial takes a slice of string tokens and a pointer to a struct,
+// and populates the struct from the leading tokens, just like FromSlice(),
+// but stops at the first token that is neither one of the struct's flags
+// nor the argument of one (or at a "--" token, which is consumed), and
+// returns the remaining tokens untouched. The struct's positional fields
+// (if any) take one of the remaining tokens each (a slice of positionals
+// takes all of them), before the rest is returned. If the first remaining
+// token names one of the struct's subcommands, parsing stops there, and the
+// subcommand is not populated. Fields tagged arg-rest are not populated
+// either: the tokens following "--" are returned instead.
+//
+// This allows wrapper tools to pass on the tokens they do not understand,
+// and programs to dispatch subcommands manually:
+//
+//	rest, err := cleanarg.ParsePartial(os.Args[1:], &global)
+func ParsePartial(tokens []string, data any) ([]string, error) {
+	return NewParser().ParsePartial(tokens, data)
+}
+
+// ParsePartial takes a slice of string tokens and a pointer to a struct,
+// and populates the struct from the leading tokens, just like the function
+// ParsePartial(), but taking the configuration of the Parser into account.
+// Returns the remaining tokens.
+func (p *Parser) ParsePartial(tokens []string, data any) ([]string, error) {
+	rest, err := parsePartial(p, tokens, data)
+	return rest, handleError(p, p.onError, err, data)
+}
+
+// ParsePartial implements Parser.ParsePartial(), without the Parser's
+// error handling.
+func parsePartial(p *Parser, tokens []string, data any) ([]string, error) {
+	v, err := unwrap(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err = p.preprocess(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	options, positionals, err := analyzeStruct(v)
+	if err != nil {
+		return nil, err
+	}
+
+	consumed, rest, err := splitPartial(v, options, positionals, tokens,
+		p.fused)
+	if err != nil {
+		return nil, err
+	}
+
+	err = populateStruct(consumed, v, p, &parseState{defaults: p.defaults,
+		partial: true})
+	if err != nil {
+		return nil, err
+	}
+	return rest, nil
+}
+
+// SplitPartial takes a reflect.Value, which must represent a struct, the
+// struct's options and positionals, and a slice of tokens, and splits the
+// tokens into those that belong to the struct and the remaining ones. The
+// leading options are followed by a "--" token and the tokens for the
+// positional fields (if any), so that these are not taken for flags.
+func splitPartial(v reflect.Value, options map[string]fieldInfo,
+	positionals []fieldInfo, tokens []string,
+	isFused bool) ([]string, []string, error) {
+
+	end := firstPositional(options, tokens, isFused)
+	if end < 0 {
+		end = slices.Index(tokens, endFlagsIndicator)
+	}
+	if end < 0 {
+		end = len(tokens)
+	}
+
+	consumed := append([]string{}, tokens[:end]...)
+	rest := tokens[end:]
+
+	if len(rest) > 0 && rest[0] == endFlagsIndicator {
+		rest = rest[1:]
+	} else if len(rest) > 0 {
+		// Stop at a subcommand
+		commands, err := findCommands(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		if lookupCommand(commands, rest[0]) != nil {
+			return consumed, append([]string{}, rest...), nil
+		}
+	}
+
+	// Tokens for the positional fields (too few are reported as errors)
+	n := len(positionals)
+	for _, info := range positionals {
+		if info.isSlice {
+			n = len(rest)
+		}
+	}
+	n = min(n, len(rest))
+	if n > 0 {
+		consumed = append(append(consumed, endFlagsIndicator), rest[:n]...)
+	}
+
+	return consumed, append([]string{}, rest[n:]...), nil
+}
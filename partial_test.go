@@ -0,0 +1,120 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"reflect"
+)
+
+type partialArgs struct {
+	Verbose bool   `arg-flag:"-v"`
+	Level   int    `arg-flag:"-l" arg-default:"1"`
+	Host    string `arg-flag:"-H"`
+}
+
+type partialPosArgs struct {
+	Verbose bool `arg-flag:"-v"`
+	Host    string
+}
+
+type partialCmdArgs struct {
+	Verbose bool `arg-flag:"-v"`
+	Run     *struct {
+		Fast bool `arg-flag:"-f"`
+	} `arg-command:"run"`
+}
+
+func Test_ParsePartial(t *testing.T) {
+	tests := []struct {
+		slice []string
+		want  partialArgs
+		rest  []string
+	}{
+		{[]string{}, partialArgs{false, 1, ""}, []string{}},
+		{[]string{"-v", "-l", "3"}, partialArgs{true, 3, ""}, []string{}},
+		{[]string{"-vl3", "cmd", "-v"}, partialArgs{true, 3, ""},
+			[]string{"cmd", "-v"}},
+		{[]string{"-H", "h", "-x", "-v"}, partialArgs{false, 1, "h"},
+			[]string{"-x", "-v"}},
+		{[]string{"-v", "--", "-l", "2"}, partialArgs{true, 1, ""},
+			[]string{"-l", "2"}},
+		{[]string{"a", "--", "b"}, partialArgs{false, 1, ""},
+			[]string{"a", "--", "b"}},
+	}
+
+	for _, test := range tests {
+		a := partialArgs{}
+		rest, err := ParsePartial(test.slice, &a)
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+		if !reflect.DeepEqual(a, test.want) ||
+			!reflect.DeepEqual(rest, test.rest) {
+			t.Errorf("%v: got=%v %q want=%v %q", test.slice, a, rest,
+				test.want, test.rest)
+		}
+	}
+
+	// Errors among the leading options are reported
+	for _, slice := range [][]string{{"-l"}, {"-l", "x", "a"}, {"-vx", "a"}} {
+		a := partialArgs{}
+		if _, err := ParsePartial(slice, &a); err == nil {
+			t.Errorf("%v: Expected error", slice)
+		}
+	}
+}
+
+func Test_ParsePartialPositionals(t *testing.T) {
+	tests := []struct {
+		slice []string
+		want  partialPosArgs
+		rest  []string
+	}{
+		{[]string{"h"}, partialPosArgs{false, "h"}, []string{}},
+		{[]string{"-v", "h", "-v", "x"}, partialPosArgs{true, "h"},
+			[]string{"-v", "x"}},
+		{[]string{"-v", "--", "-h", "x"}, partialPosArgs{true, "-h"},
+			[]string{"x"}},
+	}
+
+	for _, test := range tests {
+		a := partialPosArgs{}
+		rest, err := ParsePartial(test.slice, &a)
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+		if !reflect.DeepEqual(a, test.want) ||
+			!reflect.DeepEqual(rest, test.rest) {
+			t.Errorf("%v: got=%v %q want=%v %q", test.slice, a, rest,
+				test.want, test.rest)
+		}
+	}
+
+	// Too few tokens for the positional fields
+	a := partialPosArgs{}
+	if _, err := ParsePartial([]string{"-v"}, &a); err == nil {
+		t.Errorf("Expected error for missing positional")
+	}
+}
+
+func Test_ParsePartialCommand(t *testing.T) {
+	a := partialCmdArgs{}
+	rest, err := ParsePartial([]string{"-v", "run", "-f"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !a.Verbose || a.Run != nil ||
+		!reflect.DeepEqual(rest, []string{"run", "-f"}) {
+		t.Errorf("got=%+v %q", a, rest)
+	}
+
+	// Tokens are not modified
+	tokens := []string{"-v", "x", "y"}
+	rest, _ = NewParser().ParsePartial(tokens, &partialArgs{})
+	rest[0] = "z"
+	if tokens[1] != "x" {
+		t.Errorf("Tokens modified: %q", tokens)
+	}
+}
@@ -0,0 +1,212 @@
+package cleanarg
+
+import (
+	"reflect"
+	"strings"
+)
+
+// tokenAt is a token, together with its index in the tokens it was taken
+// from.
+type tokenAt struct {
+	token string
+	index int
+}
+
+// SplitPersistent takes a map of options of a struct, the type of the
+// struct of a selected subcommand (or a pointer to it), and the tokens that
+// follow the subcommand's name, and moves the flags of persistent options
+// (arg-persistent) of the struct, together with their arguments, out of
+// the subcommand's tokens, so that they can be given after the subcommand
+// name, too. Flags of the subcommand itself take precedence, and so do
+// flags of its own subcommands, which are searched recursively. Compound
+// flags that combine flags of both kinds (as in "-vq") are split in two.
+// Tokens after "--" are left alone.
+// Returns the moved tokens, and the remaining tokens of the subcommand,
+// each with its index in the given tokens (so that errors and reports can
+// refer to the tokens as given).
+// Returns an error if the subcommand's struct (or one of its subcommands)
+// is malformed.
+func splitPersistent(options map[string]fieldInfo, t reflect.Type,
+	tokens []string, isFused bool) ([]tokenAt, []tokenAt, error) {
+
+	if !hasPersistent(options) {
+		return nil, tokensAt(tokens, 0), nil
+	}
+
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	v := reflect.New(t).Elem()
+	cmdOptions, cmdPositionals, err := analyzeStruct(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	commands, err := findCommands(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	moved, rest, searched := []tokenAt{}, []tokenAt{}, false
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i] == endFlagsIndicator {
+			rest = append(rest, tokensAt(tokens[i:], i)...)
+			break
+		}
+
+		// Compound flags of both kinds, with argument
+		own, persistent, ok := splitCompound(options, cmdOptions, tokens[i:],
+			isFused)
+		if ok {
+			rest = append(rest, shiftTokens(own, i)...)
+			moved = append(moved, shiftTokens(persistent, i)...)
+			i += len(own) + len(persistent) - 2
+			continue
+		}
+
+		// Flags of the subcommand, and persistent flags, with argument
+		flag, arg := chopToken(tokens[i])
+		info, isOwn := cmdOptions[flag]
+		if !isOwn {
+			info = options[flag]
+		}
+		if isOwn || info.persistent {
+			n := 1
+			if takesArgument(info) && arg == "" && !isFused &&
+				i+1 < len(tokens) {
+				n = 2
+			}
+			if isOwn {
+				rest = append(rest, tokensAt(tokens[i:i+n], i)...)
+			} else {
+				moved = append(moved, tokensAt(tokens[i:i+n], i)...)
+			}
+			i += n - 1
+			continue
+		}
+
+		if searched || len(commands) == 0 {
+			rest = append(rest, tokenAt{tokens[i], i})
+			continue
+		}
+
+		// The first positional token may select a nested subcommand (or
+		// the default subcommand, which receives the token)
+		searched = true
+		cmd, next := lookupCommand(commands, tokens[i]), i+1
+		if cmd == nil {
+			cmd, next = defaultCommand(commands), i
+		} else {
+			rest = append(rest, tokenAt{tokens[i], i})
+		}
+		if cmd != nil {
+			m, r, err := splitPersistent(options, cmd.Type, tokens[next:],
+				isFused)
+			if err != nil {
+				return nil, nil, err
+			}
+			return append(moved, shiftTokens(m, next)...),
+				append(rest, shiftTokens(r, next)...), nil
+		}
+
+		rest = append(rest, tokenAt{tokens[i], i})
+		if len(cmdPositionals) == 0 {
+			// An error, which the subcommand reports
+			return moved, append(rest, tokensAt(tokens[i+1:], i+1)...), nil
+		}
+	}
+	return moved, rest, nil
+}
+
+// SplitCompound takes a map of options of a struct, the map of options of
+// a selected subcommand, and tokens, and splits the first token, if it is
+// a compound flag (as in "-vq") that combines flags of the subcommand with
+// persistent flags (arg-persistent) of the struct, into a compound flag of
+// each kind ("-q" and "-v"). As with other compound flags, only the last
+// flag may take an argument, which is the rest of the token, or else the
+// next token (unless values must be fused). Flags of the subcommand take
+// precedence. Returns the tokens of the subcommand, and the persistent
+// tokens, each with its index in the given tokens. The boolean return
+// value is false if the first token is not such a compound flag.
+func splitCompound(options, cmdOptions map[string]fieldInfo,
+	tokens []string, isFused bool) ([]tokenAt, []tokenAt, bool) {
+
+	token := tokens[0]
+	if len(token) < 3 || (token[0] != '-' && token[0] != '+') ||
+		strings.HasPrefix(token, "--") {
+		return nil, nil, false
+	}
+	if _, ok := cmdOptions[token]; ok {
+		return nil, nil, false
+	}
+
+	prefix := token[:1]
+	own, persistent := prefix, prefix
+	nextToOwn, nextToPersistent := false, false // argument in next token
+	for k := 1; k < len(token); k++ {
+		flag := prefix + token[k:k+1]
+		info, isOwn := cmdOptions[flag]
+		if !isOwn {
+			info = options[flag]
+			if !info.persistent {
+				return nil, nil, false
+			}
+		}
+
+		// A flag that takes an argument ends the compound flag
+		part := token[k : k+1]
+		hasNext := false
+		if takesArgument(info) {
+			part = token[k:]
+			hasNext = k == len(token)-1 && !isFused && len(tokens) > 1
+		}
+		if isOwn {
+			own, nextToOwn = own+part, hasNext
+		} else {
+			persistent, nextToPersistent = persistent+part, hasNext
+		}
+		if takesArgument(info) {
+			break
+		}
+	}
+
+	if own == prefix || persistent == prefix {
+		return nil, nil, false
+	}
+	ownAt, persistentAt := []tokenAt{{own, 0}}, []tokenAt{{persistent, 0}}
+	if nextToOwn {
+		ownAt = append(ownAt, tokenAt{tokens[1], 1})
+	}
+	if nextToPersistent {
+		persistentAt = append(persistentAt, tokenAt{tokens[1], 1})
+	}
+	return ownAt, persistentAt, true
+}
+
+// TokensAt returns the tokens, each with its index, counting from start.
+func tokensAt(tokens []string, start int) []tokenAt {
+	out := make([]tokenAt, len(tokens))
+	for i, token := range tokens {
+		out[i] = tokenAt{token, start + i}
+	}
+	return out
+}
+
+// HasPersistent returns true if any of the options is persistent
+// (arg-persistent).
+func hasPersistent(options map[string]fieldInfo) bool {
+	for _, info := range options {
+		if info.persistent {
+			return true
+		}
+	}
+	return false
+}
+
+// ShiftTokens adds the offset to the index of each of the tokens, and
+// returns the tokens.
+func shiftTokens(tokens []tokenAt, offset int) []tokenAt {
+	for i := range tokens {
+		tokens[i].index += offset
+	}
+	return tokens
+}
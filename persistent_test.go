@@ -0,0 +1,153 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"slices"
+	"strings"
+)
+
+type pRemoteCmd struct {
+	Name string
+}
+
+type pCloneCmd struct {
+	Depth  int    `arg-flag:"--depth"`
+	Output string `arg-flag:"-o"` // shadows the persistent -o
+	URL    string
+}
+
+type pPushCmd struct {
+	Force  bool        `arg-flag:"-f"`
+	Remote *pRemoteCmd `arg-command:"remote"`
+}
+
+type persistentArgs struct {
+	Verbose bool       `arg-flag:"-v --verbose" arg-persistent:""`
+	Config  string     `arg-flag:"-c --config" arg-persistent:""`
+	Output  string     `arg-flag:"-o" arg-persistent:""`
+	Dry     bool       `arg-flag:"--dry"`
+	Clone   *pCloneCmd `arg-command:"clone"`
+	Push    *pPushCmd  `arg-command:"push"`
+}
+
+func Test_FromSlicePersistent(t *testing.T) {
+	tests := []struct {
+		slice   []string
+		verbose bool
+		config  string
+		output  string
+		clone   *pCloneCmd
+	}{
+		{[]string{"--verbose", "clone", "url"}, true, "", "",
+			&pCloneCmd{URL: "url"}},
+		{[]string{"clone", "--verbose", "url"}, true, "", "",
+			&pCloneCmd{URL: "url"}},
+		{[]string{"clone", "url", "-c", "x.conf", "--depth", "1"}, false,
+			"x.conf", "", &pCloneCmd{Depth: 1, URL: "url"}},
+		{[]string{"clone", "--config=y.conf", "-v", "url"}, true, "y.conf", "",
+			&pCloneCmd{URL: "url"}},
+		{[]string{"-o", "a", "clone", "-o", "b", "url"}, false, "", "a",
+			&pCloneCmd{Output: "b", URL: "url"}},
+	}
+
+	for _, test := range tests {
+		a := persistentArgs{}
+		if err := FromSlice(test.slice, &a); err != nil {
+			t.Fatalf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if a.Verbose != test.verbose || a.Config != test.config ||
+			a.Output != test.output || a.Clone == nil ||
+			*a.Clone != *test.clone {
+
+			t.Errorf("%v: got=%+v %+v", test.slice, a, a.Clone)
+		}
+	}
+
+	// Tokens following "--" are not flags
+	a := persistentArgs{}
+	err := FromSlice([]string{"clone", "--", "-v"}, &a)
+	if err != nil || a.Verbose || a.Clone == nil || a.Clone.URL != "-v" {
+		t.Errorf("got=%+v, %v", a, err)
+	}
+}
+
+func Test_FromSlicePersistentNested(t *testing.T) {
+	a := persistentArgs{}
+	err := FromSlice([]string{"push", "-f", "remote", "-v", "origin"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !a.Verbose || a.Push == nil || !a.Push.Force ||
+		a.Push.Remote == nil || a.Push.Remote.Name != "origin" {
+
+		t.Errorf("got=%+v", a)
+	}
+
+	// Options that are not persistent belong to the struct itself
+	a = persistentArgs{}
+	err = FromSlice([]string{"clone", "--dry", "url"}, &a)
+	if err == nil || !strings.Contains(err.Error(), "clone") {
+		t.Errorf("Expected error for --dry after clone: %v", err)
+	}
+
+	bad := struct {
+		Name string `arg-persistent:""`
+	}{}
+	err = FromSlice([]string{}, &bad)
+	if err == nil || err.Error() != "arg-persistent requires arg-flag: Name" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func Test_FromSlicePersistentCompound(t *testing.T) {
+	tests := []struct {
+		slice   []string
+		verbose bool
+		config  string
+	}{
+		{[]string{"push", "-fv"}, true, ""},
+		{[]string{"push", "-vf"}, true, ""},
+		{[]string{"push", "-vfc", "x.conf"}, true, "x.conf"},
+		{[]string{"push", "-fcx.conf", "-v"}, true, "x.conf"},
+	}
+
+	for _, test := range tests {
+		a := persistentArgs{}
+		if err := FromSlice(test.slice, &a); err != nil {
+			t.Fatalf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if a.Verbose != test.verbose || a.Config != test.config ||
+			a.Push == nil || !a.Push.Force {
+
+			t.Errorf("%v: got=%+v %+v", test.slice, a, a.Push)
+		}
+	}
+}
+
+func Test_FromSlicePersistentIndices(t *testing.T) {
+	// Token indices refer to the tokens as given
+	var pe *ParseError
+	err := FromSlice([]string{"clone", "-v", "--depth", "x"}, &persistentArgs{})
+	if !errors.As(err, &pe) || pe.Index != 2 {
+		t.Errorf("got=%v (%+v) want index 2", err, pe)
+	}
+	err = FromSlice([]string{"clone", "url", "-c"}, &persistentArgs{})
+	if !errors.As(err, &pe) || pe.Index != 2 {
+		t.Errorf("got=%v (%+v) want index 2", err, pe)
+	}
+
+	r, err := FromSliceWithReport([]string{"clone", "-v", "--depth", "1",
+		"url", "-c", "x.conf"}, &persistentArgs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for name, want := range map[string][]int{
+		"Verbose": {1}, "Config": {5}, "Clone.Depth": {2}, "Clone.URL": {4},
+	} {
+		if f := r.Fields[name]; f == nil || !slices.Equal(f.Positions, want) {
+			t.Errorf("%s: got=%+v want=%v", name, f, want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package cleanarg
+
+import (
+	"slices"
+)
+
+// EnablePosixOrdering turns on POSIX ordering: the first token that is
+// neither a flag nor the argument of a flag ends the flags, and all
+// following tokens are treated as positionals (like getopt does when
+// POSIXLY_CORRECT is set), as if "--" preceded it. By default, flags and
+// positionals may be interspersed. The name of a subcommand still selects
+// the subcommand, whose tokens are parsed in the same way. In strict mode,
+// an unknown flag before the first positional is reported as an error.
+func (p *Parser) EnablePosixOrdering() {
+	p.posix = true
+}
+
+// PosixOrder takes a map of options and a slice of tokens, and returns the
+// tokens with a "--" token inserted before the first token that is neither
+// a flag nor the argument of a flag (unless it already follows "--"), and
+// the index of the inserted token (-1 if none was inserted). If strict is
+// true, and the first such token looks like a flag, nothing is inserted,
+// so that the token is reported as an unknown flag.
+func posixOrder(options map[string]fieldInfo, tokens []string,
+	isFused, strict bool) ([]string, int) {
+
+	i := firstPositional(options, tokens, isFused)
+	if i < 0 || strict && looksLikeFlag(tokens[i]) {
+		return tokens, -1
+	}
+
+	return slices.Insert(slices.Clone(tokens), i, endFlagsIndicator), i
+}
@@ -0,0 +1,108 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"reflect"
+	"time"
+)
+
+type posixArgs struct {
+	Verbose bool `arg-flag:"-v"`
+	Level   int  `arg-flag:"-l"`
+	Args    []string
+}
+
+func Test_ParserPosixOrdering(t *testing.T) {
+	tests := []struct {
+		slice []string
+		want  posixArgs
+	}{
+		{[]string{}, posixArgs{}},
+		{[]string{"-v", "-l", "2", "a"}, posixArgs{true, 2, []string{"a"}}},
+		{[]string{"a", "-v", "-l", "2"},
+			posixArgs{false, 0, []string{"a", "-v", "-l", "2"}}},
+		{[]string{"-l", "2", "a", "--", "b"},
+			posixArgs{false, 2, []string{"a", "--", "b"}}},
+		{[]string{"-v", "--", "-l"}, posixArgs{true, 0, []string{"-l"}}},
+		{[]string{"-x", "-v"}, posixArgs{false, 0, []string{"-x", "-v"}}},
+	}
+
+	for _, test := range tests {
+		p := NewParser(WithPosixOrdering())
+
+		a := posixArgs{}
+		if err := p.Parse(test.slice, &a); err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+		if !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.slice, a, test.want)
+		}
+	}
+
+	// In strict mode, unknown flags before the first positional are errors,
+	// but not after it
+	p := NewParser(WithPosixOrdering(), WithStrict())
+	if err := p.Parse([]string{"-x", "a"}, &posixArgs{}); !errors.Is(err,
+		ErrUnknownFlag) {
+		t.Errorf("Strict: expected unknown flag, got: %v", err)
+	}
+	a := posixArgs{}
+	if err := p.Parse([]string{"a", "-x"}, &a); err != nil ||
+		!reflect.DeepEqual(a.Args, []string{"a", "-x"}) {
+		t.Errorf("Strict: got=%v err=%v", a, err)
+	}
+}
+
+func Test_ParserPosixOrderingCommand(t *testing.T) {
+	type cmdArgs struct {
+		Verbose bool `arg-flag:"-v"`
+		Run     struct {
+			Fast bool `arg-flag:"-f"`
+			Args []string
+		} `arg-command:"run"`
+	}
+
+	p := NewParser(WithPosixOrdering())
+	a := cmdArgs{}
+	if err := p.Parse([]string{"-v", "run", "-f", "x", "-f"}, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !a.Verbose || !a.Run.Fast ||
+		!reflect.DeepEqual(a.Run.Args, []string{"x", "-f"}) {
+		t.Errorf("got=%+v", a)
+	}
+}
+
+func Test_ParserPosixOrderingReport(t *testing.T) {
+	p := NewParser(WithPosixOrdering())
+	a := posixArgs{}
+	report, err := p.ParseWithReport([]string{"-v", "a", "-l"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := report.Fields["Args"].Positions; !reflect.DeepEqual(got,
+		[]int{1, 2}) {
+		t.Errorf("Positions: got=%v want=[1 2]", got)
+	}
+}
+
+func Test_ParserPosixOrderingNegative(t *testing.T) {
+	type negArgs struct {
+		Verbose bool `arg-flag:"-v"`
+		Delta   time.Duration
+		Rest    []string
+	}
+
+	a := negArgs{}
+	p := NewParser(WithPosixOrdering(), WithStrict())
+	if err := p.Parse([]string{"-v", "-1h", "-x"}, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !a.Verbose || a.Delta != -time.Hour ||
+		!reflect.DeepEqual(a.Rest, []string{"-x"}) {
+		t.Errorf("got=%+v", a)
+	}
+}
@@ -0,0 +1,126 @@
+package cleanarg
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// CheckRangeTags takes a fieldInfo, and checks its bounds (arg-min and
+// arg-max), if any: they are only permitted on numeric fields (int,
+// float64, time.Duration, ByteSize), must be valid values of the field (taking
+// arg-format into account), and the lower bound must not exceed the upper
+// bound. Returns an error if one of these conditions is violated.
+func checkRangeTags(info fieldInfo) error {
+	if info.minval == "" && info.maxval == "" {
+		return nil
+	}
+
+	switch info.baseType {
+	case reflect.TypeOf(0), reflect.TypeOf(0.0), reflect.TypeOf(time.Duration(0)),
+		typeByteSize:
+	default:
+		return fmt.Errorf("%s and %s require numeric field: %s",
+			tagMin, tagMax, info.Name)
+	}
+
+	lo, err := convertBound(info, info.minval)
+	if err != nil {
+		return fmt.Errorf("malformed %s: %s", tagMin, info.Name)
+	}
+	hi, err := convertBound(info, info.maxval)
+	if err != nil {
+		return fmt.Errorf("malformed %s: %s", tagMax, info.Name)
+	}
+
+	if lo.IsValid() && hi.IsValid() && compareNumbers(lo, hi) > 0 {
+		return fmt.Errorf("%s exceeds %s: %s", tagMin, tagMax, info.Name)
+	}
+	return nil
+}
+
+// ConvertBound takes a fieldInfo and one of its bounds (as given in the
+// arg-min or arg-max tag), and converts the bound to the field's type.
+// Returns the zero reflect.Value if the bound is empty, or an error if it
+// cannot be converted.
+func convertBound(info fieldInfo, s string) (reflect.Value, error) {
+	if s == "" {
+		return reflect.Value{}, nil
+	}
+
+	// The bound itself is not subject to choices, or to bounds
+	info.value, info.defaultval = s, ""
+	info.choices = nil
+	info.minval, info.maxval = "", ""
+	return convertToType(info)
+}
+
+// CheckRange takes a fieldInfo and a converted value of the field, and
+// checks that the value lies within the field's bounds (arg-min, arg-max),
+// which have been checked by checkRangeTags. Returns the value, or an error
+// that names the bounds if it is out of range.
+func checkRange(info fieldInfo, vv reflect.Value) (reflect.Value, error) {
+	if info.minval == "" && info.maxval == "" {
+		return vv, nil
+	}
+
+	lo, _ := convertBound(info, info.minval)
+	hi, _ := convertBound(info, info.maxval)
+
+	if (!lo.IsValid() || compareNumbers(vv, lo) >= 0) &&
+		(!hi.IsValid() || compareNumbers(vv, hi) <= 0) {
+		return vv, nil
+	}
+
+	var err error
+	switch flag := displayFlag(info); {
+	case lo.IsValid() && hi.IsValid():
+		err = errorf("%s must be between %s and %s", flag, info.minval,
+			info.maxval)
+	case lo.IsValid():
+		err = errorf("%s must be at least %s", flag, info.minval)
+	default:
+		err = errorf("%s must be at most %s", flag, info.maxval)
+	}
+	return reflect.Value{}, valueError(ErrOutOfRange, info, err)
+}
+
+// RangeHint returns a brief description of the bounds of the supplied
+// field (arg-min, arg-max) for usage messages, or the empty string if the
+// field has no bounds.
+func rangeHint(info fieldInfo) string {
+	switch {
+	case info.minval != "" && info.maxval != "":
+		return messagef("range: %s to %s", info.minval, info.maxval)
+	case info.minval != "":
+		return messagef("min: %s", info.minval)
+	case info.maxval != "":
+		return messagef("max: %s", info.maxval)
+	}
+	return ""
+}
+
+// CompareNumbers compares two values of the same numeric type (int,
+// float64, time.Duration, or ByteSize), and returns -1, 0, or +1, if the
+// first is less than, equal to, or greater than the second, respectively.
+func compareNumbers(a, b reflect.Value) int {
+	if a.Kind() == reflect.Float64 {
+		x, y := a.Float(), b.Float()
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+
+	x, y := a.Int(), b.Int()
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"reflect"
+	"time"
+)
+
+func Test_FromSliceRange(t *testing.T) {
+	type rangeArgs struct {
+		Threads int           `arg-flag:"-t --threads" arg-min:"1" arg-max:"64" arg-default:"4"`
+		Ratio   float64       `arg-flag:"--ratio" arg-min:"0.5"`
+		Timeout time.Duration `arg-flag:"--timeout" arg-max:"1m"`
+		Size    int           `arg-flag:"--size" arg-format:"si" arg-max:"2k"`
+		Level   []int         `arg-flag:"--level" arg-min:"0" arg-max:"3"`
+	}
+
+	tests := []struct {
+		slice   []string
+		wantErr string
+	}{
+		{[]string{}, ""},
+		{[]string{"-t", "1", "--ratio", "0.5", "--timeout", "1m"}, ""},
+		{[]string{"-t", "64", "--size", "2k", "--level", "3"}, ""},
+		{[]string{"-t", "0"}, "--threads must be between 1 and 64"},
+		{[]string{"--threads", "65"}, "--threads must be between 1 and 64"},
+		{[]string{"--ratio", "0.49"}, "--ratio must be at least 0.5"},
+		{[]string{"--timeout", "61s"}, "--timeout must be at most 1m"},
+		{[]string{"--size", "2001"}, "--size must be at most 2k"},
+		{[]string{"--level", "1", "--level", "4"},
+			"--level must be between 0 and 3"},
+	}
+
+	for _, test := range tests {
+		a := rangeArgs{}
+
+		err := FromSlice(test.slice, &a)
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+		if test.wantErr != "" && !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("%v: got=%v want ErrOutOfRange", test.slice, err)
+		}
+	}
+}
+
+func Test_checkRangeTags(t *testing.T) {
+	type minString struct {
+		Name string `arg-flag:"--name" arg-min:"a"`
+	}
+	type badMin struct {
+		N int `arg-flag:"-n" arg-min:"one"`
+	}
+	type badMax struct {
+		D time.Duration `arg-flag:"-d" arg-max:"10"`
+	}
+	type inverted struct {
+		N int `arg-flag:"-n" arg-min:"5" arg-max:"4"`
+	}
+	type badDefault struct {
+		N int `arg-flag:"-n" arg-min:"5" arg-default:"4"`
+	}
+
+	tests := []struct {
+		data    any
+		wantErr string
+	}{
+		{&minString{}, "arg-min and arg-max require numeric field: Name"},
+		{&badMin{}, "malformed arg-min: N"},
+		{&badMax{}, "malformed arg-max: D"},
+		{&inverted{}, "arg-min exceeds arg-max: N"},
+		{&badDefault{}, "invalid default value: -n must be at least 5"},
+	}
+
+	for _, test := range tests {
+		err := FromSlice([]string{}, test.data)
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("%T: got=%v want=%s", test.data, err, test.wantErr)
+		}
+	}
+}
+
+func Test_rangeHint(t *testing.T) {
+	type hintArgs struct {
+		A int     `arg-flag:"-a" arg-min:"1" arg-max:"9"`
+		B int     `arg-flag:"-b" arg-min:"1"`
+		C float64 `arg-flag:"-c" arg-max:"75%" arg-format:"percent"`
+		D int     `arg-flag:"-d"`
+	}
+
+	options, _, err := analyzeStruct(reflect.ValueOf(&hintArgs{}).Elem())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		flag, hint string
+	}{
+		{"-a", "range: 1 to 9"},
+		{"-b", "min: 1"},
+		{"-c", "e.g. 75%, 75; max: 75%"},
+		{"-d", ""},
+	}
+
+	for _, test := range tests {
+		if got := formatHint(options[test.flag]); got != test.hint {
+			t.Errorf("%s: got=%q want=%q", test.flag, got, test.hint)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cleanarg
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// Token that is replaced by the tokens read from standard input (see
+// Parser.EnableStdinArgs())
+const stdinIndicator = "-@"
+
+// FromReader reads tokens from r, and populates the struct that data points
+// to from them, just like FromSlice(). Tokens are separated by whitespace,
+// including newlines, and may be quoted following the rules of the shell
+// (see splitShell()); quoted tokens can not span lines. Empty lines, and
+// lines whose first non-blank character is "#", are skipped. This is useful
+// for very long (generated) argument lists, and in xargs-style pipelines.
+// Returns an error if reading from r fails, or if a line is malformed.
+func FromReader(r io.Reader, data any) error {
+	return (&Parser{}).ParseReader(r, data)
+}
+
+// ParseReader reads tokens from r, and populates the struct that data
+// points to from them, just like the package-level FromReader(), but
+// taking the configuration of the Parser into account.
+func (p *Parser) ParseReader(r io.Reader, data any) error {
+	tokens, err := readTokens(r)
+	if err != nil {
+		return handleError(p, p.onError, err, data)
+	}
+	return p.Parse(tokens, data)
+}
+
+// EnableStdinArgs makes the token "-@" (before "--") stand for the tokens
+// read from standard input, as by FromReader(), so that "find . | prog -v
+// -@" passes the names of the files found to prog. Standard input can only
+// be read once, so "-@" may be given only once.
+func (p *Parser) EnableStdinArgs() {
+	p.stdinArgs = true
+}
+
+// InputReader returns the reader for the tokens read for "-@" (and for
+// answers to confirmation prompts).
+func (p *Parser) inputReader() io.Reader {
+	if p.stdin == nil {
+		return os.Stdin
+	}
+	return p.stdin
+}
+
+// ReadTokens reads all lines from r, and splits them into tokens (see
+// FromReader()). Returns an error if reading from r fails, or if a line
+// cannot be split.
+func readTokens(r io.Reader) ([]string, error) {
+	tokens := []string{}
+
+	// Lines may be arbitrarily long (unlike with bufio.Scanner)
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		s := strings.TrimSpace(line)
+		if s != "" && !strings.HasPrefix(s, "#") {
+			words, err := splitShell(s)
+			if err != nil {
+				return nil, errorf("line %d: %w", n, err)
+			}
+			tokens = append(tokens, words...)
+		}
+
+		if err != nil {
+			return tokens, nil
+		}
+	}
+}
+
+// ExpandStdinArgs takes a Parser and a slice of tokens, and returns the
+// tokens with the token "-@" (if it precedes "--") replaced by the tokens
+// read from the Parser's input reader. Returns an error if "-@" occurs more
+// than once, or if the tokens cannot be read.
+func expandStdinArgs(p *Parser, tokens []string) ([]string, error) {
+	at := -1
+	for i, t := range tokens {
+		if t == endFlagsIndicator {
+			break
+		}
+		if t != stdinIndicator {
+			continue
+		}
+		if at >= 0 {
+			return nil, errorf("%s given more than once", stdinIndicator)
+		}
+		at = i
+	}
+	if at < 0 {
+		return tokens, nil
+	}
+
+	read, err := readTokens(p.inputReader())
+	if err != nil {
+		return nil, errorf("reading %s: %w", stdinIndicator, err)
+	}
+
+	out := append([]string{}, tokens[:at]...)
+	out = append(out, read...)
+	return append(out, tokens[at+1:]...), nil
+}
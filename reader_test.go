@@ -0,0 +1,88 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"reflect"
+	"strings"
+)
+
+type readerArgs struct {
+	Verbose bool `arg-flag:"-v"`
+	Level   int  `arg-flag:"-l"`
+	Files   []string
+}
+
+func Test_readTokens(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    []string
+		wantErr bool
+	}{
+		{"", []string{}, false},
+		{"a b\nc", []string{"a", "b", "c"}, false},
+		{"  a\t'b c'\n\n# comment\n\"d\\\"\"\n", []string{"a", "b c", "d\""},
+			false},
+		{"a\n'b\nc'", nil, true},
+	}
+
+	for _, test := range tests {
+		got, err := readTokens(strings.NewReader(test.text))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: Unexpected error: %v", test.text, err)
+		}
+		if err == nil && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got=%q want=%q", test.text, got, test.want)
+		}
+	}
+}
+
+func Test_FromReader(t *testing.T) {
+	a := readerArgs{}
+	err := FromReader(strings.NewReader("-v -l 3\nf1\n'f 2'\n"), &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := readerArgs{true, 3, []string{"f1", "f 2"}}
+	if !reflect.DeepEqual(a, want) {
+		t.Errorf("got=%+v want=%+v", a, want)
+	}
+
+	if err := FromReader(strings.NewReader("-l"), &a); err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func Test_ParserStdinArgs(t *testing.T) {
+	tests := []struct {
+		slice   []string
+		want    readerArgs
+		wantErr bool
+	}{
+		{[]string{"-v", "-@", "c"}, readerArgs{true, 2, []string{"a", "b", "c"}},
+			false},
+		{[]string{"--", "-@"}, readerArgs{false, 0, []string{"-@"}}, false},
+		{[]string{"-@", "-@"}, readerArgs{}, true},
+	}
+
+	for _, test := range tests {
+		p := NewParser(WithStdinArgs(),
+			WithInputReader(strings.NewReader("-l 2\na b\n")))
+
+		a := readerArgs{}
+		err := p.Parse(test.slice, &a)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if err == nil && !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%v: got=%+v want=%+v", test.slice, a, test.want)
+		}
+	}
+
+	// Without the option, "-@" is an ordinary token
+	a := readerArgs{}
+	if err := FromSlice([]string{"-@"}, &a); err != nil ||
+		!reflect.DeepEqual(a.Files, []string{"-@"}) {
+		t.Errorf("got=%+v, %v", a, err)
+	}
+}
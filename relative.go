@@ -0,0 +1,103 @@
+package cleanarg
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The current time; replaced in tests.
+var timeNow = time.Now
+
+// One term of a relative time offset, such as "2h" or "1.5d".
+var offsetTermRE = regexp.MustCompile(`^([0-9]+(?:\.[0-9]*)?)(ns|us|µs|ms|s|m|h|d|w)`)
+
+// Units of relative time offsets, beyond those of time.ParseDuration().
+var offsetUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+// ParseRelativeTime converts its argument, a relative time expression, to a
+// time.Time. An expression consists of an anchor ("now", "today",
+// "yesterday", or "tomorrow"; the last three are midnight in the given
+// location), optionally followed by an offset ("now-2h", "today+9h30m"),
+// or of an offset alone, which is relative to now ("-3d", "+1w"). Offsets
+// are durations, which may use the units "d" (days of 24 hours) and "w"
+// (weeks) in addition to those of time.ParseDuration().
+// The boolean return value is false if the argument is not a relative time
+// expression at all (so that it can be parsed as an absolute time).
+// Returns an error if it is, but is malformed.
+func parseRelativeTime(s string, loc *time.Location) (time.Time, bool, error) {
+	now := timeNow().In(loc)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	anchors := []struct {
+		name string
+		t    time.Time
+	}{
+		{"now", now},
+		{"today", midnight},
+		{"yesterday", midnight.AddDate(0, 0, -1)},
+		{"tomorrow", midnight.AddDate(0, 0, 1)},
+	}
+
+	base, rest, found := now, strings.TrimSpace(s), false
+	for _, a := range anchors {
+		if r, ok := strings.CutPrefix(strings.ToLower(rest), a.name); ok {
+			base, rest, found = a.t, strings.TrimSpace(r), true
+			break
+		}
+	}
+
+	if rest == "" {
+		return base, found, nil
+	}
+	if rest[0] != '+' && rest[0] != '-' {
+		if found {
+			return time.Time{}, true, errorf("invalid relative time: %s", s)
+		}
+		return time.Time{}, false, nil
+	}
+
+	offset, err := parseOffset(strings.TrimSpace(rest[1:]))
+	if err != nil {
+		return time.Time{}, true, errorf("invalid relative time: %s", s)
+	}
+	if rest[0] == '-' {
+		offset = -offset
+	}
+	return base.Add(offset), true, nil
+}
+
+// ParseOffset converts a sequence of terms like "3d", "2h", or "30m" (as
+// in "1d12h") to the sum of their durations.
+// Returns an error if the string is empty or malformed.
+func parseOffset(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, errorf("missing offset")
+	}
+
+	total := time.Duration(0)
+	for s != "" {
+		m := offsetTermRE.FindStringSubmatch(s)
+		if m == nil {
+			return 0, errorf("malformed offset: %s", s)
+		}
+		f, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, err
+		}
+		total += time.Duration(f * float64(offsetUnits[m[2]]))
+		s = s[len(m[0]):]
+	}
+	return total, nil
+}
@@ -0,0 +1,97 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"time"
+)
+
+func Test_parseRelativeTime(t *testing.T) {
+	now := time.Date(2024, 3, 15, 13, 45, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	midnight := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		s          string
+		want       time.Time
+		isRelative bool
+		wantErr    bool
+	}{
+		{"now", now, true, false},
+		{"NOW", now, true, false},
+		{"today", midnight, true, false},
+		{"yesterday", midnight.AddDate(0, 0, -1), true, false},
+		{"tomorrow", midnight.AddDate(0, 0, 1), true, false},
+		{"now-2h", now.Add(-2 * time.Hour), true, false},
+		{"now + 30m", now.Add(30 * time.Minute), true, false},
+		{"today+9h30m", midnight.Add(9*time.Hour + 30*time.Minute), true, false},
+		{"-3d", now.AddDate(0, 0, -3), true, false},
+		{"+1w", now.AddDate(0, 0, 7), true, false},
+		{"-1.5d", now.Add(-36 * time.Hour), true, false},
+		{"yesterday-1d12h", midnight.Add(-60 * time.Hour), true, false},
+		{"2024-03-01 10:00:00", time.Time{}, false, false},
+		{"now-", time.Time{}, true, true},
+		{"now-2x", time.Time{}, true, true},
+		{"-h", time.Time{}, true, true},
+		{"nowish", time.Time{}, true, true},
+	}
+
+	for _, test := range tests {
+		got, isRelative, err := parseRelativeTime(test.s, time.UTC)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: Unexpected error: %v", test.s, err)
+		}
+		if isRelative != test.isRelative {
+			t.Errorf("%q: got relative=%v want=%v", test.s, isRelative,
+				test.isRelative)
+		}
+		if err == nil && !got.Equal(test.want) {
+			t.Errorf("%q: got=%v want=%v", test.s, got, test.want)
+		}
+	}
+}
+
+func Test_FromSliceRelative(t *testing.T) {
+	now := time.Date(2024, 3, 15, 23, 30, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	type relativeArgs struct {
+		Since time.Time `arg-flag:"--since" arg-relative:"" arg-default:"now-1h"`
+		Until time.Time `arg-flag:"--until" arg-relative:""`
+		Day   time.Time `arg-flag:"--day" arg-relative:"" arg-location:"Asia/Tokyo"`
+		Fixed time.Time `arg-flag:"--fixed"`
+	}
+
+	a := relativeArgs{}
+	err := FromSlice([]string{"--until", "2024-03-15 12:00:00"}, &a)
+	if err != nil || !a.Since.Equal(now.Add(-time.Hour)) ||
+		!a.Until.Equal(time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("got=%v, %v", a, err)
+	}
+
+	// "today" is midnight in the field's location (already the 16th in Tokyo)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("Time zone database not available: %v", err)
+	}
+	a = relativeArgs{}
+	if err := FromSlice([]string{"--day", "today"}, &a); err != nil ||
+		!a.Day.Equal(time.Date(2024, 3, 16, 0, 0, 0, 0, tokyo)) {
+		t.Errorf("got=%v, %v", a.Day, err)
+	}
+
+	// Relative expressions are opt-in
+	if err := FromSlice([]string{"--fixed", "now"}, &relativeArgs{}); err == nil {
+		t.Errorf("Expected error for relative time without %s", tagRelative)
+	}
+
+	type intRelative struct {
+		N int `arg-flag:"-n" arg-relative:""`
+	}
+	if err := FromSlice([]string{}, &intRelative{}); err == nil {
+		t.Errorf("Expected error for arg-relative on int field")
+	}
+}
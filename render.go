@@ -0,0 +1,364 @@
+package cleanarg
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tokens that need no quoting for the shell
+var shellSafeRE = regexp.MustCompile(`^[0-9A-Za-z_@%+=:,./-]+$`)
+
+// ToSlice is the inverse of FromSlice(): it takes a pointer to a populated
+// struct, and returns a slice of tokens that FromSlice() turns into the
+// same struct again (to pass the configuration on to a child process, say).
+// Options that hold their default value are omitted; otherwise, they are
+// given in the order of their fields, using the first long flag of each
+// option (or its first flag, if it has no long flag), or a fixed-value
+// flag (arg-const) with the option's value.
+// Positionals, assignments (arg-assign), the tokens of the arg-rest field,
+// and the selected subcommand (if any) follow the options.
+// Returns an error if the struct is malformed, or if a value cannot be
+// expressed by tokens (an empty slice whose default is not empty, or an
+// element of a slice that equals its reset value, say).
+func ToSlice(data any) ([]string, error) {
+	v, err := unwrap(data)
+	if err != nil {
+		return nil, err
+	}
+	return structTokens(v, false)
+}
+
+// ToShellString takes a pointer to a populated struct, and returns the
+// tokens of ToSlice() as a single string, quoted for the POSIX shell, for
+// logging or for passing to "sh -c" (prefixed by the program name). The
+// values of fields tagged arg-secret are replaced by "********".
+// Returns an error if ToSlice() does.
+func ToShellString(data any) (string, error) {
+	v, err := unwrap(data)
+	if err != nil {
+		return "", err
+	}
+	tokens, err := structTokens(v, true)
+	if err != nil {
+		return "", err
+	}
+
+	quoted := []string{}
+	for _, t := range tokens {
+		quoted = append(quoted, quoteShell(t))
+	}
+	return strings.Join(quoted, " "), nil
+}
+
+// QuoteShell returns the token quoted for the POSIX shell (using single
+// quotes), unless it consists of characters that need no quoting only.
+func quoteShell(s string) string {
+	if shellSafeRE.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// StructTokens takes a reflect.Value, which must represent a struct, and
+// returns the tokens that populate the struct with its current values (see
+// ToSlice()), redacting the values of secret fields, if requested.
+// Returns an error if the struct is malformed, or if a value cannot be
+// expressed by tokens.
+func structTokens(v reflect.Value, redact bool) ([]string, error) {
+	options, positionals, err := analyzeStruct(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Options, in the order of their fields: the flags of a field, and its
+	// fixed-value flags (if any)
+	names, byName := []string{}, map[string][]fieldInfo{}
+	for _, info := range uniqueOptions(options) {
+		if _, ok := byName[info.Name]; !ok {
+			names = append(names, info.Name)
+		}
+		byName[info.Name] = append(byName[info.Name], info)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		a, b := byName[names[i]][0].Index, byName[names[j]][0].Index
+		return slices.Compare(a, b) < 0
+	})
+
+	out := []string{}
+	for _, name := range names {
+		tokens, err := optionTokens(byName[name], v, redact)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, tokens...)
+	}
+
+	// Assignments (arg-assign), in sorted order
+	if field, ok, err := findAssignField(v); err != nil {
+		return nil, err
+	} else if ok {
+		vars := v.FieldByIndex(field.Index).Interface().(map[string]string)
+		keys := []string{}
+		for k := range vars {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			out = append(out, k+"="+vars[k])
+		}
+	}
+
+	// Positionals, following "--" if one of them looks like a flag
+	posTokens, needEnd := []string{}, false
+	for _, info := range positionals {
+		val, ok := currentValue(info, v)
+		if !ok {
+			continue
+		}
+		if info.section != "" {
+			posTokens = append(posTokens, info.section)
+		}
+		elems, err := valueTokens(info, val, redact)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range elems {
+			needEnd = needEnd || e == endFlagsIndicator || looksLikeFlag(e)
+		}
+		posTokens = append(posTokens, elems...)
+	}
+
+	rest, hasRest, err := findRestField(v)
+	if err != nil {
+		return nil, err
+	}
+	if needEnd && hasRest {
+		return nil, fmt.Errorf("positional value looks like a flag: %v",
+			posTokens)
+	}
+	if needEnd {
+		out = append(out, endFlagsIndicator)
+	}
+	out = append(out, posTokens...)
+
+	// Tokens following "--" (arg-rest)
+	restTokens := []string{}
+	if hasRest {
+		restTokens = v.FieldByIndex(rest.Index).Interface().([]string)
+	}
+	if len(restTokens) > 0 {
+		out = append(append(out, endFlagsIndicator), restTokens...)
+	}
+
+	// The selected subcommand: a non-nil pointer, or a non-zero struct
+	commands, err := findCommands(v)
+	if err != nil {
+		return nil, err
+	}
+	for _, cmd := range commands {
+		field := v.FieldByIndex(cmd.Index)
+		if field.IsZero() {
+			continue
+		}
+		if len(posTokens) > 0 || len(restTokens) > 0 {
+			return nil, fmt.Errorf("positionals not permitted with "+
+				"subcommand: %s", cmd.name)
+		}
+
+		tokens, err := structTokens(reflect.Indirect(field), redact)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cmd.name, err)
+		}
+		out = append(append(out, cmd.name), tokens...)
+		break
+	}
+
+	return out, nil
+}
+
+// OptionTokens takes the fieldInfos of an option (with all of its flags,
+// and its fixed-value flags), and a reflect.Value, which must represent the
+// struct, and returns the tokens that set the option to its current value
+// (none, if it holds its default value).
+// Returns an error if the value cannot be expressed by tokens.
+func optionTokens(infos []fieldInfo, v reflect.Value,
+	redact bool) ([]string, error) {
+
+	info, consts := infos[0], []fieldInfo{}
+	for _, i := range infos {
+		if i.isConst {
+			consts = append(consts, i)
+		} else {
+			info = i
+		}
+	}
+
+	val, ok := currentValue(info, v)
+	if !ok {
+		return nil, nil
+	}
+	// Pointers and Optionals that are set are never omitted
+	omittable := !info.isPointer && !info.isOptional
+
+	elems, err := valueTokens(info, val, false)
+	if err != nil {
+		return nil, err
+	}
+	flag := preferredFlag(info)
+
+	// Counters are incremented from their default value
+	if info.isCount {
+		n, def := int(val.Int()), 0
+		if info.defaultval != "" {
+			def, _ = strconv.Atoi(info.defaultval)
+		}
+		if n >= def {
+			out := []string{}
+			for i := def; i < n; i++ {
+				out = append(out, flag)
+			}
+			return out, nil
+		}
+	}
+
+	// Slices replace their default value
+	if info.isSlice {
+		joined := joinValues(elems, defaultSeparator(info))
+		switch {
+		case joined == info.defaultval && omittable:
+			return nil, nil
+		case len(elems) == 0 && info.canReset:
+			return []string{flag, info.resetval}, nil
+		case len(elems) == 0:
+			return nil, fmt.Errorf("empty slice not representable: %s",
+				info.Name)
+		}
+
+		out := []string{}
+		for _, e := range elems {
+			// An element equal to the reset value would reset the slice
+			if info.canReset && e == info.resetval {
+				return nil, fmt.Errorf("slice element not representable: "+
+					"%s: %s", info.Name, e)
+			}
+			if redact && info.secret {
+				e = redactedValue
+			} else if info.sep != "" {
+				e = joinValues([]string{e}, info.sep)
+			}
+			out = append(out, flagValueTokens(flag, e)...)
+		}
+		return out, nil
+	}
+
+	s := elems[0]
+	if omittable && isDefaultValue(info, val, s) {
+		return nil, nil
+	}
+	for _, c := range consts {
+		if c.constval == s {
+			return []string{c.allFlags[0]}, nil
+		}
+	}
+
+	switch {
+	case info.isConst || info.isCount:
+		return nil, fmt.Errorf("value not representable: %s", info.Name)
+	case info.baseType == reflect.TypeOf(true) && s == "true":
+		return []string{flag}, nil
+	case info.baseType == reflect.TypeOf(true):
+		return []string{flag + "=" + s}, nil
+	case redact && info.secret:
+		return flagValueTokens(flag, redactedValue), nil
+	}
+	return flagValueTokens(flag, s), nil
+}
+
+// FlagValueTokens takes a flag and its value, and returns the tokens that
+// give the value to the flag: "--flag=value" for long flags (so that values
+// like "--" are not taken for flags), and "-f", "value" otherwise.
+func flagValueTokens(flag, value string) []string {
+	if strings.HasPrefix(flag, "--") {
+		return []string{flag + "=" + value}
+	}
+	return []string{flag, value}
+}
+
+// IsDefaultValue takes a fieldInfo, and the current value of the field,
+// both as is and formatted, and reports whether the field holds its default
+// value (or its zero value, if it has no default value).
+func isDefaultValue(info fieldInfo, val reflect.Value, s string) bool {
+	if info.baseType == reflect.TypeOf(true) {
+		def, err := parseBool(info.defaultval)
+		return val.Bool() == (def && err == nil)
+	}
+	if info.defaultval == "" {
+		return val.IsZero()
+	}
+	return s == info.defaultval
+}
+
+// ValueTokens takes a fieldInfo and the current value of the field, and
+// returns the value formatted as tokens (one for each element of a slice).
+// Boolean values are formatted as "true" or "false". The values of secret
+// fields are replaced by "********", if requested.
+func valueTokens(info fieldInfo, val reflect.Value,
+	redact bool) ([]string, error) {
+
+	elems := []reflect.Value{val}
+	if info.isSlice {
+		elems = []reflect.Value{}
+		for i := 0; i < val.Len(); i++ {
+			elems = append(elems, val.Index(i))
+		}
+	}
+
+	out := []string{}
+	for _, e := range elems {
+		s, err := formatValue(info, e.Interface())
+		if err != nil {
+			return nil, err
+		}
+		if e.Kind() == reflect.Bool {
+			s = strconv.FormatBool(e.Bool())
+		}
+		if redact && info.secret {
+			s = redactedValue
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// CurrentValue takes a fieldInfo and a reflect.Value, which must represent
+// the struct, and returns the field's current value: the value pointed to,
+// for pointers, or the value held, for Optionals. The boolean return value
+// is false if the pointer is nil, or the Optional is unset.
+func currentValue(info fieldInfo, v reflect.Value) (reflect.Value, bool) {
+	field := v.FieldByIndex(info.Index)
+	switch {
+	case info.isOptional:
+		return field.Addr().Interface().(optionalValue).getValue()
+	case info.isPointer && field.IsNil():
+		return reflect.Value{}, false
+	case info.isPointer:
+		return field.Elem(), true
+	}
+	return field, true
+}
+
+// PreferredFlag returns the flag used for an option by ToSlice(): its first
+// long flag, or its first flag, if it has no long flag.
+func preferredFlag(info fieldInfo) string {
+	for _, f := range info.allFlags {
+		if strings.HasPrefix(f, "--") {
+			return f
+		}
+	}
+	return info.allFlags[0]
+}
@@ -0,0 +1,114 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"reflect"
+	"time"
+)
+
+type renderArgs struct {
+	Verbose bool          `arg-flag:"-v --verbose"`
+	Color   bool          `arg-flag:"--color" arg-negate:"" arg-default:"yes"`
+	Level   int           `arg-flag:"-l" arg-const:"-q=0" arg-default:"1"`
+	Debug   int           `arg-flag:"-d" arg-count:""`
+	Name    string        `arg-flag:"-n --name"`
+	Token   string        `arg-flag:"--token" arg-secret:""`
+	Tags    []string      `arg-flag:"-t" arg-sep:","`
+	Wait    time.Duration `arg-flag:"-w" arg-default:"1m0s"`
+	Limit   *int          `arg-flag:"--limit"`
+	Src     string
+	Dst     []string
+}
+
+func Test_ToSlice(t *testing.T) {
+	tests := []struct {
+		slice, want []string
+	}{
+		{[]string{"a"}, []string{"a"}},
+		{[]string{"-v", "-l", "1", "a", "b"}, []string{"--verbose", "a", "b"}},
+		{[]string{"--no-color", "-q", "-ddd", "a"},
+			[]string{"--no-color", "-q", "-d", "-d", "-d", "a"}},
+		{[]string{"-n", "x y", "-t", "a,b", "-t", "c\\,d", "a"},
+			[]string{"--name=x y", "-t", "a", "-t", "b", "-t", "c\\,d", "a"}},
+		{[]string{"-w", "2s", "--limit", "0", "a"},
+			[]string{"-w", "2s", "--limit=0", "a"}},
+		{[]string{"--token", "s3cr3t", "--", "-a", "--"},
+			[]string{"--token=s3cr3t", "--", "-a", "--"}},
+		{[]string{"--name=--", "--token=-x", "a"},
+			[]string{"--name=--", "--token=-x", "a"}},
+	}
+
+	for _, test := range tests {
+		a := renderArgs{}
+		if err := FromSlice(test.slice, &a); err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+
+		got, err := ToSlice(&a)
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got=%q want=%q", test.slice, got, test.want)
+		}
+
+		// Round trip
+		b := renderArgs{}
+		if err := FromSlice(got, &b); err != nil || !reflect.DeepEqual(a, b) {
+			t.Errorf("%v: round trip: got=%+v want=%+v (%v)", test.slice, b,
+				a, err)
+		}
+	}
+
+	// Subcommands, and tokens following "--" (arg-rest)
+	type cmdArgs struct {
+		Verbose bool                  `arg-flag:"-v"`
+		Clone   *struct{ URL string } `arg-command:"clone"`
+		Rest    []string              `arg-rest:""`
+	}
+	for _, slice := range [][]string{{"-v", "clone", "u"}, {"--", "x"}} {
+		a := cmdArgs{}
+		if err := FromSlice(slice, &a); err != nil {
+			t.Fatalf("%v: Unexpected error: %v", slice, err)
+		}
+		got, err := ToSlice(&a)
+		if err != nil || !reflect.DeepEqual(got, slice) {
+			t.Errorf("%v: got=%q, %v", slice, got, err)
+		}
+	}
+
+	// Empty slices cannot be expressed, unless the default is empty
+	type emptyArgs struct {
+		Tags []string `arg-flag:"-t" arg-default:"a"`
+	}
+	if _, err := ToSlice(&emptyArgs{Tags: []string{}}); err == nil {
+		t.Errorf("Expected error")
+	}
+
+	// Neither can elements that equal the reset value (arg-reset)
+	type resetArgs struct {
+		Tags []string `arg-flag:"-t" arg-reset:"none"`
+	}
+	_, err := ToSlice(&resetArgs{Tags: []string{"none"}})
+	if err == nil || err.Error() != "slice element not representable: "+
+		"Tags: none" {
+		t.Errorf("got=%v", err)
+	}
+}
+
+func Test_ToShellString(t *testing.T) {
+	a := renderArgs{Name: "it's", Token: "s3cr3t", Src: "a b",
+		Dst: []string{"-x", "c"}, Color: true, Level: 1, Wait: time.Minute}
+
+	got, err := ToShellString(&a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := `'--name=it'\''s' '--token=********' -- 'a b' -x c`
+	if got != want {
+		t.Errorf("got=%s want=%s", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+package cleanarg
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// ParseLines reads lines from r, and parses each of them into a fresh
+// struct, just like FromSlice(). Each line is split into tokens following
+// the rules of the shell (see splitShell()); empty lines, and lines whose
+// first non-blank character is "#", are skipped. For each remaining line,
+// newData is called to obtain a pointer to a fresh struct, which is then
+// populated and passed to handle, together with the error (if any) that
+// occurred while splitting or parsing the line. (The struct may be only
+// partially populated in case of an error.)
+// The loop continues until the end of input is reached, or until handle
+// returns an error, which is then returned by ParseLines. Returns an error
+// if reading from r fails.
+func ParseLines(r io.Reader, newData func() any,
+	handle func(data any, err error) error) error {
+	return (&Parser{}).ParseLines(r, newData, handle)
+}
+
+// ParseLines reads lines from r, and parses each of them into a fresh
+// struct, just like the package-level ParseLines(), but taking the
+// configuration of the Parser into account.
+func (p *Parser) ParseLines(r io.Reader, newData func() any,
+	handle func(data any, err error) error) error {
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		data := newData()
+
+		tokens, err := splitShell(line)
+		if err == nil {
+			err = p.Parse(tokens, data)
+		}
+
+		if err := handle(data, err); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// SplitShell splits a line into tokens, following the quoting rules of
+// the POSIX shell: tokens are separated by unquoted whitespace; within
+// single quotes, all characters are literal; within double quotes, a
+// backslash only escapes '"', '\', '$', and '`'; elsewhere, a backslash
+// escapes any character. Variables and other expansions are not performed.
+// Returns an error if a quote is not terminated, or the line ends in an
+// escaping backslash.
+func splitShell(line string) ([]string, error) {
+	tokens := []string{}
+
+	token, inToken := strings.Builder{}, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+
+		case c == '\\':
+			if i+1 >= len(line) {
+				return nil, errorf("unterminated escape: %s", line)
+			}
+			i++
+			token.WriteByte(line[i])
+			inToken = true
+
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, errorf("unterminated quote: %s", line)
+			}
+			token.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inToken = true
+
+		case c == '"':
+			closed := false
+			for i++; i < len(line); i++ {
+				if line[i] == '"' {
+					closed = true
+					break
+				}
+				if line[i] == '\\' && i+1 < len(line) &&
+					strings.IndexByte("\"\\$`", line[i+1]) >= 0 {
+					i++
+				}
+				token.WriteByte(line[i])
+			}
+			if !closed {
+				return nil, errorf("unterminated quote: %s", line)
+			}
+			inToken = true
+
+		default:
+			token.WriteByte(c)
+			inToken = true
+		}
+	}
+
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+
+	return tokens, nil
+}
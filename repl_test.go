@@ -0,0 +1,103 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"fmt"
+	"slices"
+	"strings"
+)
+
+func Test_splitShell(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{"", []string{}, false},
+		{"   ", []string{}, false},
+		{"a", []string{"a"}, false},
+		{" a  b\tc ", []string{"a", "b", "c"}, false},
+		{`'a b' c`, []string{"a b", "c"}, false},
+		{`"a b" c`, []string{"a b", "c"}, false},
+		{`a"b c"d`, []string{"ab cd"}, false},
+		{`''`, []string{""}, false},
+		{`"" x`, []string{"", "x"}, false},
+		{`a\ b`, []string{"a b"}, false},
+		{`'a\ b'`, []string{`a\ b`}, false},
+		{`"a\"b"`, []string{`a"b`}, false},
+		{`"a\b"`, []string{`a\b`}, false},
+		{`"$x"`, []string{`$x`}, false},
+		{`'it'\''s'`, []string{`it's`}, false},
+		{`--name="x y"`, []string{"--name=x y"}, false},
+		{`'a`, nil, true},
+		{`"a`, nil, true},
+		{`a\`, nil, true},
+	}
+
+	for _, test := range tests {
+		got, err := splitShell(test.line)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: Unexpected error: %v", test.line, err)
+			continue
+		}
+
+		if err == nil && !slices.Equal(got, test.want) {
+			t.Errorf("%s: got=%q want=%q", test.line, got, test.want)
+		}
+	}
+}
+
+func Test_ParseLines(t *testing.T) {
+	type command struct {
+		Verbose bool `arg-flag:"-v"`
+		Name    string
+		Args    []string
+	}
+
+	input := `
+# comment
+set -v "a b" c
+  get x
+
+bad 'unterminated
+stop now
+ignored
+`
+
+	got, errs := []command{}, 0
+	err := ParseLines(strings.NewReader(input),
+		func() any { return &command{} },
+		func(data any, err error) error {
+			if err != nil {
+				errs++
+				return nil
+			}
+
+			c := data.(*command)
+			if c.Name == "stop" {
+				return fmt.Errorf("stopped")
+			}
+			got = append(got, *c)
+			return nil
+		})
+
+	if err == nil || err.Error() != "stopped" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if errs != 1 {
+		t.Errorf("Errors: got=%d want=1", errs)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("Commands: got=%v", got)
+	}
+	if got[0].Name != "set" || !got[0].Verbose ||
+		!slices.Equal(got[0].Args, []string{"a b", "c"}) {
+		t.Errorf("Command 0: got=%v", got[0])
+	}
+	if got[1].Name != "get" || got[1].Verbose ||
+		!slices.Equal(got[1].Args, []string{"x"}) {
+		t.Errorf("Command 1: got=%v", got[1])
+	}
+}
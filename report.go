@@ -0,0 +1,316 @@
+package cleanarg
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ParseReport describes which fields of a struct were set from the command
+// line, as returned by Parser.ParseWithReport(). Fields of subcommands are
+// included (if the subcommand was selected), and are named by their path
+// (eg. "Clone.Depth"), as are fields of nested structs (eg. "DB.Host").
+type ParseReport struct {
+	Fields map[string]*FieldReport // keyed on field name (or path)
+}
+
+// FieldReport describes how a single field was set from the command line,
+// and where its final value came from.
+type FieldReport struct {
+	Name      string   // Name (or path) of the struct field
+	Set       bool     // True if the field was set from the command line
+	Count     int      // Number of occurrences on the command line
+	Flags     []string // Flags used to set the field, in order
+	Positions []int    // Indices of the tokens that set the field, in order
+	Source    Source   // Where the field's final value came from
+	Origin    string   // The flag, variable, file, or tag that supplied it
+}
+
+// Source identifies where the final value of a field came from. Later
+// sources take precedence over earlier ones.
+type Source int
+
+const (
+	SourceNone        Source = iota // Not set (the value present before parsing)
+	SourceDefault                   // arg-default, runtime or sticky default
+	SourceConfig                    // Configuration file
+	SourceEnv                       // Environment variable (arg-env)
+	SourceCommandLine               // Command line
+)
+
+// String returns a description of the source.
+func (s Source) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceConfig:
+		return "config file"
+	case SourceEnv:
+		return "environment"
+	case SourceCommandLine:
+		return "command line"
+	default:
+		return "none"
+	}
+}
+
+// IsSet returns true if the field with the given name (or path) was set
+// from the command line.
+func (r *ParseReport) IsSet(name string) bool {
+	f, ok := r.Fields[name]
+	return ok && f.Set
+}
+
+// Count returns the number of times the field with the given name (or
+// path) occurred on the command line.
+func (r *ParseReport) Count(name string) int {
+	if f, ok := r.Fields[name]; ok {
+		return f.Count
+	}
+	return 0
+}
+
+// Source returns where the final value of the field with the given name (or
+// path) came from.
+func (r *ParseReport) Source(name string) Source {
+	if f, ok := r.Fields[name]; ok {
+		return f.Source
+	}
+	return SourceNone
+}
+
+// Provenance returns a description of where the final value of the field
+// with the given name (or path) came from, such as "command line (--port)",
+// "environment (PORT)", "config file (app.toml)", or "default
+// (arg-default)". Returns an empty string if the field is not known.
+func (r *ParseReport) Provenance(name string) string {
+	f, ok := r.Fields[name]
+	if !ok {
+		return ""
+	}
+	if f.Origin == "" {
+		return f.Source.String()
+	}
+	return fmt.Sprintf("%v (%s)", f.Source, f.Origin)
+}
+
+// ParseWithReport takes a slice of string tokens and a pointer to a struct,
+// and populates the struct from the tokens, just like Parse(). In addition,
+// it returns a report of the fields that were set from the command line
+// (as opposed to default values), including the number of occurrences and
+// the indices of the tokens (in the slice of tokens, after preprocessing).
+func (p *Parser) ParseWithReport(tokens []string, data any) (*ParseReport,
+	error) {
+
+	report, err := parseWithReport(p, tokens, data)
+	return report, handleError(p, p.onError, err, data)
+}
+
+// ParseWithReport implements Parser.ParseWithReport(), without the
+// Parser's error handling.
+func parseWithReport(p *Parser, tokens []string, data any) (*ParseReport,
+	error) {
+
+	v, err := unwrap(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err = p.preprocess(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ParseReport{Fields: map[string]*FieldReport{}}
+	st := &parseState{defaults: p.defaults, report: report}
+	if err := populateStruct(tokens, v, p, st); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// FromSliceWithReport takes a pointer to a struct and populates the struct
+// by processing a slice of string tokens, just like FromSlice(), and
+// returns a report of the fields that were set from the command line.
+func FromSliceWithReport(tokens []string, data any) (*ParseReport, error) {
+	return NewParser().ParseWithReport(tokens, data)
+}
+
+// fieldOrigin describes where the value of a field came from, if it was
+// not set from the command line.
+type fieldOrigin struct {
+	source Source
+	origin string
+}
+
+// fieldOrigins holds the origins of the fields' values, keyed on field
+// name. Later additions take the place of earlier ones.
+type fieldOrigins map[string]fieldOrigin
+
+// Add records the given source for the fields, which are mapped to the
+// origin of their values (a file, or a variable).
+func (o fieldOrigins) add(fields map[string]string, source Source) {
+	for name, origin := range fields {
+		o[name] = fieldOrigin{source, origin}
+	}
+}
+
+// AddDefaults takes a map of options, the subset of them that received
+// their default values, and the runtime defaults, and records the default
+// values' origins. Options missing from the subset hold preset values
+// (with sticky defaults).
+func (o fieldOrigins) addDefaults(options, defaulted map[string]fieldInfo,
+	defaults map[string]any) {
+
+	for flag, info := range options {
+		if _, ok := defaulted[flag]; !ok {
+			o[info.Name] = fieldOrigin{SourceDefault, "preset value"}
+			continue
+		}
+		o.addDefault(info, defaults)
+	}
+}
+
+// AddPositionalDefaults takes the positional fields of a struct, and the
+// runtime defaults, and records the default values' origins. Positionals
+// that receive tokens take their origins from the command line instead
+// (see recordSection).
+func (o fieldOrigins) addPositionalDefaults(positionals []fieldInfo,
+	defaults map[string]any) {
+
+	for _, info := range positionals {
+		o.addDefault(info, defaults)
+	}
+}
+
+// AddDefault records the origin of the field's default value (a runtime
+// default, or the arg-default tag), if it has one.
+func (o fieldOrigins) addDefault(info fieldInfo, defaults map[string]any) {
+	if info.defaultval == "" {
+		return
+	}
+
+	if _, ok := defaults[info.Name]; ok {
+		o[info.Name] = fieldOrigin{SourceDefault, "runtime default"}
+	} else {
+		o[info.Name] = fieldOrigin{SourceDefault, tagDefault}
+	}
+}
+
+// Record adds the fields of a struct to the report: all options and
+// positionals (in their sections, see arg-section), the options that were
+// supplied in the tokens (with their flags and token indices), those of
+// them that took effect (see applyDuplicatePolicy), which determine the
+// origins of the values, the token indices of the positional tokens (in
+// the order in which they were passed to populateSections, or nil if not
+// known), and the origins of the values not set from the tokens.
+func (r *ParseReport) record(st *parseState, options map[string]fieldInfo,
+	sections []positionalSection, supplied, retained []fieldInfo,
+	posIndices []int, origins fieldOrigins) {
+
+	entry := func(info fieldInfo) *FieldReport {
+		name := st.path + info.Name
+		if _, ok := r.Fields[name]; !ok {
+			r.Fields[name] = &FieldReport{
+				Name: name, Flags: []string{}, Positions: []int{},
+				Source: origins[info.Name].source,
+				Origin: origins[info.Name].origin,
+			}
+		}
+		return r.Fields[name]
+	}
+
+	for _, info := range options {
+		entry(info)
+	}
+	for _, info := range supplied {
+		f := entry(info)
+		f.Set = true
+		f.Count += 1
+		f.Flags = append(f.Flags, info.flag)
+		f.Source = SourceCommandLine
+		if info.index >= 0 {
+			f.Positions = append(f.Positions, st.position(info.index))
+		}
+	}
+	for _, info := range retained {
+		entry(info).Origin = info.flag
+	}
+
+	for _, sec := range sections {
+		for _, info := range sec.fields {
+			entry(info)
+		}
+	}
+	if posIndices == nil {
+		return
+	}
+
+	for _, sec := range sections {
+		r.recordSection(entry, st, sec.fields,
+			posIndices[sec.start:sec.end])
+	}
+}
+
+// RecordSection adds the token indices of the positional tokens of one
+// section (see arg-section) to the report, assigning them to the section's
+// positionals like populatePositionals() does. The entry function returns
+// the report of a field.
+func (r *ParseReport) recordSection(entry func(fieldInfo) *FieldReport,
+	st *parseState, positionals []fieldInfo, posIndices []int) {
+
+	pos := len(positionals)
+	for i, info := range positionals {
+		if info.isSlice {
+			pos = i
+		}
+	}
+	after := len(positionals) - pos - 1
+	for i, info := range positionals {
+		var idx []int
+		switch {
+		case i < pos && i >= len(posIndices): // omitted (arg-optional)
+		case i < pos: // before the slice (or no slice at all)
+			idx = posIndices[i : i+1]
+		case i == pos: // the slice
+			idx = posIndices[pos : len(posIndices)-after]
+		default: // after the slice, counting from the end
+			k := len(posIndices) - (len(positionals) - i)
+			idx = posIndices[k : k+1]
+		}
+
+		f := entry(info)
+		for _, k := range idx {
+			f.Source, f.Origin = SourceCommandLine, ""
+			f.Set = true
+			f.Count += 1
+			f.Positions = append(f.Positions, st.position(k))
+		}
+	}
+}
+
+// PositionalTokenIndices takes a reflect.Value, which must represent a
+// struct, the struct's options, and a slice of tokens, and returns the
+// indices of the tokens that are assigned to positional fields: tokens
+// before "--" that are neither flags nor arguments of flags (nor
+// assignments, if the struct has an arg-assign field), and all tokens
+// after "--".
+func positionalTokenIndices(v reflect.Value, options map[string]fieldInfo,
+	tokens []string, isFused bool) []int {
+
+	_, hasAssign, _ := findAssignField(v)
+
+	out := []int{}
+	for _, i := range positionalIndices(options, tokens, isFused) {
+		if hasAssign && assignmentRE.MatchString(tokens[i]) {
+			continue
+		}
+		out = append(out, i)
+	}
+
+	for i := len(tokens) - countAfterEndFlags(tokens); i < len(tokens); i++ {
+		out = append(out, i)
+	}
+
+	return out
+}
@@ -0,0 +1,241 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"slices"
+	"strings"
+)
+
+func Test_ParseWithReport(t *testing.T) {
+	type reportArgs struct {
+		Verbose bool     `arg-flag:"-v --verbose"`
+		Timeout int      `arg-flag:"-t --timeout" arg-default:"30"`
+		Tags    []string `arg-flag:"--tag"`
+		First   string
+		Middle  []string
+		Last    string
+	}
+
+	tests := []struct {
+		slice     []string
+		name      string
+		set       bool
+		count     int
+		flags     []string
+		positions []int
+	}{
+		{[]string{"a", "b"}, "Timeout", false, 0, []string{}, []int{}},
+		{[]string{"-t", "5", "a", "b"}, "Timeout", true, 1,
+			[]string{"-t"}, []int{0}},
+		{[]string{"a", "-vv", "--verbose", "b"}, "Verbose", true, 3,
+			[]string{"-v", "-v", "--verbose"}, []int{1, 1, 2}},
+		{[]string{"--tag=x", "a", "--tag", "y", "b"}, "Tags", true, 2,
+			[]string{"--tag", "--tag"}, []int{0, 2}},
+		{[]string{"a", "-v", "b"}, "First", true, 1, []string{}, []int{0}},
+		{[]string{"a", "-v", "b"}, "Middle", false, 0, []string{}, []int{}},
+		{[]string{"a", "m", "-v", "n", "--", "-x", "b"}, "Middle", true, 3,
+			[]string{}, []int{1, 3, 5}},
+		{[]string{"a", "m", "-v", "n", "--", "-x", "b"}, "Last", true, 1,
+			[]string{}, []int{6}},
+	}
+
+	for _, test := range tests {
+		r, err := FromSliceWithReport(test.slice, &reportArgs{})
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+
+		f, ok := r.Fields[test.name]
+		if !ok {
+			t.Errorf("%v: Missing field %s", test.slice, test.name)
+			continue
+		}
+		if f.Set != test.set || f.Count != test.count ||
+			!slices.Equal(f.Flags, test.flags) ||
+			!slices.Equal(f.Positions, test.positions) {
+			t.Errorf("%v: got=%+v", test.slice, *f)
+		}
+		if r.IsSet(test.name) != test.set || r.Count(test.name) != test.count {
+			t.Errorf("%v: IsSet/Count inconsistent", test.slice)
+		}
+	}
+
+	if r, _ := FromSliceWithReport([]string{"a", "b"}, &reportArgs{}); r.IsSet("X") {
+		t.Errorf("Unknown field reported as set")
+	}
+}
+
+func Test_ParseWithReportCommands(t *testing.T) {
+	r, err := FromSliceWithReport(
+		[]string{"-C", "dir", "clone", "--depth", "1", "url"}, &commandArgs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !r.IsSet("Dir") || r.IsSet("Verbose") {
+		t.Errorf("Top level: got=%v %v", r.Fields["Dir"], r.Fields["Verbose"])
+	}
+	if f := r.Fields["Clone.Depth"]; f == nil || !slices.Equal(f.Positions, []int{3}) {
+		t.Errorf("Subcommand option: got=%+v", f)
+	}
+	if f := r.Fields["Clone.Repo"]; f == nil || !slices.Equal(f.Positions, []int{5}) {
+		t.Errorf("Subcommand positional: got=%+v", f)
+	}
+	if _, ok := r.Fields["Push.Force"]; ok {
+		t.Errorf("Unselected subcommand reported")
+	}
+
+	// Parse errors
+	if _, err := FromSliceWithReport([]string{"pull"}, &commandArgs{}); err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func Test_ParseWithReportOptional(t *testing.T) {
+	type optArgs struct {
+		Src string
+		Dst string `arg-optional:""`
+	}
+
+	r, err := FromSliceWithReport([]string{"a"}, &optArgs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !r.IsSet("Src") || r.IsSet("Dst") {
+		t.Errorf("got=%+v %+v", r.Fields["Src"], r.Fields["Dst"])
+	}
+}
+
+func Test_ParseReportProvenance(t *testing.T) {
+	type provArgs struct {
+		Port    int      `arg-flag:"-p --port" arg-default:"80"`
+		Host    string   `arg-flag:"--host" arg-env:"CLEANARG_HOST"`
+		User    string   `arg-flag:"--user" arg-default:"root"`
+		Threads int      `arg-flag:"--threads"`
+		Tags    []string `arg-flag:"--tag"`
+		Files   []string
+	}
+
+	t.Setenv("CLEANARG_HOST", "example.com")
+	config := writeConfig(t, "app.json", `{"user": "admin", "tag": ["a"]}`)
+
+	p := NewParser()
+	p.AddConfigFile(config)
+	p.SetDefault("Threads", 4)
+
+	r, err := p.ParseWithReport([]string{"--port", "8080", "x"}, &provArgs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		source     Source
+		provenance string
+	}{
+		{"Port", SourceCommandLine, "command line (--port)"},
+		{"Host", SourceEnv, "environment (CLEANARG_HOST)"},
+		{"User", SourceConfig, "config file (" + config + ")"},
+		{"Threads", SourceDefault, "default (runtime default)"},
+		{"Tags", SourceConfig, "config file (" + config + ")"},
+		{"Files", SourceCommandLine, "command line"},
+		{"Other", SourceNone, ""},
+	}
+
+	for _, test := range tests {
+		if s := r.Source(test.name); s != test.source {
+			t.Errorf("%s: got=%v want=%v", test.name, s, test.source)
+		}
+		if s := r.Provenance(test.name); s != test.provenance {
+			t.Errorf("%s: got=%q want=%q", test.name, s, test.provenance)
+		}
+	}
+
+	// Without configuration, arg-default and unset fields
+	r, err = FromSliceWithReport([]string{}, &provArgs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s := r.Provenance("User"); s != "default (arg-default)" {
+		t.Errorf("User: got=%q", s)
+	}
+	if s := r.Provenance("Threads"); s != "none" {
+		t.Errorf("Threads: got=%q", s)
+	}
+
+	// Preset values, with sticky defaults
+	p = NewParser()
+	p.EnableStickyDefaults()
+	r, err = p.ParseWithReport([]string{}, &provArgs{Threads: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s := r.Provenance("Threads"); s != "default (preset value)" {
+		t.Errorf("Threads (sticky): got=%q", s)
+	}
+}
+
+func Test_ParseReportProvenancePositional(t *testing.T) {
+	type posArgs struct {
+		Src  string
+		Dst  string `arg-optional:"" arg-default:"."`
+		Mode string `arg-optional:"" arg-default:"copy"`
+	}
+
+	r, err := FromSliceWithReport([]string{"a", "b"}, &posArgs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		provenance string
+	}{
+		{"Src", "command line"},
+		{"Dst", "command line"},
+		{"Mode", "default (arg-default)"},
+	}
+	for _, test := range tests {
+		if s := r.Provenance(test.name); s != test.provenance {
+			t.Errorf("%s: got=%q want=%q", test.name, s, test.provenance)
+		}
+	}
+
+	// Runtime defaults
+	p := NewParser(WithDefault("Mode", "move"))
+	r, err = p.ParseWithReport([]string{"a"}, &posArgs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s := r.Provenance("Mode"); s != "default (runtime default)" {
+		t.Errorf("Mode: got=%q", s)
+	}
+	if s := r.Provenance("Dst"); s != "default (arg-default)" {
+		t.Errorf("Dst: got=%q", s)
+	}
+}
+
+func Test_WriteValuesWithReport(t *testing.T) {
+	type valArgs struct {
+		Port int    `arg-flag:"--port" arg-default:"80"`
+		Name string `arg-flag:"--name"`
+	}
+
+	a := valArgs{}
+	r, err := FromSliceWithReport([]string{"--name", "x"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	buf := strings.Builder{}
+	if err := WriteValuesWithReport(&buf, &a, r); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "Port   int      80   default (arg-default)\n" +
+		"Name   string   x    command line (--name)\n"
+	if buf.String() != want {
+		t.Errorf("got=%q want=%q", buf.String(), want)
+	}
+}
@@ -0,0 +1,48 @@
+package cleanarg
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// CheckResetTag takes a fieldInfo, and checks its reset value (arg-reset),
+// if any: it is only permitted on slice fields, and must not be empty
+// (since an empty value can not be told apart from a missing one).
+// Returns an error if it does not meet these conditions.
+func checkResetTag(info fieldInfo) error {
+	if !info.canReset {
+		return nil
+	}
+
+	if !info.isSlice {
+		return fmt.Errorf("%s requires slice field: %s", tagReset, info.Name)
+	}
+	if info.resetval == "" {
+		return fmt.Errorf("malformed %s: %s", tagReset, info.Name)
+	}
+	return nil
+}
+
+// IsReset reports whether the value in the supplied fieldInfo is the reset
+// value (arg-reset) of its slice field.
+func isReset(info fieldInfo) bool {
+	return info.isSlice && info.canReset && info.value == info.resetval
+}
+
+// ResetSlice takes a fieldInfo of a slice field, and a reflect.Value, which
+// must represent the struct to populate, and empties the slice (so that it
+// is empty, rather than nil).
+func resetSlice(info fieldInfo, v reflect.Value) {
+	field := v.FieldByIndex(info.Index)
+	field.Set(reflect.MakeSlice(field.Type(), 0, 0))
+}
+
+// ResetHint returns a brief hint on the reset value of a slice field
+// (arg-reset), for usage messages, or the empty string if it has none.
+func resetHint(info fieldInfo) string {
+	if !info.canReset {
+		return ""
+	}
+	return messagef("%s clears", strconv.Quote(info.resetval))
+}
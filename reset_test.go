@@ -0,0 +1,95 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+func Test_FromSliceReset(t *testing.T) {
+	type resetArgs struct {
+		Tags  []string `arg-flag:"-t --tags" arg-default:"a,b" arg-reset:"none"`
+		Ports []int    `arg-flag:"-p --ports" arg-sep:"," arg-reset:"-"`
+	}
+
+	tests := []struct {
+		tokens []string
+		tags   []string
+		ports  []int
+	}{
+		{[]string{}, []string{"a", "b"}, nil},
+		{[]string{"-t", "none"}, []string{}, nil},
+		{[]string{"-t", "none", "-t", "c"}, []string{"c"}, nil},
+		{[]string{"-t", "c", "--tags", "none"}, []string{}, nil},
+		{[]string{"-p", "1,2", "--ports=-"}, []string{"a", "b"}, []int{}},
+		{[]string{"-p", "1,2", "--ports", "-", "-p", "3"}, []string{"a", "b"},
+			[]int{3}},
+	}
+
+	for _, test := range tests {
+		a := resetArgs{}
+		if err := FromSlice(test.tokens, &a); err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.tokens, err)
+			continue
+		}
+		if !reflect.DeepEqual(a.Tags, test.tags) ||
+			!reflect.DeepEqual(a.Ports, test.ports) {
+			t.Errorf("%v: got=%+v", test.tokens, a)
+		}
+	}
+
+	sb := strings.Builder{}
+	WriteUsage(&sb, &resetArgs{})
+	if !strings.Contains(sb.String(), `"none" clears`) ||
+		!strings.Contains(sb.String(), `"-" clears`) {
+		t.Errorf("Expected hints in usage:\n%s", sb.String())
+	}
+
+	bad := []struct {
+		data any
+		text string
+	}{
+		{&struct {
+			Tag string `arg-flag:"-t" arg-reset:"none"`
+		}{}, "requires slice field"},
+		{&struct {
+			Tags []string `arg-flag:"-t" arg-reset:""`
+		}{}, "malformed"},
+	}
+	for _, test := range bad {
+		err := FromSlice([]string{}, test.data)
+		if err == nil || !strings.Contains(err.Error(), test.text) {
+			t.Errorf("Expected error %q: got=%v", test.text, err)
+		}
+	}
+}
+
+func Test_FromSliceResetConfig(t *testing.T) {
+	type resetArgs struct {
+		Tags []string `arg-flag:"-t --tags" arg-reset:"none"`
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{"tags": ["x", "y"]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := resetArgs{}
+	p := NewParser(WithConfigFile(path))
+	if err := p.Parse([]string{}, &a); err != nil ||
+		!reflect.DeepEqual(a.Tags, []string{"x", "y"}) {
+		t.Fatalf("Unexpected result: got=%v, %v", a.Tags, err)
+	}
+
+	a = resetArgs{}
+	if err := p.Parse([]string{"-t", "none"}, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(a.Tags) != 0 {
+		t.Errorf("Expected empty slice: got=%v", a.Tags)
+	}
+}
@@ -0,0 +1,64 @@
+package cleanarg
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// FindRestField takes a reflect.Value, which must represent a struct, and
+// returns the field tagged arg-rest, if any. The boolean return value
+// indicates whether such a field was found.
+// Returns an error if there is more than one such field, or if the field
+// is not of type []string.
+func findRestField(v reflect.Value) (reflect.StructField, bool, error) {
+	found, out := false, reflect.StructField{}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+
+		if _, ok := field.Tag.Lookup(tagRest); !ok {
+			continue
+		}
+		if _, ok := field.Tag.Lookup(tagIgnore); ok {
+			continue
+		}
+
+		if found {
+			return reflect.StructField{}, false,
+				fmt.Errorf("at most one field may be tagged %s", tagRest)
+		}
+		if field.Type != reflect.TypeOf([]string{}) {
+			return reflect.StructField{}, false,
+				fmt.Errorf("field tagged %s must be []string", tagRest)
+		}
+
+		found, out = true, field
+	}
+
+	return out, found, nil
+}
+
+// PopulateRest takes a reflect.Value, which must represent a pointer to
+// the struct to populate, and a slice of tokens. If the struct has a field
+// tagged arg-rest, and the tokens contain the "--" token, all tokens
+// following it are stored in this field (verbatim, replacing its previous
+// contents), and removed from the slice of tokens, together with the "--"
+// token itself. Returns the remaining tokens, or an error if the struct is
+// malformed.
+func populateRest(v reflect.Value, tokens []string) ([]string, error) {
+	field, ok, err := findRestField(v)
+	if err != nil || !ok {
+		return tokens, err
+	}
+
+	i := slices.Index(tokens, endFlagsIndicator)
+	if i < 0 {
+		return tokens, nil
+	}
+
+	rest := append([]string{}, tokens[i+1:]...)
+	v.FieldByIndex(field.Index).Set(reflect.ValueOf(rest))
+
+	return tokens[:i], nil
+}
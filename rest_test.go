@@ -0,0 +1,98 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"reflect"
+	"strings"
+)
+
+type restArgs struct {
+	Verbose bool     `arg-flag:"-v"`
+	Files   []string
+	Command []string `arg-rest:"" arg-help:"Command to run"`
+}
+
+func Test_PopulateRest(t *testing.T) {
+	tests := []struct {
+		slice []string
+		want  restArgs
+	}{
+		{[]string{}, restArgs{false, nil, nil}},
+		{[]string{"-v", "a"}, restArgs{true, []string{"a"}, nil}},
+		{[]string{"a", "--"}, restArgs{false, []string{"a"}, []string{}}},
+		{[]string{"-v", "a", "--", "cmd", "-v", "--", "x"},
+			restArgs{true, []string{"a"}, []string{"cmd", "-v", "--", "x"}}},
+		{[]string{"--", "-v"}, restArgs{false, nil, []string{"-v"}}},
+	}
+
+	for _, test := range tests {
+		a := restArgs{}
+		if err := FromSlice(test.slice, &a); err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+		if !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%v: got=%#v want=%#v", test.slice, a, test.want)
+		}
+	}
+
+	// Subcommands receive the tokens following their name
+	type cmdArgs struct {
+		Rest []string `arg-rest:""`
+		Run  struct {
+			Args []string `arg-rest:""`
+		} `arg-command:"run"`
+	}
+	c := cmdArgs{}
+	if err := FromSlice([]string{"run", "--", "x"}, &c); err != nil ||
+		c.Rest != nil || !reflect.DeepEqual(c.Run.Args, []string{"x"}) {
+		t.Errorf("Subcommand: got=%+v err=%v", c, err)
+	}
+}
+
+func Test_FindRestField(t *testing.T) {
+	tests := []struct {
+		data    any
+		found   bool
+		wantErr bool
+	}{
+		{restArgs{}, true, false},
+		{struct{ A []string }{}, false, false},
+		{struct {
+			A []string `arg-rest:"" arg-ignore:""`
+		}{}, false, false},
+		{struct {
+			A []int `arg-rest:""`
+		}{}, false, true},
+		{struct {
+			A string `arg-rest:""`
+		}{}, false, true},
+		{struct {
+			A, B []string `arg-rest:""`
+		}{}, false, true},
+	}
+
+	for i, test := range tests {
+		_, found, err := findRestField(reflect.ValueOf(test.data))
+		if found != test.found || (err != nil) != test.wantErr {
+			t.Errorf("%d: got=%v,%v want=%v,%v",
+				i, found, err, test.found, test.wantErr)
+		}
+	}
+}
+
+func Test_WriteUsageRest(t *testing.T) {
+	sb := strings.Builder{}
+	WriteShortUsage(&sb, &restArgs{})
+	want := shortUsagePrefix + "[-v] [string]+ [-- string]+ \n"
+	if sb.String() != want {
+		t.Errorf("want=%s\ngot=%s", want, sb.String())
+	}
+
+	sb = strings.Builder{}
+	WriteUsage(&sb, &restArgs{})
+	if !strings.Contains(sb.String(), "[-- string] (repeatable) Command to run") {
+		t.Errorf("Missing rest:\n%s", sb.String())
+	}
+}
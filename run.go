@@ -0,0 +1,106 @@
+package cleanarg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+)
+
+// A Runner is a struct (typically, the struct of a subcommand) that knows
+// how to carry out its command, once it has been populated. Execute()
+// invokes the Run method of the selected subcommand.
+type Runner interface {
+	Run(ctx context.Context) error
+}
+
+// Execute takes a context and a pointer to a struct, populates the struct
+// with the command-line arguments, with automatic help handling (see
+// FromCommandLineWithHelp()), and invokes the Run method of the selected
+// subcommand (see Parser.Execute()). If help is requested, the program
+// exits with code 0 once the usage message is written (as with
+// ExitOnError), so that all other errors can be handled alike:
+//
+//	func main() {
+//		if err := cleanarg.Execute(context.Background(), &Config{}); err != nil {
+//			fmt.Fprintln(os.Stderr, err)
+//			os.Exit(1)
+//		}
+//	}
+func Execute(ctx context.Context, data any) error {
+	err := NewParser(WithHelp()).Execute(ctx, os.Args[1:], data)
+	if errors.Is(err, ErrHelp) {
+		osExit(0)
+	}
+	return err
+}
+
+// Execute takes a context, a slice of string tokens, and a pointer to a
+// struct, populates the struct from the tokens, just like Parse(), and then
+// invokes the Run method (see Runner) of the innermost selected subcommand
+// that implements Runner (with nested subcommands, this may be one of the
+// outer ones), or else of the struct itself. The error returned by Run is
+// returned as it is.
+// Returns an error if parsing fails (handled according to the Parser's
+// error handling), an error of kind ErrMissingCommand if no subcommand is
+// selected and the struct does not implement Runner, and a plain error if
+// a subcommand is selected but neither it nor the struct implements Runner.
+func (p *Parser) Execute(ctx context.Context, tokens []string,
+	data any) error {
+
+	selected := []reflect.Value{}
+	err := parseSelected(p, tokens, data, &selected)
+	if err != nil {
+		return handleError(p, p.onError, err, data)
+	}
+
+	for i := len(selected) - 1; i >= 0; i-- {
+		if r, ok := asRunner(selected[i]); ok {
+			return r.Run(ctx)
+		}
+	}
+	if r, ok := data.(Runner); ok {
+		return r.Run(ctx)
+	}
+
+	if len(selected) > 0 {
+		return fmt.Errorf("command does not implement Runner: %s",
+			selected[len(selected)-1].Type())
+	}
+	err = newParseError(ErrMissingCommand, "", -1, "missing command")
+	return handleError(p, p.onError, err, data)
+}
+
+// ParseSelected populates the struct that data points to from the tokens,
+// like Parser.Parse() (but without the Parser's error handling), and
+// appends the structs of the selected subcommands to selected, outermost
+// first.
+func parseSelected(p *Parser, tokens []string, data any,
+	selected *[]reflect.Value) error {
+
+	v, err := unwrap(data)
+	if err != nil {
+		return err
+	}
+
+	tokens, err = p.preprocess(tokens)
+	if err != nil {
+		return err
+	}
+
+	return populateStruct(tokens, v, p, &parseState{defaults: p.defaults,
+		selected: selected})
+}
+
+// AsRunner returns the Runner that the struct represented by v implements,
+// with a pointer receiver (if v is addressable) or a value receiver.
+func asRunner(v reflect.Value) (Runner, bool) {
+	if v.CanAddr() {
+		if r, ok := v.Addr().Interface().(Runner); ok {
+			return r, true
+		}
+	}
+	r, ok := v.Interface().(Runner)
+	return r, ok
+}
@@ -0,0 +1,121 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+)
+
+type ctxKey struct{}
+
+// runLog records the commands that were run, and their context.
+type runLog struct {
+	runs []string
+}
+
+type addCmd struct {
+	log  *runLog `arg-ignore:""`
+	Name string
+}
+
+func (a *addCmd) Run(ctx context.Context) error {
+	run := "add " + a.Name + " " + ctx.Value(ctxKey{}).(string)
+	a.log.runs = append(a.log.runs, run)
+	return nil
+}
+
+type listCmd struct {
+	All bool `arg-flag:"-a"`
+}
+
+type remoteGroup struct {
+	Add  *addCmd  `arg-command:"add"`
+	List *listCmd `arg-command:"list"`
+}
+
+// Value receiver: the remote group runs when "list" is selected
+func (r remoteGroup) Run(ctx context.Context) error {
+	if r.List != nil && r.List.All {
+		return errors.New("list all")
+	}
+	return errors.New("remote")
+}
+
+type statusCmd struct{}
+
+type runArgs struct {
+	Verbose bool         `arg-flag:"-v"`
+	Remote  *remoteGroup `arg-command:"remote"`
+	Status  *statusCmd   `arg-command:"status"`
+}
+
+func Test_Execute(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKey{}, "ctx")
+
+	log := &runLog{}
+	a := runArgs{Remote: &remoteGroup{Add: &addCmd{log: log}}}
+	err := NewParser().Execute(ctx, []string{"-v", "remote", "add", "x"}, &a)
+	if err != nil || strings.Join(log.runs, ",") != "add x ctx" || !a.Verbose {
+		t.Errorf("Unexpected result: %v, %v", log.runs, err)
+	}
+
+	tests := []struct {
+		tokens  []string
+		wantErr string
+	}{
+		{[]string{"remote", "list", "-a"}, "list all"},
+		{[]string{"remote"}, "remote"},
+		{[]string{"status"},
+			"command does not implement Runner: cleanarg.statusCmd"},
+		{[]string{}, "missing command"},
+		{[]string{"fetch"},
+			"unknown command: fetch (available: remote, status)"},
+	}
+	for _, test := range tests {
+		err := NewParser().Execute(ctx, test.tokens, &runArgs{})
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("%v: got=%v want=%s", test.tokens, err, test.wantErr)
+		}
+	}
+
+	err = NewParser().Execute(ctx, []string{}, &runArgs{})
+	if !errors.Is(err, ErrMissingCommand) {
+		t.Errorf("Expected ErrMissingCommand, got: %v", err)
+	}
+}
+
+func Test_ExecuteHelp(t *testing.T) {
+	var stderr bytes.Buffer
+	p := NewParser(WithHelp(), WithErrorWriter(&stderr))
+
+	err := p.Execute(context.Background(), []string{"remote", "-h"},
+		&runArgs{})
+	if !errors.Is(err, ErrHelp) || !strings.Contains(stderr.String(), "add") {
+		t.Errorf("Unexpected result: %v, %q", err, stderr.String())
+	}
+}
+
+func Test_ExecuteCommandLineHelp(t *testing.T) {
+	codes := exitCodes(t)
+	args := os.Args
+	os.Args = []string{"tool", "remote", "--help"}
+	t.Cleanup(func() { os.Args = args })
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	stderr := os.Stderr
+	os.Stderr = devNull
+	t.Cleanup(func() { os.Stderr = stderr; devNull.Close() })
+
+	err = Execute(context.Background(), &runArgs{})
+	if !errors.Is(err, ErrHelp) || !slices.Equal(*codes, []int{0}) {
+		t.Errorf("got=%v, exit codes %v want ErrHelp, [0]", err, *codes)
+	}
+}
@@ -0,0 +1,21 @@
+package cleanarg
+
+import (
+	"reflect"
+	"unicode/utf8"
+)
+
+// The type of rune fields (which is the type of int32 fields as well).
+var typeRune = reflect.TypeOf(rune(0))
+
+// ParseRune converts its argument, which must consist of exactly one
+// (UTF-8 encoded) character, such as "," or "→", to a rune.
+// Returns an error if the argument is empty, longer than one character, or
+// not valid UTF-8.
+func parseRune(s string) (rune, error) {
+	r, size := utf8.DecodeRuneInString(s)
+	if (r == utf8.RuneError && size <= 1) || size != len(s) {
+		return 0, errorf("expected a single character: %q", s)
+	}
+	return r, nil
+}
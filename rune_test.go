@@ -0,0 +1,66 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"strings"
+)
+
+func Test_parseRune(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    rune
+		wantErr bool
+	}{
+		{",", ',', false},
+		{"\t", '\t', false},
+		{"→", '→', false},
+		{"�", '�', false},
+		{"", 0, true},
+		{"ab", 0, true},
+		{",,", 0, true},
+		{"\xff", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := parseRune(test.s)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: Unexpected error: %v", test.s, err)
+		}
+		if err == nil && got != test.want {
+			t.Errorf("%q: got=%q want=%q", test.s, got, test.want)
+		}
+	}
+}
+
+func Test_FromSliceRune(t *testing.T) {
+	type runeArgs struct {
+		Delim rune   `arg-flag:"-d --delimiter" arg-default:","`
+		Quote []rune `arg-flag:"-q"`
+	}
+
+	a := runeArgs{}
+	if err := FromSlice([]string{"-q", "'", "-q", "\""}, &a); err != nil ||
+		a.Delim != ',' || string(a.Quote) != "'\"" {
+		t.Errorf("got=%v, %v", a, err)
+	}
+
+	a = runeArgs{}
+	if err := FromSlice([]string{"-d", "\t"}, &a); err != nil || a.Delim != '\t' {
+		t.Errorf("got=%v, %v", a, err)
+	}
+
+	err := FromSlice([]string{"--delimiter", "::"}, &runeArgs{})
+	if !errors.Is(err, ErrConversion) ||
+		err.Error() != `expected a single character: "::"` {
+		t.Errorf("got=%v want ErrConversion", err)
+	}
+
+	sb := strings.Builder{}
+	WriteUsage(&sb, &runeArgs{})
+	if !strings.Contains(sb.String(), "-d --delimiter [rune=,]") ||
+		!strings.Contains(sb.String(), "(a single character)") {
+		t.Errorf("Unexpected usage:\n%s", sb.String())
+	}
+}
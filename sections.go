@@ -0,0 +1,111 @@
+package cleanarg
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+)
+
+// A token that separates sections of positionals (arg-section): not empty,
+// without whitespace, and not looking like a flag.
+var sectionRE = regexp.MustCompile(`^[^-+\s]\S*$`)
+
+// A section of positional fields (see arg-section), and the range of the
+// positional tokens assigned to it (not including the separator).
+type positionalSection struct {
+	fields     []fieldInfo
+	start, end int
+}
+
+// CheckSections takes the positional fields of a struct, and checks their
+// section separators (arg-section), if any: the first positional may not
+// start a section (it always belongs to the first one), and the separators
+// must be distinct. Returns an error if one of these conditions is
+// violated.
+func checkSections(positionals []fieldInfo) error {
+	seen := map[string]struct{}{}
+	for i, info := range positionals {
+		if info.section == "" {
+			continue
+		}
+		if i == 0 {
+			return fmt.Errorf("%s not permitted on first positional: %s",
+				tagSection, info.Name)
+		}
+		if _, ok := seen[info.section]; ok {
+			return fmt.Errorf("duplicate %s: %s", tagSection, info.section)
+		}
+		seen[info.section] = struct{}{}
+	}
+	return nil
+}
+
+// SplitSections takes the positional fields of a struct, and the positional
+// tokens, and splits both into sections: each positional tagged arg-section
+// starts a new section of fields, and each section's tokens follow the
+// left-most occurrence of its separator (following the previous
+// section's). A section whose separator does not occur receives no tokens.
+// Without arg-section tags, there is a single section, which receives all
+// tokens.
+func splitSections(positionals []fieldInfo,
+	tokens []string) []positionalSection {
+
+	out := []positionalSection{}
+	for _, info := range positionals {
+		if len(out) == 0 || info.section != "" {
+			out = append(out, positionalSection{})
+		}
+		out[len(out)-1].fields = append(out[len(out)-1].fields, info)
+	}
+	if len(out) == 0 {
+		return []positionalSection{{start: 0, end: len(tokens)}}
+	}
+
+	// Positions of the separators (-1: none for the first section, -2: the
+	// separator does not occur)
+	at, cur := make([]int, len(out)), 0
+	at[0] = -1
+	for k := 1; k < len(out); k++ {
+		at[k] = -2
+		if j := slices.Index(tokens[cur:], out[k].fields[0].section); j >= 0 {
+			at[k] = cur + j
+			cur = at[k] + 1
+		}
+	}
+
+	for k := range out {
+		if at[k] == -2 {
+			continue
+		}
+		out[k].start, out[k].end = at[k]+1, len(tokens)
+		for m := k + 1; m < len(out); m++ {
+			if at[m] >= 0 {
+				out[k].end = at[m]
+				break
+			}
+		}
+	}
+	return out
+}
+
+// PopulateSections takes a slice of positional fields, the positional
+// tokens, and a reflect.Value, which must represent a pointer to the struct
+// to be populated, and populates the positional fields of each section
+// (see arg-section) from the section's tokens, like populatePositionals()
+// does. Returns an error if one of the sections cannot be populated.
+func populateSections(positionals []fieldInfo, tokens []string,
+	v reflect.Value) error {
+
+	sections := splitSections(positionals, tokens)
+	for _, sec := range sections {
+		err := populatePositionals(sec.fields, tokens[sec.start:sec.end], v)
+		if err != nil && len(sections) > 1 && sec.fields[0].section != "" {
+			return errorf("section %s: %w", sec.fields[0].section, err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
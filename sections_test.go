@@ -0,0 +1,119 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"reflect"
+	"strings"
+)
+
+type sectionArgs struct {
+	Verbose bool `arg-flag:"-v"`
+	Inputs  []string
+	Outputs []string `arg-section:"::"`
+	Log     string   `arg-section:"log:"`
+}
+
+func Test_splitSections(t *testing.T) {
+	v, _ := unwrap(&sectionArgs{})
+	_, positionals, _ := analyzeStruct(v)
+
+	tests := []struct {
+		tokens []string
+		want   [][2]int
+	}{
+		{[]string{"a", "::", "b", "log:", "c"}, [][2]int{{0, 1}, {2, 3}, {4, 5}}},
+		{[]string{"a", "b", "log:", "c"}, [][2]int{{0, 2}, {0, 0}, {3, 4}}},
+		{[]string{"a", "::", "b", "::"}, [][2]int{{0, 1}, {2, 4}, {0, 0}}},
+		{[]string{"log:", "::", "a"}, [][2]int{{0, 1}, {2, 3}, {0, 0}}},
+		{[]string{}, [][2]int{{0, 0}, {0, 0}, {0, 0}}},
+	}
+
+	for _, test := range tests {
+		got := [][2]int{}
+		for _, sec := range splitSections(positionals, test.tokens) {
+			got = append(got, [2]int{sec.start, sec.end})
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.tokens, got, test.want)
+		}
+	}
+}
+
+func Test_FromSliceSections(t *testing.T) {
+	tests := []struct {
+		slice   []string
+		want    sectionArgs
+		wantErr bool
+	}{
+		{[]string{"a", "b", "::", "c", "-v", "log:", "d"},
+			sectionArgs{true, []string{"a", "b"}, []string{"c"}, "d"}, false},
+		{[]string{"::", "c", "log:", "d"},
+			sectionArgs{false, nil, []string{"c"}, "d"}, false},
+		{[]string{"a", "log:", "d"},
+			sectionArgs{false, []string{"a"}, nil, "d"}, false},
+		{[]string{"a", "::", "b"}, sectionArgs{}, true},
+		{[]string{"a", "log:", "d", "e"}, sectionArgs{}, true},
+	}
+
+	for _, test := range tests {
+		a := sectionArgs{}
+		err := FromSlice(test.slice, &a)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if err == nil && !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%v: got=%+v want=%+v", test.slice, a, test.want)
+		}
+	}
+
+	// Token indices in the report skip the separators
+	report, err := FromSliceWithReport([]string{"a", "::", "b", "c",
+		"log:", "d"}, &sectionArgs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := report.Fields["Outputs"].Positions; !reflect.DeepEqual(got,
+		[]int{2, 3}) {
+		t.Errorf("Report: got=%v", got)
+	}
+
+	sb := strings.Builder{}
+	WriteShortUsage(&sb, &sectionArgs{})
+	if !strings.Contains(sb.String(), "[string]+ :: [string]+ log: [string]") {
+		t.Errorf("Unexpected usage:\n%s", sb.String())
+	}
+
+	bad := []struct {
+		data any
+		text string
+	}{
+		{&struct {
+			A string `arg-section:"::"`
+		}{}, "first positional"},
+		{&struct {
+			A, B string
+			C    string `arg-section:"::"`
+			D    string `arg-section:"::"`
+		}{}, "duplicate"},
+		{&struct {
+			A string
+			B string `arg-section:"--x"`
+		}{}, "malformed"},
+		{&struct {
+			A string `arg-flag:"-a" arg-section:"::"`
+		}{}, "requires positional"},
+		{&struct {
+			A []string
+			B []string
+		}{}, "at most one"},
+	}
+	for i, b := range bad {
+		err := FromSlice([]string{}, b.data)
+		if err == nil || !strings.Contains(strings.ToLower(err.Error()),
+			b.text) {
+			t.Errorf("%d: Expected error containing %q, got: %v", i, b.text,
+				err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package cleanarg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The separator of the elements of slice default values (arg-default), for
+// fields without a separator of their own (arg-sep).
+const defaultSep = ","
+
+// CheckSeparatorTag takes a fieldInfo, and checks its separator (arg-sep),
+// if any: it is only permitted on slice fields, and must not contain a
+// backslash (which escapes the separator). Returns an error if it does not
+// meet these conditions.
+func checkSeparatorTag(info fieldInfo) error {
+	if _, ok := info.Tag.Lookup(tagSep); !ok {
+		return nil
+	}
+
+	if !info.isSlice {
+		return fmt.Errorf("%s requires slice field: %s", tagSep, info.Name)
+	}
+	if info.sep == "" || strings.Contains(info.sep, `\`) {
+		return fmt.Errorf("malformed %s: %s", tagSep, info.Name)
+	}
+	return nil
+}
+
+// SplitValue splits its argument, the value of a slice field, at each
+// occurrence of the separator (arg-sep). A backslash escapes a following
+// separator or backslash, which is then kept literally; other backslashes
+// are kept as they are. So with separator ",", the value `a\,b,c` yields
+// "a,b" and "c".
+func splitValue(s, sep string) []string {
+	parts := []string{}
+	cur := strings.Builder{}
+
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, `\`+sep):
+			cur.WriteString(sep)
+			s = s[1+len(sep):]
+		case strings.HasPrefix(s, `\\`):
+			cur.WriteString(`\`)
+			s = s[2:]
+		case strings.HasPrefix(s, sep):
+			parts = append(parts, cur.String())
+			cur.Reset()
+			s = s[len(sep):]
+		default:
+			cur.WriteByte(s[0])
+			s = s[1:]
+		}
+	}
+
+	return append(parts, cur.String())
+}
+
+// DefaultSeparator returns the separator of the elements of the default
+// value of a slice field: its separator (arg-sep), or else a comma.
+func defaultSeparator(info fieldInfo) string {
+	if info.sep != "" {
+		return info.sep
+	}
+	return defaultSep
+}
+
+// JoinValues is the inverse of splitValue: it joins its arguments, which
+// are formatted elements of a slice, with the separator, escaping any
+// separators (and backslashes) that they contain.
+func joinValues(elems []string, sep string) string {
+	escaped := make([]string, len(elems))
+	for i, e := range elems {
+		e = strings.ReplaceAll(e, `\`, `\\`)
+		escaped[i] = strings.ReplaceAll(e, sep, `\`+sep)
+	}
+	return strings.Join(escaped, sep)
+}
+
+// SeparatorHint returns a brief hint on the separator of a slice field
+// (arg-sep), for usage messages, or the empty string if it has none.
+func separatorHint(info fieldInfo) string {
+	if info.sep == "" {
+		return ""
+	}
+	return messagef("separated by %s", strconv.Quote(info.sep))
+}
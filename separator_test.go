@@ -0,0 +1,153 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"reflect"
+	"strings"
+)
+
+func Test_splitValue(t *testing.T) {
+	tests := []struct {
+		s, sep string
+		want   []string
+	}{
+		{"a,b,c", ",", []string{"a", "b", "c"}},
+		{"a", ",", []string{"a"}},
+		{"a,,b,", ",", []string{"a", "", "b", ""}},
+		{`a\,b,c`, ",", []string{"a,b", "c"}},
+		{`a\\,b`, ",", []string{`a\`, "b"}},
+		{`a\b`, ",", []string{`a\b`}},
+		{"a::b:c", "::", []string{"a", "b:c"}},
+		{`a\::b`, "::", []string{"a::b"}},
+		{"α;β", ";", []string{"α", "β"}},
+	}
+
+	for _, test := range tests {
+		got := splitValue(test.s, test.sep)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got=%q want=%q", test.s, got, test.want)
+		}
+	}
+}
+
+func Test_FromSliceSeparator(t *testing.T) {
+	type sepArgs struct {
+		Tags  []string `arg-flag:"-t --tags" arg-sep:","`
+		Ports []int    `arg-flag:"-p" arg-sep:":"`
+		Files []string `arg-sep:","`
+	}
+
+	a := sepArgs{}
+	err := FromSlice([]string{"--tags", `a,b\,c`, "-t", "d", "-p", "80:443",
+		"x,y", "z"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(a.Tags, []string{"a", "b,c", "d"}) ||
+		!reflect.DeepEqual(a.Ports, []int{80, 443}) ||
+		!reflect.DeepEqual(a.Files, []string{"x", "y", "z"}) {
+		t.Errorf("Unexpected values: got=%+v", a)
+	}
+
+	err = FromSlice([]string{"-p", "80:x"}, &sepArgs{})
+	if !errors.Is(err, ErrConversion) {
+		t.Errorf("Expected ErrConversion: got=%v", err)
+	}
+
+	sb := strings.Builder{}
+	WriteUsage(&sb, &sepArgs{})
+	if !strings.Contains(sb.String(), `(separated by ",")`) {
+		t.Errorf("Expected hint in usage:\n%s", sb.String())
+	}
+}
+
+func Test_checkSeparatorTag(t *testing.T) {
+	tests := []struct {
+		data any
+		text string
+	}{
+		{&struct {
+			A int `arg-flag:"-a" arg-sep:","`
+		}{}, "requires slice field"},
+		{&struct {
+			A []int `arg-flag:"-a" arg-sep:""`
+		}{}, "malformed"},
+		{&struct {
+			A []int `arg-flag:"-a" arg-sep:"\\"`
+		}{}, "malformed"},
+	}
+
+	for _, test := range tests {
+		err := FromSlice([]string{}, test.data)
+		if err == nil || !strings.Contains(err.Error(), test.text) {
+			t.Errorf("Expected error %q: got=%v", test.text, err)
+		}
+	}
+}
+
+func Test_joinValues(t *testing.T) {
+	tests := [][]string{
+		{"a", "b", "c"},
+		{"a,b", `c\`, ""},
+		{`\,`},
+	}
+
+	for _, elems := range tests {
+		s := joinValues(elems, ",")
+		if got := splitValue(s, ","); !reflect.DeepEqual(got, elems) {
+			t.Errorf("%q: got=%q via %q", elems, got, s)
+		}
+	}
+}
+
+func Test_FromSliceSliceDefault(t *testing.T) {
+	type defArgs struct {
+		Tags  []string `arg-flag:"-t" arg-default:"a,b\\,c"`
+		Ports []int    `arg-flag:"-p" arg-sep:":" arg-default:"80:443"`
+		Level []int    `arg-flag:"-l" arg-default:"1"`
+	}
+
+	a := defArgs{}
+	if err := FromSlice([]string{}, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(a.Tags, []string{"a", "b,c"}) ||
+		!reflect.DeepEqual(a.Ports, []int{80, 443}) ||
+		!reflect.DeepEqual(a.Level, []int{1}) {
+		t.Errorf("Unexpected defaults: got=%+v", a)
+	}
+
+	// Values from the command line replace the default elements
+	a = defArgs{}
+	err := FromSlice([]string{"-t", "x", "-p", "8080", "-t", "y"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(a.Tags, []string{"x", "y"}) ||
+		!reflect.DeepEqual(a.Ports, []int{8080}) ||
+		!reflect.DeepEqual(a.Level, []int{1}) {
+		t.Errorf("Unexpected values: got=%+v", a)
+	}
+
+	// Runtime defaults may be slices
+	a = defArgs{}
+	p := NewParser(WithDefault("Tags", []string{"u,v", "w"}),
+		WithDefault("Ports", []int{22}))
+	if err := p.Parse([]string{}, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(a.Tags, []string{"u,v", "w"}) ||
+		!reflect.DeepEqual(a.Ports, []int{22}) {
+		t.Errorf("Unexpected runtime defaults: got=%+v", a)
+	}
+
+	type badArgs struct {
+		Ports []int `arg-flag:"-p" arg-default:"80,x"`
+	}
+	if err := FromSlice([]string{}, &badArgs{}); err == nil ||
+		!strings.Contains(err.Error(), "invalid default value") {
+		t.Errorf("Expected invalid default: got=%v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package cleanarg
+
+import (
+	"strings"
+)
+
+// EnableSingleDashLong turns on single-dash long flags: a long flag may
+// also be given with a single dash, as in "-name" for "--name" (or
+// "-name=x" for "--name=x"), as with find or java. A token is taken for a
+// long flag if the corresponding long flag is defined (this includes
+// --help and --version, when handled automatically); otherwise it is
+// processed as a short flag or as compound short flags, as usual. Hence
+// "-vx" means "--vx", if that is defined, and "-v -x" otherwise. Tokens that
+// are arguments of flags, or that follow "--" (or, with POSIX ordering, the
+// first positional token), are never rewritten.
+func (p *Parser) EnableSingleDashLong() {
+	p.singleDash = true
+}
+
+// RewriteSingleDash takes a Parser, a map of options, and a slice of
+// tokens, and returns the tokens with each single-dash long flag (such as
+// "-name" or "-name=x") replaced by its double-dash form, if that is a
+// known long flag (see rewriteFlags).
+func rewriteSingleDash(p *Parser, options map[string]fieldInfo,
+	tokens []string, isFused, hasCommands bool) []string {
+
+	return rewriteFlags(options, tokens, isFused, hasCommands, p.posix,
+		func(s string) (string, bool) {
+			name, _, _ := strings.Cut(s, "=")
+			if len(name) > 2 && name[0] == '-' && name[1] != '-' &&
+				isKnownFlag(p, options, "-"+name) {
+				return "-" + s, true
+			}
+			return s, false
+		})
+}
+
+// IsKnownFlag returns true if the flag is defined by one of the options,
+// or is handled automatically by the Parser (--help and --version).
+func isKnownFlag(p *Parser, options map[string]fieldInfo, flag string) bool {
+	if _, ok := options[flag]; ok {
+		return true
+	}
+	if _, ok := helpFlags[flag]; ok && p.help {
+		return true
+	}
+	return flag == versionFlag && p.version != ""
+}
@@ -0,0 +1,111 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"reflect"
+)
+
+func Test_rewriteSingleDash(t *testing.T) {
+	type dashArgs struct {
+		Name    string `arg-flag:"-n --name"`
+		Verbose bool   `arg-flag:"-v --vx"`
+		Extra   bool   `arg-flag:"-x"`
+		Heap    string `arg-flag:"--Xmx"`
+		Files   []string
+	}
+
+	v, _ := unwrap(&dashArgs{})
+	options, _, _ := analyzeStruct(v)
+
+	tests := []struct {
+		tokens, want []string
+		commands     bool
+	}{
+		{[]string{"-name", "a"}, []string{"--name", "a"}, false},
+		{[]string{"-name=a", "-Xmx=1g"}, []string{"--name=a", "--Xmx=1g"},
+			false},
+		{[]string{"-vx", "-xv"}, []string{"--vx", "-xv"}, false},
+		{[]string{"-n", "-vx"}, []string{"-n", "-vx"}, false},
+		{[]string{"-xn", "-vx"}, []string{"-xn", "-vx"}, false},
+		{[]string{"-Xmx", "-name"}, []string{"--Xmx", "-name"}, false},
+		{[]string{"-nope", "--name", "a"}, []string{"-nope", "--name", "a"},
+			false},
+		{[]string{"f", "-name", "a"}, []string{"f", "--name", "a"}, false},
+		{[]string{"f", "-name", "a"}, []string{"f", "-name", "a"}, true},
+		{[]string{"--", "-name"}, []string{"--", "-name"}, false},
+		{[]string{"-help"}, []string{"-help"}, false},
+	}
+
+	for _, test := range tests {
+		got := rewriteSingleDash(&Parser{}, options, test.tokens, false,
+			test.commands)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.tokens, got, test.want)
+		}
+	}
+
+	p := &Parser{help: true}
+	got := rewriteSingleDash(p, options, []string{"-help"}, false, false)
+	if !reflect.DeepEqual(got, []string{"--help"}) {
+		t.Errorf("Help: got=%v", got)
+	}
+}
+
+func Test_ParserSingleDashLong(t *testing.T) {
+	type findArgs struct {
+		Name  string `arg-flag:"--name"`
+		Type  string `arg-flag:"-t --type"`
+		Print bool   `arg-flag:"--print"`
+		Paths []string
+	}
+
+	p := NewParser(WithSingleDashLong())
+	a := findArgs{}
+	err := p.Parse([]string{".", "-name", "*.go", "-type=f", "-print"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := findArgs{"*.go", "f", true, []string{"."}}
+	if !reflect.DeepEqual(a, want) {
+		t.Errorf("got=%+v want=%+v", a, want)
+	}
+
+	// Without the option, single-dash long flags are unknown
+	p = NewParser(WithStrict())
+	if err := p.Parse([]string{"-name", "x"}, &findArgs{}); !errors.Is(err,
+		ErrUnknownFlag) {
+		t.Errorf("Expected unknown flag, got: %v", err)
+	}
+}
+
+func Test_ParserSingleDashLongPosix(t *testing.T) {
+	type posixDashArgs struct {
+		Name  string `arg-flag:"--name"`
+		Lines int    `arg-flag:"-n" arg-number:""`
+		Files []string
+	}
+
+	tests := []struct {
+		tokens []string
+		want   posixDashArgs
+	}{
+		{[]string{"-name", "x", "a"}, posixDashArgs{"x", 0, []string{"a"}}},
+		{[]string{"a", "-name", "x"},
+			posixDashArgs{"", 0, []string{"a", "-name", "x"}}},
+		{[]string{"-5", "-name", "x", "a"},
+			posixDashArgs{"x", 5, []string{"a"}}},
+	}
+
+	for _, test := range tests {
+		p := NewParser(WithSingleDashLong(), WithPosixOrdering())
+		a := posixDashArgs{}
+		if err := p.Parse(test.tokens, &a); err != nil {
+			t.Fatalf("%v: Unexpected error: %v", test.tokens, err)
+		}
+		if !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%v: got=%+v want=%+v", test.tokens, a, test.want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package cleanarg
+
+import (
+	"reflect"
+)
+
+// OptionSpec is a read-only description of a struct field that is set by
+// command-line flags, as part of a Spec. A field with fixed-value flags
+// (arg-const) is described by several OptionSpecs: one for its regular
+// flags (if any), and one for each fixed-value flag.
+type OptionSpec struct {
+	Name       string       // Name of the struct field
+	Flags      []string     // All flags for this option, sorted
+	Aliases    []string     // Flags not listed in usage (arg-alias), sorted
+	Type       reflect.Type // Type of the field (element or pointed-to type)
+	Repeatable bool         // True if the field is a slice, or a counter
+	Count      bool         // True if each flag increments the field (arg-count)
+	MaxCount   int          // Maximum number of occurrences (0: unlimited)
+	Const      bool         // True if the flags set a fixed value (arg-const)
+	ConstValue string       // The fixed value, if Const is true
+	ArgName    string       // Placeholder for the argument in usage messages
+	Help       string       // Help text (arg-help), without delimiters
+	Default    string       // Default value (arg-default)
+	Format     string       // Format string or keywords (arg-format)
+	Secret     bool         // True if the value is sensitive (arg-secret)
+	URL        string       // Link to further documentation (arg-url)
+	Choices    []string     // Permitted values (arg-choices)
+	Pattern    string       // Pattern for permitted values (arg-pattern)
+	Min        string       // Lower bound of the value (arg-min)
+	Max        string       // Upper bound of the value (arg-max)
+	Separator  string       // Separator of several values (arg-sep)
+	Env        string       // Environment variable to fall back on (arg-env)
+	Group      string       // Heading in usage messages (arg-group)
+	Xor        string       // Group of mutually exclusive options (arg-xor)
+	RequireOne string       // Group of which one must be supplied (arg-require-one)
+	Together   string       // Group supplied all or none (arg-together)
+	Requires   []string     // Flags of options this one requires (arg-requires)
+	Required   bool         // True if the option must be supplied (arg-required)
+	Persistent bool         // True if accepted after subcommands (arg-persistent)
+	Hidden     bool         // True if omitted from usage and completion (arg-hidden)
+	Deprecated bool         // True if the flags are deprecated (arg-deprecated)
+	Notice     string       // What to use instead, if deprecated (arg-deprecated)
+}
+
+// PositionalSpec is a read-only description of a struct field that is set
+// by positional command-line arguments, as part of a Spec.
+type PositionalSpec struct {
+	Name       string       // Name of the struct field
+	Type       reflect.Type // Type of the field (element or pointed-to type)
+	Repeatable bool         // True if the field is a slice
+	Optional   bool         // True if the argument may be omitted (arg-optional)
+	Section    string       // Token that starts the field's section (arg-section)
+	ArgName    string       // Placeholder for the argument in usage messages
+	Help       string       // Help text (arg-help), without delimiters
+	Format     string       // Format string or keywords (arg-format)
+	Secret     bool         // True if the value is sensitive (arg-secret)
+	URL        string       // Link to further documentation (arg-url)
+	Choices    []string     // Permitted values (arg-choices)
+	Pattern    string       // Pattern for permitted values (arg-pattern)
+	Min        string       // Lower bound of the value (arg-min)
+	Max        string       // Upper bound of the value (arg-max)
+	Separator  string       // Separator of several values (arg-sep)
+}
+
+// Spec is a read-only description of the command-line interface that a
+// struct defines, as returned by Analyze(): its options, ordered by their
+// first flag (as in PrintUsage()), its positional fields, in the order of
+// the fields, and its subcommands, in the order of the fields. Ignored
+// fields are not included.
+type Spec struct {
+	Options     []OptionSpec
+	Positionals []PositionalSpec
+	Commands    []CommandSpec
+}
+
+// CommandSpec is a read-only description of a subcommand (arg-command), as
+// part of a Spec.
+type CommandSpec struct {
+	Name    string   // Name of the subcommand
+	Aliases []string // Further names of the subcommand
+	Default bool     // True if selected by default (arg-default-command)
+	Field   string   // Name of the struct field
+	Help    string   // Help text (arg-help)
+	Spec    *Spec    // Description of the subcommand's struct
+}
+
+// Analyze takes a pointer to a struct and returns a description of the
+// options, positional fields, and subcommands that the struct defines, as
+// they would be used by FromSlice() and PrintUsage(). The description is a
+// copy: modifying it does not affect later calls.
+// Returns an error if the struct contains unsupported types.
+func Analyze(data any) (*Spec, error) {
+	v, err := unwrap(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return analyzeSpec(v)
+}
+
+// AnalyzeSpec takes a reflect.Value, which must represent a struct, and
+// returns a description of the struct (including its subcommands).
+func analyzeSpec(v reflect.Value) (*Spec, error) {
+	options, positionals, err := analyzeStruct(v)
+	if err != nil {
+		return nil, err
+	}
+	commands, err := findCommands(v)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &Spec{
+		Options:     []OptionSpec{},
+		Positionals: []PositionalSpec{},
+		Commands:    []CommandSpec{},
+	}
+
+	for _, info := range uniqueOptions(options) {
+		help, argname := formatHelp(info, false)
+
+		spec.Options = append(spec.Options, OptionSpec{
+			Name:       info.Name,
+			Flags:      append([]string{}, info.allFlags...),
+			Aliases:    optionAliases(options, info),
+			Type:       info.baseType,
+			Repeatable: info.isSlice || info.isCount,
+			Count:      info.isCount,
+			MaxCount:   info.maxCount,
+			Const:      info.isConst,
+			ConstValue: info.constval,
+			ArgName:    argname,
+			Help:       help,
+			Default:    info.defaultval,
+			Format:     info.format,
+			Secret:     info.secret,
+			URL:        info.url,
+			Choices:    append([]string{}, info.choices...),
+			Pattern:    patternString(info),
+			Min:        info.minval,
+			Max:        info.maxval,
+			Separator:  info.sep,
+			Env:        info.env,
+			Group:      info.group,
+			Xor:        info.xorGroup,
+			RequireOne: info.reqGroup,
+			Together:   info.togGroup,
+			Requires:   append([]string{}, info.requires...),
+			Required:   info.required && !info.isConst,
+			Persistent: info.persistent,
+			Hidden:     info.hidden,
+			Deprecated: info.isDeprec,
+			Notice:     info.deprecated,
+		})
+	}
+
+	for _, info := range positionals {
+		help, argname := formatHelp(info, false)
+
+		spec.Positionals = append(spec.Positionals, PositionalSpec{
+			Name:       info.Name,
+			Type:       info.baseType,
+			Repeatable: info.isSlice,
+			Optional:   info.mayOmit,
+			Section:    info.section,
+			ArgName:    argname,
+			Help:       help,
+			Format:     info.format,
+			Secret:     info.secret,
+			URL:        info.url,
+			Choices:    append([]string{}, info.choices...),
+			Pattern:    patternString(info),
+			Min:        info.minval,
+			Max:        info.maxval,
+			Separator:  info.sep,
+		})
+	}
+
+	for _, cmd := range commands {
+		typ := cmd.Type
+		if typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+
+		sub, err := analyzeSpec(reflect.New(typ).Elem())
+		if err != nil {
+			return nil, err
+		}
+		spec.Commands = append(spec.Commands, CommandSpec{
+			Name:    cmd.name,
+			Aliases: append([]string{}, cmd.aliases...),
+			Default: cmd.isDef,
+			Field:   cmd.Name,
+			Help:    cmd.help,
+			Spec:    sub,
+		})
+	}
+
+	return spec, nil
+}
+
+// PatternString returns the pattern of the supplied field (arg-pattern), or
+// the empty string if it has none.
+func patternString(info fieldInfo) string {
+	if info.pattern == nil {
+		return ""
+	}
+	return info.pattern.String()
+}
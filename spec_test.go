@@ -0,0 +1,136 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"reflect"
+	"slices"
+)
+
+func Test_Analyze(t *testing.T) {
+	spec, err := Analyze(&simpleArgs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	opts, pos := spec.Options, spec.Positionals
+
+	wantOpts := []struct {
+		name, argname, help, def string
+		flags                    []string
+	}{
+		{"Counter", "counter", "This is the counter here", "", []string{"+c"}},
+		{"Flag", "bool", "This is a flag", "", []string{"-b"}},
+		{"Name", "string", "", "unknown", []string{"-s", "--name"}},
+		{"Time", "time.Time", "", "", []string{"--time"}},
+	}
+
+	if len(opts) != len(wantOpts) {
+		t.Fatalf("Options: got=%d want=%d", len(opts), len(wantOpts))
+	}
+	for i, w := range wantOpts {
+		o := opts[i]
+		if o.Name != w.name || o.ArgName != w.argname || o.Help != w.help ||
+			o.Default != w.def || !slices.Equal(o.Flags, w.flags) {
+			t.Errorf("Option %d: got=%+v want=%+v", i, o, w)
+		}
+	}
+
+	wantPos := []struct {
+		name       string
+		repeatable bool
+	}{
+		{"Number", false},
+		{"Source", false},
+		{"Rest", true},
+	}
+
+	if len(pos) != len(wantPos) {
+		t.Fatalf("Positionals: got=%d want=%d", len(pos), len(wantPos))
+	}
+	for i, w := range wantPos {
+		if pos[i].Name != w.name || pos[i].Repeatable != w.repeatable {
+			t.Errorf("Positional %d: got=%+v want=%+v", i, pos[i], w)
+		}
+	}
+	if pos[2].Type != reflect.TypeOf("") {
+		t.Errorf("Positional type: got=%v", pos[2].Type)
+	}
+
+	// Modifying the returned flags must not affect later calls
+	opts[2].Flags[0] = "-X"
+	spec, _ = Analyze(&simpleArgs{})
+	if spec.Options[2].Flags[0] != "-s" {
+		t.Errorf("Spec is not read-only")
+	}
+	if len(spec.Commands) != 0 {
+		t.Errorf("Commands: got=%v", spec.Commands)
+	}
+
+	// Hidden options are described (and marked)
+	spec, _ = Analyze(&struct {
+		Debug bool `arg-flag:"--debug" arg-hidden:""`
+	}{})
+	if len(spec.Options) != 1 || !spec.Options[0].Hidden {
+		t.Errorf("Hidden option: got=%+v", spec.Options)
+	}
+
+	// Required options are marked
+	spec, _ = Analyze(&struct {
+		Name string `arg-flag:"--name" arg-required:""`
+	}{})
+	if len(spec.Options) != 1 || !spec.Options[0].Required {
+		t.Errorf("Required option: got=%+v", spec.Options)
+	}
+
+	// Group memberships are described
+	spec, _ = Analyze(&struct {
+		JSON bool   `arg-flag:"--json" arg-xor:"fmt" arg-require-one:"out"`
+		User string `arg-flag:"--user" arg-together:"auth" arg-requires:"--json"`
+	}{})
+	if o := spec.Options[0]; o.Xor != "fmt" || o.RequireOne != "out" ||
+		o.Together != "" || len(o.Requires) != 0 {
+		t.Errorf("Groups: got=%+v", o)
+	}
+	if o := spec.Options[1]; o.Xor != "" || o.Together != "auth" ||
+		!slices.Equal(o.Requires, []string{"--json"}) {
+		t.Errorf("Groups: got=%+v", o)
+	}
+
+	if _, err := Analyze(simpleArgs{}); err == nil {
+		t.Errorf("Expected error for non-pointer")
+	}
+}
+
+func Test_AnalyzeCommands(t *testing.T) {
+	type clone struct {
+		Depth int `arg-flag:"--depth"`
+		URL   string
+	}
+	type args struct {
+		Verbose bool     `arg-flag:"-v"`
+		Clone   *clone   `arg-command:"clone" arg-help:"Clone a repository"`
+		Init    struct{} `arg-command:"init"`
+	}
+
+	spec, err := Analyze(&args{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(spec.Options) != 1 || len(spec.Positionals) != 0 ||
+		len(spec.Commands) != 2 {
+		t.Fatalf("got=%+v", spec)
+	}
+
+	c := spec.Commands[0]
+	if c.Name != "clone" || c.Field != "Clone" || c.Help != "Clone a repository" {
+		t.Errorf("Command: got=%+v", c)
+	}
+	if len(c.Spec.Options) != 1 || c.Spec.Options[0].Name != "Depth" ||
+		len(c.Spec.Positionals) != 1 || c.Spec.Positionals[0].Name != "URL" {
+		t.Errorf("Command spec: got=%+v", c.Spec)
+	}
+	if spec.Commands[1].Name != "init" || len(spec.Commands[1].Spec.Options) != 0 {
+		t.Errorf("Command: got=%+v", spec.Commands[1])
+	}
+}
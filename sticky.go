@@ -0,0 +1,89 @@
+package cleanarg
+
+import (
+	"reflect"
+)
+
+// EnableStickyDefaults turns on sticky defaults: values that are already
+// present in the struct when it is parsed (because the application has
+// loaded them from a configuration file, say) are treated as defaults.
+// Fields holding a non-zero value do not receive their arg-default value,
+// and slices holding elements are replaced (rather than appended to) by
+// values from the command line. Only fields that are actually mentioned on
+// the command line (or whose arg-env variable is set) are overwritten.
+func (p *Parser) EnableStickyDefaults() {
+	p.sticky = true
+}
+
+// WithoutPreset takes a map of options and a reflect.Value, which must
+// represent the struct to populate, and returns a copy of the map without
+// the options whose fields hold non-zero values.
+func withoutPreset(options map[string]fieldInfo,
+	v reflect.Value) map[string]fieldInfo {
+
+	out := map[string]fieldInfo{}
+	for flag, info := range options {
+		if v.FieldByIndex(info.Index).IsZero() {
+			out[flag] = info
+		}
+	}
+	return out
+}
+
+// ResetSlices takes the options retained from the command line, the
+// positional fields, the number of positional tokens, and a reflect.Value,
+// which must represent the struct to populate, and clears all slice fields
+// that will receive values from the command line, so that these values
+// replace the slice's previous elements.
+func resetSlices(retained []fieldInfo, positionals []fieldInfo,
+	posTokens int, v reflect.Value) {
+
+	for _, info := range retained {
+		if info.isSlice {
+			v.FieldByIndex(info.Index).SetZero()
+		}
+	}
+
+	// The positional slice receives all tokens not used by other fields
+	for _, info := range positionals {
+		if info.isSlice && posTokens >= len(positionals) {
+			v.FieldByIndex(info.Index).SetZero()
+		}
+	}
+}
+
+// ResetConfigured takes the options retained from the command line, the
+// names of the fields populated from configuration files, and a
+// reflect.Value, which must represent the struct to populate, and clears
+// those of the slice fields that will receive values from the command line.
+func resetConfigured(retained []fieldInfo, configured map[string]string,
+	v reflect.Value) {
+
+	for _, info := range retained {
+		if _, ok := configured[info.Name]; info.isSlice && ok {
+			v.FieldByIndex(info.Index).SetZero()
+		}
+	}
+}
+
+// ResetDefaulted takes the options retained from the command line, the
+// options populated with their default values, and a reflect.Value, which
+// must represent the struct to populate, and clears those of the slice
+// fields with default values that will receive values from the command
+// line, so that these values replace the default elements.
+func resetDefaulted(retained []fieldInfo, defaulted map[string]fieldInfo,
+	v reflect.Value) {
+
+	names := map[string]struct{}{}
+	for _, info := range defaulted {
+		if info.isSlice && info.defaultval != "" {
+			names[info.Name] = struct{}{}
+		}
+	}
+
+	for _, info := range retained {
+		if _, ok := names[info.Name]; ok {
+			v.FieldByIndex(info.Index).SetZero()
+		}
+	}
+}
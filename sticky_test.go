@@ -0,0 +1,57 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"reflect"
+)
+
+type stickyArgs struct {
+	Port  int      `arg-flag:"-p" arg-default:"80"`
+	Host  string   `arg-flag:"-H" arg-default:"localhost"`
+	Tags  []string `arg-flag:"-t"`
+	Files []string
+}
+
+func Test_ParserStickyDefaults(t *testing.T) {
+	preset := func() stickyArgs {
+		return stickyArgs{Port: 8080, Tags: []string{"a"},
+			Files: []string{"f"}}
+	}
+
+	tests := []struct {
+		slice []string
+		want  stickyArgs
+	}{
+		{[]string{}, stickyArgs{8080, "localhost", []string{"a"},
+			[]string{"f"}}},
+		{[]string{"-p", "9"}, stickyArgs{9, "localhost", []string{"a"},
+			[]string{"f"}}},
+		{[]string{"-H", "h", "-t", "b", "-t", "c"}, stickyArgs{8080, "h",
+			[]string{"b", "c"}, []string{"f"}}},
+		{[]string{"g"}, stickyArgs{8080, "localhost", []string{"a"},
+			[]string{"g"}}},
+	}
+
+	for _, test := range tests {
+		p := NewParser()
+		p.EnableStickyDefaults()
+
+		a := preset()
+		if err := p.Parse(test.slice, &a); err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+		if !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.slice, a, test.want)
+		}
+	}
+
+	// Without sticky defaults, defaults overwrite and slices are appended
+	a := preset()
+	if err := FromSlice([]string{"-t", "b", "g"}, &a); err != nil ||
+		!reflect.DeepEqual(a, stickyArgs{80, "localhost",
+			[]string{"a", "b"}, []string{"f", "g"}}) {
+		t.Errorf("Not sticky: got=%v err=%v", a, err)
+	}
+}
@@ -0,0 +1,158 @@
+package cleanarg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Maximum edit distance for a known flag to be suggested for an unknown one
+const maxSuggestDistance = 2
+
+// EnableStrict turns on strict mode: tokens that look like flags (-x, +x,
+// --xx, or --xx=value), but do not match any of the struct's flags, are
+// reported as errors (with suggestions for the closest known flags), rather
+// than treated as positional arguments. Tokens following "--", and tokens
+// that look like numbers (like -5, -1.5, -2k, or -1h30m), are never
+// reported.
+func (p *Parser) EnableStrict() {
+	p.strict = true
+}
+
+// A sign followed by a digit (or a decimal point and a digit) starts a
+// number, in any of the syntaxes of numeric fields (as in -5, -.5, -2k,
+// -1h30m, or -75%), rather than a flag.
+var numberRE = regexp.MustCompile(`^[-+]\.?[0-9]`)
+
+// LooksLikeFlag returns true if the token has the syntax of a flag (with or
+// without an attached argument), and does not look like a number (such as
+// a negative value for a numeric positional).
+func looksLikeFlag(token string) bool {
+	if _, err := strconv.ParseFloat(token, 64); err == nil {
+		return false
+	}
+	if numberRE.MatchString(token) {
+		return false
+	}
+
+	flag, _ := chopToken(token)
+	return shortFlagRE.MatchString(flag) || longFlagRE.MatchString(flag)
+}
+
+// CheckUnknownFlags takes a map of options and a slice of tokens, and
+// returns an error for the first token (before "--") that looks like a
+// flag, but is not one of the options' flags. Returns nil otherwise.
+func checkUnknownFlags(options map[string]fieldInfo, tokens []string,
+	isFused bool) error {
+
+	for _, i := range positionalIndices(options, tokens, isFused) {
+		if looksLikeFlag(tokens[i]) {
+			return unknownFlagError(options, tokens, i)
+		}
+	}
+	return nil
+}
+
+// HintUnknownFlags takes an error that occurred while populating the
+// positional fields, a map of options, and a slice of tokens. If one of
+// the tokens (before "--") looks like a flag, but is not one of the
+// options' flags, and a similar flag exists, the suggestion is appended to
+// the error (since the mistyped flag was probably the cause of the error).
+// Returns the error unchanged otherwise.
+func hintUnknownFlags(err error, options map[string]fieldInfo,
+	tokens []string, isFused bool) error {
+
+	for _, i := range positionalIndices(options, tokens, isFused) {
+		if !looksLikeFlag(tokens[i]) {
+			continue
+		}
+
+		flag, _ := chopToken(tokens[i])
+		if s := suggest(flag, optionFlags(options)); len(s) > 0 {
+			return fmt.Errorf("%w (%v)", err,
+				unknownFlagError(options, tokens, i).msg)
+		}
+	}
+	return err
+}
+
+// UnknownFlagError returns an error for the unknown flag in the token with
+// the given index, which includes the closest matches among the options'
+// flags, if any.
+func unknownFlagError(options map[string]fieldInfo, tokens []string,
+	index int) *ParseError {
+
+	flag, _ := chopToken(tokens[index])
+
+	var err *ParseError
+	if s := suggest(flag, optionFlags(options)); len(s) == 0 {
+		err = newParseError(ErrUnknownFlag, tokens[index], index,
+			"unknown flag %s", flag)
+	} else {
+		err = newParseError(ErrUnknownFlag, tokens[index], index,
+			"unknown flag %s, did you mean %s?", flag, strings.Join(s, " or "))
+	}
+	err.Flag = flag
+
+	return err
+}
+
+// OptionFlags returns all flags of the options, in sorted order, except
+// for those of hidden options (arg-hidden), which are never suggested.
+func optionFlags(options map[string]fieldInfo) []string {
+	return visibleFlags(options)
+}
+
+// Suggest takes a word and a slice of candidates, and returns the
+// candidates with the smallest edit distance to the word (in the order of
+// the candidates), provided the distance does not exceed
+// maxSuggestDistance and is smaller than the length of the word (not
+// counting leading dashes and plus signs, so that short flags are not
+// suggested for each other). Returns an empty slice if there are no such
+// candidates.
+func suggest(word string, candidates []string) []string {
+	out := []string{}
+	best := maxSuggestDistance + 1
+	size := len(strings.TrimLeft(word, "-+"))
+
+	for _, c := range candidates {
+		d := levenshtein(word, c)
+		if d >= size || d > maxSuggestDistance || d > best {
+			continue
+		}
+		if d < best {
+			best, out = d, out[:0]
+		}
+		out = append(out, c)
+	}
+
+	return out
+}
+
+// Levenshtein returns the edit distance between two strings: the minimal
+// number of single-character insertions, deletions, and substitutions
+// required to turn one into the other.
+func levenshtein(a, b string) int {
+	s, t := []rune(a), []rune(b)
+
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(t)]
+}
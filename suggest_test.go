@@ -0,0 +1,149 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"slices"
+	"strings"
+)
+
+func Test_levenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"abc", "abc", 0},
+		{"--verbse", "--verbose", 1},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"-verbose", "--verbose", 1},
+	}
+
+	for _, test := range tests {
+		if got := levenshtein(test.a, test.b); got != test.want {
+			t.Errorf("%s,%s: got=%d want=%d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func Test_suggest(t *testing.T) {
+	candidates := []string{"-v", "-f", "--verbose", "--version", "--force"}
+
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"--verbse", []string{"--verbose"}},
+		{"--verison", []string{"--version"}},
+		{"--versio", []string{"--version"}},
+		{"--vers", []string{}},
+		{"--forc", []string{"--force"}},
+		{"-x", []string{}},
+		{"--quiet", []string{}},
+		{"--verbos", []string{"--verbose"}},
+		{"--verb", []string{}},
+	}
+
+	for _, test := range tests {
+		if got := suggest(test.word, candidates); !slices.Equal(got, test.want) {
+			t.Errorf("%s: got=%v want=%v", test.word, got, test.want)
+		}
+	}
+}
+
+func Test_looksLikeFlag(t *testing.T) {
+	tests := []struct {
+		token string
+		want  bool
+	}{
+		{"-v", true},
+		{"+v", true},
+		{"--verbose", true},
+		{"--out=x", true},
+		{"-5", false},
+		{"-1.5", false},
+		{"-.5", false},
+		{"-1e3", false},
+		{"-2k", false},
+		{"-1h30m", false},
+		{"-75%", false},
+		{"-3,14", false},
+		{"-inf", false},
+		{"-", false},
+		{"a", false},
+	}
+
+	for _, test := range tests {
+		if got := looksLikeFlag(test.token); got != test.want {
+			t.Errorf("%s: got=%v want=%v", test.token, got, test.want)
+		}
+	}
+}
+
+func Test_ParserStrict(t *testing.T) {
+	type strictArgs struct {
+		Verbose bool   `arg-flag:"-v --verbose"`
+		Output  string `arg-flag:"-o --output"`
+		Files   []string
+	}
+
+	tests := []struct {
+		slice []string
+		err   string
+	}{
+		{[]string{"-v", "a", "b"}, ""},
+		{[]string{"-o", "-x", "a"}, ""},
+		{[]string{"a", "-5", "-1.5"}, ""},
+		{[]string{"a", "-2k", "-1h30m", "-75%"}, ""},
+		{[]string{"--", "--verbse"}, ""},
+		{[]string{"--verbse", "a"}, "unknown flag --verbse, did you mean --verbose?"},
+		{[]string{"--outptu=x"}, "unknown flag --outptu, did you mean --output?"},
+		{[]string{"a", "-x"}, "unknown flag -x"},
+	}
+
+	for _, test := range tests {
+		p := NewParser()
+		p.EnableStrict()
+
+		err := p.Parse(test.slice, &strictArgs{})
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		case test.err != "" && (err == nil || err.Error() != test.err):
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.err)
+		}
+	}
+
+	// Not strict: unknown flags are positionals
+	a := strictArgs{}
+	if err := FromSlice([]string{"--verbse"}, &a); err != nil ||
+		!slices.Equal(a.Files, []string{"--verbse"}) {
+		t.Errorf("Not strict: got=%v err=%v", a, err)
+	}
+}
+
+func Test_hintUnknownFlags(t *testing.T) {
+	type hintArgs struct {
+		Verbose bool `arg-flag:"--verbose"`
+		File    string
+	}
+
+	err := FromSlice([]string{"--verbse", "a"}, &hintArgs{})
+	if err == nil || !strings.Contains(err.Error(), "did you mean --verbose?") {
+		t.Errorf("Expected suggestion, got: %v", err)
+	}
+
+	err = FromSlice([]string{"b", "a"}, &hintArgs{})
+	if err == nil || strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("Expected no suggestion, got: %v", err)
+	}
+
+	// Subcommands: a mistyped flag is not reported as unknown command
+	err = FromSlice([]string{"--verbse", "push"}, &commandArgs{})
+	if err == nil || err.Error() != "unknown flag --verbse" {
+		t.Errorf("Expected unknown flag, got: %v", err)
+	}
+}
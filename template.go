@@ -0,0 +1,241 @@
+package cleanarg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConfigFormat is the format of a configuration file template (see
+// WriteConfigTemplate()).
+type ConfigFormat int
+
+const (
+	ConfigJSON ConfigFormat = iota // JSON (without comments)
+	ConfigYAML                     // YAML
+	ConfigTOML                     // TOML
+)
+
+// Numbers that are valid in all formats (as in JSON)
+var templateNumberRE = regexp.MustCompile(
+	`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// templateEntry is an option, as it appears in a configuration template.
+type templateEntry struct {
+	key    string   // long flag without "--", or field name
+	help   string   // help text (arg-help), without delimiters
+	values []string // default value(s), formatted for the file
+	isSet  bool     // true if the option has a default value to show
+	isList bool     // true for slices
+}
+
+// WriteConfigTemplate takes a writer, a pointer to a struct, and a format,
+// and writes a configuration file template for the struct's options, which
+// can be read by Parser.AddConfigFile() (for "mytool --init-config", say).
+// Options appear in the order of their fields, keyed on their first long
+// flag without the leading "--" (or their field name, if they have none),
+// with their default value (arg-default). In YAML and TOML, each option is
+// preceded by its help text (arg-help, without the delimiters of a term)
+// as a comment, and options without a default value are commented out;
+// JSON has no comments, so it has no help texts, and options without a
+// default value are null. The default values of secret fields (arg-secret)
+// are not written: these fields appear as if they had none. Options tagged
+// arg-config or arg-hidden are left out, as are positionals and
+// subcommands, which can not be set from configuration files.
+// Returns an error if the struct is malformed, or the format unknown.
+func WriteConfigTemplate(w io.Writer, data any, format ConfigFormat) error {
+	v, err := unwrap(data)
+	if err != nil {
+		return err
+	}
+	options, _, err := analyzeStruct(v)
+	if err != nil {
+		return err
+	}
+	entries := templateEntries(options, format)
+
+	switch format {
+	case ConfigJSON:
+		writeJSONTemplate(w, entries)
+	case ConfigYAML:
+		writeTextTemplate(w, entries, ": ")
+	case ConfigTOML:
+		writeTextTemplate(w, entries, " = ")
+	default:
+		return fmt.Errorf("unknown config format: %d", format)
+	}
+	return nil
+}
+
+// TemplateEntries takes a map of options, as returned by analyzeStruct, and
+// a format, and returns the entries of the configuration template, in the
+// order of the options' fields (see WriteConfigTemplate()). The fixed-value
+// flags of an option (arg-const) are merged into the option.
+func templateEntries(options map[string]fieldInfo,
+	format ConfigFormat) []templateEntry {
+
+	// Options, by field name, preferring the entry for the regular flags
+	byName := map[string]fieldInfo{}
+	for _, info := range uniqueOptions(options) {
+		if info.isConfig || info.hidden {
+			continue
+		}
+		prev, ok := byName[info.Name]
+		if !ok || (prev.isConst && !info.isConst) {
+			byName[info.Name] = info
+		}
+	}
+
+	infos := []fieldInfo{}
+	for _, info := range byName {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return slices.Compare(infos[i].Index, infos[j].Index) < 0
+	})
+
+	out := []templateEntry{}
+	for _, info := range infos {
+		help, _ := formatHelp(info, false)
+		entry := templateEntry{
+			key:    info.Name,
+			help:   help,
+			isSet:  info.defaultval != "" && !info.secret,
+			isList: info.isSlice,
+		}
+		if !info.isConst {
+			for _, f := range info.allFlags {
+				if strings.HasPrefix(f, "--") {
+					entry.key = f[2:]
+					break
+				}
+			}
+		}
+
+		elems := []string{}
+		switch {
+		case entry.isSet && info.isSlice:
+			elems = splitValue(info.defaultval, defaultSeparator(info))
+		case entry.isSet:
+			elems = []string{info.defaultval}
+		case !info.isSlice:
+			elems = []string{zeroTemplateValue(info)}
+		}
+		for _, e := range elems {
+			entry.values = append(entry.values,
+				templateValue(info.baseType, e, format))
+		}
+		out = append(out, entry)
+	}
+
+	return out
+}
+
+// ZeroTemplateValue returns the placeholder value of an option without
+// default value: false for booleans, 0 for numbers, and the empty string
+// otherwise.
+func zeroTemplateValue(info fieldInfo) string {
+	switch info.baseType.Kind() {
+	case reflect.Bool:
+		return "false"
+	case reflect.Int, reflect.Float64:
+		if info.baseType.PkgPath() == "" {
+			return "0"
+		}
+	}
+	return ""
+}
+
+// TemplateValue takes the base type of an option and a value, as given on
+// the command line, and returns the value as it appears in a configuration
+// file of the given format: booleans and numbers bare (if the value is
+// valid as such), and everything else quoted.
+func templateValue(t reflect.Type, s string, format ConfigFormat) string {
+	if t.PkgPath() == "" {
+		switch t.Kind() {
+		case reflect.Bool:
+			if s == "true" || s == "false" {
+				return s
+			}
+		case reflect.Int, reflect.Float64:
+			if templateNumberRE.MatchString(s) {
+				return s
+			}
+		}
+	}
+
+	if format == ConfigJSON {
+		buf, _ := json.Marshal(s)
+		return string(buf)
+	}
+	return strconv.Quote(s)
+}
+
+// WriteJSONTemplate writes the entries of a configuration template as a
+// JSON object, with options without default value set to null.
+func writeJSONTemplate(w io.Writer, entries []templateEntry) {
+	fmt.Fprintf(w, "{\n")
+	for i, e := range entries {
+		key, _ := json.Marshal(e.key)
+		value := "null"
+		if e.isSet || e.isList {
+			value = templateValueText(e)
+		}
+		sep := ","
+		if i == len(entries)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(w, "    %s: %s%s\n", key, value, sep)
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+// WriteTextTemplate writes the entries of a configuration template as
+// "key<sep>value" lines (as in YAML and TOML), each preceded by the lines
+// of its help text as comments. Entries without default value are
+// commented out.
+func writeTextTemplate(w io.Writer, entries []templateEntry, sep string) {
+	for i, e := range entries {
+		if i > 0 {
+			fmt.Fprintf(w, "\n")
+		}
+		if e.help != "" {
+			for _, line := range strings.Split(e.help, "\n") {
+				fmt.Fprintf(w, "# %s\n", line)
+			}
+		}
+		prefix := ""
+		if !e.isSet {
+			prefix = "# "
+		}
+		fmt.Fprintf(w, "%s%s%s%s\n", prefix, templateKey(e.key), sep,
+			templateValueText(e))
+	}
+}
+
+// TemplateValueText returns the value of an entry of a configuration
+// template: a single value, or an array in brackets.
+func templateValueText(e templateEntry) string {
+	if !e.isList {
+		return e.values[0]
+	}
+	return "[" + strings.Join(e.values, ", ") + "]"
+}
+
+// TemplateKey returns the key, quoted if it is not a bare key (which
+// consists of letters, digits, "-", and "_" only).
+func templateKey(key string) string {
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+			c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return strconv.Quote(key)
+		}
+	}
+	return key
+}
@@ -0,0 +1,143 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type templateDB struct {
+	Host string `arg-flag:"--host" arg-default:"localhost"`
+}
+
+type templateArgs struct {
+	Port    int           `arg-flag:"-p --port" arg-default:"8080" arg-help:"The *port* to listen on"`
+	Name    string        `arg-flag:"--name" arg-help:"Name of the \"service\""`
+	Verbose bool          `arg-flag:"-v"`
+	Level   int           `arg-flag:"--level" arg-const:"-q=0 --debug=3" arg-default:"1"`
+	Tags    []string      `arg-flag:"-t --tag" arg-default:"web,prod"`
+	Timeout time.Duration `arg-flag:"--timeout" arg-default:"1m30s"`
+	Config  string        `arg-flag:"--config" arg-config:""`
+	Debug   bool          `arg-flag:"--debug-all" arg-hidden:""`
+	DB      templateDB    `arg-prefix:"db"`
+	File    string
+}
+
+func Test_WriteConfigTemplate(t *testing.T) {
+	tests := []struct {
+		format ConfigFormat
+		want   string
+	}{
+		{ConfigTOML, `# The port to listen on
+port = 8080
+
+# Name of the "service"
+# name = ""
+
+# Verbose = false
+
+level = 1
+
+tag = ["web", "prod"]
+
+timeout = "1m30s"
+
+db-host = "localhost"
+`},
+		{ConfigYAML, `# The port to listen on
+port: 8080
+
+# Name of the "service"
+# name: ""
+
+# Verbose: false
+
+level: 1
+
+tag: ["web", "prod"]
+
+timeout: "1m30s"
+
+db-host: "localhost"
+`},
+		{ConfigJSON, `{
+    "port": 8080,
+    "name": null,
+    "Verbose": null,
+    "level": 1,
+    "tag": ["web", "prod"],
+    "timeout": "1m30s",
+    "db-host": "localhost"
+}
+`},
+	}
+
+	for _, test := range tests {
+		sb := strings.Builder{}
+		if err := WriteConfigTemplate(&sb, &templateArgs{}, test.format); err != nil {
+			t.Fatalf("%d: Unexpected error: %v", test.format, err)
+		}
+		if sb.String() != test.want {
+			t.Errorf("%d: got:\n%s\nwant:\n%s", test.format, sb.String(),
+				test.want)
+		}
+	}
+
+	if err := WriteConfigTemplate(&strings.Builder{}, &templateArgs{},
+		ConfigFormat(9)); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+}
+
+func Test_WriteConfigTemplateSecret(t *testing.T) {
+	type secretArgs struct {
+		Pw string `arg-flag:"--pw" arg-secret:"" arg-default:"hunter2"`
+	}
+
+	for format, want := range map[ConfigFormat]string{
+		ConfigJSON: "{\n    \"pw\": null\n}\n",
+		ConfigYAML: "# pw: \"\"\n",
+		ConfigTOML: "# pw = \"\"\n",
+	} {
+		sb := strings.Builder{}
+		if err := WriteConfigTemplate(&sb, &secretArgs{}, format); err != nil {
+			t.Fatalf("%d: Unexpected error: %v", format, err)
+		}
+		if sb.String() != want {
+			t.Errorf("%d: got=%q want=%q", format, sb.String(), want)
+		}
+	}
+}
+
+func Test_WriteConfigTemplateRoundTrip(t *testing.T) {
+	want := templateArgs{}
+	if err := FromSlice([]string{"f"}, &want); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for format, ext := range map[ConfigFormat]string{ConfigJSON: ".json",
+		ConfigYAML: ".yaml", ConfigTOML: ".toml"} {
+
+		sb := strings.Builder{}
+		if err := WriteConfigTemplate(&sb, &templateArgs{}, format); err != nil {
+			t.Fatalf("%s: Unexpected error: %v", ext, err)
+		}
+		path := filepath.Join(t.TempDir(), "config"+ext)
+		if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		got := templateArgs{}
+		if err := NewParser(WithConfigFile(path)).Parse([]string{"f"},
+			&got); err != nil {
+			t.Fatalf("%s: Unexpected error: %v\n%s", ext, err, sb.String())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got=%+v want=%+v", ext, got, want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package cleanarg
+
+import (
+	"slices"
+)
+
+// AddTerminator registers an additional token that ends the flags, just
+// like "--" does: all following tokens are treated as positionals (or go
+// to the arg-rest field, if any). Only the left-most terminator, whether
+// "--" or one registered here, is special; all later ones (including
+// "--") are kept as literal positionals. This is useful when wrapping
+// programs that use "--" themselves, as in "wrap -v ::: prog -x -- file".
+// The empty string is ignored.
+func (p *Parser) AddTerminator(term string) {
+	if term == "" || slices.Contains(p.terminators, term) {
+		return
+	}
+	p.terminators = append(p.terminators, term)
+}
+
+// ReplaceTerminator takes a slice of tokens and the additional terminators
+// registered with the Parser, and returns the tokens with the left-most
+// terminator replaced by "--", unless it is "--" already. The tokens are
+// returned unchanged if there are no additional terminators.
+func replaceTerminator(tokens []string, terminators []string) []string {
+	if len(terminators) == 0 {
+		return tokens
+	}
+
+	i := slices.IndexFunc(tokens, func(s string) bool {
+		return s == endFlagsIndicator || slices.Contains(terminators, s)
+	})
+	if i < 0 || tokens[i] == endFlagsIndicator {
+		return tokens
+	}
+
+	out := slices.Clone(tokens)
+	out[i] = endFlagsIndicator
+	return out
+}
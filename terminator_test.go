@@ -0,0 +1,60 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"reflect"
+)
+
+func Test_replaceTerminator(t *testing.T) {
+	tests := []struct {
+		tokens, terms, want []string
+	}{
+		{[]string{"a", ":::", "b"}, nil, []string{"a", ":::", "b"}},
+		{[]string{"a", ":::", "b"}, []string{":::"}, []string{"a", "--", "b"}},
+		{[]string{"a", ":::", "b", ":::"}, []string{":::"},
+			[]string{"a", "--", "b", ":::"}},
+		{[]string{"a", "--", "b", ":::"}, []string{":::"},
+			[]string{"a", "--", "b", ":::"}},
+		{[]string{":::", "--"}, []string{":::"}, []string{"--", "--"}},
+		{[]string{"a", "b"}, []string{":::"}, []string{"a", "b"}},
+	}
+
+	for _, test := range tests {
+		got := replaceTerminator(test.tokens, test.terms)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.tokens, got, test.want)
+		}
+	}
+}
+
+func Test_ParserTerminator(t *testing.T) {
+	type wrapArgs struct {
+		Verbose bool `arg-flag:"-v"`
+		Args    []string
+	}
+
+	tests := []struct {
+		slice []string
+		want  wrapArgs
+	}{
+		{[]string{"-v", ":::", "prog", "-v", "--", "f"},
+			wrapArgs{true, []string{"prog", "-v", "--", "f"}}},
+		{[]string{"--", "-v", ":::", "--"},
+			wrapArgs{false, []string{"-v", ":::", "--"}}},
+		{[]string{"a", "-v"}, wrapArgs{true, []string{"a"}}},
+	}
+
+	for _, test := range tests {
+		p := NewParser(WithTerminator(":::"), WithTerminator(""))
+
+		a := wrapArgs{}
+		if err := p.Parse(test.slice, &a); err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+			continue
+		}
+		if !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.slice, a, test.want)
+		}
+	}
+}
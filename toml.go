@@ -0,0 +1,244 @@
+package cleanarg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The TOML parser supports the subset of TOML that is commonly used for
+// configuration files: key/value pairs, dotted and quoted keys, tables,
+// basic and literal strings, numbers, booleans, dates (as strings), and
+// arrays of scalars (which may span several lines). Multi-line strings,
+// inline tables, and arrays of tables are not supported. Scalars other
+// than booleans are returned as strings, to be interpreted by
+// convertToType().
+
+// Numbers may contain underscores between digits, which are removed
+var tomlNumberRE = regexp.MustCompile(
+	`^[+-]?[0-9][0-9_]*(\.[0-9_]+)?([eE][+-]?[0-9_]+)?$`)
+
+// ParseTOMLConfig takes the contents of a TOML configuration file, and
+// returns its values.
+func parseTOMLConfig(buf []byte) (configValues, error) {
+	root := map[string]any{}
+	table := root
+
+	lines := strings.Split(string(buf), "\n")
+	for n := 0; n < len(lines); n++ {
+		num := n + 1
+		line := strings.TrimSpace(stripComment(lines[n]))
+
+		switch {
+		case line == "":
+			continue
+
+		case strings.HasPrefix(line, "[["):
+			return nil, fmt.Errorf("line %d: arrays of tables not supported",
+				num)
+
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: malformed table: %s", num, line)
+			}
+			keys, err := parseTOMLKey(line[1 : len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			table, err = tomlTable(root, keys)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			continue
+		}
+
+		k, v, ok := cutUnquoted(line, '=')
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value: %s", num, line)
+		}
+		keys, err := parseTOMLKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+
+		// Arrays may span several lines
+		v = strings.TrimSpace(v)
+		for strings.HasPrefix(v, "[") && !balancedBrackets(v) &&
+			n+1 < len(lines) {
+			n++
+			v += " " + strings.TrimSpace(stripComment(lines[n]))
+		}
+
+		value, err := parseTOMLValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+
+		t, err := tomlTable(table, keys[:len(keys)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		last := keys[len(keys)-1]
+		if _, ok := t[last]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key: %s", num, last)
+		}
+		t[last] = value
+	}
+
+	out := configValues{}
+	if err := flattenConfig(root, "", out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TOMLTable returns the (nested) table with the given keys, creating
+// tables as needed. Returns an error if one of the keys holds a value.
+func tomlTable(root map[string]any, keys []string) (map[string]any, error) {
+	t := root
+	for _, k := range keys {
+		x, ok := t[k]
+		if !ok {
+			x = map[string]any{}
+			t[k] = x
+		}
+		next, ok := x.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("key is not a table: %s", k)
+		}
+		t = next
+	}
+	return t, nil
+}
+
+// ParseTOMLKey splits a (possibly dotted) key into its parts, which may be
+// bare or quoted.
+func parseTOMLKey(s string) ([]string, error) {
+	out := []string{}
+
+	rest := strings.TrimSpace(s)
+	for {
+		var part string
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			end := closingQuote(rest)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote: %s", s)
+			}
+			x, err := parseTOMLString(rest[:end+1])
+			if err != nil {
+				return nil, err
+			}
+			part, rest = x, strings.TrimSpace(rest[end+1:])
+		} else {
+			part, rest, _ = strings.Cut(rest, ".")
+			part, rest = strings.TrimSpace(part), "."+rest
+			if rest == "." {
+				rest = ""
+			}
+			if part == "" || strings.ContainsAny(part, " \t\"'") {
+				return nil, fmt.Errorf("malformed key: %s", s)
+			}
+		}
+		out = append(out, part)
+
+		if rest == "" {
+			return out, nil
+		}
+		if rest[0] != '.' {
+			return nil, fmt.Errorf("malformed key: %s", s)
+		}
+		rest = strings.TrimSpace(rest[1:])
+	}
+}
+
+// ParseTOMLValue parses a value: a string, a boolean, an array, or another
+// scalar (number or date), which is returned as a string.
+func parseTOMLValue(s string) (any, error) {
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("missing value")
+
+	case strings.HasPrefix(s, `"""`) || strings.HasPrefix(s, `'''`):
+		return nil, fmt.Errorf("multi-line strings not supported: %s", s)
+
+	case s[0] == '"' || s[0] == '\'':
+		if closingQuote(s) != len(s)-1 {
+			return nil, fmt.Errorf("malformed string: %s", s)
+		}
+		return parseTOMLString(s)
+
+	case s[0] == '{':
+		return nil, fmt.Errorf("inline tables not supported: %s", s)
+
+	case s[0] == '[':
+		if !strings.HasSuffix(s, "]") || !balancedBrackets(s) {
+			return nil, fmt.Errorf("malformed array: %s", s)
+		}
+		out := []any{}
+		for _, item := range splitFlow(s[1 : len(s)-1]) {
+			if item == "" {
+				continue // trailing comma
+			}
+			x, err := parseTOMLValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, x)
+		}
+		return out, nil
+
+	case s == "true":
+		return true, nil
+
+	case s == "false":
+		return false, nil
+
+	case tomlNumberRE.MatchString(s):
+		return strings.ReplaceAll(s, "_", ""), nil
+	}
+
+	return s, nil
+}
+
+// ParseTOMLString parses a basic (double-quoted) or literal (single-quoted)
+// string.
+func parseTOMLString(s string) (string, error) {
+	if s[0] == '\'' {
+		return s[1 : len(s)-1], nil
+	}
+	return strconv.Unquote(s)
+}
+
+// CutUnquoted splits s at the first occurrence of sep that is not quoted.
+func cutUnquoted(s string, sep byte) (string, string, bool) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			if end := closingQuote(s[i:]); end >= 0 {
+				i += end
+			}
+		case sep:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+// BalancedBrackets returns true if all (unquoted) brackets in s are closed.
+func balancedBrackets(s string) bool {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			if end := closingQuote(s[i:]); end >= 0 {
+				i += end
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+	}
+	return depth == 0
+}
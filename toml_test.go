@@ -0,0 +1,63 @@
+package cleanarg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseTOMLConfig(t *testing.T) {
+	tests := []struct {
+		input string
+		want  configValues
+	}{
+		{"", configValues{}},
+		{"# comment\nport = 8080\n", configValues{"port": {"8080"}}},
+		{"port = 1_000 # comment\n", configValues{"port": {"1000"}}},
+		{"name = \"a # b\"\n", configValues{"name": {"a # b"}}},
+		{"name = 'C:\\dir'\n", configValues{"name": {"C:\\dir"}}},
+		{"name = \"tab\\there\"\n", configValues{"name": {"tab\there"}}},
+		{"verbose = false\n", configValues{"verbose": {"false"}}},
+		{"date = 2024-01-02\n", configValues{"date": {"2024-01-02"}}},
+		{"tag = [\"a\", 'b',]\n", configValues{"tag": {"a", "b"}}},
+		{"tag = [\n  \"a\", # first\n  \"b\"\n]\nport = 1\n",
+			configValues{"tag": {"a", "b"}, "port": {"1"}}},
+		{"port = 1\n[db]\nhost = \"x\"\n[db.pool]\nsize = 4\n",
+			configValues{"port": {"1"}, "db-host": {"x"},
+				"db-pool-size": {"4"}}},
+		{"db.host = \"x\"\n\"a.b\" = 1\n",
+			configValues{"db-host": {"x"}, "a.b": {"1"}}},
+	}
+
+	for _, test := range tests {
+		res, err := parseTOMLConfig([]byte(test.input))
+		if err != nil {
+			t.Errorf("%q: Unexpected error: %v", test.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, test.want) {
+			t.Errorf("%q: got=%v want=%v", test.input, res, test.want)
+		}
+	}
+}
+
+func Test_parseTOMLConfigErr(t *testing.T) {
+	tests := []string{
+		"port 8080\n",
+		"port =\n",
+		"a = 1\na = 2\n",
+		"a = 1\n[a]\n",
+		"[[servers]]\nname = \"x\"\n",
+		"[db\n",
+		"a = {b = 1}\n",
+		"a = \"\"\"text\"\"\"\n",
+		"a = \"open\n",
+		"a = [1, 2\n",
+		"a b = 1\n",
+	}
+
+	for _, test := range tests {
+		if _, err := parseTOMLConfig([]byte(test)); err == nil {
+			t.Errorf("%q: Expected error", test)
+		}
+	}
+}
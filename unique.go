@@ -0,0 +1,105 @@
+package cleanarg
+
+import (
+	"cmp"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// CheckUniqueTags takes a fieldInfo, and checks its arg-unique and
+// arg-sorted tags, if any: they are only permitted on slice fields, whose
+// elements must be comparable (arg-unique) or ordered (arg-sorted; see
+// compareElems). Returns an error if one of these conditions is violated.
+func checkUniqueTags(info fieldInfo) error {
+	if info.unique && !(info.isSlice && info.baseType.Comparable()) {
+		return fmt.Errorf("%s requires slice of comparable type: %s",
+			tagUnique, info.Name)
+	}
+	if info.sorted && !(info.isSlice && isOrdered(info.baseType)) {
+		return fmt.Errorf("%s requires slice of ordered type: %s",
+			tagSorted, info.Name)
+	}
+	return nil
+}
+
+// IsOrdered reports whether values of the supplied type can be compared
+// by compareElems: numbers (including durations, byte sizes, file modes,
+// and runes), strings, times, and IP addresses (netip.Addr).
+func isOrdered(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64,
+		reflect.String:
+		return true
+	}
+	return t == reflect.TypeOf(time.Time{}) || t == typeAddr
+}
+
+// CompareElems compares two values of the same ordered type (see
+// isOrdered), and returns -1, 0, or +1, if the first is less than, equal
+// to, or greater than the second, respectively.
+func compareElems(a, b reflect.Value) int {
+	switch {
+	case a.CanInt():
+		return cmp.Compare(a.Int(), b.Int())
+	case a.CanUint():
+		return cmp.Compare(a.Uint(), b.Uint())
+	case a.CanFloat():
+		return cmp.Compare(a.Float(), b.Float())
+	case a.Kind() == reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	}
+
+	switch x := a.Interface().(type) {
+	case time.Time:
+		return x.Compare(b.Interface().(time.Time))
+	case netip.Addr:
+		return x.Compare(b.Interface().(netip.Addr))
+	}
+	return 0 // never get here
+}
+
+// NormalizeSlices takes the options and positional fields of a struct, and
+// a reflect.Value, which must represent the populated struct, and removes
+// duplicate elements (keeping the first occurrence) from all slices tagged
+// arg-unique, and sorts all slices tagged arg-sorted.
+func normalizeSlices(options map[string]fieldInfo, positionals []fieldInfo,
+	v reflect.Value) {
+
+	for _, info := range append(uniqueOptions(options), positionals...) {
+		field := v.FieldByIndex(info.Index)
+		if info.unique {
+			field.Set(uniqueElems(field))
+		}
+		if info.sorted {
+			sort.SliceStable(field.Interface(), func(i, j int) bool {
+				return compareElems(field.Index(i), field.Index(j)) < 0
+			})
+		}
+	}
+}
+
+// UniqueElems takes a reflect.Value of a slice of comparable elements, and
+// returns a slice of the same elements in the same order, but without
+// duplicates. A nil slice is returned unchanged.
+func uniqueElems(s reflect.Value) reflect.Value {
+	if s.IsNil() {
+		return s
+	}
+
+	out := reflect.MakeSlice(s.Type(), 0, s.Len())
+	seen := map[any]struct{}{}
+	for i := 0; i < s.Len(); i++ {
+		elem := s.Index(i)
+		if _, ok := seen[elem.Interface()]; ok {
+			continue
+		}
+		seen[elem.Interface()] = struct{}{}
+		out = reflect.Append(out, elem)
+	}
+	return out
+}
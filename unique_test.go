@@ -0,0 +1,81 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"net/netip"
+	"reflect"
+	"strings"
+	"time"
+)
+
+func Test_compareElems(t *testing.T) {
+	tests := []struct {
+		a, b any
+		want int
+	}{
+		{1, 2, -1},
+		{2, 2, 0},
+		{-1.5, -2.5, 1},
+		{"b", "a", 1},
+		{time.Second, time.Minute, -1},
+		{ByteSize(1024), ByteSize(1000), 1},
+		{rune('a'), rune('b'), -1},
+		{time.Unix(0, 0), time.Unix(1, 0), -1},
+		{netip.MustParseAddr("10.0.0.2"), netip.MustParseAddr("10.0.0.10"), -1},
+	}
+
+	for _, test := range tests {
+		if !isOrdered(reflect.TypeOf(test.a)) {
+			t.Errorf("%T: expected ordered type", test.a)
+		}
+		got := compareElems(reflect.ValueOf(test.a), reflect.ValueOf(test.b))
+		if got != test.want {
+			t.Errorf("%v, %v: got=%d want=%d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func Test_FromSliceUnique(t *testing.T) {
+	type uniqueArgs struct {
+		Labels []string `arg-flag:"-l" arg-unique:""`
+		Ports  []int    `arg-flag:"-p" arg-sorted:"" arg-sep:","`
+		Tags   []string `arg-flag:"-t" arg-unique:"" arg-sorted:"" arg-default:"z,a"`
+		Files  []string `arg-unique:""`
+	}
+
+	a := uniqueArgs{}
+	err := FromSlice([]string{"-l", "b", "-l", "a", "-l", "b", "-p", "443,80",
+		"-p", "80", "f", "f", "g"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(a.Labels, []string{"b", "a"}) ||
+		!reflect.DeepEqual(a.Ports, []int{80, 80, 443}) ||
+		!reflect.DeepEqual(a.Tags, []string{"a", "z"}) ||
+		!reflect.DeepEqual(a.Files, []string{"f", "g"}) {
+		t.Errorf("Unexpected values: got=%+v", a)
+	}
+
+	tests := []struct {
+		data any
+		text string
+	}{
+		{&struct {
+			Label string `arg-flag:"-l" arg-unique:""`
+		}{}, "requires slice of comparable type"},
+		{&struct {
+			Keys [][]byte `arg-flag:"-k" arg-unique:""`
+		}{}, "requires slice of comparable type"},
+		{&struct {
+			Flags []bool `arg-flag:"-f" arg-sorted:""`
+		}{}, "requires slice of ordered type"},
+	}
+
+	for _, test := range tests {
+		err := FromSlice([]string{}, test.data)
+		if err == nil || !strings.Contains(err.Error(), test.text) {
+			t.Errorf("Expected error %q: got=%v", test.text, err)
+		}
+	}
+}
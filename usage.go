@@ -0,0 +1,232 @@
+package cleanarg
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SortOrder is the order in which usage messages list options.
+type SortOrder int
+
+const (
+	// SortByFlag lists options in the sorted order of their flags (short
+	// flags first).
+	SortByFlag SortOrder = iota
+
+	// SortByDeclaration lists options in the order in which their fields
+	// are declared in the struct.
+	SortByDeclaration
+)
+
+// Grouping is the way in which the detailed usage message groups options.
+type Grouping int
+
+const (
+	// GroupByTag lists options under the headings given by their arg-group
+	// tags (if any option has one).
+	GroupByTag Grouping = iota
+
+	// GroupNone lists all options together, ignoring arg-group tags.
+	GroupNone
+)
+
+// UsageOptions tune the usage messages written by WriteUsageWith() and
+// WriteShortUsageWith() (and by a Parser with automatic help, see
+// Parser.SetUsageOptions()). The zero value gives the messages of
+// WriteUsage() and WriteShortUsage().
+//
+// With ShowDefaults, default values are shown as "(default: 8080)" after
+// the help text (rather than as in [int=8080]), for all options; with
+// ShowEnv, the environment variables of the options (arg-env) are listed
+// at the end of the message. Columns is the width to which help texts are
+// wrapped (0: they are not wrapped).
+//
+// The texts that surround the detailed usage message are written as they
+// are (blank lines separate paragraphs): the synopsis and the prologue
+// precede the list of options, the examples (one invocation each) and the
+// epilogue follow it.
+type UsageOptions struct {
+	SortOrder    SortOrder // Order of the options
+	GroupBy      Grouping  // Grouping of the options
+	Program      string    // Program name (default: base name of os.Args[0])
+	Columns      int       // Width of the message (0: unlimited)
+	ShowDefaults bool      // Show default values after the help texts
+	ShowEnv      bool      // List the environment variables (arg-env)
+	ShowHidden   bool      // Include hidden options (arg-hidden)
+
+	Synopsis string   // One-line description of the program
+	Prologue string   // Text before the options
+	Examples []string // Example invocations, after the options
+	Epilogue string   // Text after the examples
+
+	envPrefix string // Prefix of derived environment variables (Parser)
+}
+
+// WriteUsageWith writes the detailed usage message for the struct that
+// data points to, like WriteUsage(), as tuned by the UsageOptions, and
+// surrounded by their texts (synopsis, prologue, examples, and epilogue).
+// Returns an error if the struct contains unsupported types.
+func WriteUsageWith(w io.Writer, data any, opts UsageOptions) error {
+	return writeUsage(w, data, opts, "")
+}
+
+// WriteShortUsageWith writes the one-line usage message for the struct
+// that data points to, like WriteShortUsage(), as tuned by the
+// UsageOptions.
+// Returns an error if the struct contains unsupported types.
+func WriteShortUsageWith(w io.Writer, data any, opts UsageOptions) error {
+	return writeShortUsage(w, data, opts)
+}
+
+// UsageString takes a pointer to a struct and returns the detailed usage
+// message that WriteUsage() writes, for embedding in error messages or
+// dialogs.
+// Returns an error if the struct contains unsupported types.
+func UsageString(data any) (string, error) {
+	sb := strings.Builder{}
+	if err := WriteUsage(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// ShortUsageString takes a pointer to a struct and returns the one-line
+// usage message that WriteShortUsage() writes (including the newline).
+// Returns an error if the struct contains unsupported types.
+func ShortUsageString(data any) (string, error) {
+	sb := strings.Builder{}
+	if err := WriteShortUsage(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// SetUsageOptions sets the options for the help message written with
+// automatic help handling (see EnableHelp()).
+func (p *Parser) SetUsageOptions(opts UsageOptions) {
+	p.usage = opts
+}
+
+// ProgramName returns the program name shown in the short usage line: the
+// Program of the UsageOptions, or else the base name of os.Args[0].
+func (opts UsageOptions) programName() string {
+	if opts.Program != "" {
+		return opts.Program
+	}
+	if len(os.Args) == 0 {
+		return ""
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// WriteUsageHeader writes the synopsis and the prologue of the
+// UsageOptions (if any) to w, each followed by a blank line.
+func writeUsageHeader(w io.Writer, opts UsageOptions) {
+	for _, s := range []string{opts.Synopsis, opts.Prologue} {
+		if s = strings.TrimSpace(s); s != "" {
+			fmt.Fprintf(w, "%s\n\n", s)
+		}
+	}
+}
+
+// WriteUsageFooter writes the examples and the epilogue of the
+// UsageOptions (if any) to w, each preceded by a blank line. Examples are
+// listed under the heading "Examples", indented like options.
+func writeUsageFooter(w io.Writer, opts UsageOptions) {
+	if len(opts.Examples) > 0 {
+		fmt.Fprintf(w, "\n%s:\n", message(examplesHeading))
+		for _, ex := range opts.Examples {
+			fmt.Fprintf(w, "    %s\n", ex)
+		}
+	}
+	if s := strings.TrimSpace(opts.Epilogue); s != "" {
+		fmt.Fprintf(w, "\n%s\n", s)
+	}
+}
+
+// UsageView takes a map of options, as built by analyzeStruct, and the
+// UsageOptions, and returns the options as usage messages show them: with
+// ShowHidden, no option is hidden, with GroupNone, no option has a group,
+// and with the Parser's environment prefix, options have the environment
+// variables derived from it.
+func usageView(options map[string]fieldInfo,
+	opts UsageOptions) map[string]fieldInfo {
+
+	out := copyOptions(withEnvPrefix(options, opts.envPrefix))
+	for k, info := range out {
+		if opts.ShowHidden {
+			info.hidden = false
+		}
+		if opts.GroupBy == GroupNone {
+			info.group = ""
+		}
+		out[k] = info
+	}
+	return out
+}
+
+// WriteUsageEnv writes the environment variables of the options (arg-env)
+// to w, in sorted order, each followed by the flags of its option, under
+// the heading "Environment". Writes nothing if there are none.
+func writeUsageEnv(w io.Writer, options map[string]fieldInfo) {
+	envs := []fieldInfo{}
+	for _, info := range uniqueOptions(options) {
+		if info.env != "" && !info.isConst && !info.hidden {
+			envs = append(envs, info)
+		}
+	}
+	if len(envs) == 0 {
+		return
+	}
+	sort.SliceStable(envs, func(i, j int) bool {
+		return envs[i].env < envs[j].env
+	})
+
+	fmt.Fprintf(w, "\n%s:\n", message(environmentHeading))
+	for _, info := range envs {
+		fmt.Fprintf(w, "    %s\n       %s\n", info.env,
+			messagef("Sets %s", strings.Join(info.allFlags, " ")))
+	}
+}
+
+// WrapText takes a text and a width, and splits the text into lines of at
+// most the given width, at whitespace (words longer than the width get a
+// line of their own). The text is returned as a single line if the width
+// is not positive.
+func wrapText(s string, width int) []string {
+	words := strings.Fields(s)
+	if width <= 0 || len(words) == 0 {
+		return []string{s}
+	}
+
+	lines, line := []string{}, words[0]
+	for _, word := range words[1:] {
+		if len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		line += " " + word
+	}
+	return append(lines, line)
+}
+
+// UsageFlags takes a map of options, as built by analyzeStruct, and the
+// UsageOptions, and returns the flags shown in usage messages (see
+// visibleFlags), in the requested order. In declaration order, the flags
+// of each field remain sorted.
+func usageFlags(options map[string]fieldInfo, opts UsageOptions) []string {
+	keys := visibleFlags(options)
+	if opts.SortOrder == SortByDeclaration {
+		sort.SliceStable(keys, func(i, j int) bool {
+			a, b := options[keys[i]].Index, options[keys[j]].Index
+			return slices.Compare(a, b) < 0
+		})
+	}
+	return keys
+}
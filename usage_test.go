@@ -0,0 +1,258 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+)
+
+type orderArgs struct {
+	Verbose bool   `arg-flag:"-v --verbose"`
+	Output  string `arg-flag:"-o"`
+	Quiet   bool   `arg-flag:"-q" arg-const:"--silent=true"`
+	All     bool   `arg-flag:"-a"`
+	Files   []string
+}
+
+func Test_WriteShortUsageWith(t *testing.T) {
+	tests := []struct {
+		opts UsageOptions
+		want string
+	}{
+		{UsageOptions{},
+			shortUsagePrefix + "[-aqv] [-o string] [--silent] [string]+ \n"},
+		{UsageOptions{SortOrder: SortByDeclaration, Program: "mytool"},
+			"usage: mytool [-vqa] [-o string] [--silent] [string]+ \n"},
+	}
+
+	for _, test := range tests {
+		sb := strings.Builder{}
+		if err := WriteShortUsageWith(&sb, &orderArgs{}, test.opts); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if sb.String() != test.want {
+			t.Errorf("%+v: got=%q want=%q", test.opts, sb.String(), test.want)
+		}
+	}
+}
+
+func Test_WriteUsageWith(t *testing.T) {
+	sb := strings.Builder{}
+	err := WriteUsageWith(&sb, &orderArgs{},
+		UsageOptions{SortOrder: SortByDeclaration})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := "    -v --verbose \n" +
+		"    -o [string]\n" +
+		"    -q \n" +
+		"    --silent \n       (sets value true)\n" +
+		"    -a \n" +
+		"    [string] (repeatable) Files\n"
+	if sb.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", sb.String(), want)
+	}
+
+	// The default is the sorted order of WriteUsage()
+	sb.Reset()
+	WriteUsageWith(&sb, &orderArgs{}, UsageOptions{})
+	plain := strings.Builder{}
+	WriteUsage(&plain, &orderArgs{})
+	if sb.String() != plain.String() {
+		t.Errorf("got:\n%s\nwant:\n%s", sb.String(), plain.String())
+	}
+}
+
+func Test_WriteUsageWithTexts(t *testing.T) {
+	type textArgs struct {
+		Verbose bool `arg-flag:"-v"`
+		Files   []string
+	}
+
+	sb := strings.Builder{}
+	err := WriteUsageWith(&sb, &textArgs{}, UsageOptions{
+		Synopsis: "mytool - copy files",
+		Prologue: "Copies files.\n\nSecond paragraph.\n",
+		Examples: []string{"mytool a b", "mytool -v a"},
+		Epilogue: "Report bugs to <bugs@example.com>.",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := "mytool - copy files\n\n" +
+		"Copies files.\n\nSecond paragraph.\n\n" +
+		"    -v \n" +
+		"    [string] (repeatable) Files\n" +
+		"\nExamples:\n" +
+		"    mytool a b\n" +
+		"    mytool -v a\n" +
+		"\nReport bugs to <bugs@example.com>.\n"
+	if sb.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", sb.String(), want)
+	}
+
+	// Automatic flags precede the examples
+	var stderr bytes.Buffer
+	p := NewParser(WithHelp(), WithErrorWriter(&stderr),
+		WithUsageOptions(UsageOptions{Examples: []string{"mytool a"}}))
+	if err := p.Parse([]string{"-h"}, &textArgs{}); !errors.Is(err, ErrHelp) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(stderr.String(), "    -h --help \n"+
+		"       Show this help message\n\nExamples:\n    mytool a\n") {
+		t.Errorf("Unexpected help:\n%s", stderr.String())
+	}
+}
+
+func Test_WriteUsageWithKnobs(t *testing.T) {
+	type knobArgs struct {
+		Port  int    `arg-flag:"--port" arg-default:"8080" arg-env:"APP_PORT" arg-group:"Network" arg-help:"The port to listen on for incoming connections"`
+		Key   string `arg-flag:"--key" arg-default:"dev" arg-secret:""`
+		Debug bool   `arg-flag:"--debug" arg-hidden:"" arg-env:"APP_DEBUG"`
+	}
+
+	tests := []struct {
+		opts UsageOptions
+		want string
+	}{
+		{UsageOptions{},
+			"Options:\n" +
+				"    --key [string=dev]\n" +
+				"Network:\n" +
+				"    --port [int=8080]\n" +
+				"       The port to listen on for incoming connections (env: APP_PORT)\n"},
+		{UsageOptions{GroupBy: GroupNone, ShowDefaults: true, Columns: 40},
+			"    --key [string]\n" +
+				"       (default: ********)\n" +
+				"    --port [int]\n" +
+				"       The port to listen on for\n" +
+				"       incoming connections (env:\n" +
+				"       APP_PORT) (default: 8080)\n"},
+		{UsageOptions{GroupBy: GroupNone, ShowHidden: true, ShowEnv: true},
+			"    --key [string=dev]\n" +
+				"    --port [int=8080]\n" +
+				"       The port to listen on for incoming connections (env: APP_PORT)\n" +
+				"    --debug \n" +
+				"       (env: APP_DEBUG)\n" +
+				"\nEnvironment:\n" +
+				"    APP_DEBUG\n       Sets --debug\n" +
+				"    APP_PORT\n       Sets --port\n"},
+		{UsageOptions{ShowEnv: true},
+			"Options:\n" +
+				"    --key [string=dev]\n" +
+				"Network:\n" +
+				"    --port [int=8080]\n" +
+				"       The port to listen on for incoming connections (env: APP_PORT)\n" +
+				"\nEnvironment:\n" +
+				"    APP_PORT\n       Sets --port\n"},
+	}
+
+	for _, test := range tests {
+		sb := strings.Builder{}
+		if err := WriteUsageWith(&sb, &knobArgs{}, test.opts); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if sb.String() != test.want {
+			t.Errorf("%+v: got:\n%s\nwant:\n%s", test.opts, sb.String(),
+				test.want)
+		}
+	}
+}
+
+func Test_WriteUsageRequired(t *testing.T) {
+	type requiredArgs struct {
+		Name    string `arg-flag:"-n" arg-required:"" arg-help:"Your name"`
+		Port    int    `arg-flag:"--port" arg-default:"8080" arg-required:""`
+		Verbose bool   `arg-flag:"-v"`
+		Force   bool   `arg-flag:"-f" arg-required:""`
+	}
+
+	sb := strings.Builder{}
+	if err := WriteShortUsage(&sb, &requiredArgs{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := shortUsagePrefix + "-f -n string [-v] --port int \n"
+	if sb.String() != want {
+		t.Errorf("got=%q want=%q", sb.String(), want)
+	}
+
+	sb.Reset()
+	err := WriteUsageWith(&sb, &requiredArgs{}, UsageOptions{ShowDefaults: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want = "    -f \n       (required)\n" +
+		"    -n [string]\n       Your name (required)\n" +
+		"    -v \n" +
+		"    --port [int]\n       (required) (default: 8080)\n"
+	if sb.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", sb.String(), want)
+	}
+}
+
+func Test_UsageString(t *testing.T) {
+	long, err := UsageString(&orderArgs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sb := strings.Builder{}
+	WriteUsage(&sb, &orderArgs{})
+	if long != sb.String() {
+		t.Errorf("got=%q want=%q", long, sb.String())
+	}
+
+	short, err := ShortUsageString(&orderArgs{})
+	want := shortUsagePrefix + "[-aqv] [-o string] [--silent] [string]+ \n"
+	if err != nil || short != want {
+		t.Errorf("got=%q, %v want=%q", short, err, want)
+	}
+
+	if _, err := UsageString(orderArgs{}); err == nil {
+		t.Errorf("Expected error for non-pointer")
+	}
+	if _, err := ShortUsageString(orderArgs{}); err == nil {
+		t.Errorf("Expected error for non-pointer")
+	}
+}
+
+func Test_wrapText(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  []string
+	}{
+		{"a b c", 0, []string{"a b c"}},
+		{"", 10, []string{""}},
+		{"aa bb cc", 5, []string{"aa bb", "cc"}},
+		{"aaaaaaa bb", 5, []string{"aaaaaaa", "bb"}},
+		{" aa  bb ", 10, []string{"aa bb"}},
+	}
+
+	for _, test := range tests {
+		got := wrapText(test.s, test.width)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%q, %d: got=%q want=%q", test.s, test.width, got,
+				test.want)
+		}
+	}
+}
+
+func Test_ParserUsageOptions(t *testing.T) {
+	var stderr bytes.Buffer
+	p := NewParser(WithHelp(), WithErrorWriter(&stderr),
+		WithUsageOptions(UsageOptions{SortOrder: SortByDeclaration}))
+
+	err := p.Parse([]string{"--help"}, &orderArgs{})
+	if !errors.Is(err, ErrHelp) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(stderr.String(), "Usage: [-vqa] ") ||
+		!strings.Contains(stderr.String(), "    -v --verbose \n    -o [string]\n") {
+		t.Errorf("Unexpected help:\n%s", stderr.String())
+	}
+}
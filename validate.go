@@ -0,0 +1,252 @@
+package cleanarg
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// A Validator checks its own value. If the struct to populate implements
+// Validator, its Validate() method is called after the struct has been
+// populated (and after its subcommand, if any), so that it can check rules
+// that involve several fields. If the type of a field implements Validator,
+// Validate() is called on each value converted for the field (including
+// default values, and each element of a slice). Errors returned by
+// Validate() are reported as ParseErrors of kind ErrValidation, which wrap
+// the original error.
+type Validator interface {
+	Validate() error
+}
+
+// ValidateGroups takes the map of options, as returned by analyzeStruct,
+// and the options that were actually supplied on the command line, and
+// checks the constraints defined by the arg-xor and arg-require-one tags:
+// at most one member of each arg-xor group, and at least one member of
+// each arg-require-one group, must be supplied. (Tag a field with both to
+// require exactly one member of a group.) Likewise, it checks the arg-together
+// and arg-requires tags: either all or none of the members of each
+// arg-together group must be supplied, and if an option tagged arg-requires
+// is supplied, so must be the options named by the tag's flags.
+// Returns an error that lists the members of the offending group, if a
+// constraint is violated.
+func validateGroups(options map[string]fieldInfo, supplied []fieldInfo) error {
+	// Flags actually used, keyed on field name (only first occurrence)
+	used := map[string]string{}
+	for _, info := range supplied {
+		if _, ok := used[info.Name]; !ok {
+			used[info.Name] = info.flag
+		}
+	}
+
+	// Flags used (for arg-xor) or available (for arg-require-one) in each
+	// group, in usage order; group names in order of first appearance
+	xorUsed, xorNames := map[string][]string{}, []string{}
+	reqFlags, reqNames := map[string][]string{}, []string{}
+	xorKnown, satisfied := map[string]bool{}, map[string]bool{}
+
+	// Members of each arg-together group, and the flags used
+	togFlags, togNames := map[string][]string{}, []string{}
+	togUsed := map[string][]string{}
+
+	seen := map[string]struct{}{}
+	for _, info := range uniqueOptions(options) {
+		if _, ok := seen[info.Name]; ok {
+			continue
+		}
+		seen[info.Name] = struct{}{}
+
+		flag, isUsed := used[info.Name]
+
+		if g := info.xorGroup; g != "" {
+			if !xorKnown[g] {
+				xorKnown[g] = true
+				xorNames = append(xorNames, g)
+			}
+			if isUsed {
+				xorUsed[g] = append(xorUsed[g], flag)
+			}
+		}
+
+		if g := info.reqGroup; g != "" {
+			if _, ok := reqFlags[g]; !ok {
+				reqNames = append(reqNames, g)
+			}
+			reqFlags[g] = append(reqFlags[g], displayFlag(info))
+			if isUsed {
+				satisfied[g] = true
+			}
+		}
+
+		if g := info.togGroup; g != "" {
+			if _, ok := togFlags[g]; !ok {
+				togNames = append(togNames, g)
+			}
+			togFlags[g] = append(togFlags[g], displayFlag(info))
+			if isUsed {
+				togUsed[g] = append(togUsed[g], flag)
+			}
+		}
+
+		for _, f := range info.requires {
+			target := options[f]
+			if _, ok := used[target.Name]; isUsed && !ok {
+				err := newParseError(ErrRequired, "", -1,
+					"flag %s requires %s", flag, displayFlag(target))
+				err.Flag = flag
+				return err
+			}
+		}
+	}
+
+	for _, g := range xorNames {
+		if flags := xorUsed[g]; len(flags) > 1 {
+			err := newParseError(ErrExclusive, "", -1,
+				"flags %s are mutually exclusive", strings.Join(flags, ", "))
+			err.Flag = flags[1]
+			return err
+		}
+	}
+
+	for _, g := range reqNames {
+		if !satisfied[g] {
+			return newParseError(ErrRequired, "", -1,
+				"one of %s is required", strings.Join(reqFlags[g], ", "))
+		}
+	}
+
+	for _, g := range togNames {
+		if flags := togUsed[g]; len(flags) > 0 &&
+			len(flags) < len(togFlags[g]) {
+			err := newParseError(ErrRequired, "", -1,
+				"flags %s must be given together",
+				strings.Join(togFlags[g], ", "))
+			err.Flag = flags[0]
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckRequires takes a map of options, as built by analyzeStruct, and
+// checks that the flags named by arg-requires tags are flags of the
+// struct, whether or not the options are supplied.
+// Returns an error for the first unknown flag.
+func checkRequires(options map[string]fieldInfo) error {
+	for _, info := range uniqueOptions(options) {
+		for _, f := range info.requires {
+			if _, ok := options[f]; !ok {
+				return fmt.Errorf("%s: unknown flag: %s", tagRequires, f)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateRequired takes the map of options, as returned by analyzeStruct,
+// the options that were actually supplied on the command line, and the
+// fields set from configuration files and environment variables (keyed on
+// field name), and checks that each option tagged arg-required was set by
+// one of these (a default value does not suffice).
+// Returns an error for the first option that was not set, in usage order.
+func validateRequired(options map[string]fieldInfo, supplied []fieldInfo,
+	configured, environ map[string]string) error {
+
+	used := map[string]struct{}{}
+	for _, info := range supplied {
+		used[info.Name] = struct{}{}
+	}
+
+	for _, info := range uniqueOptions(options) {
+		if !info.required || info.isConst {
+			continue
+		}
+		_, isUsed := used[info.Name]
+		_, isConfigured := configured[info.Name]
+		_, isEnv := environ[info.Name]
+		if !isUsed && !isConfigured && !isEnv {
+			err := newParseError(ErrRequired, "", -1,
+				"flag %s is required", displayFlag(info))
+			err.Field, err.Flag = info.Name, displayFlag(info)
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateRepeats takes the options that were supplied on the command line,
+// in order, and checks the restrictions defined by the arg-once and
+// arg-max-count tags: the flags of such an option (including its
+// fixed-value flags) may be given at most once, or at most the given
+// number of times, respectively.
+// Returns an error for the first occurrence that exceeds the limit.
+func validateRepeats(supplied []fieldInfo) error {
+	counts := map[string]int{}
+
+	for _, info := range supplied {
+		counts[info.Name] += 1
+		if info.maxCount == 0 || counts[info.Name] <= info.maxCount {
+			continue
+		}
+
+		var err *ParseError
+		if info.maxCount == 1 {
+			err = newParseError(ErrRepeated, info.flag, info.index,
+				"flag %s may be given only once", info.flag)
+		} else {
+			err = newParseError(ErrRepeated, info.flag, info.index,
+				"flag %s may be given at most %d times", info.flag,
+				info.maxCount)
+		}
+		err.Field, err.Flag = info.Name, info.flag
+		return err
+	}
+
+	return nil
+}
+
+// DisplayFlag returns the flag that is used to refer to an option in
+// messages: the last (ie. longest) of its flags.
+func displayFlag(info fieldInfo) string {
+	if len(info.allFlags) == 0 {
+		return info.Name
+	}
+	return info.allFlags[len(info.allFlags)-1]
+}
+
+// ValidateValue takes a converted value, and calls its Validate() method,
+// if its type (or a pointer to it) implements Validator.
+// Returns the error returned by Validate(), if any.
+func validateValue(vv reflect.Value) error {
+	if x, ok := vv.Interface().(Validator); ok {
+		return x.Validate()
+	}
+
+	// Methods with pointer receivers require an addressable copy
+	ptr := reflect.New(vv.Type())
+	ptr.Elem().Set(vv)
+	if x, ok := ptr.Interface().(Validator); ok {
+		return x.Validate()
+	}
+	return nil
+}
+
+// ValidateStruct takes a reflect.Value, which must represent a populated
+// (addressable) struct, and calls the struct's Validate() method, if it
+// implements Validator. Returns a ParseError that wraps the error returned
+// by Validate() (subcommands add their names, see populateCommand).
+func validateStruct(v reflect.Value) error {
+	x, ok := v.Addr().Interface().(Validator)
+	if !ok {
+		return nil
+	}
+
+	err := x.Validate()
+	if err == nil {
+		return nil
+	}
+
+	pe := newParseError(ErrValidation, "", -1, "%v", err)
+	pe.Err = err
+	return pe
+}
@@ -0,0 +1,312 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+func Test_validateGroups(t *testing.T) {
+	type groupArgs struct {
+		File    string `arg-flag:"-f --file" arg-xor:"input" arg-require-one:"input"`
+		URL     string `arg-flag:"--url" arg-xor:"input" arg-require-one:"input"`
+		Stdin   bool   `arg-flag:"--stdin" arg-xor:"input" arg-require-one:"input"`
+		Quiet   bool   `arg-flag:"-q" arg-xor:"noise"`
+		Verbose bool   `arg-flag:"-v" arg-xor:"noise"`
+		Name    string `arg-flag:"--name" arg-require-one:"id"`
+		ID      int    `arg-flag:"--id" arg-require-one:"id"`
+	}
+
+	tests := []struct {
+		slice   []string
+		wantErr string
+	}{
+		{[]string{"--file", "a", "--id", "1"}, ""},
+		{[]string{"--url", "b", "--name", "x", "--id", "2"}, ""},
+		{[]string{"--stdin", "-q", "--id", "1"}, ""},
+		{[]string{"--stdin", "-f", "a", "--id", "1"},
+			"flags -f, --stdin are mutually exclusive"},
+		{[]string{"--stdin", "-q", "-v", "--id", "1"},
+			"flags -q, -v are mutually exclusive"},
+		{[]string{"--id", "1"}, "one of --file, --url, --stdin is required"},
+		{[]string{"--stdin"}, "one of --id, --name is required"},
+		{[]string{"-f", "a", "-f", "b", "--id", "1"}, ""}, // repeated is ok
+	}
+
+	for _, test := range tests {
+		a := groupArgs{}
+
+		err := FromSlice(test.slice, &a)
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+	}
+}
+
+func Test_validateTogether(t *testing.T) {
+	type togetherArgs struct {
+		User     string `arg-flag:"-u --user" arg-together:"auth"`
+		Password string `arg-flag:"--password" arg-together:"auth"`
+		TLS      bool   `arg-flag:"--tls" arg-requires:"--cert --key"`
+		Cert     string `arg-flag:"--cert"`
+		Key      string `arg-flag:"-k --key"`
+	}
+
+	tests := []struct {
+		slice   []string
+		wantErr string
+	}{
+		{[]string{}, ""},
+		{[]string{"-u", "joe", "--password", "pw"}, ""},
+		{[]string{"--tls", "--cert", "c", "-k", "k"}, ""},
+		{[]string{"--cert", "c"}, ""},
+		{[]string{"-u", "joe"},
+			"flags --user, --password must be given together"},
+		{[]string{"--password", "pw"},
+			"flags --user, --password must be given together"},
+		{[]string{"--tls", "--key", "k"}, "flag --tls requires --cert"},
+		{[]string{"--tls", "--cert", "c"}, "flag --tls requires --key"},
+	}
+
+	for _, test := range tests {
+		a := togetherArgs{}
+
+		err := FromSlice(test.slice, &a)
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+		if test.wantErr != "" && !errors.Is(err, ErrRequired) {
+			t.Errorf("%v: got=%v want ErrRequired", test.slice, err)
+		}
+	}
+
+	type badArgs struct {
+		TLS bool `arg-flag:"--tls" arg-requires:"--cert"`
+	}
+	if err := FromSlice([]string{}, &badArgs{}); err == nil {
+		t.Errorf("Expected error for unknown flag in arg-requires")
+	}
+	var b strings.Builder
+	if err := WriteUsage(&b, &badArgs{}); err == nil ||
+		err.Error() != "arg-requires: unknown flag: --cert" {
+		t.Errorf("Expected error for unknown flag in arg-requires: %v", err)
+	}
+}
+
+func Test_validateRequired(t *testing.T) {
+	type requiredArgs struct {
+		Name  string `arg-flag:"-n --name" arg-required:""`
+		Port  int    `arg-flag:"--port" arg-default:"8080" arg-required:""`
+		Token string `arg-flag:"--token" arg-env:"TEST_REQUIRED_TOKEN" arg-required:""`
+		Files []string
+	}
+
+	tests := []struct {
+		slice   []string
+		env     string
+		wantErr string
+	}{
+		{[]string{"-n", "a", "--port", "1"}, "t", ""},
+		{[]string{"--name", "a", "--port", "1", "--token", "x"}, "", ""},
+		{[]string{"--port", "1"}, "t", "flag --name is required"},
+		{[]string{"-n", "a"}, "t", "flag --port is required"}, // default
+		{[]string{"-n", "a", "--port", "1"}, "", "flag --token is required"},
+	}
+
+	for _, test := range tests {
+		if test.env != "" {
+			t.Setenv("TEST_REQUIRED_TOKEN", test.env)
+		} else {
+			t.Setenv("TEST_REQUIRED_TOKEN", "")
+			os.Unsetenv("TEST_REQUIRED_TOKEN")
+		}
+		a := requiredArgs{}
+
+		err := FromSlice(test.slice, &a)
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+		if test.wantErr != "" && !errors.Is(err, ErrRequired) {
+			t.Errorf("%v: got=%v want ErrRequired", test.slice, err)
+		}
+	}
+
+	type badArgs struct {
+		Name string `arg-required:""`
+	}
+	err := FromSlice([]string{"a"}, &badArgs{})
+	if err == nil || err.Error() != "arg-required requires arg-flag: Name" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func Test_validateRepeats(t *testing.T) {
+	type repeatArgs struct {
+		Output  string   `arg-flag:"-o --output" arg-once:""`
+		Level   int      `arg-flag:"-l" arg-const:"-q=0" arg-once:""`
+		Tags    []string `arg-flag:"-t" arg-max-count:"2"`
+		Verbose int      `arg-flag:"-v" arg-count:"" arg-max-count:"3"`
+		Name    string   `arg-flag:"-n"`
+	}
+
+	tests := []struct {
+		slice   []string
+		wantErr string
+	}{
+		{[]string{"-o", "a", "-t", "x", "-t", "y", "-vvv"}, ""},
+		{[]string{"-n", "a", "-n", "b"}, ""}, // unrestricted
+		{[]string{"-o", "a", "--output", "b"},
+			"flag --output may be given only once"},
+		{[]string{"-l", "1", "-q"}, "flag -q may be given only once"},
+		{[]string{"-t", "x", "-t", "y", "-t", "z"},
+			"flag -t may be given at most 2 times"},
+		{[]string{"-vv", "-vv"}, "flag -v may be given at most 3 times"},
+	}
+
+	for _, test := range tests {
+		a := repeatArgs{}
+
+		err := FromSlice(test.slice, &a)
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+		if test.wantErr != "" && !errors.Is(err, ErrRepeated) {
+			t.Errorf("%v: Expected ErrRepeated, got: %v", test.slice, err)
+		}
+	}
+
+	// Malformed tags
+	bad := []any{
+		&struct {
+			N []int `arg-flag:"-n" arg-max-count:"0"`
+		}{},
+		&struct {
+			N []int `arg-flag:"-n" arg-max-count:"x"`
+		}{},
+		&struct {
+			N []int `arg-flag:"-n" arg-once:"" arg-max-count:"2"`
+		}{},
+		&struct {
+			N string `arg-once:""`
+		}{},
+	}
+	for i, data := range bad {
+		if err := FromSlice([]string{"a"}, data); err == nil {
+			t.Errorf("%d: Expected error", i)
+		}
+	}
+}
+
+// rangeArgs checks that Low does not exceed High.
+type rangeArgs struct {
+	Low  int       `arg-flag:"--low"`
+	High int       `arg-flag:"--high" arg-default:"10"`
+	Sub  *rangeSub `arg-command:"sub"`
+}
+
+func (r rangeArgs) Validate() error {
+	if r.Low > r.High {
+		return fmt.Errorf("--low (%d) exceeds --high (%d)", r.Low, r.High)
+	}
+	return nil
+}
+
+// rangeSub requires a name (pointer receiver).
+type rangeSub struct {
+	Name string `arg-flag:"--name"`
+}
+
+func (r *rangeSub) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+func Test_validateStruct(t *testing.T) {
+	tests := []struct {
+		slice   []string
+		wantErr string
+	}{
+		{[]string{}, ""},
+		{[]string{"--low", "3", "--high", "5"}, ""},
+		{[]string{"--low", "11"}, "--low (11) exceeds --high (10)"},
+		{[]string{"sub", "--name", "x"}, ""},
+		{[]string{"sub"}, "sub: name is required"},
+		{[]string{"--low", "11", "sub"}, "sub: name is required"},
+	}
+
+	for _, test := range tests {
+		a := rangeArgs{}
+
+		err := FromSlice(test.slice, &a)
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.slice, err)
+		}
+		if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%v: got=%v want=%s", test.slice, err, test.wantErr)
+		}
+		if test.wantErr != "" && !errors.Is(err, ErrValidation) {
+			t.Errorf("%v: got=%v want ErrValidation", test.slice, err)
+		}
+	}
+}
+
+// evenInt is even, and validates itself.
+type evenInt int
+
+func (e evenInt) Validate() error {
+	if e%2 != 0 {
+		return fmt.Errorf("%d is odd", e)
+	}
+	return nil
+}
+
+// shortString is at most three bytes long (pointer receiver).
+type shortString string
+
+func (s *shortString) Validate() error {
+	if len(*s) > 3 {
+		return fmt.Errorf("%q is too long", string(*s))
+	}
+	return nil
+}
+
+func Test_validateValue(t *testing.T) {
+	tests := []struct {
+		value   any
+		wantErr string
+	}{
+		{evenInt(2), ""},
+		{evenInt(3), "3 is odd"},
+		{shortString("abc"), ""},
+		{shortString("abcd"), `"abcd" is too long`},
+		{17, ""},
+	}
+
+	for _, test := range tests {
+		err := validateValue(reflect.ValueOf(test.value))
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%v: Unexpected error: %v", test.value, err)
+		}
+		if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%v: got=%v want=%s", test.value, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package cleanarg
+
+import (
+	"strings"
+)
+
+// EnableWindowsFlags turns on Windows-style flags: a flag may also be given
+// with a slash instead of dashes, as in "/v" for "-v" or "/name" for
+// "--name", and its argument may follow a colon, as in "/name:x" (or
+// "/v:false", for a boolean flag). If automatic help is enabled, "/?"
+// requests the help message. A token is only taken for a Windows-style flag
+// if the corresponding flag is defined, so that paths like "/tmp" remain
+// positionals (unless --tmp is a flag). Tokens that are arguments of flags,
+// or that follow "--" (or, with POSIX ordering, the first positional
+// token), are never rewritten.
+func (p *Parser) EnableWindowsFlags() {
+	p.windows = true
+}
+
+// RewriteWindowsFlags takes a Parser, a map of options, and a slice of
+// tokens, and returns the tokens with each Windows-style flag (such as
+// "/v", "/name", or "/name:x") replaced by its usual form ("-v", "--name",
+// or "--name=x"), if that is a known flag (see rewriteFlags).
+func rewriteWindowsFlags(p *Parser, options map[string]fieldInfo,
+	tokens []string, isFused, hasCommands bool) []string {
+
+	return rewriteFlags(options, tokens, isFused, hasCommands, p.posix,
+		func(s string) (string, bool) {
+			if s == "/?" && p.help {
+				return "--help", true
+			}
+			if len(s) < 2 || s[0] != '/' {
+				return s, false
+			}
+
+			name, value, hasValue := strings.Cut(s[1:], ":")
+			flag := "--" + name
+			if len(name) == 1 {
+				flag = "-" + name
+			}
+			if !isKnownFlag(p, options, flag) {
+				return s, false
+			}
+
+			if !hasValue {
+				return flag, true
+			}
+			return flag + "=" + value, true
+		})
+}
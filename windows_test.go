@@ -0,0 +1,100 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"errors"
+	"io"
+	"reflect"
+)
+
+func Test_rewriteWindowsFlags(t *testing.T) {
+	type winArgs struct {
+		Name    string `arg-flag:"-n --name"`
+		Verbose bool   `arg-flag:"-v --verbose"`
+		Files   []string
+	}
+
+	v, _ := unwrap(&winArgs{})
+	options, _, _ := analyzeStruct(v)
+
+	tests := []struct {
+		tokens, want []string
+		commands     bool
+	}{
+		{[]string{"/v", "/name", "a"}, []string{"-v", "--name", "a"}, false},
+		{[]string{"/name:a", "/n:b"}, []string{"--name=a", "-n=b"}, false},
+		{[]string{"/v:false", "/verbose:no"},
+			[]string{"-v=false", "--verbose=no"}, false},
+		{[]string{"/tmp", "/x:1"}, []string{"/tmp", "/x:1"}, false},
+		{[]string{"-n", "/v"}, []string{"-n", "/v"}, false},
+		{[]string{"f", "/v"}, []string{"f", "-v"}, false},
+		{[]string{"f", "/v"}, []string{"f", "/v"}, true},
+		{[]string{"--", "/v"}, []string{"--", "/v"}, false},
+		{[]string{"/?"}, []string{"/?"}, false},
+	}
+
+	for _, test := range tests {
+		got := rewriteWindowsFlags(&Parser{}, options, test.tokens, false,
+			test.commands)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got=%v want=%v", test.tokens, got, test.want)
+		}
+	}
+
+	p := &Parser{help: true}
+	got := rewriteWindowsFlags(p, options, []string{"/?"}, false, false)
+	if !reflect.DeepEqual(got, []string{"--help"}) {
+		t.Errorf("Help: got=%v", got)
+	}
+}
+
+func Test_ParserWindowsFlags(t *testing.T) {
+	type copyArgs struct {
+		Yes     bool   `arg-flag:"-y"`
+		Exclude string `arg-flag:"--exclude"`
+		Paths   []string
+	}
+
+	p := NewParser(WithWindowsFlags())
+	a := copyArgs{}
+	err := p.Parse([]string{"/y", "/exclude:*.tmp", "a", "/tmp/b"}, &a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := copyArgs{true, "*.tmp", []string{"a", "/tmp/b"}}
+	if !reflect.DeepEqual(a, want) {
+		t.Errorf("got=%+v want=%+v", a, want)
+	}
+
+	p = NewParser(WithWindowsFlags(), WithHelp(), WithErrorWriter(io.Discard))
+	if err := p.Parse([]string{"/?"}, &copyArgs{}); !errors.Is(err, ErrHelp) {
+		t.Errorf("Expected help, got: %v", err)
+	}
+}
+
+func Test_ParserWindowsFlagsPosix(t *testing.T) {
+	type posixCopyArgs struct {
+		Name  string `arg-flag:"--name"`
+		Paths []string
+	}
+
+	tests := []struct {
+		tokens []string
+		want   posixCopyArgs
+	}{
+		{[]string{"/name:x", "a"}, posixCopyArgs{"x", []string{"a"}}},
+		{[]string{"a", "/name:x"}, posixCopyArgs{"", []string{"a", "/name:x"}}},
+	}
+
+	for _, test := range tests {
+		p := NewParser(WithWindowsFlags(), WithPosixOrdering())
+		a := posixCopyArgs{}
+		if err := p.Parse(test.tokens, &a); err != nil {
+			t.Fatalf("%v: Unexpected error: %v", test.tokens, err)
+		}
+		if !reflect.DeepEqual(a, test.want) {
+			t.Errorf("%v: got=%+v want=%+v", test.tokens, a, test.want)
+		}
+	}
+}
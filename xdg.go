@@ -0,0 +1,101 @@
+package cleanarg
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// AddUserConfigFile registers the configuration files of the given name
+// (eg. "config.toml") in the conventional configuration directories of the
+// application (eg. "mytool"), which exist when AddUserConfigFile() is
+// called (see ConfigFilePaths()), in order of increasing precedence, so
+// that the user's file takes precedence over system-wide ones. Files that
+// do not exist are skipped. (See AddConfigFile() for the format of the
+// files.) Returns the paths of the registered files.
+func (p *Parser) AddUserConfigFile(app, name string) []string {
+	found := []string{}
+	for _, path := range ConfigFilePaths(app, name) {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			p.AddConfigFile(path)
+			found = append(found, path)
+		}
+	}
+	return found
+}
+
+// WithUserConfigFile registers the configuration files of the given name
+// in the application's configuration directories (see
+// Parser.AddUserConfigFile()).
+func WithUserConfigFile(app, name string) Option {
+	return func(p *Parser) { p.AddUserConfigFile(app, name) }
+}
+
+// ConfigFilePaths returns the paths at which configuration files of the
+// given name are looked for, for the given application, in order of
+// increasing precedence: the file name, in the application's subdirectory
+// of each of the platform's configuration directories. These are:
+//
+//   - on Unix: the directories in $XDG_CONFIG_DIRS (default: /etc/xdg),
+//     then $XDG_CONFIG_HOME (default: ~/.config);
+//   - on macOS: ~/Library/Application Support, then $XDG_CONFIG_HOME (or
+//     ~/.config, if it exists);
+//   - on Windows: %APPDATA%, then $XDG_CONFIG_HOME (if set).
+func ConfigFilePaths(app, name string) []string {
+	paths := []string{}
+	for _, dir := range configDirs(runtime.GOOS, os.Getenv) {
+		paths = append(paths, filepath.Join(dir, app, name))
+	}
+	return paths
+}
+
+// ConfigDirs takes the name of the operating system (as in runtime.GOOS)
+// and a function that looks up environment variables, and returns the
+// configuration directories of the platform, in order of increasing
+// precedence (see ConfigFilePaths()). Directories whose location is not
+// known (as with an unset home directory) are left out.
+func configDirs(goos string, getenv func(string) string) []string {
+	dirs := []string{}
+	home := getenv("HOME")
+
+	// The user's XDG directory; ~/.config is not used on Windows, and on
+	// macOS only if it exists
+	xdgHome := getenv("XDG_CONFIG_HOME")
+	if xdgHome == "" && home != "" && goos != "windows" {
+		xdgHome = filepath.Join(home, ".config")
+		if _, err := os.Stat(xdgHome); err != nil && goos == "darwin" {
+			xdgHome = ""
+		}
+	}
+
+	switch goos {
+	case "windows":
+		if appData := getenv("APPDATA"); appData != "" {
+			dirs = append(dirs, appData)
+		}
+
+	case "darwin", "ios":
+		if home != "" {
+			dirs = append(dirs,
+				filepath.Join(home, "Library", "Application Support"))
+		}
+
+	default:
+		sys := getenv("XDG_CONFIG_DIRS")
+		if sys == "" {
+			sys = "/etc/xdg"
+		}
+		list := strings.Split(sys, ":")
+		for i := len(list) - 1; i >= 0; i-- {
+			if list[i] != "" {
+				dirs = append(dirs, list[i])
+			}
+		}
+	}
+
+	if xdgHome != "" {
+		dirs = append(dirs, xdgHome)
+	}
+	return dirs
+}
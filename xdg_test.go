@@ -0,0 +1,97 @@
+package cleanarg
+
+import (
+	"testing"
+
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+func Test_configDirs(t *testing.T) {
+	tests := []struct {
+		goos string
+		env  map[string]string
+		want []string
+	}{
+		{"linux", map[string]string{"HOME": "/home/u"},
+			[]string{"/etc/xdg", "/home/u/.config"}},
+		{"linux", map[string]string{"HOME": "/home/u",
+			"XDG_CONFIG_HOME": "/cfg", "XDG_CONFIG_DIRS": "/a:/b"},
+			[]string{"/b", "/a", "/cfg"}},
+		{"linux", map[string]string{}, []string{"/etc/xdg"}},
+		{"darwin", map[string]string{"HOME": "/nonexistent/u"},
+			[]string{"/nonexistent/u/Library/Application Support"}},
+		{"darwin", map[string]string{"HOME": "/nonexistent/u",
+			"XDG_CONFIG_HOME": "/cfg"},
+			[]string{"/nonexistent/u/Library/Application Support", "/cfg"}},
+		{"windows", map[string]string{"APPDATA": `C:\AppData`,
+			"HOME": "/home/u"}, []string{`C:\AppData`}},
+	}
+
+	for _, test := range tests {
+		getenv := func(k string) string { return test.env[k] }
+		got := configDirs(test.goos, getenv)
+		if strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%s %v: got=%q want=%q", test.goos, test.env, got,
+				test.want)
+		}
+	}
+}
+
+func Test_AddUserConfigFile(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("XDG directories only")
+	}
+
+	sys, user := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_DIRS", sys)
+	t.Setenv("XDG_CONFIG_HOME", user)
+
+	write := func(dir, content string) string {
+		path := filepath.Join(dir, "mytool", "config.json")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	type args struct {
+		Host string `arg-flag:"--host"`
+		Port int    `arg-flag:"--port"`
+	}
+
+	// No files: nothing is registered
+	p := NewParser()
+	if found := p.AddUserConfigFile("mytool", "config.json"); len(found) != 0 {
+		t.Errorf("Unexpected files: %v", found)
+	}
+
+	sysPath := write(sys, `{"host": "sys", "port": 1}`)
+	userPath := write(user, `{"port": 2}`)
+
+	p = NewParser()
+	found := p.AddUserConfigFile("mytool", "config.json")
+	if strings.Join(found, ",") != sysPath+","+userPath {
+		t.Errorf("Unexpected files: %v", found)
+	}
+
+	a := args{}
+	if err := p.Parse([]string{}, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a.Host != "sys" || a.Port != 2 {
+		t.Errorf("Unexpected result: %+v", a)
+	}
+
+	a = args{}
+	err := NewParser(WithUserConfigFile("mytool", "config.json")).Parse(
+		[]string{"--port", "3"}, &a)
+	if err != nil || a.Host != "sys" || a.Port != 3 {
+		t.Errorf("Unexpected result: %v, %+v", err, a)
+	}
+}
@@ -0,0 +1,334 @@
+package cleanarg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The YAML parser supports the subset of YAML that is commonly used for
+// configuration files: nested mappings (by indentation), block sequences
+// ("- item") and flow sequences ("[a, b]") of scalars, plain and quoted
+// scalars, and comments. Anchors, tags, multi-line scalars, flow mappings,
+// and multiple documents are not supported. All scalars are returned as
+// strings (nulls as nil), to be interpreted by convertToType().
+
+// yamlLine is a non-empty line of a YAML document, without comments.
+type yamlLine struct {
+	num    int    // line number, starting at 1
+	indent int    // number of leading spaces
+	text   string // the line's content, without indentation and comment
+}
+
+// ParseYAMLConfig takes the contents of a YAML configuration file, which
+// must consist of a single mapping, and returns its values.
+func parseYAMLConfig(buf []byte) (configValues, error) {
+	lines, err := yamlLines(string(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	obj := map[string]any{}
+	if len(lines) > 0 {
+		var i int
+		obj, i, err = parseYAMLMap(lines, 0, lines[0].indent)
+		if err != nil {
+			return nil, err
+		}
+		if i < len(lines) {
+			return nil, fmt.Errorf("line %d: unexpected indentation",
+				lines[i].num)
+		}
+	}
+
+	out := configValues{}
+	if err := flattenConfig(obj, "", out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// YAMLLines splits a YAML document into lines, and removes comments,
+// empty lines, and document markers. Returns an error if a line is
+// indented with tabs.
+func yamlLines(doc string) ([]yamlLine, error) {
+	out := []yamlLine{}
+
+	for n, line := range strings.Split(doc, "\n") {
+		line = strings.TrimRight(stripComment(line), " \t\r")
+
+		text := strings.TrimLeft(line, " ")
+		if text == "" || text == "---" || text == "..." {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tabs not permitted for indentation",
+				n+1)
+		}
+
+		out = append(out, yamlLine{n + 1, len(line) - len(text), text})
+	}
+
+	return out, nil
+}
+
+// StripComment removes a comment (starting with "#" at the beginning of
+// the line, or after whitespace) from a line, unless the "#" is quoted.
+// Quotes only count at the beginning of a value (not in "it's").
+func stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case (c == '"' || c == '\'') &&
+			(i == 0 || strings.ContainsRune(" \t:[,=", rune(line[i-1]))):
+			end := closingQuote(line[i:])
+			if end < 0 {
+				return line
+			}
+			i += end
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+// ParseYAMLMap parses a block mapping, starting at line i, whose keys are
+// indented by the given number of spaces. Returns the mapping and the index
+// of the first line that does not belong to it.
+func parseYAMLMap(lines []yamlLine, i, indent int) (map[string]any, int,
+	error) {
+
+	out := map[string]any{}
+
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		if strings.HasPrefix(line.text, "-") {
+			return nil, i, fmt.Errorf("line %d: unexpected list item", line.num)
+		}
+
+		key, value, err := splitYAMLKey(line.text)
+		if err != nil {
+			return nil, i, fmt.Errorf("line %d: %w", line.num, err)
+		}
+		if _, ok := out[key]; ok {
+			return nil, i, fmt.Errorf("line %d: duplicate key: %s", line.num, key)
+		}
+		i++
+
+		switch {
+		case value != "":
+			out[key], err = parseYAMLValue(value)
+			if err != nil {
+				return nil, i, fmt.Errorf("line %d: %w", line.num, err)
+			}
+
+		case i < len(lines) && lines[i].indent > indent:
+			out[key], i, err = parseYAMLBlock(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, i, err
+			}
+
+		case i < len(lines) && lines[i].indent == indent &&
+			strings.HasPrefix(lines[i].text, "-"):
+			// Sequences may be indented at the level of their key
+			out[key], i, err = parseYAMLList(lines, i, indent)
+			if err != nil {
+				return nil, i, err
+			}
+
+		default:
+			out[key] = nil
+		}
+	}
+
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, i, fmt.Errorf("line %d: unexpected indentation",
+			lines[i].num)
+	}
+
+	return out, i, nil
+}
+
+// ParseYAMLBlock parses a block mapping or block sequence, starting at
+// line i, indented by the given number of spaces.
+func parseYAMLBlock(lines []yamlLine, i, indent int) (any, int, error) {
+	if strings.HasPrefix(lines[i].text, "-") {
+		return parseYAMLList(lines, i, indent)
+	}
+	return parseYAMLMap(lines, i, indent)
+}
+
+// ParseYAMLList parses a block sequence of scalars, starting at line i,
+// whose items are indented by the given number of spaces.
+func parseYAMLList(lines []yamlLine, i, indent int) ([]any, int, error) {
+	out := []any{}
+
+	for i < len(lines) && lines[i].indent == indent &&
+		strings.HasPrefix(lines[i].text, "-") {
+
+		line := lines[i]
+		item := strings.TrimPrefix(line.text, "-")
+		if item != "" && item[0] != ' ' {
+			return nil, i, fmt.Errorf("line %d: malformed list item", line.num)
+		}
+		item = strings.TrimSpace(item)
+		i++
+
+		if item == "" || item == "-" || strings.HasPrefix(item, "- ") ||
+			i < len(lines) && lines[i].indent > indent {
+			return nil, i, fmt.Errorf("line %d: nested lists not supported",
+				line.num)
+		}
+		if _, _, err := splitYAMLKey(item); err == nil {
+			return nil, i, fmt.Errorf("line %d: mappings in lists not supported",
+				line.num)
+		}
+
+		x, err := parseYAMLScalar(item)
+		if err != nil {
+			return nil, i, fmt.Errorf("line %d: %w", line.num, err)
+		}
+		out = append(out, x)
+	}
+
+	return out, i, nil
+}
+
+// SplitYAMLKey splits a line of the form "key: value" (or "key:") into
+// key and value. The key may be quoted. Returns an error if the line does
+// not contain a key.
+func splitYAMLKey(text string) (string, string, error) {
+	key, rest := "", ""
+
+	if text != "" && (text[0] == '"' || text[0] == '\'') {
+		end := closingQuote(text)
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated quote: %s", text)
+		}
+		k, err := parseYAMLScalar(text[:end+1])
+		if err != nil {
+			return "", "", err
+		}
+		key, rest = k.(string), text[end+1:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", fmt.Errorf("expected key: %s", text)
+		}
+		rest = rest[1:]
+
+	} else {
+		idx := strings.Index(text+" ", ": ")
+		if idx < 0 {
+			return "", "", fmt.Errorf("expected key: %s", text)
+		}
+		key, rest = text[:idx], text[idx+1:]
+	}
+
+	if rest != "" && rest[0] != ' ' {
+		return "", "", fmt.Errorf("expected key: %s", text)
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(rest), nil
+}
+
+// ParseYAMLValue parses the value of a mapping entry: a flow sequence, or
+// a scalar.
+func parseYAMLValue(s string) (any, error) {
+	switch {
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("unterminated list: %s", s)
+		}
+		out := []any{}
+		for _, item := range splitFlow(s[1 : len(s)-1]) {
+			x, err := parseYAMLScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, x)
+		}
+		return out, nil
+
+	case strings.HasPrefix(s, "{"):
+		return nil, fmt.Errorf("flow mappings not supported: %s", s)
+
+	case s == "|" || s == ">" || strings.HasPrefix(s, "|") ||
+		strings.HasPrefix(s, ">"):
+		return nil, fmt.Errorf("multi-line scalars not supported: %s", s)
+
+	case strings.HasPrefix(s, "&") || strings.HasPrefix(s, "*") ||
+		strings.HasPrefix(s, "!"):
+		return nil, fmt.Errorf("anchors and tags not supported: %s", s)
+	}
+
+	return parseYAMLScalar(s)
+}
+
+// ParseYAMLScalar parses a plain, single-quoted, or double-quoted scalar.
+// Returns nil for nulls, and a string otherwise.
+func parseYAMLScalar(s string) (any, error) {
+	s = strings.TrimSpace(s)
+
+	switch {
+	case s == "" || s == "~" || s == "null" || s == "Null" || s == "NULL":
+		return nil, nil
+
+	case s[0] == '"':
+		if closingQuote(s) != len(s)-1 {
+			return nil, fmt.Errorf("malformed quoted string: %s", s)
+		}
+		return strconv.Unquote(s)
+
+	case s[0] == '\'':
+		if closingQuote(s) != len(s)-1 {
+			return nil, fmt.Errorf("malformed quoted string: %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+
+	return s, nil
+}
+
+// ClosingQuote returns the index of the quote that closes the quoted
+// string at the beginning of s, or -1 if there is none. In single-quoted
+// strings, quotes are escaped by doubling them; in double-quoted strings,
+// by a backslash.
+func closingQuote(s string) int {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case q == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+// SplitFlow splits the items of a flow sequence on commas, except for
+// commas within quotes. Returns an empty slice for an empty sequence.
+func splitFlow(s string) []string {
+	out := []string{}
+	if strings.TrimSpace(s) == "" {
+		return out
+	}
+
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			if end := closingQuote(s[i:]); end >= 0 {
+				i += end
+			}
+		case ',':
+			out = append(out, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	out = append(out, strings.TrimSpace(s[start:]))
+
+	return out
+}
@@ -0,0 +1,61 @@
+package cleanarg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseYAMLConfig(t *testing.T) {
+	tests := []struct {
+		input string
+		want  configValues
+	}{
+		{"", configValues{}},
+		{"# comment\nport: 8080\n", configValues{"port": {"8080"}}},
+		{"name: 'it''s' # comment\n", configValues{"name": {"it's"}}},
+		{"name: \"a # b\"\n", configValues{"name": {"a # b"}}},
+		{"name: a#b\n", configValues{"name": {"a#b"}}},
+		{"n: ~\nm: null\n", configValues{}},
+		{"verbose: true\n", configValues{"verbose": {"true"}}},
+		{"tag: [a, \"b, c\"]\n", configValues{"tag": {"a", "b, c"}}},
+		{"tag:\n  - a\n  - b\n", configValues{"tag": {"a", "b"}}},
+		{"tag:\n- a\n- b\nport: 1\n",
+			configValues{"tag": {"a", "b"}, "port": {"1"}}},
+		{"db:\n  host: x\n  pool:\n    size: 4\nport: 1\n",
+			configValues{"db-host": {"x"}, "db-pool-size": {"4"},
+				"port": {"1"}}},
+		{"\"a key\": 1\n", configValues{"a key": {"1"}}},
+	}
+
+	for _, test := range tests {
+		res, err := parseYAMLConfig([]byte(test.input))
+		if err != nil {
+			t.Errorf("%q: Unexpected error: %v", test.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, test.want) {
+			t.Errorf("%q: got=%v want=%v", test.input, res, test.want)
+		}
+	}
+}
+
+func Test_parseYAMLConfigErr(t *testing.T) {
+	tests := []string{
+		"port 8080\n",
+		"- a\n- b\n",
+		"a: 1\na: 2\n",
+		"a: {b: 1}\n",
+		"a: |\n  text\n",
+		"a: &x 1\n",
+		"a: \"open\n",
+		"a:\n  - b: 1\n",
+		"a:\n  - - 1\n",
+		"a: 1\n  b: 2\n",
+	}
+
+	for _, test := range tests {
+		if _, err := parseYAMLConfig([]byte(test)); err == nil {
+			t.Errorf("%q: Expected error", test)
+		}
+	}
+}